@@ -0,0 +1,224 @@
+package ab
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrLateEvent = errors.New("ab: event is older than watermark")
+
+type Exposure struct {
+	UserID  string    `json:"user_id"`
+	Variant string    `json:"variant"`
+	At      time.Time `json:"at"`
+}
+
+type Conversion struct {
+	UserID string    `json:"user_id"`
+	Value  float64   `json:"value"`
+	At     time.Time `json:"at"`
+}
+
+type AttributionOptions struct {
+	// Window is the duration after the first exposure where conversions are
+	// attributed to the variant, e.g. 7 days.
+	Window time.Duration
+
+	// AllowedLateness is how far behind the latest event time an event may
+	// arrive before it is dropped.
+	AllowedLateness time.Duration
+}
+
+func (o *AttributionOptions) Valid() error {
+	o.Window = cmp.Or(o.Window, 7*24*time.Hour)
+	if o.Window <= 0 {
+		return errors.New("ab: Window must be greater than 0")
+	}
+	if o.AllowedLateness < 0 {
+		return errors.New("ab: AllowedLateness must not be negative")
+	}
+
+	return nil
+}
+
+// Attribution attributes conversions to the variant the user was first
+// exposed to, as long as the conversion happens within the attribution
+// window.
+// Events may arrive out of order. The results are always recomputed from the
+// raw events, so late events that are still within the allowed lateness
+// update the results.
+type Attribution struct {
+	opts *AttributionOptions
+
+	mu          sync.Mutex
+	exposures   map[string]Exposure
+	conversions map[string][]Conversion
+	maxAt       time.Time
+	late        int
+	dropped     int
+}
+
+func NewAttribution(opts *AttributionOptions) *Attribution {
+	if opts == nil {
+		opts = new(AttributionOptions)
+	}
+	if err := opts.Valid(); err != nil {
+		panic(err)
+	}
+
+	return &Attribution{
+		opts:        opts,
+		exposures:   make(map[string]Exposure),
+		conversions: make(map[string][]Conversion),
+	}
+}
+
+// Expose records the exposure. Only the earliest exposure of the user is
+// kept.
+func (a *Attribution) Expose(e Exposure) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.observe(e.At); err != nil {
+		return err
+	}
+
+	prev, ok := a.exposures[e.UserID]
+	if !ok || e.At.Before(prev.At) {
+		a.exposures[e.UserID] = e
+	}
+
+	return nil
+}
+
+// Convert records the conversion. Conversions may arrive before the
+// exposure, and will be attributed once the exposure is recorded.
+func (a *Attribution) Convert(c Conversion) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.observe(c.At); err != nil {
+		return err
+	}
+
+	a.conversions[c.UserID] = append(a.conversions[c.UserID], c)
+
+	return nil
+}
+
+// Watermark returns the time before which all events are assumed to have
+// arrived.
+func (a *Attribution) Watermark() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.watermark()
+}
+
+type VariantAttribution struct {
+	Variant     string  `json:"variant"`
+	Exposures   int     `json:"exposures"`
+	Conversions int     `json:"conversions"`
+	Converted   int     `json:"converted"`
+	Value       float64 `json:"value"`
+	Rate        float64 `json:"rate"`
+
+	// Pending is the number of users whose attribution window is still open
+	// at the watermark.
+	Pending int `json:"pending"`
+}
+
+type AttributionResult struct {
+	Window time.Duration `json:"window"`
+	// Start is the time of the first exposure.
+	Start     time.Time            `json:"start"`
+	Watermark time.Time            `json:"watermark"`
+	Late      int                  `json:"late"`
+	Dropped   int                  `json:"dropped"`
+	Variants  []VariantAttribution `json:"variants"`
+}
+
+// Result computes the attribution per variant.
+func (a *Attribution) Result() *AttributionResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	watermark := a.watermark()
+
+	var start time.Time
+	byVariant := make(map[string]*VariantAttribution)
+	for userID, e := range a.exposures {
+		if start.IsZero() || e.At.Before(start) {
+			start = e.At
+		}
+
+		v, ok := byVariant[e.Variant]
+		if !ok {
+			v = &VariantAttribution{Variant: e.Variant}
+			byVariant[e.Variant] = v
+		}
+		v.Exposures++
+
+		end := e.At.Add(a.opts.Window)
+		if end.After(watermark) {
+			v.Pending++
+		}
+
+		var converted bool
+		for _, c := range a.conversions[userID] {
+			if c.At.Before(e.At) || !c.At.Before(end) {
+				continue
+			}
+			converted = true
+			v.Conversions++
+			v.Value += c.Value
+		}
+		if converted {
+			v.Converted++
+		}
+	}
+
+	variants := make([]VariantAttribution, 0, len(byVariant))
+	for _, v := range byVariant {
+		v.Rate = float64(v.Converted) / float64(v.Exposures)
+		variants = append(variants, *v)
+	}
+	slices.SortFunc(variants, func(a, b VariantAttribution) int {
+		return cmp.Compare(a.Variant, b.Variant)
+	})
+
+	return &AttributionResult{
+		Window:    a.opts.Window,
+		Start:     start,
+		Watermark: watermark,
+		Late:      a.late,
+		Dropped:   a.dropped,
+		Variants:  variants,
+	}
+}
+
+func (a *Attribution) observe(at time.Time) error {
+	if at.Before(a.watermark()) {
+		a.dropped++
+		return ErrLateEvent
+	}
+	if at.Before(a.maxAt) {
+		a.late++
+	}
+	if at.After(a.maxAt) {
+		a.maxAt = at
+	}
+
+	return nil
+}
+
+func (a *Attribution) watermark() time.Time {
+	if a.maxAt.IsZero() {
+		return a.maxAt
+	}
+
+	return a.maxAt.Add(-a.opts.AllowedLateness)
+}
@@ -0,0 +1,68 @@
+package ab_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttribution(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	attr := ab.NewAttribution(&ab.AttributionOptions{
+		Window:          7 * day,
+		AllowedLateness: 2 * day,
+	})
+
+	is := assert.New(t)
+	is.Nil(attr.Expose(ab.Exposure{UserID: "a", Variant: "control", At: now}))
+	is.Nil(attr.Expose(ab.Exposure{UserID: "b", Variant: "treatment", At: now}))
+	is.Nil(attr.Convert(ab.Conversion{UserID: "b", Value: 10, At: now.Add(2 * day)}))
+
+	// Outside the attribution window.
+	is.Nil(attr.Convert(ab.Conversion{UserID: "a", Value: 10, At: now.Add(8 * day)}))
+
+	res := attr.Result()
+	is.Equal(now.Add(6*day), res.Watermark)
+	is.Equal([]ab.VariantAttribution{
+		{Variant: "control", Exposures: 1, Rate: 0, Pending: 1},
+		{Variant: "treatment", Exposures: 1, Conversions: 1, Converted: 1, Value: 10, Rate: 1, Pending: 1},
+	}, res.Variants)
+
+	// Late event within the allowed lateness is attributed.
+	is.Nil(attr.Convert(ab.Conversion{UserID: "a", Value: 5, At: now.Add(6*day + time.Hour)}))
+
+	// Late event older than the watermark is dropped.
+	is.ErrorIs(attr.Convert(ab.Conversion{UserID: "a", Value: 5, At: now.Add(day)}), ab.ErrLateEvent)
+
+	res = attr.Result()
+	is.Equal(1, res.Late)
+	is.Equal(1, res.Dropped)
+	is.Equal(ab.VariantAttribution{
+		Variant:     "control",
+		Exposures:   1,
+		Conversions: 1,
+		Converted:   1,
+		Value:       5,
+		Rate:        1,
+		Pending:     1,
+	}, res.Variants[0])
+}
+
+func TestAttributionPending(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	attr := ab.NewAttribution(&ab.AttributionOptions{
+		Window: time.Hour,
+	})
+
+	is := assert.New(t)
+	is.Nil(attr.Expose(ab.Exposure{UserID: "a", Variant: "control", At: now}))
+	is.Nil(attr.Expose(ab.Exposure{UserID: "b", Variant: "control", At: now.Add(time.Hour)}))
+
+	res := attr.Result()
+	is.Equal(1, res.Variants[0].Pending)
+}
@@ -0,0 +1,239 @@
+// Package bandit implements contextual multi-armed bandits, which learn a
+// linear model of the reward per arm from the features of the request, e.g.
+// the user attributes.
+package bandit
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownArm        = errors.New("bandit: unknown arm")
+	ErrDimensionMismatch = errors.New("bandit: dimension mismatch")
+	ErrUnknownPull       = errors.New("bandit: unknown pull")
+	ErrPullExpired       = errors.New("bandit: pull expired")
+)
+
+type Options struct {
+	// Alpha scales the exploration. For LinUCB, it is the width of the upper
+	// confidence bound, and for LinTS, the scale of the posterior. Defaults
+	// to 1.
+	Alpha float64
+
+	// Lambda is the ridge regularization of the linear models. Defaults to 1.
+	Lambda float64
+
+	// Rand is the source of randomness for LinTS, and for breaking ties.
+	Rand *rand.Rand
+
+	// AttributionWindow is how long the reward of a pull is accepted, see
+	// Pull. Defaults to 24 hours.
+	AttributionWindow time.Duration
+
+	Now func() time.Time
+}
+
+func (o *Options) valid() *Options {
+	o = cmp.Or(o, &Options{})
+	o.Alpha = cmp.Or(o.Alpha, 1)
+	o.Lambda = cmp.Or(o.Lambda, 1)
+	o.AttributionWindow = cmp.Or(o.AttributionWindow, 24*time.Hour)
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.Rand == nil {
+		o.Rand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	return o
+}
+
+// Bandit selects the arm with the highest score of the predicted reward for
+// the features, and learns the reward of the selected arm.
+type Bandit struct {
+	opts  *Options
+	dim   int
+	arms  []string
+	score func(m *model, x []float64) float64
+
+	mu     sync.Mutex
+	models map[string]*model
+	pulls  map[string]*pull
+}
+
+// NewLinUCB returns a Bandit that selects the arm with the highest upper
+// confidence bound of the predicted reward.
+func NewLinUCB(arms []string, dim int, opts *Options) *Bandit {
+	opts = opts.valid()
+
+	return newBandit(arms, dim, opts, func(m *model, x []float64) float64 {
+		return m.mean(x) + opts.Alpha*math.Sqrt(m.variance(x))
+	})
+}
+
+// NewLinTS returns a Bandit that selects the arm with the highest reward
+// sampled from the posterior of the linear model, i.e. Thompson sampling with
+// Bayesian linear regression.
+func NewLinTS(arms []string, dim int, opts *Options) *Bandit {
+	opts = opts.valid()
+
+	// For a given context, the sampled reward of each arm is normally
+	// distributed, so there is no need to sample the full weights.
+	return newBandit(arms, dim, opts, func(m *model, x []float64) float64 {
+		return m.mean(x) + opts.Alpha*math.Sqrt(m.variance(x))*opts.Rand.NormFloat64()
+	})
+}
+
+func newBandit(arms []string, dim int, opts *Options, score func(*model, []float64) float64) *Bandit {
+	models := make(map[string]*model)
+	for _, arm := range arms {
+		models[arm] = newModel(dim, opts.Lambda)
+	}
+
+	return &Bandit{
+		opts:   opts,
+		dim:    dim,
+		arms:   arms,
+		score:  score,
+		models: models,
+		pulls:  make(map[string]*pull),
+	}
+}
+
+// SelectArm returns the arm with the highest score for the features. Ties
+// are broken randomly.
+func (b *Bandit) SelectArm(features []float64) (string, error) {
+	if len(features) != b.dim {
+		return "", fmt.Errorf("%w: want %d, got %d", ErrDimensionMismatch, b.dim, len(features))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var best string
+	var ties int
+	top := math.Inf(-1)
+	for _, arm := range b.arms {
+		s := b.score(b.models[arm], features)
+		switch {
+		case s > top:
+			best, top, ties = arm, s, 1
+		case s == top:
+			// Reservoir sampling picks each of the tied arms uniformly.
+			ties++
+			if b.opts.Rand.IntN(ties) == 0 {
+				best = arm
+			}
+		}
+	}
+
+	return best, nil
+}
+
+// Update learns the reward of the arm for the features.
+func (b *Bandit) Update(arm string, features []float64, reward float64) error {
+	if len(features) != b.dim {
+		return fmt.Errorf("%w: want %d, got %d", ErrDimensionMismatch, b.dim, len(features))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.models[arm]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownArm, arm)
+	}
+	m.update(features, reward)
+
+	return nil
+}
+
+// Predict returns the expected reward of the arm for the features.
+func (b *Bandit) Predict(arm string, features []float64) (float64, error) {
+	if len(features) != b.dim {
+		return 0, fmt.Errorf("%w: want %d, got %d", ErrDimensionMismatch, b.dim, len(features))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.models[arm]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownArm, arm)
+	}
+
+	return m.mean(features), nil
+}
+
+// model is a ridge regression, which keeps the inverse of the design matrix
+// A = λI + Σxxᵀ up to date with the Sherman-Morrison formula, so that no
+// matrix inversion is required.
+type model struct {
+	ainv  [][]float64
+	b     []float64
+	theta []float64
+}
+
+func newModel(dim int, lambda float64) *model {
+	ainv := make([][]float64, dim)
+	for i := range ainv {
+		ainv[i] = make([]float64, dim)
+		ainv[i][i] = 1 / lambda
+	}
+
+	return &model{
+		ainv:  ainv,
+		b:     make([]float64, dim),
+		theta: make([]float64, dim),
+	}
+}
+
+func (m *model) mean(x []float64) float64 {
+	return dot(m.theta, x)
+}
+
+// variance returns xᵀA⁻¹x.
+func (m *model) variance(x []float64) float64 {
+	return dot(x, m.mul(x))
+}
+
+func (m *model) update(x []float64, reward float64) {
+	// A⁻¹ -= (A⁻¹x)(A⁻¹x)ᵀ / (1 + xᵀA⁻¹x), since A⁻¹ is symmetric.
+	ax := m.mul(x)
+	denom := 1 + dot(x, ax)
+	for i := range m.ainv {
+		for j := range m.ainv[i] {
+			m.ainv[i][j] -= ax[i] * ax[j] / denom
+		}
+	}
+
+	for i := range m.b {
+		m.b[i] += reward * x[i]
+	}
+	m.theta = m.mul(m.b)
+}
+
+// mul returns A⁻¹v.
+func (m *model) mul(v []float64) []float64 {
+	res := make([]float64, len(v))
+	for i, row := range m.ainv {
+		res[i] = dot(row, v)
+	}
+
+	return res
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+
+	return s
+}
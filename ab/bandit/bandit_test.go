@@ -0,0 +1,160 @@
+package bandit_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab/bandit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextual(t *testing.T) {
+	tests := map[string]func(arms []string, dim int, opts *bandit.Options) *bandit.Bandit{
+		"LinUCB": bandit.NewLinUCB,
+		"LinTS":  bandit.NewLinTS,
+	}
+
+	// The mobile users prefer the banner, while the desktop users prefer the
+	// sidebar.
+	mobile := []float64{1, 0}
+	desktop := []float64{0, 1}
+	reward := func(arm string, x []float64) float64 {
+		if (arm == "banner") == (x[0] == 1) {
+			return 1
+		}
+
+		return 0
+	}
+
+	for name, newBandit := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 1))
+			b := newBandit([]string{"banner", "sidebar"}, 2, &bandit.Options{
+				Rand: r,
+			})
+
+			is := assert.New(t)
+			for range 500 {
+				x := mobile
+				if r.IntN(2) == 0 {
+					x = desktop
+				}
+
+				arm, err := b.SelectArm(x)
+				is.Nil(err)
+				is.Nil(b.Update(arm, x, reward(arm, x)))
+			}
+
+			arm, err := b.SelectArm(mobile)
+			is.Nil(err)
+			is.Equal("banner", arm)
+
+			arm, err = b.SelectArm(desktop)
+			is.Nil(err)
+			is.Equal("sidebar", arm)
+
+			p, err := b.Predict("banner", mobile)
+			is.Nil(err)
+			is.InDelta(1, p, 0.1)
+		})
+	}
+}
+
+func TestContextualErrors(t *testing.T) {
+	b := bandit.NewLinUCB([]string{"a"}, 2, nil)
+
+	is := assert.New(t)
+	_, err := b.SelectArm([]float64{1})
+	is.ErrorIs(err, bandit.ErrDimensionMismatch)
+	is.ErrorIs(b.Update("b", []float64{1, 0}, 1), bandit.ErrUnknownArm)
+}
+
+func TestDelayedReward(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := bandit.NewLinUCB([]string{"a", "b"}, 1, &bandit.Options{
+		Rand:              rand.New(rand.NewPCG(1, 1)),
+		AttributionWindow: time.Hour,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	x := []float64{1}
+
+	is := assert.New(t)
+	for i := range 4 {
+		_, err := b.Pull(fmt.Sprint("pull-", i), x)
+		is.Nil(err)
+	}
+	is.Equal(4, b.Pending())
+
+	is.Nil(b.Reward("pull-0", 1))
+	is.ErrorIs(b.Reward("pull-0", 1), bandit.ErrUnknownPull)
+	is.ErrorIs(b.Rewards(map[string]float64{
+		"pull-1":  1,
+		"pull-99": 1,
+	}), bandit.ErrUnknownPull)
+	is.Equal(2, b.Pending())
+
+	// Not expired yet.
+	is.Equal(0, b.Reconcile())
+
+	now = now.Add(time.Hour + time.Second)
+	is.ErrorIs(b.Reward("pull-2", 1), bandit.ErrPullExpired)
+	is.Equal(2, b.Reconcile())
+	is.Equal(0, b.Pending())
+}
+
+func TestDelayedRewardBias(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewPCG(1, 1))
+	b := bandit.NewLinTS([]string{"fast", "slow"}, 1, &bandit.Options{
+		Rand:              r,
+		AttributionWindow: time.Hour,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	x := []float64{1}
+
+	// Both arms convert half of the time, but the conversions of the slow arm
+	// arrive 30 minutes later, in batch.
+	is := assert.New(t)
+	due := make(map[time.Time]map[string]float64)
+	for i := range 1000 {
+		id := fmt.Sprint("pull-", i)
+		arm, err := b.Pull(id, x)
+		is.Nil(err)
+
+		if r.IntN(2) == 0 {
+			switch arm {
+			case "fast":
+				is.Nil(b.Reward(id, 1))
+			case "slow":
+				at := now.Add(30 * time.Minute)
+				if due[at] == nil {
+					due[at] = make(map[string]float64)
+				}
+				due[at][id] = 1
+			}
+		}
+
+		now = now.Add(time.Minute)
+		if rewards, ok := due[now]; ok {
+			is.Nil(b.Rewards(rewards))
+			delete(due, now)
+		}
+		b.Reconcile()
+	}
+
+	now = now.Add(2 * time.Hour)
+	b.Reconcile()
+	is.Equal(0, b.Pending())
+
+	for _, arm := range []string{"fast", "slow"} {
+		p, err := b.Predict(arm, x)
+		is.Nil(err)
+		is.InDelta(0.5, p, 0.15)
+	}
+}
@@ -0,0 +1,113 @@
+package bandit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// pull is the arm selected for the features, waiting for the reward.
+type pull struct {
+	arm      string
+	features []float64
+	at       time.Time
+}
+
+// Pull selects the arm for the features, like SelectArm, and keeps the pull
+// until the reward is attributed with Reward, for rewards that arrive late,
+// e.g. conversions. The id identifies the pull, e.g. the request ID.
+//
+// Pulls without a reward within the attribution window are learned as zero
+// reward by Reconcile. Otherwise the arms with slow feedback are learned
+// only from the few rewards that arrived, and the bandit is biased towards
+// the arms with fast feedback.
+func (b *Bandit) Pull(id string, features []float64) (string, error) {
+	arm, err := b.SelectArm(features)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	b.pulls[id] = &pull{
+		arm:      arm,
+		features: slices.Clone(features),
+		at:       b.opts.Now(),
+	}
+	b.mu.Unlock()
+
+	return arm, nil
+}
+
+// Reward attributes the reward to the pull, and learns the reward of the
+// arm. Each pull is rewarded at most once.
+func (b *Bandit) Reward(id string, reward float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.reward(id, reward, b.opts.Now())
+}
+
+// Rewards attributes the rewards in batch, keyed by the pull ID. The rewards
+// of the unknown or expired pulls are skipped, and the errors are joined.
+func (b *Bandit) Rewards(rewards map[string]float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.Now()
+
+	var errs []error
+	for id, reward := range rewards {
+		errs = append(errs, b.reward(id, reward, now))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *Bandit) reward(id string, reward float64, now time.Time) error {
+	p, ok := b.pulls[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPull, id)
+	}
+	// The expired pull is left for Reconcile, which learns the zero reward.
+	if now.Sub(p.at) > b.opts.AttributionWindow {
+		return fmt.Errorf("%w: %s", ErrPullExpired, id)
+	}
+
+	delete(b.pulls, id)
+	b.models[p.arm].update(p.features, reward)
+
+	return nil
+}
+
+// Reconcile learns the zero reward for the pulls without a reward within the
+// attribution window, and returns the number of pulls reconciled. It should
+// be called periodically, e.g. every minute.
+func (b *Bandit) Reconcile() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.Now()
+
+	var n int
+	for id, p := range b.pulls {
+		if now.Sub(p.at) <= b.opts.AttributionWindow {
+			continue
+		}
+
+		delete(b.pulls, id)
+		b.models[p.arm].update(p.features, 0)
+		n++
+	}
+
+	return n
+}
+
+// Pending returns the number of pulls waiting for the reward.
+func (b *Bandit) Pending() int {
+	b.mu.Lock()
+	n := len(b.pulls)
+	b.mu.Unlock()
+
+	return n
+}
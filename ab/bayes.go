@@ -0,0 +1,190 @@
+package ab
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+type BayesianOptions struct {
+	// Control is the variant the others are compared to. Defaults to the
+	// default variant of the experiment, or the first variant.
+	Control string
+}
+
+// BayesianResult compares the variants to the control with the posterior
+// distributions, which is easier to act on than the p-values, e.g. ship the
+// variant when the probability to beat the control is above 95%, and the
+// expected loss is below what we can afford.
+type BayesianResult struct {
+	Control string `json:"control"`
+
+	// Conversion compares the conversion rates with the Beta-Binomial
+	// model, with a uniform Beta(1, 1) prior.
+	Conversion []VariantPosterior `json:"conversion"`
+
+	// Metrics compares the means of the metrics with the normal
+	// approximation.
+	Metrics map[string][]VariantPosterior `json:"metrics,omitempty"`
+}
+
+type VariantPosterior struct {
+	Variant string `json:"variant"`
+
+	// Mean is the posterior mean, e.g. the conversion rate.
+	Mean float64 `json:"mean"`
+
+	// ProbabilityToBeatControl is the probability that the variant is
+	// better than the control.
+	ProbabilityToBeatControl float64 `json:"probability_to_beat_control"`
+
+	// ExpectedLoss is the expected decrease of the mean when choosing the
+	// variant over the control, if the variant is worse.
+	ExpectedLoss float64 `json:"expected_loss"`
+}
+
+// bayesian compares the variants to the control.
+func bayesian(exp *Experiment, res *ExperimentResults, opts *BayesianOptions) *BayesianResult {
+	control := controlVariant(exp, opts.Control)
+
+	out := &BayesianResult{
+		Control: control,
+	}
+
+	type beta struct{ a, b float64 }
+	posteriors := make(map[string]beta)
+	for _, v := range res.Attribution.Variants {
+		posteriors[v.Variant] = beta{
+			a: float64(v.Converted) + 1,
+			b: float64(v.Exposures-v.Converted) + 1,
+		}
+	}
+
+	c, ok := posteriors[control]
+	if !ok {
+		c = beta{1, 1}
+	}
+	for _, v := range exp.Variants {
+		if v.Name == control {
+			continue
+		}
+
+		p, ok := posteriors[v.Name]
+		if !ok {
+			p = beta{1, 1}
+		}
+
+		out.Conversion = append(out.Conversion, VariantPosterior{
+			Variant:                  v.Name,
+			Mean:                     p.a / (p.a + p.b),
+			ProbabilityToBeatControl: betaProbGreater(c.a, c.b, p.a, p.b),
+			ExpectedLoss:             betaExpectedLoss(c.a, c.b, p.a, p.b),
+		})
+	}
+
+	for metric, summaries := range res.Metrics {
+		i := slices.IndexFunc(summaries, func(m MetricSummary) bool {
+			return m.Variant == control
+		})
+		if i < 0 {
+			continue
+		}
+		cm, cse := summaries[i].Mean(), summaries[i].StdErr()
+
+		for _, m := range summaries {
+			if m.Variant == control {
+				continue
+			}
+			if out.Metrics == nil {
+				out.Metrics = make(map[string][]VariantPosterior)
+			}
+
+			mean, se := m.Mean(), m.StdErr()
+			out.Metrics[metric] = append(out.Metrics[metric], VariantPosterior{
+				Variant:                  m.Variant,
+				Mean:                     mean,
+				ProbabilityToBeatControl: normalProbGreater(cm, cse, mean, se),
+				ExpectedLoss:             normalExpectedLoss(cm, cse, mean, se),
+			})
+		}
+	}
+
+	return out
+}
+
+// controlVariant returns the control variant, which defaults to the default
+// variant of the experiment, or the first variant.
+func controlVariant(exp *Experiment, control string) string {
+	control = cmp.Or(control, exp.Default)
+	if !slices.ContainsFunc(exp.Variants, func(v Variant) bool { return v.Name == control }) && len(exp.Variants) > 0 {
+		control = exp.Variants[0].Name
+	}
+
+	return control
+}
+
+// betaProbGreater returns P(Y > X), where X ~ Beta(a1, b1) and
+// Y ~ Beta(a2, b2), with the closed form for integer a2, see
+// https://www.evanmiller.org/bayesian-ab-testing.html.
+func betaProbGreater(a1, b1, a2, b2 float64) float64 {
+	var p float64
+	for i := 0.0; i < a2; i++ {
+		p += math.Exp(lbeta(a1+i, b1+b2) - math.Log(b2+i) - lbeta(1+i, b2) - lbeta(a1, b1))
+	}
+
+	return min(max(p, 0), 1)
+}
+
+// betaExpectedLoss returns E[max(X - Y, 0)], where X ~ Beta(a1, b1) and
+// Y ~ Beta(a2, b2).
+func betaExpectedLoss(a1, b1, a2, b2 float64) float64 {
+	// E[X 1{X > Y}] - E[Y 1{X > Y}], where the weighted expectations are the
+	// probabilities with the parameter incremented.
+	x := a1 / (a1 + b1) * (1 - betaProbGreater(a1+1, b1, a2, b2))
+	y := a2 / (a2 + b2) * (1 - betaProbGreater(a1, b1, a2+1, b2))
+
+	return max(x-y, 0)
+}
+
+func lbeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+
+	return la + lb - lab
+}
+
+// normalProbGreater returns P(Y > X), where X ~ N(m1, s1²) and
+// Y ~ N(m2, s2²).
+func normalProbGreater(m1, s1, m2, s2 float64) float64 {
+	s := math.Hypot(s1, s2)
+	if s == 0 {
+		switch {
+		case m2 > m1:
+			return 1
+		case m2 < m1:
+			return 0
+		default:
+			return 0.5
+		}
+	}
+
+	return normalCDF((m2 - m1) / s)
+}
+
+// normalExpectedLoss returns E[max(X - Y, 0)], where X ~ N(m1, s1²) and
+// Y ~ N(m2, s2²).
+func normalExpectedLoss(m1, s1, m2, s2 float64) float64 {
+	m := m1 - m2
+	s := math.Hypot(s1, s2)
+	if s == 0 {
+		return max(m, 0)
+	}
+
+	z := m / s
+	return m*normalCDF(z) + s*math.Exp(-z*z/2)/math.Sqrt(2*math.Pi)
+}
+
+func normalCDF(z float64) float64 {
+	return math.Erfc(-z/math.Sqrt2) / 2
+}
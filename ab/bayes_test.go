@@ -0,0 +1,67 @@
+package ab_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadResultsBayesian(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(store.SaveExperiment(ctx, &ab.Experiment{
+		ID:      "checkout",
+		Rollout: 100,
+		Default: "control",
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+	}))
+
+	// 10% conversion for control, 20% for treatment.
+	for i := range 1000 {
+		variant := "control"
+		converted := i%20 == 1
+		value := 10.0 + float64(i%3)
+		if i%2 == 0 {
+			variant = "treatment"
+			converted = i%10 == 0
+			value += 1
+		}
+		userID := fmt.Sprint("user-", i)
+		at := now.Add(time.Duration(i) * time.Minute)
+		is.Nil(store.SaveExposure(ctx, "checkout", ab.Exposure{UserID: userID, Variant: variant, At: at}))
+		if converted {
+			is.Nil(store.SaveConversion(ctx, "checkout", ab.Conversion{UserID: userID, At: at.Add(time.Minute)}))
+		}
+		is.Nil(store.AddMetric(ctx, "checkout", "revenue", variant, value))
+	}
+
+	res, err := ab.LoadResults(ctx, store, "checkout", &ab.ResultsOptions{
+		Metrics:  []string{"revenue"},
+		Bayesian: &ab.BayesianOptions{},
+	})
+	is.Nil(err)
+	is.Equal("control", res.Bayesian.Control)
+	is.Len(res.Bayesian.Conversion, 1)
+
+	conv := res.Bayesian.Conversion[0]
+	is.Equal("treatment", conv.Variant)
+	is.InDelta(101.0/502, conv.Mean, 1e-9)
+	is.Greater(conv.ProbabilityToBeatControl, 0.99)
+	is.Less(conv.ExpectedLoss, 1e-4)
+
+	revenue := res.Bayesian.Metrics["revenue"]
+	is.Len(revenue, 1)
+	is.InDelta(12, revenue[0].Mean, 0.01)
+	is.Greater(revenue[0].ProbabilityToBeatControl, 0.99)
+}
@@ -0,0 +1,202 @@
+package ab
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sync"
+)
+
+// CUPED reduces the variance of a metric using a pre-experiment covariate of
+// the same users, e.g. the revenue in the weeks before the experiment.
+// The metric is adjusted by the part explained by the covariate:
+//
+//	Y' = Y - θ(X - mean(X)), where θ = cov(X, Y) / var(X)
+//
+// θ is estimated from all the users regardless of the variant, so the
+// adjusted means remain unbiased, while the variances shrink by the squared
+// correlation between the covariate and the metric.
+type CUPED struct {
+	mu         sync.Mutex
+	covariates map[string]float64
+	users      map[string]*cupedUser
+}
+
+type cupedUser struct {
+	variant string
+	value   float64
+}
+
+func NewCUPED() *CUPED {
+	return &CUPED{
+		covariates: make(map[string]float64),
+		users:      make(map[string]*cupedUser),
+	}
+}
+
+// SetCovariate sets the pre-experiment covariate of the user. Users without a
+// covariate are assigned the mean covariate, so they are not adjusted.
+func (c *CUPED) SetCovariate(userID string, x float64) {
+	c.mu.Lock()
+	c.covariates[userID] = x
+	c.mu.Unlock()
+}
+
+// Observe adds the value to the metric of the user in the variant. The values
+// are summed per user, e.g. the revenue of every purchase.
+func (c *CUPED) Observe(userID, variant string, value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	u, ok := c.users[userID]
+	if !ok {
+		u = &cupedUser{variant: variant}
+		c.users[userID] = u
+	}
+	u.value += value
+}
+
+type VariantCUPED struct {
+	Variant  string  `json:"variant"`
+	Users    int     `json:"users"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+
+	AdjustedMean     float64 `json:"adjusted_mean"`
+	AdjustedVariance float64 `json:"adjusted_variance"`
+}
+
+// StdErr returns the standard error of the adjusted mean.
+func (v VariantCUPED) StdErr() float64 {
+	if v.Users == 0 {
+		return 0
+	}
+
+	return math.Sqrt(v.AdjustedVariance / float64(v.Users))
+}
+
+type CUPEDResult struct {
+	Theta float64 `json:"theta"`
+
+	// VarianceReduction is the fraction of the variance removed by the
+	// adjustment, across all the users.
+	VarianceReduction float64        `json:"variance_reduction"`
+	Variants          []VariantCUPED `json:"variants"`
+}
+
+// Result computes the adjusted mean and variance per variant.
+func (c *CUPED) Result() *CUPEDResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n, sumX float64
+	for userID := range c.users {
+		if x, ok := c.covariates[userID]; ok {
+			sumX += x
+			n++
+		}
+	}
+	var meanX float64
+	if n > 0 {
+		meanX = sumX / n
+	}
+
+	covariate := func(userID string) float64 {
+		if x, ok := c.covariates[userID]; ok {
+			return x
+		}
+
+		return meanX
+	}
+
+	var meanY float64
+	for _, u := range c.users {
+		meanY += u.value
+	}
+	if len(c.users) > 0 {
+		meanY /= float64(len(c.users))
+	}
+
+	var cov, varX float64
+	for userID, u := range c.users {
+		dx := covariate(userID) - meanX
+		cov += dx * (u.value - meanY)
+		varX += dx * dx
+	}
+
+	var theta float64
+	if varX > 0 {
+		theta = cov / varX
+	}
+
+	type stats struct {
+		y, adj []float64
+	}
+	byVariant := make(map[string]*stats)
+	var all, allAdj []float64
+	for userID, u := range c.users {
+		s, ok := byVariant[u.variant]
+		if !ok {
+			s = new(stats)
+			byVariant[u.variant] = s
+		}
+
+		adj := u.value - theta*(covariate(userID)-meanX)
+		s.y = append(s.y, u.value)
+		s.adj = append(s.adj, adj)
+		all = append(all, u.value)
+		allAdj = append(allAdj, adj)
+	}
+
+	variants := make([]VariantCUPED, 0, len(byVariant))
+	for variant, s := range byVariant {
+		mean, variance := meanVariance(s.y)
+		adjMean, adjVariance := meanVariance(s.adj)
+		variants = append(variants, VariantCUPED{
+			Variant:          variant,
+			Users:            len(s.y),
+			Mean:             mean,
+			Variance:         variance,
+			AdjustedMean:     adjMean,
+			AdjustedVariance: adjVariance,
+		})
+	}
+	slices.SortFunc(variants, func(a, b VariantCUPED) int {
+		return cmp.Compare(a.Variant, b.Variant)
+	})
+
+	var reduction float64
+	if _, v := meanVariance(all); v > 0 {
+		_, adjV := meanVariance(allAdj)
+		reduction = 1 - adjV/v
+	}
+
+	return &CUPEDResult{
+		Theta:             theta,
+		VarianceReduction: reduction,
+		Variants:          variants,
+	}
+}
+
+// meanVariance returns the mean and the sample variance.
+func meanVariance(xs []float64) (mean, variance float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+
+	if len(xs) < 2 {
+		return mean, 0
+	}
+
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	variance /= float64(len(xs) - 1)
+
+	return mean, variance
+}
@@ -0,0 +1,61 @@
+package ab_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCUPED(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+
+	c := ab.NewCUPED()
+	for i := range 2_000 {
+		userID := fmt.Sprint("user-", i)
+		variant, lift := "control", 0.0
+		if i%2 == 1 {
+			variant, lift = "treatment", 1.0
+		}
+
+		// The pre-period revenue explains most of the revenue.
+		pre := 100 + 20*r.NormFloat64()
+		c.SetCovariate(userID, pre)
+		c.Observe(userID, variant, pre+lift+r.NormFloat64())
+	}
+
+	res := c.Result()
+
+	is := assert.New(t)
+	is.InDelta(1, res.Theta, 0.05)
+	is.Greater(res.VarianceReduction, 0.99)
+
+	control, treatment := res.Variants[0], res.Variants[1]
+	is.Equal("control", control.Variant)
+	is.Equal(1_000, control.Users)
+	is.Less(control.AdjustedVariance, control.Variance/100)
+	is.InDelta(1, treatment.AdjustedMean-control.AdjustedMean, 0.2)
+	is.Less(treatment.StdErr(), 0.05)
+}
+
+func TestCUPEDMissingCovariate(t *testing.T) {
+	c := ab.NewCUPED()
+	c.Observe("a", "control", 1)
+	c.Observe("a", "control", 2)
+	c.Observe("b", "control", 5)
+
+	res := c.Result()
+
+	is := assert.New(t)
+	is.Equal(0.0, res.Theta)
+	is.Equal([]ab.VariantCUPED{{
+		Variant:          "control",
+		Users:            2,
+		Mean:             4,
+		Variance:         2,
+		AdjustedMean:     4,
+		AdjustedVariance: 2,
+	}}, res.Variants)
+}
@@ -0,0 +1,157 @@
+package ab
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+type Interaction struct {
+	UserID string
+	ItemID string
+	At     time.Time
+}
+
+// Recommender is a recommendation algorithm that can be evaluated offline.
+type Recommender interface {
+	// Fit trains the recommender on the interactions.
+	Fit(interactions []Interaction)
+	// Recommend returns up to k items for the user, ordered by relevance.
+	Recommend(userID string, k int) []string
+}
+
+type Evaluation struct {
+	Name      string
+	Users     int
+	Precision float64 // Mean precision@k.
+	Recall    float64 // Mean recall@k.
+	NDCG      float64 // Mean NDCG@k.
+	// Coverage is the fraction of the items that are recommended to at least
+	// one user.
+	Coverage float64
+}
+
+// SplitByTime splits the interactions into the train set before the time,
+// and the test set at or after the time.
+func SplitByTime(interactions []Interaction, at time.Time) (train, test []Interaction) {
+	for _, i := range interactions {
+		if i.At.Before(at) {
+			train = append(train, i)
+		} else {
+			test = append(test, i)
+		}
+	}
+
+	return
+}
+
+// Evaluate fits each recommender on the interactions before the split time,
+// and measures the top k recommendations against the interactions after.
+// The results are returned in the order of the names.
+func Evaluate(recommenders map[string]Recommender, interactions []Interaction, split time.Time, k int) []Evaluation {
+	train, test := SplitByTime(interactions, split)
+
+	items := make(map[string]bool)
+	for _, i := range interactions {
+		items[i.ItemID] = true
+	}
+
+	relevant := make(map[string]map[string]bool)
+	for _, i := range test {
+		if relevant[i.UserID] == nil {
+			relevant[i.UserID] = make(map[string]bool)
+		}
+		relevant[i.UserID][i.ItemID] = true
+	}
+
+	names := make([]string, 0, len(recommenders))
+	for name := range recommenders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	res := make([]Evaluation, len(names))
+	for n, name := range names {
+		r := recommenders[name]
+		r.Fit(train)
+
+		e := Evaluation{Name: name, Users: len(relevant)}
+		recommended := make(map[string]bool)
+		for userID, rel := range relevant {
+			recs := r.Recommend(userID, k)
+
+			var hits int
+			var dcg float64
+			for i, item := range recs {
+				recommended[item] = true
+				if rel[item] {
+					hits++
+					dcg += 1 / math.Log2(float64(i+2))
+				}
+			}
+
+			var idcg float64
+			for i := range min(len(rel), k) {
+				idcg += 1 / math.Log2(float64(i+2))
+			}
+
+			e.Precision += float64(hits) / float64(k)
+			e.Recall += float64(hits) / float64(len(rel))
+			e.NDCG += dcg / idcg
+		}
+
+		if e.Users > 0 {
+			e.Precision /= float64(e.Users)
+			e.Recall /= float64(e.Users)
+			e.NDCG /= float64(e.Users)
+		}
+		if len(items) > 0 {
+			e.Coverage = float64(len(recommended)) / float64(len(items))
+		}
+		res[n] = e
+	}
+
+	return res
+}
+
+// Popularity recommends the most interacted items that the user has not
+// interacted with. It is the baseline for other recommenders.
+type Popularity struct {
+	items []string
+	seen  map[string]map[string]bool
+}
+
+func (p *Popularity) Fit(interactions []Interaction) {
+	counts := make(map[string]int)
+	p.seen = make(map[string]map[string]bool)
+	for _, i := range interactions {
+		counts[i.ItemID]++
+		if p.seen[i.UserID] == nil {
+			p.seen[i.UserID] = make(map[string]bool)
+		}
+		p.seen[i.UserID][i.ItemID] = true
+	}
+
+	p.items = make([]string, 0, len(counts))
+	for item := range counts {
+		p.items = append(p.items, item)
+	}
+	slices.SortFunc(p.items, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+}
+
+func (p *Popularity) Recommend(userID string, k int) []string {
+	var res []string
+	for _, item := range p.items {
+		if len(res) == k {
+			break
+		}
+		if !p.seen[userID][item] {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
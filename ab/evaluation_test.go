@@ -0,0 +1,43 @@
+package ab_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRecommender []string
+
+func (s staticRecommender) Fit([]ab.Interaction) {}
+
+func (s staticRecommender) Recommend(userID string, k int) []string {
+	return s[:min(k, len(s))]
+}
+
+func TestEvaluate(t *testing.T) {
+	split := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	before := split.Add(-time.Hour)
+	after := split.Add(time.Hour)
+
+	interactions := []ab.Interaction{
+		{UserID: "a", ItemID: "x", At: before},
+		{UserID: "b", ItemID: "x", At: before},
+		{UserID: "b", ItemID: "y", At: before},
+		{UserID: "c", ItemID: "z", At: before},
+		{UserID: "a", ItemID: "y", At: after},
+		{UserID: "c", ItemID: "x", At: after},
+	}
+
+	res := ab.Evaluate(map[string]ab.Recommender{
+		"popularity": new(ab.Popularity),
+		"static":     staticRecommender{"z", "y"},
+	}, interactions, split, 1)
+
+	is := assert.New(t)
+	is.Equal([]ab.Evaluation{
+		{Name: "popularity", Users: 2, Precision: 1, Recall: 1, NDCG: 1, Coverage: 2.0 / 3},
+		{Name: "static", Users: 2, Precision: 0, Recall: 0, NDCG: 0, Coverage: 1.0 / 3},
+	}, res)
+}
@@ -0,0 +1,355 @@
+package ab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spaolacci/murmur3"
+)
+
+var (
+	ErrExperimentNotFound = errors.New("ab: experiment not found")
+	ErrNoVariants         = errors.New("ab: experiment has no variants")
+)
+
+// Reasons for the assignment decision.
+const (
+	ReasonPaused         = "paused"
+	ReasonRuleNotMatched = "rule not matched"
+	ReasonNotInRollout   = "not in rollout"
+	ReasonAssigned       = "assigned"
+)
+
+type Variant struct {
+	Name   string `json:"name"`
+	Weight uint64 `json:"weight"`
+}
+
+// Rule targets the users that are eligible for the experiment. Attributes of
+// the user, such as the country, can be passed through the context.
+type Rule struct {
+	Name  string                                        `json:"name"`
+	Match func(ctx context.Context, userID string) bool `json:"-"`
+}
+
+type Experiment struct {
+	ID string `json:"id"`
+	// Seed is the salt of the hash of the experiment id and the user id.
+	// Changing the seed reshuffles the users between the variants.
+	Seed uint32 `json:"seed"`
+	// Rollout is the percentage of eligible users included in the
+	// experiment, from 0 to 100.
+	Rollout uint64 `json:"rollout"`
+	// Rules are not persisted by the stores, since the matchers are
+	// functions.
+	Rules    []Rule    `json:"-"`
+	Variants []Variant `json:"variants"`
+	// Default is the variant for users excluded from the experiment.
+	Default string `json:"default"`
+	// Paused excludes all users from the experiment.
+	Paused bool `json:"paused"`
+	// Layer is the id of the layer of the mutually exclusive experiments, if
+	// any.
+	Layer string `json:"layer,omitempty"`
+	// Segments are the ids of the segments the users must be in, which are
+	// evaluated as rules.
+	Segments []string `json:"segments,omitempty"`
+}
+
+// Explanation is the decision trace of the assignment of a user.
+type Explanation struct {
+	ExperimentID string `json:"experiment_id"`
+	UserID       string `json:"user_id"`
+	Seed         uint32 `json:"seed"`
+	Layer        string `json:"layer,omitempty"`
+	// LayerBucket is the bucket of the user in the layer, which must be
+	// allocated to the experiment to be included.
+	LayerBucket uint64       `json:"layer_bucket"`
+	Rules       []RuleResult `json:"rules"`
+	// RolloutBucket is the bucket of the user, from 0 to 99, which must be
+	// less than the Rollout to be included.
+	RolloutBucket uint64 `json:"rollout_bucket"`
+	Rollout       uint64 `json:"rollout"`
+	InRollout     bool   `json:"in_rollout"`
+	// VariantBucket is the bucket of the user within the total weight of the
+	// variants.
+	VariantBucket uint64 `json:"variant_bucket"`
+	Variant       string `json:"variant"`
+	Reason        string `json:"reason"`
+}
+
+type RuleResult struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+}
+
+// ExposureLogger records the users that actually saw the variant, so that the
+// analytics do not count the users that were assigned but never exposed.
+type ExposureLogger interface {
+	LogExposure(ctx context.Context, experimentID string, e Exposure) error
+}
+
+type ExposureLoggerFunc func(ctx context.Context, experimentID string, e Exposure) error
+
+func (f ExposureLoggerFunc) LogExposure(ctx context.Context, experimentID string, e Exposure) error {
+	return f(ctx, experimentID, e)
+}
+
+// Assigner assigns users to the variants of the experiments
+// deterministically, so that the same user always gets the same variant for
+// the same seed, across instances and without shared state.
+type Assigner struct {
+	// ExposureLogger logs the exposures from Expose.
+	ExposureLogger ExposureLogger
+	// Exporter exports the assignments from Assign and Expose. The exposures
+	// and conversions are exported by the store, see ExportEvents.
+	Exporter Exporter
+	// Segments resolves the segments of the experiments.
+	Segments *Segments
+	Now      func() time.Time
+
+	mu          sync.RWMutex
+	experiments map[string]*Experiment
+	layers      map[string]*Layer
+}
+
+func NewAssigner(experiments ...*Experiment) *Assigner {
+	a := &Assigner{
+		Segments:    NewSegments(),
+		Now:         time.Now,
+		experiments: make(map[string]*Experiment),
+		layers:      make(map[string]*Layer),
+	}
+	for _, exp := range experiments {
+		a.experiments[exp.ID] = exp
+	}
+
+	return a
+}
+
+func (a *Assigner) Add(exp *Experiment) {
+	a.mu.Lock()
+	a.experiments[exp.ID] = exp
+	a.mu.Unlock()
+}
+
+// AddLayer adds or replaces the layer of the experiments.
+func (a *Assigner) AddLayer(l *Layer) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.layers[l.ID] = l
+	a.mu.Unlock()
+
+	return nil
+}
+
+// Load adds the stored experiments. Since the rules are not persisted, the
+// rules of the experiments already added are kept.
+func (a *Assigner) Load(ctx context.Context, s ExperimentStore) error {
+	exps, err := s.ListExperiments(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, exp := range exps {
+		if prev, ok := a.experiments[exp.ID]; ok {
+			exp.Rules = prev.Rules
+		}
+		a.experiments[exp.ID] = exp
+	}
+
+	return nil
+}
+
+// Assign returns the variant of the user.
+// The variant is returned even when the assignment fails to be exported.
+func (a *Assigner) Assign(ctx context.Context, experimentID, userID string) (string, error) {
+	e, err := a.Explain(ctx, experimentID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return e.Variant, a.export(ctx, e)
+}
+
+// Expose is like Assign, but also logs the exposure when the user is in the
+// experiment. It should be called when the variant is shown to the user.
+// The variant is returned even when the exposure fails to be logged.
+func (a *Assigner) Expose(ctx context.Context, experimentID, userID string) (string, error) {
+	e, err := a.Explain(ctx, experimentID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	// The users excluded from the experiment get the default variant, and
+	// are not part of the analysis.
+	if e.Reason != ReasonAssigned || a.ExposureLogger == nil {
+		return e.Variant, a.export(ctx, e)
+	}
+
+	err = a.ExposureLogger.LogExposure(ctx, experimentID, Exposure{
+		UserID:  userID,
+		Variant: e.Variant,
+		At:      a.Now(),
+	})
+
+	return e.Variant, errors.Join(err, a.export(ctx, e))
+}
+
+// export exports the assignment of the users in the experiment.
+func (a *Assigner) export(ctx context.Context, e *Explanation) error {
+	if e.Reason != ReasonAssigned || a.Exporter == nil {
+		return nil
+	}
+
+	return a.Exporter.Export(ctx, Event{
+		Type:         EventAssignment,
+		ExperimentID: e.ExperimentID,
+		UserID:       e.UserID,
+		Variant:      e.Variant,
+		At:           a.Now(),
+	})
+}
+
+// Explain returns the full decision trace of the assignment, which can be
+// used to reproduce why the user is assigned to the variant.
+func (a *Assigner) Explain(ctx context.Context, experimentID, userID string) (*Explanation, error) {
+	a.mu.RLock()
+	exp, ok := a.experiments[experimentID]
+	var layer *Layer
+	if ok && exp.Layer != "" {
+		layer = a.layers[exp.Layer]
+	}
+	a.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExperimentNotFound, experimentID)
+	}
+	if len(exp.Segments) > 0 {
+		e := *exp
+		e.Rules = append(slices.Clone(exp.Rules), a.Segments.Rules(exp.Segments)...)
+		exp = &e
+	}
+	if exp.Layer == "" {
+		return exp.Explain(ctx, userID)
+	}
+	if layer == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLayerNotFound, exp.Layer)
+	}
+
+	// The rules are not evaluated for the users in the other experiments of
+	// the layer.
+	bucket := layer.Bucket(userID)
+	if id, _ := layer.Experiment(bucket); id != exp.ID {
+		return &Explanation{
+			ExperimentID: exp.ID,
+			UserID:       userID,
+			Seed:         exp.Seed,
+			Layer:        exp.Layer,
+			LayerBucket:  bucket,
+			Rollout:      exp.Rollout,
+			Variant:      exp.Default,
+			Reason:       ReasonNotInLayer,
+		}, nil
+	}
+
+	e, err := exp.Explain(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e.Layer = exp.Layer
+	e.LayerBucket = bucket
+
+	return e, nil
+}
+
+// Simulate assigns n generated users, and returns the number of users per
+// variant. The same seed generates the same users.
+func (a *Assigner) Simulate(ctx context.Context, experimentID string, n int, seed uint64) (map[string]int, error) {
+	r := rand.New(rand.NewPCG(seed, seed))
+
+	res := make(map[string]int)
+	for range n {
+		userID := strconv.FormatUint(r.Uint64(), 36)
+		variant, err := a.Assign(ctx, experimentID, userID)
+		if err != nil {
+			return nil, err
+		}
+		res[variant]++
+	}
+
+	return res, nil
+}
+
+func (exp *Experiment) Explain(ctx context.Context, userID string) (*Explanation, error) {
+	var total uint64
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoVariants, exp.ID)
+	}
+
+	e := &Explanation{
+		ExperimentID: exp.ID,
+		UserID:       userID,
+		Seed:         exp.Seed,
+		Rollout:      exp.Rollout,
+		Variant:      exp.Default,
+	}
+
+	if exp.Paused {
+		e.Reason = ReasonPaused
+		return e, nil
+	}
+
+	// All rules are evaluated, so that the trace is complete.
+	matched := true
+	for _, r := range exp.Rules {
+		ok := r.Match(ctx, userID)
+		e.Rules = append(e.Rules, RuleResult{Name: r.Name, Matched: ok})
+		matched = matched && ok
+	}
+	if !matched {
+		e.Reason = ReasonRuleNotMatched
+		return e, nil
+	}
+
+	// The rollout and variant buckets are hashed independently, so that
+	// increasing the rollout does not move existing users between variants.
+	e.RolloutBucket = HashSeed(exp.ID+":rollout:"+userID, exp.Seed, 100)
+	e.InRollout = e.RolloutBucket < exp.Rollout
+	if !e.InRollout {
+		e.Reason = ReasonNotInRollout
+		return e, nil
+	}
+
+	e.VariantBucket = HashSeed(exp.ID+":variant:"+userID, exp.Seed, total)
+	var cum uint64
+	for _, v := range exp.Variants {
+		cum += v.Weight
+		if e.VariantBucket < cum {
+			e.Variant = v.Name
+			break
+		}
+	}
+	e.Reason = ReasonAssigned
+
+	return e, nil
+}
+
+// HashSeed is like Hash, but with a seed.
+func HashSeed(key string, seed uint32, size uint64) uint64 {
+	return murmur3.Sum64WithSeed([]byte(key), seed) % size
+}
@@ -0,0 +1,123 @@
+package ab_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+type countryKey struct{}
+
+func TestAssignerExplain(t *testing.T) {
+	exp := &ab.Experiment{
+		ID:      "checkout",
+		Seed:    42,
+		Rollout: 100,
+		Rules: []ab.Rule{{
+			Name: "country is MY",
+			Match: func(ctx context.Context, userID string) bool {
+				return ctx.Value(countryKey{}) == "MY"
+			},
+		}},
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50},
+		},
+		Default: "control",
+	}
+	a := ab.NewAssigner(exp)
+
+	is := assert.New(t)
+	e, err := a.Explain(context.Background(), "checkout", "user-1")
+	is.Nil(err)
+	is.Equal(ab.ReasonRuleNotMatched, e.Reason)
+	is.Equal([]ab.RuleResult{{Name: "country is MY", Matched: false}}, e.Rules)
+	is.Equal("control", e.Variant)
+
+	ctx := context.WithValue(context.Background(), countryKey{}, "MY")
+	e, err = a.Explain(ctx, "checkout", "user-1")
+	is.Nil(err)
+	is.Equal(ab.ReasonAssigned, e.Reason)
+	is.True(e.InRollout)
+
+	// The assignment is reproducible.
+	again, err := a.Explain(ctx, "checkout", "user-1")
+	is.Nil(err)
+	is.Equal(e, again)
+
+	_, err = a.Explain(ctx, "unknown", "user-1")
+	is.ErrorIs(err, ab.ErrExperimentNotFound)
+}
+
+func TestAssignerSimulate(t *testing.T) {
+	a := ab.NewAssigner(&ab.Experiment{
+		ID:      "checkout",
+		Rollout: 50,
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+		Default: "excluded",
+	})
+
+	ctx := context.Background()
+	is := assert.New(t)
+	got, err := a.Simulate(ctx, "checkout", 10_000, 1)
+	is.Nil(err)
+
+	again, err := a.Simulate(ctx, "checkout", 10_000, 1)
+	is.Nil(err)
+	is.Equal(got, again)
+
+	is.InDelta(5_000, got["excluded"], 300)
+	is.InDelta(2_500, got["control"], 300)
+	is.InDelta(2_500, got["treatment"], 300)
+}
+
+func TestAssignerExpose(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := ab.NewAssigner(&ab.Experiment{
+		ID:      "checkout",
+		Seed:    42,
+		Rollout: 50,
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+		Default: "excluded",
+	})
+	a.Now = func() time.Time { return now }
+
+	var exposures []ab.Exposure
+	a.ExposureLogger = ab.ExposureLoggerFunc(func(ctx context.Context, experimentID string, e ab.Exposure) error {
+		exposures = append(exposures, e)
+		return nil
+	})
+
+	ctx := context.Background()
+	is := assert.New(t)
+
+	var excluded int
+	for i := range 10 {
+		userID := fmt.Sprint("user-", i)
+		variant, err := a.Expose(ctx, "checkout", userID)
+		is.Nil(err)
+
+		// Assign does not log the exposure.
+		assigned, err := a.Assign(ctx, "checkout", userID)
+		is.Nil(err)
+		is.Equal(variant, assigned)
+
+		if variant == "excluded" {
+			excluded++
+			continue
+		}
+		is.Equal(ab.Exposure{UserID: userID, Variant: variant, At: now}, exposures[len(exposures)-1])
+	}
+	is.Len(exposures, 10-excluded)
+	is.Positive(excluded)
+}
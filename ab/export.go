@@ -0,0 +1,125 @@
+package ab
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Types of the exported events.
+const (
+	EventAssignment = "assignment"
+	EventExposure   = "exposure"
+	EventConversion = "conversion"
+)
+
+// Event is the exported assignment, exposure or conversion, so that the data
+// warehouses can join the experiments with the product analytics. The JSON
+// schema is stable, with one event per line in JSONExporter:
+//
+//	{"type":"assignment","experiment_id":"checkout","user_id":"u1","variant":"b","at":"2024-01-01T00:00:00Z"}
+//	{"type":"exposure","experiment_id":"checkout","user_id":"u1","variant":"b","at":"2024-01-01T00:00:01Z"}
+//	{"type":"conversion","experiment_id":"checkout","user_id":"u1","value":9.9,"at":"2024-01-01T00:05:00Z"}
+//
+// The variant is empty for conversions, since they are attributed to the
+// variant at analysis time.
+type Event struct {
+	Type         string    `json:"type"`
+	ExperimentID string    `json:"experiment_id"`
+	UserID       string    `json:"user_id"`
+	Variant      string    `json:"variant,omitempty"`
+	Value        float64   `json:"value,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// Exporter streams the events to a sink, e.g. a channel, a file, or a message
+// broker through ExporterFunc:
+//
+//	exporter := ab.ExporterFunc(func(ctx context.Context, e ab.Event) error {
+//		return publisher.Publish(ctx, "ab.events", e)
+//	})
+type Exporter interface {
+	Export(ctx context.Context, e Event) error
+}
+
+type ExporterFunc func(ctx context.Context, e Event) error
+
+func (f ExporterFunc) Export(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// ChanExporter sends the events to the channel, blocking until the event is
+// received or the context is done.
+type ChanExporter chan<- Event
+
+func (ch ChanExporter) Export(ctx context.Context, e Event) error {
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case ch <- e:
+		return nil
+	}
+}
+
+// JSONExporter writes the events as newline-delimited JSON, e.g. to a file.
+type JSONExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (j *JSONExporter) Export(ctx context.Context, e Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.enc.Encode(e)
+}
+
+// ExportEvents returns the store that also exports the exposures and the
+// conversions saved, e.g. by the Handler, after they are saved.
+func ExportEvents(s Store, e Exporter) Store {
+	return &exportStore{
+		Store:    s,
+		exporter: e,
+	}
+}
+
+type exportStore struct {
+	Store
+	exporter Exporter
+}
+
+func (s *exportStore) SaveExposure(ctx context.Context, experimentID string, e Exposure) error {
+	if err := s.Store.SaveExposure(ctx, experimentID, e); err != nil {
+		return err
+	}
+
+	return s.exporter.Export(ctx, Event{
+		Type:         EventExposure,
+		ExperimentID: experimentID,
+		UserID:       e.UserID,
+		Variant:      e.Variant,
+		At:           e.At,
+	})
+}
+
+func (s *exportStore) SaveConversion(ctx context.Context, experimentID string, c Conversion) error {
+	if err := s.Store.SaveConversion(ctx, experimentID, c); err != nil {
+		return err
+	}
+
+	return s.exporter.Export(ctx, Event{
+		Type:         EventConversion,
+		ExperimentID: experimentID,
+		UserID:       c.UserID,
+		Value:        c.Value,
+		At:           c.At,
+	})
+}
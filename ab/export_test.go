@@ -0,0 +1,69 @@
+package ab_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	exporter := ab.NewJSONExporter(&buf)
+	store := ab.ExportEvents(ab.NewRedisStore(redistest.New(t).Client()), exporter)
+
+	a := ab.NewAssigner(&ab.Experiment{
+		ID:       "checkout",
+		Rollout:  100,
+		Variants: []ab.Variant{{Name: "treatment", Weight: 1}},
+	})
+	a.Now = func() time.Time { return now }
+	a.Exporter = exporter
+	a.ExposureLogger = ab.ExposureLoggerFunc(store.SaveExposure)
+
+	is := assert.New(t)
+	_, err := a.Expose(ctx, "checkout", "user-1")
+	is.Nil(err)
+	is.Nil(store.SaveConversion(ctx, "checkout", ab.Conversion{
+		UserID: "user-1",
+		Value:  9.9,
+		At:     now.Add(time.Minute),
+	}))
+
+	var events []ab.Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e ab.Event
+		is.Nil(dec.Decode(&e))
+		events = append(events, e)
+	}
+
+	is.Equal([]ab.Event{
+		{Type: ab.EventExposure, ExperimentID: "checkout", UserID: "user-1", Variant: "treatment", At: now},
+		{Type: ab.EventAssignment, ExperimentID: "checkout", UserID: "user-1", Variant: "treatment", At: now},
+		{Type: ab.EventConversion, ExperimentID: "checkout", UserID: "user-1", Value: 9.9, At: now.Add(time.Minute)},
+	}, events)
+}
+
+func TestChanExporter(t *testing.T) {
+	ch := make(chan ab.Event, 1)
+	exporter := ab.ChanExporter(ch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	is := assert.New(t)
+	is.Nil(exporter.Export(ctx, ab.Event{Type: ab.EventAssignment}))
+	is.Equal(ab.EventAssignment, (<-ch).Type)
+
+	// Blocks until the context is canceled when the channel is full.
+	ch <- ab.Event{}
+	cancel()
+	is.ErrorIs(exporter.Export(ctx, ab.Event{}), context.Canceled)
+}
@@ -0,0 +1,62 @@
+package ab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var ErrFlagNotFound = errors.New("ab: flag not found")
+
+// Reasons for the flag evaluation.
+const (
+	ReasonDisabled  = "disabled"
+	ReasonInRollout = "in rollout"
+)
+
+// Flag serves the Value to the percentage of users in the rollout when
+// enabled, and the Default otherwise. The values are JSON, so that the flags
+// can be evaluated by non-Go services.
+type Flag struct {
+	ID      string `json:"id"`
+	Enabled bool   `json:"enabled"`
+	// Rollout is the percentage of users served the Value, from 0 to 100.
+	Rollout uint64          `json:"rollout"`
+	Value   json.RawMessage `json:"value"`
+	Default json.RawMessage `json:"default"`
+	// Segments are the ids of the segments the users must be in to be served
+	// the Value.
+	Segments []string `json:"segments,omitempty"`
+}
+
+type FlagResult struct {
+	FlagID string          `json:"flag_id"`
+	UserID string          `json:"user_id"`
+	Value  json.RawMessage `json:"value"`
+	Reason string          `json:"reason"`
+}
+
+// Evaluate returns the value of the flag for the user, with the attributes
+// in the context. The rollout is deterministic, so increasing the rollout
+// only adds users.
+func (f *Flag) Evaluate(ctx context.Context, userID string, segments *Segments) *FlagResult {
+	res := &FlagResult{
+		FlagID: f.ID,
+		UserID: userID,
+		Value:  f.Default,
+	}
+
+	switch {
+	case !f.Enabled:
+		res.Reason = ReasonDisabled
+	case !segments.Match(ctx, userID, f.Segments):
+		res.Reason = ReasonRuleNotMatched
+	case HashSeed(f.ID+":rollout:"+userID, 0, 100) >= f.Rollout:
+		res.Reason = ReasonNotInRollout
+	default:
+		res.Value = f.Value
+		res.Reason = ReasonInRollout
+	}
+
+	return res
+}
@@ -0,0 +1,41 @@
+package ab_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagEvaluate(t *testing.T) {
+	f := &ab.Flag{
+		ID:      "new-checkout",
+		Rollout: 50,
+		Value:   json.RawMessage(`true`),
+		Default: json.RawMessage(`false`),
+	}
+
+	ctx := context.Background()
+	is := assert.New(t)
+	res := f.Evaluate(ctx, "user-1", nil)
+	is.Equal(ab.ReasonDisabled, res.Reason)
+	is.JSONEq(`false`, string(res.Value))
+
+	f.Enabled = true
+
+	var in int
+	for i := range 1_000 {
+		res := f.Evaluate(ctx, fmt.Sprint("user-", i), nil)
+		if res.Reason == ab.ReasonInRollout {
+			is.JSONEq(`true`, string(res.Value))
+			in++
+		} else {
+			is.Equal(ab.ReasonNotInRollout, res.Reason)
+			is.JSONEq(`false`, string(res.Value))
+		}
+	}
+	is.InDelta(500, in, 50)
+}
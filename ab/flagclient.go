@@ -0,0 +1,224 @@
+package ab
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FlagWatcher streams the ids of the changed flags, until the context is
+// done.
+type FlagWatcher interface {
+	WatchFlags(ctx context.Context) (<-chan string, error)
+}
+
+type FlagClientOptions struct {
+	// RefreshInterval is the interval to reload all the flags, in case the
+	// changes are missed. Defaults to 1m.
+	RefreshInterval time.Duration
+}
+
+func (o *FlagClientOptions) valid() *FlagClientOptions {
+	o = cmp.Or(o, &FlagClientOptions{})
+	o.RefreshInterval = cmp.Or(o.RefreshInterval, time.Minute)
+
+	return o
+}
+
+// FlagClient evaluates the flags locally from an in-memory snapshot of the
+// store, which is kept up to date by Sync. The segments are also loaded if
+// the store is a SegmentStore.
+//
+//	c := ab.NewFlagClient(store, nil)
+//	if err := c.Load(ctx); err != nil {
+//		return err
+//	}
+//
+//	stop, errCh := c.Sync(ctx)
+//	defer stop()
+//
+//	if c.BoolFlag(ctx, "new-checkout", userID, false) {
+//		// ...
+//	}
+type FlagClient struct {
+	store FlagStore
+	opts  *FlagClientOptions
+
+	mu       sync.RWMutex
+	flags    map[string]*Flag
+	segments *Segments
+}
+
+func NewFlagClient(store FlagStore, opts *FlagClientOptions) *FlagClient {
+	return &FlagClient{
+		store: store,
+		opts:  opts.valid(),
+		flags: make(map[string]*Flag),
+	}
+}
+
+// Load replaces the snapshot with all the flags and segments in the store.
+func (c *FlagClient) Load(ctx context.Context) error {
+	flags, err := c.store.ListFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	m := make(map[string]*Flag, len(flags))
+	for _, f := range flags {
+		m[f.ID] = f
+	}
+
+	var segments *Segments
+	if s, ok := c.store.(SegmentStore); ok {
+		segments, err = LoadSegments(ctx, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.flags = m
+	c.segments = segments
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Sync reloads the flags and segments periodically, and the changed flags as
+// soon as they are changed if the store is a FlagWatcher. The errors are sent
+// to the channel, which must be drained.
+func (c *FlagClient) Sync(ctx context.Context) (func(), <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error)
+	send := func(err error) {
+		select {
+		case <-ctx.Done():
+		case errCh <- err:
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(errCh)
+
+		var changes <-chan string
+		if w, ok := c.store.(FlagWatcher); ok {
+			var err error
+			changes, err = w.WatchFlags(ctx)
+			if err != nil {
+				send(err)
+			}
+		}
+
+		// Reload after watching, so that the changes in between are not
+		// missed.
+		if err := c.Load(ctx); err != nil {
+			send(err)
+		}
+
+		t := time.NewTicker(c.opts.RefreshInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := c.Load(ctx); err != nil {
+					send(err)
+				}
+			case id, ok := <-changes:
+				if !ok {
+					// Fallback to the periodic reload.
+					changes = nil
+					continue
+				}
+				if err := c.reload(ctx, id); err != nil {
+					send(err)
+				}
+			}
+		}
+	}()
+
+	return sync.OnceFunc(func() {
+		cancel()
+		wg.Wait()
+	}), errCh
+}
+
+// Evaluate evaluates the flag from the snapshot, for the user with the
+// attributes in the context.
+func (c *FlagClient) Evaluate(ctx context.Context, flagID, userID string) (*FlagResult, error) {
+	c.mu.RLock()
+	f, ok := c.flags[flagID]
+	segments := c.segments
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFlagNotFound, flagID)
+	}
+
+	return f.Evaluate(ctx, userID, segments), nil
+}
+
+// BoolFlag returns the value of the flag for the user, or the default if the
+// flag does not exist or is not a bool.
+func (c *FlagClient) BoolFlag(ctx context.Context, flagID, userID string, def bool) bool {
+	return flagValue(ctx, c, flagID, userID, def)
+}
+
+// StringFlag is like BoolFlag, but for string values.
+func (c *FlagClient) StringFlag(ctx context.Context, flagID, userID string, def string) string {
+	return flagValue(ctx, c, flagID, userID, def)
+}
+
+// IntFlag is like BoolFlag, but for integer values.
+func (c *FlagClient) IntFlag(ctx context.Context, flagID, userID string, def int) int {
+	return flagValue(ctx, c, flagID, userID, def)
+}
+
+// FloatFlag is like BoolFlag, but for number values.
+func (c *FlagClient) FloatFlag(ctx context.Context, flagID, userID string, def float64) float64 {
+	return flagValue(ctx, c, flagID, userID, def)
+}
+
+func (c *FlagClient) reload(ctx context.Context, id string) error {
+	f, err := c.store.LoadFlag(ctx, id)
+	if errors.Is(err, ErrFlagNotFound) {
+		c.mu.Lock()
+		delete(c.flags, id)
+		c.mu.Unlock()
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.flags[id] = f
+	c.mu.Unlock()
+
+	return nil
+}
+
+func flagValue[T any](ctx context.Context, c *FlagClient, flagID, userID string, def T) T {
+	res, err := c.Evaluate(ctx, flagID, userID)
+	if err != nil || len(res.Value) == 0 {
+		return def
+	}
+
+	var v T
+	if err := json.Unmarshal(res.Value, &v); err != nil {
+		return def
+	}
+
+	return v
+}
@@ -0,0 +1,57 @@
+package ab_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagClient(t *testing.T) {
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(store.SaveFlag(ctx, &ab.Flag{
+		ID:      "max-items",
+		Enabled: true,
+		Rollout: 100,
+		Value:   json.RawMessage(`10`),
+		Default: json.RawMessage(`5`),
+	}))
+
+	c := ab.NewFlagClient(store, nil)
+	is.Nil(c.Load(ctx))
+	is.Equal(10, c.IntFlag(ctx, "max-items", "user-1", 1))
+	is.Equal(1, c.IntFlag(ctx, "unknown", "user-1", 1))
+	// The default is returned when the type does not match.
+	is.Equal("none", c.StringFlag(ctx, "max-items", "user-1", "none"))
+
+	stop, errCh := c.Sync(ctx)
+	t.Cleanup(stop)
+	go func() {
+		for err := range errCh {
+			t.Error(err)
+		}
+	}()
+
+	is.Nil(store.SaveFlag(ctx, &ab.Flag{
+		ID:      "dark-mode",
+		Enabled: true,
+		Rollout: 100,
+		Value:   json.RawMessage(`true`),
+	}))
+	is.Eventually(func() bool {
+		return c.BoolFlag(ctx, "dark-mode", "user-1", false)
+	}, time.Second, 10*time.Millisecond)
+
+	is.Nil(store.DeleteFlag(ctx, "max-items"))
+	is.Eventually(func() bool {
+		_, err := c.Evaluate(ctx, "max-items", "user-1")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
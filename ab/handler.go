@@ -0,0 +1,426 @@
+package ab
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidRequest = errors.New("ab: invalid request")
+
+// Handler returns the admin and evaluation API of the experiments and flags
+// in the store, so that they can be managed and evaluated by non-Go services.
+//
+//	GET  /experiments
+//	POST /experiments
+//	GET  /experiments/{id}
+//	POST /experiments/{id}/pause
+//	POST /experiments/{id}/resume
+//	POST /experiments/{id}/assign       {"user_id": "...", "attributes": {}}
+//	POST /experiments/{id}/expose       {"user_id": "...", "attributes": {}}
+//	POST /experiments/{id}/conversions  {"user_id": "...", "value": 1}
+//	GET  /experiments/{id}/results?window=168h&metric=revenue&mde=0.02
+//	GET  /flags
+//	POST /flags
+//	GET  /flags/{id}
+//	POST /flags/{id}/enable
+//	POST /flags/{id}/disable
+//	POST /flags/{id}/evaluate           {"user_id": "...", "attributes": {}}
+//	GET  /segments
+//	POST /segments
+//	GET  /segments/{id}
+//
+// The experiment rules are not persisted, so the users are only targeted by
+// the segments, which are matched against the attributes. The options are
+// the defaults of the results endpoint.
+func Handler(s Store, opts *ResultsOptions) http.Handler {
+	h := &handler{
+		store: s,
+		opts:  opts.valid(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /experiments", h.listExperiments)
+	mux.HandleFunc("POST /experiments", h.saveExperiment)
+	mux.HandleFunc("GET /experiments/{id}", h.getExperiment)
+	mux.HandleFunc("POST /experiments/{id}/pause", h.pauseExperiment(true))
+	mux.HandleFunc("POST /experiments/{id}/resume", h.pauseExperiment(false))
+	mux.HandleFunc("POST /experiments/{id}/assign", h.assign)
+	mux.HandleFunc("POST /experiments/{id}/expose", h.expose)
+	mux.HandleFunc("POST /experiments/{id}/conversions", h.convert)
+	mux.HandleFunc("GET /experiments/{id}/results", h.results)
+	mux.HandleFunc("GET /flags", h.listFlags)
+	mux.HandleFunc("POST /flags", h.saveFlag)
+	mux.HandleFunc("GET /flags/{id}", h.getFlag)
+	mux.HandleFunc("POST /flags/{id}/enable", h.enableFlag(true))
+	mux.HandleFunc("POST /flags/{id}/disable", h.enableFlag(false))
+	mux.HandleFunc("POST /flags/{id}/evaluate", h.evaluate)
+	mux.HandleFunc("GET /segments", h.listSegments)
+	mux.HandleFunc("POST /segments", h.saveSegment)
+	mux.HandleFunc("GET /segments/{id}", h.getSegment)
+
+	return mux
+}
+
+type handler struct {
+	store Store
+	opts  *ResultsOptions
+}
+
+type userRequest struct {
+	UserID     string     `json:"user_id"`
+	Value      float64    `json:"value"`
+	Attributes Attributes `json:"attributes"`
+}
+
+func (h *handler) listExperiments(w http.ResponseWriter, r *http.Request) {
+	exps, err := h.store.ListExperiments(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, exps)
+}
+
+// saveExperiment creates or replaces the experiment.
+func (h *handler) saveExperiment(w http.ResponseWriter, r *http.Request) {
+	var exp Experiment
+	if err := json.NewDecoder(r.Body).Decode(&exp); err != nil {
+		writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+	if exp.ID == "" {
+		writeError(w, fmt.Errorf("%w: id is required", errInvalidRequest))
+		return
+	}
+	if len(exp.Variants) == 0 {
+		writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, ErrNoVariants))
+		return
+	}
+
+	if err := h.store.SaveExperiment(r.Context(), &exp); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, exp)
+}
+
+func (h *handler) getExperiment(w http.ResponseWriter, r *http.Request) {
+	exp, err := h.store.LoadExperiment(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, exp)
+}
+
+func (h *handler) pauseExperiment(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		exp, err := h.store.LoadExperiment(ctx, r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		exp.Paused = paused
+		if err := h.store.SaveExperiment(ctx, exp); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, exp)
+	}
+}
+
+func (h *handler) assign(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := WithAttributes(r.Context(), req.Attributes)
+	a, err := h.assigner(ctx, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	e, err := a.Explain(ctx, r.PathValue("id"), req.UserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *handler) expose(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := WithAttributes(r.Context(), req.Attributes)
+	a, err := h.assigner(ctx, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	a.ExposureLogger = ExposureLoggerFunc(h.store.SaveExposure)
+	variant, err := a.Expose(ctx, r.PathValue("id"), req.UserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"variant": variant})
+}
+
+func (h *handler) convert(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = h.store.SaveConversion(r.Context(), r.PathValue("id"), Conversion{
+		UserID: req.UserID,
+		Value:  req.Value,
+		At:     time.Now(),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) results(w http.ResponseWriter, r *http.Request) {
+	opts := *h.opts
+	if q := r.URL.Query(); len(q["metric"]) > 0 {
+		opts.Metrics = q["metric"]
+	}
+	if s := r.URL.Query().Get("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+			return
+		}
+
+		opts.Attribution = &AttributionOptions{Window: d}
+		if err := opts.Attribution.Valid(); err != nil {
+			writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+			return
+		}
+	}
+
+	if s := r.URL.Query().Get("mde"); s != "" {
+		mde, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+			return
+		}
+
+		power := cmp.Or(opts.Power, &PowerOptions{})
+		opts.Power = &PowerOptions{
+			MDE:          mde,
+			Alpha:        power.Alpha,
+			Power:        power.Power,
+			Control:      power.Control,
+			BaselineRate: power.BaselineRate,
+		}
+	}
+
+	res, err := LoadResults(r.Context(), h.store, r.PathValue("id"), &opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *handler) listFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.store.ListFlags(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flags)
+}
+
+// saveFlag creates or replaces the flag.
+func (h *handler) saveFlag(w http.ResponseWriter, r *http.Request) {
+	var f Flag
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+	if f.ID == "" {
+		writeError(w, fmt.Errorf("%w: id is required", errInvalidRequest))
+		return
+	}
+
+	if err := h.store.SaveFlag(r.Context(), &f); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, f)
+}
+
+func (h *handler) getFlag(w http.ResponseWriter, r *http.Request) {
+	f, err := h.store.LoadFlag(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (h *handler) enableFlag(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		f, err := h.store.LoadFlag(ctx, r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		f.Enabled = enabled
+		if err := h.store.SaveFlag(ctx, f); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, f)
+	}
+}
+
+func (h *handler) evaluate(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	f, err := h.store.LoadFlag(ctx, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	segments, err := LoadSegments(ctx, h.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f.Evaluate(WithAttributes(ctx, req.Attributes), req.UserID, segments))
+}
+
+func (h *handler) listSegments(w http.ResponseWriter, r *http.Request) {
+	segs, err := h.store.ListSegments(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, segs)
+}
+
+// saveSegment creates or replaces the segment.
+func (h *handler) saveSegment(w http.ResponseWriter, r *http.Request) {
+	var seg Segment
+	if err := json.NewDecoder(r.Body).Decode(&seg); err != nil {
+		writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+	if seg.ID == "" {
+		writeError(w, fmt.Errorf("%w: id is required", errInvalidRequest))
+		return
+	}
+	if err := seg.Criterion.Validate(); err != nil {
+		writeError(w, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+
+	if err := h.store.SaveSegment(r.Context(), &seg); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, seg)
+}
+
+func (h *handler) getSegment(w http.ResponseWriter, r *http.Request) {
+	seg, err := h.store.LoadSegment(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, seg)
+}
+
+// assigner returns an Assigner of the experiment, with the segments.
+func (h *handler) assigner(ctx context.Context, id string) (*Assigner, error) {
+	exp, err := h.store.LoadExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	a := NewAssigner(exp)
+	a.Segments, err = LoadSegments(ctx, h.store)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func decodeUser(r *http.Request) (*userRequest, error) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", errInvalidRequest)
+	}
+
+	return &req, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errInvalidRequest), errors.Is(err, ErrInvalidPowerAnalysis):
+		code = http.StatusBadRequest
+	case errors.Is(err, ErrExperimentNotFound), errors.Is(err, ErrFlagNotFound), errors.Is(err, ErrSegmentNotFound):
+		code = http.StatusNotFound
+	}
+
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,126 @@
+package ab_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	h := ab.Handler(store, nil)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("experiments", func(t *testing.T) {
+		is := assert.New(t)
+
+		w := do("POST", "/experiments", `{
+			"id": "checkout",
+			"rollout": 100,
+			"variants": [{"name": "control", "weight": 1}, {"name": "treatment", "weight": 1}],
+			"default": "control"
+		}`)
+		is.Equal(http.StatusCreated, w.Code)
+
+		w = do("POST", "/experiments", `{"id": "empty"}`)
+		is.Equal(http.StatusBadRequest, w.Code)
+
+		w = do("POST", "/experiments/checkout/expose", `{"user_id": "user-1"}`)
+		is.Equal(http.StatusOK, w.Code)
+
+		var exposed struct {
+			Variant string `json:"variant"`
+		}
+		is.Nil(json.Unmarshal(w.Body.Bytes(), &exposed))
+
+		w = do("POST", "/experiments/checkout/conversions", `{"user_id": "user-1", "value": 10}`)
+		is.Equal(http.StatusNoContent, w.Code)
+
+		w = do("GET", "/experiments/checkout/results", "")
+		is.Equal(http.StatusOK, w.Code)
+
+		var res ab.ExperimentResults
+		is.Nil(json.Unmarshal(w.Body.Bytes(), &res))
+		is.Len(res.Attribution.Variants, 1)
+		is.Equal(exposed.Variant, res.Attribution.Variants[0].Variant)
+		is.Equal(1, res.Attribution.Variants[0].Converted)
+
+		w = do("POST", "/experiments/checkout/pause", "")
+		is.Equal(http.StatusOK, w.Code)
+
+		w = do("POST", "/experiments/checkout/assign", `{"user_id": "user-1"}`)
+		is.Equal(http.StatusOK, w.Code)
+
+		var e ab.Explanation
+		is.Nil(json.Unmarshal(w.Body.Bytes(), &e))
+		is.Equal(ab.ReasonPaused, e.Reason)
+		is.Equal("control", e.Variant)
+
+		w = do("GET", "/experiments/unknown", "")
+		is.Equal(http.StatusNotFound, w.Code)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		is := assert.New(t)
+
+		w := do("POST", "/flags", `{"id": "dark-mode", "rollout": 100, "value": "dark", "default": "light"}`)
+		is.Equal(http.StatusCreated, w.Code)
+
+		evaluate := func() *ab.FlagResult {
+			w := do("POST", "/flags/dark-mode/evaluate", `{"user_id": "user-1"}`)
+			is.Equal(http.StatusOK, w.Code)
+
+			var res ab.FlagResult
+			is.Nil(json.Unmarshal(w.Body.Bytes(), &res))
+
+			return &res
+		}
+		is.JSONEq(`"light"`, string(evaluate().Value))
+
+		w = do("POST", "/flags/dark-mode/enable", "")
+		is.Equal(http.StatusOK, w.Code)
+		is.JSONEq(`"dark"`, string(evaluate().Value))
+
+		w = do("POST", "/flags/dark-mode/evaluate", `{}`)
+		is.Equal(http.StatusBadRequest, w.Code)
+
+		w = do("POST", "/segments", `{"id": "malaysia", "criterion": {"attribute": "country", "op": "eq", "value": "MY"}}`)
+		is.Equal(http.StatusCreated, w.Code)
+
+		w = do("POST", "/segments", `{"id": "invalid", "criterion": {"attribute": "country", "op": "like"}}`)
+		is.Equal(http.StatusBadRequest, w.Code)
+
+		w = do("POST", "/flags", `{"id": "local-pay", "enabled": true, "rollout": 100, "value": true, "default": false, "segments": ["malaysia"]}`)
+		is.Equal(http.StatusCreated, w.Code)
+
+		w = do("POST", "/flags/local-pay/evaluate", `{"user_id": "user-1", "attributes": {"country": "MY"}}`)
+		is.Equal(http.StatusOK, w.Code)
+		is.JSONEq(`{"flag_id": "local-pay", "user_id": "user-1", "value": true, "reason": "in rollout"}`, w.Body.String())
+
+		w = do("POST", "/flags/local-pay/evaluate", `{"user_id": "user-1", "attributes": {"country": "SG"}}`)
+		is.Equal(http.StatusOK, w.Code)
+		is.JSONEq(`{"flag_id": "local-pay", "user_id": "user-1", "value": false, "reason": "rule not matched"}`, w.Body.String())
+
+		is.Nil(store.DeleteFlag(context.Background(), "local-pay"))
+
+		w = do("GET", "/flags", "")
+		is.Equal(http.StatusOK, w.Code)
+		is.JSONEq(`[{"id": "dark-mode", "enabled": true, "rollout": 100, "value": "dark", "default": "light"}]`, w.Body.String())
+	})
+}
@@ -0,0 +1,78 @@
+package ab
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// LayerBuckets is the number of buckets of a layer, which is the granularity
+// of the traffic allocation.
+const LayerBuckets = 1000
+
+var (
+	ErrLayerNotFound     = errors.New("ab: layer not found")
+	ErrInvalidAllocation = errors.New("ab: invalid allocation")
+)
+
+// ReasonNotInLayer is the reason when the user's bucket in the layer is
+// allocated to another experiment.
+const ReasonNotInLayer = "not in layer"
+
+// Layer splits the traffic between mutually exclusive experiments, e.g. the
+// experiments on the same surface. Each user is hashed to one bucket of the
+// layer, so the user is in at most one experiment of the layer.
+// Experiments in different layers are independent, since the layers are
+// hashed with different keys.
+type Layer struct {
+	ID string `json:"id"`
+	// Seed reshuffles the users between the buckets.
+	Seed        uint32       `json:"seed"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// Allocation allocates the buckets from Start (inclusive) to End (exclusive)
+// to the experiment. New experiments should be allocated the free buckets, so
+// that the users of the running experiments are not reshuffled.
+type Allocation struct {
+	ExperimentID string `json:"experiment_id"`
+	Start        uint64 `json:"start"`
+	End          uint64 `json:"end"`
+}
+
+// Validate checks that the allocations are within the buckets, and do not
+// overlap.
+func (l *Layer) Validate() error {
+	allocs := slices.Clone(l.Allocations)
+	slices.SortFunc(allocs, func(a, b Allocation) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	for i, a := range allocs {
+		if a.Start >= a.End || a.End > LayerBuckets {
+			return fmt.Errorf("%w: %s has buckets [%d, %d)", ErrInvalidAllocation, a.ExperimentID, a.Start, a.End)
+		}
+		if i > 0 && a.Start < allocs[i-1].End {
+			return fmt.Errorf("%w: %s overlaps with %s", ErrInvalidAllocation, a.ExperimentID, allocs[i-1].ExperimentID)
+		}
+	}
+
+	return nil
+}
+
+// Bucket returns the bucket of the user in the layer.
+func (l *Layer) Bucket(userID string) uint64 {
+	return HashSeed(l.ID+":layer:"+userID, l.Seed, LayerBuckets)
+}
+
+// Experiment returns the experiment allocated to the bucket, if any.
+func (l *Layer) Experiment(bucket uint64) (string, bool) {
+	for _, a := range l.Allocations {
+		if a.Start <= bucket && bucket < a.End {
+			return a.ExperimentID, true
+		}
+	}
+
+	return "", false
+}
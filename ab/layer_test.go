@@ -0,0 +1,72 @@
+package ab_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayer(t *testing.T) {
+	newExperiment := func(id string) *ab.Experiment {
+		return &ab.Experiment{
+			ID:      id,
+			Rollout: 100,
+			Layer:   "checkout",
+			Variants: []ab.Variant{
+				{Name: "control", Weight: 1},
+				{Name: "treatment", Weight: 1},
+			},
+			Default: "excluded",
+		}
+	}
+
+	a := ab.NewAssigner(newExperiment("button"), newExperiment("layout"))
+
+	ctx := context.Background()
+	is := assert.New(t)
+	_, err := a.Assign(ctx, "button", "user-1")
+	is.ErrorIs(err, ab.ErrLayerNotFound)
+
+	is.ErrorIs(a.AddLayer(&ab.Layer{
+		ID: "checkout",
+		Allocations: []ab.Allocation{
+			{ExperimentID: "button", Start: 0, End: 600},
+			{ExperimentID: "layout", Start: 500, End: 1000},
+		},
+	}), ab.ErrInvalidAllocation)
+
+	is.Nil(a.AddLayer(&ab.Layer{
+		ID: "checkout",
+		Allocations: []ab.Allocation{
+			{ExperimentID: "button", Start: 0, End: 500},
+			{ExperimentID: "layout", Start: 500, End: 800},
+		},
+	}))
+
+	counts := make(map[string]int)
+	for i := range 1_000 {
+		userID := fmt.Sprint("user-", i)
+
+		var in int
+		for _, id := range []string{"button", "layout"} {
+			e, err := a.Explain(ctx, id, userID)
+			is.Nil(err)
+			if e.Reason == ab.ReasonAssigned {
+				in++
+				counts[id]++
+			} else {
+				is.Equal(ab.ReasonNotInLayer, e.Reason)
+				is.Equal("excluded", e.Variant)
+			}
+		}
+
+		// The user is in at most one experiment of the layer.
+		is.LessOrEqual(in, 1)
+	}
+
+	is.InDelta(500, counts["button"], 50)
+	is.InDelta(300, counts["layout"], 50)
+}
@@ -0,0 +1,142 @@
+package ab
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+var ErrInvalidPowerAnalysis = errors.New("ab: invalid power analysis")
+
+// PlanSampleSize returns the number of users needed per variant to detect
+// the minimum detectable effect, the absolute change of the conversion rate
+// from the baseline rate, e.g. 0.02 to detect an improvement from 10% to
+// 12%.
+// The alpha is the significance level of the two-sided test, usually 0.05,
+// and the power is the probability of detecting the effect, usually 0.8.
+func PlanSampleSize(alpha, power, baselineRate, mde float64) (int, error) {
+	p1 := baselineRate
+	p2 := baselineRate + mde
+
+	switch {
+	case alpha <= 0 || alpha >= 1:
+		return 0, fmt.Errorf("%w: alpha must be between 0 and 1", ErrInvalidPowerAnalysis)
+	case power <= 0 || power >= 1:
+		return 0, fmt.Errorf("%w: power must be between 0 and 1", ErrInvalidPowerAnalysis)
+	case p1 <= 0 || p1 >= 1:
+		return 0, fmt.Errorf("%w: baseline rate must be between 0 and 1", ErrInvalidPowerAnalysis)
+	case mde == 0 || p2 <= 0 || p2 >= 1:
+		return 0, fmt.Errorf("%w: baseline rate with the mde must be between 0 and 1", ErrInvalidPowerAnalysis)
+	}
+
+	za := normalQuantile(1 - alpha/2)
+	zb := normalQuantile(power)
+	p := (p1 + p2) / 2
+
+	n := za*math.Sqrt(2*p*(1-p)) + zb*math.Sqrt(p1*(1-p1)+p2*(1-p2))
+	n = n * n / (mde * mde)
+
+	return int(math.Ceil(n)), nil
+}
+
+// normalQuantile returns the quantile of the standard normal distribution.
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+type PowerOptions struct {
+	// MDE is the minimum detectable effect, see PlanSampleSize.
+	MDE float64
+
+	// Alpha defaults to 0.05.
+	Alpha float64
+
+	// Power defaults to 0.8.
+	Power float64
+
+	// Control is the variant of the baseline rate. Defaults to the default
+	// variant of the experiment, or the first variant.
+	Control string
+
+	// BaselineRate is used until the control variant has conversions.
+	BaselineRate float64
+}
+
+func (o *PowerOptions) valid() *PowerOptions {
+	o.Alpha = cmp.Or(o.Alpha, 0.05)
+	o.Power = cmp.Or(o.Power, 0.8)
+
+	return o
+}
+
+// PowerResult is the progress of the experiment towards the sample size, so
+// that the owners know when to stop waiting for the results.
+type PowerResult struct {
+	BaselineRate float64 `json:"baseline_rate"`
+	MDE          float64 `json:"mde"`
+	// SampleSize is the number of users needed per variant.
+	SampleSize int `json:"sample_size"`
+	// Exposures is the number of users of the smallest variant.
+	Exposures int `json:"exposures"`
+	// DailyExposures is the number of users per day of the smallest variant,
+	// since the first exposure.
+	DailyExposures float64 `json:"daily_exposures"`
+	// DaysRemaining is the estimated days until every variant has the sample
+	// size, at the current traffic. It is zero when complete, or when the
+	// traffic is unknown.
+	DaysRemaining float64 `json:"days_remaining"`
+	Complete      bool    `json:"complete"`
+}
+
+// power estimates the progress of the experiment at the time.
+func power(exp *Experiment, attr *AttributionResult, opts *PowerOptions, now time.Time) (*PowerResult, error) {
+	opts = opts.valid()
+
+	control := controlVariant(exp, opts.Control)
+
+	baseline := opts.BaselineRate
+	minExposures := -1
+	for _, v := range exp.Variants {
+		i := slices.IndexFunc(attr.Variants, func(va VariantAttribution) bool {
+			return va.Variant == v.Name
+		})
+
+		var exposures int
+		if i >= 0 {
+			va := attr.Variants[i]
+			exposures = va.Exposures
+			if va.Variant == control && va.Converted > 0 {
+				baseline = va.Rate
+			}
+		}
+		if minExposures < 0 || exposures < minExposures {
+			minExposures = exposures
+		}
+	}
+	minExposures = max(minExposures, 0)
+
+	n, err := PlanSampleSize(opts.Alpha, opts.Power, baseline, opts.MDE)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PowerResult{
+		BaselineRate: baseline,
+		MDE:          opts.MDE,
+		SampleSize:   n,
+		Exposures:    minExposures,
+		Complete:     minExposures >= n,
+	}
+
+	if days := now.Sub(attr.Start).Hours() / 24; !attr.Start.IsZero() && days > 0 {
+		res.DailyExposures = float64(minExposures) / days
+	}
+	if !res.Complete && res.DailyExposures > 0 {
+		res.DaysRemaining = float64(n-minExposures) / res.DailyExposures
+	}
+
+	return res, nil
+}
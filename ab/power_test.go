@@ -0,0 +1,74 @@
+package ab_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSampleSize(t *testing.T) {
+	is := assert.New(t)
+
+	n, err := ab.PlanSampleSize(0.05, 0.8, 0.1, 0.02)
+	is.Nil(err)
+	is.Equal(3841, n)
+
+	// Smaller effects need more users.
+	m, err := ab.PlanSampleSize(0.05, 0.8, 0.1, 0.01)
+	is.Nil(err)
+	is.Greater(m, 3*n)
+
+	_, err = ab.PlanSampleSize(0.05, 0.8, 0.1, 0)
+	is.ErrorIs(err, ab.ErrInvalidPowerAnalysis)
+
+	_, err = ab.PlanSampleSize(0.05, 1, 0.1, 0.02)
+	is.ErrorIs(err, ab.ErrInvalidPowerAnalysis)
+}
+
+func TestLoadResultsPower(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(store.SaveExperiment(ctx, &ab.Experiment{
+		ID:      "checkout",
+		Rollout: 100,
+		Default: "control",
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+	}))
+
+	// 100 users per variant per day, for 2 days.
+	for i := range 400 {
+		variant := "control"
+		if i%2 == 0 {
+			variant = "treatment"
+		}
+		userID := fmt.Sprint("user-", i)
+		at := now.Add(time.Duration(i) * 2 * 24 * time.Hour / 400)
+		is.Nil(store.SaveExposure(ctx, "checkout", ab.Exposure{UserID: userID, Variant: variant, At: at}))
+		if i%20 == 1 {
+			is.Nil(store.SaveConversion(ctx, "checkout", ab.Conversion{UserID: userID, At: at.Add(time.Minute)}))
+		}
+	}
+
+	res, err := ab.LoadResults(ctx, store, "checkout", &ab.ResultsOptions{
+		Power: &ab.PowerOptions{MDE: 0.02},
+		Now:   func() time.Time { return now.Add(48 * time.Hour) },
+	})
+	is.Nil(err)
+	is.Equal(0.1, res.Power.BaselineRate)
+	is.Equal(3841, res.Power.SampleSize)
+	is.Equal(200, res.Power.Exposures)
+	is.InDelta(100, res.Power.DailyExposures, 1e-9)
+	is.InDelta(36.41, res.Power.DaysRemaining, 1e-9)
+	is.False(res.Power.Complete)
+}
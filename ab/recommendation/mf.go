@@ -0,0 +1,324 @@
+// Package recommendation implements collaborative filtering recommenders,
+// which can be evaluated offline with ab.Evaluate.
+package recommendation
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/alextanhongpin/core/ab"
+)
+
+var ErrDimensionMismatch = errors.New("recommendation: dimension mismatch")
+
+type Options struct {
+	// Factors is the number of latent dimensions of the users and items.
+	// Defaults to 16.
+	Factors int
+
+	// LearningRate is the step size of the gradient descent. Defaults to
+	// 0.05.
+	LearningRate float64
+
+	// Regularization is the L2 penalty of the factors. Defaults to 0.01.
+	Regularization float64
+
+	// Epochs is the number of passes over the interactions in Fit. Defaults
+	// to 20.
+	Epochs int
+
+	// NegativeSamples is the number of items the user has not interacted
+	// with, which are sampled as negatives for each interaction. Defaults to
+	// 3.
+	NegativeSamples int
+
+	// Rand is the source of randomness for the initial factors and the
+	// negative samples.
+	Rand *rand.Rand
+}
+
+func (o *Options) valid() *Options {
+	o = cmp.Or(o, &Options{})
+	o.Factors = cmp.Or(o.Factors, 16)
+	o.LearningRate = cmp.Or(o.LearningRate, 0.05)
+	o.Regularization = cmp.Or(o.Regularization, 0.01)
+	o.Epochs = cmp.Or(o.Epochs, 20)
+	o.NegativeSamples = cmp.Or(o.NegativeSamples, 3)
+	if o.Rand == nil {
+		o.Rand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	return o
+}
+
+// MatrixFactorization learns the latent factors of the users and items from
+// the implicit feedback, i.e. the interactions, with stochastic gradient
+// descent. The score of an item for a user is the dot product of their
+// factors plus the bias of the item, so the users without interactions are
+// recommended the popular items.
+//
+// Fit trains the model from scratch, while RecordInteraction updates the
+// model incrementally, so that the recommendations improve as the
+// interactions arrive. The model can be persisted with json.Marshal.
+type MatrixFactorization struct {
+	opts *Options
+
+	mu    sync.Mutex
+	users map[string][]float64
+	items map[string][]float64
+	bias  map[string]float64
+	seen  map[string]map[string]bool
+	ids   []string
+}
+
+var _ ab.Recommender = (*MatrixFactorization)(nil)
+
+func NewMatrixFactorization(opts *Options) *MatrixFactorization {
+	m := &MatrixFactorization{
+		opts: opts.valid(),
+	}
+	m.reset()
+
+	return m
+}
+
+// Fit discards the learned factors, and trains the model on the
+// interactions.
+func (m *MatrixFactorization) Fit(interactions []ab.Interaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+	for _, i := range interactions {
+		m.observe(i)
+	}
+
+	order := make([]int, len(interactions))
+	for i := range order {
+		order[i] = i
+	}
+
+	for range m.opts.Epochs {
+		m.opts.Rand.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		for _, i := range order {
+			m.train(interactions[i])
+		}
+	}
+}
+
+// RecordInteraction updates the model with a new interaction.
+func (m *MatrixFactorization) RecordInteraction(i ab.Interaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observe(i)
+	m.train(i)
+}
+
+// Recommend returns up to k items the user has not interacted with, ordered
+// by the score.
+func (m *MatrixFactorization) Recommend(userID string, k int) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scores := make(map[string]float64)
+	res := make([]string, 0, len(m.ids))
+	for _, item := range m.ids {
+		if m.seen[userID][item] {
+			continue
+		}
+
+		scores[item] = m.score(userID, item)
+		res = append(res, item)
+	}
+
+	slices.SortFunc(res, func(a, b string) int {
+		return cmp.Or(cmp.Compare(scores[b], scores[a]), cmp.Compare(a, b))
+	})
+
+	return res[:min(k, len(res))]
+}
+
+// Score returns the predicted preference of the user for the item.
+func (m *MatrixFactorization) Score(userID, itemID string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.score(userID, itemID)
+}
+
+// Similarity returns the cosine similarity of the factors of the items,
+// which can be used for the diversity re-ranking.
+func (m *MatrixFactorization) Similarity(a, b string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, q := m.items[a], m.items[b]
+	if p == nil || q == nil {
+		return 0
+	}
+
+	var pq, pp, qq float64
+	for f := range p {
+		pq += p[f] * q[f]
+		pp += p[f] * p[f]
+		qq += q[f] * q[f]
+	}
+	if pp == 0 || qq == 0 {
+		return 0
+	}
+
+	return pq / math.Sqrt(pp*qq)
+}
+
+type factors struct {
+	Factors int                  `json:"factors"`
+	Users   map[string][]float64 `json:"users"`
+	Items   map[string][]float64 `json:"items"`
+	Bias    map[string]float64   `json:"bias"`
+	Seen    map[string][]string  `json:"seen"`
+}
+
+func (m *MatrixFactorization) MarshalJSON() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string][]string, len(m.seen))
+	for user, items := range m.seen {
+		for item := range items {
+			seen[user] = append(seen[user], item)
+		}
+		slices.Sort(seen[user])
+	}
+
+	return json.Marshal(factors{
+		Factors: m.opts.Factors,
+		Users:   m.users,
+		Items:   m.items,
+		Bias:    m.bias,
+		Seen:    seen,
+	})
+}
+
+// UnmarshalJSON restores the learned factors. The number of factors must
+// match the options.
+func (m *MatrixFactorization) UnmarshalJSON(b []byte) error {
+	var f factors
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.opts == nil {
+		m.opts = (&Options{Factors: f.Factors}).valid()
+	}
+	if f.Factors != m.opts.Factors {
+		return fmt.Errorf("%w: want %d factors, got %d", ErrDimensionMismatch, m.opts.Factors, f.Factors)
+	}
+
+	m.reset()
+	for user, items := range f.Seen {
+		for _, item := range items {
+			m.observe(ab.Interaction{UserID: user, ItemID: item})
+		}
+	}
+	for user, p := range f.Users {
+		m.users[user] = p
+	}
+	for item, q := range f.Items {
+		if _, ok := m.items[item]; !ok {
+			m.ids = append(m.ids, item)
+		}
+		m.items[item] = q
+	}
+	for item, b := range f.Bias {
+		m.bias[item] = b
+	}
+
+	return nil
+}
+
+func (m *MatrixFactorization) reset() {
+	m.users = make(map[string][]float64)
+	m.items = make(map[string][]float64)
+	m.bias = make(map[string]float64)
+	m.seen = make(map[string]map[string]bool)
+	m.ids = nil
+}
+
+// observe adds the user and the item, initializing their factors with small
+// random values to break the symmetry.
+func (m *MatrixFactorization) observe(i ab.Interaction) {
+	if m.seen[i.UserID] == nil {
+		m.seen[i.UserID] = make(map[string]bool)
+	}
+	m.seen[i.UserID][i.ItemID] = true
+
+	if _, ok := m.users[i.UserID]; !ok {
+		m.users[i.UserID] = m.init()
+	}
+	if _, ok := m.items[i.ItemID]; !ok {
+		m.items[i.ItemID] = m.init()
+		m.ids = append(m.ids, i.ItemID)
+	}
+}
+
+func (m *MatrixFactorization) init() []float64 {
+	v := make([]float64, m.opts.Factors)
+	for i := range v {
+		v[i] = m.opts.Rand.NormFloat64() * 0.1
+	}
+
+	return v
+}
+
+// train takes a gradient step towards 1 for the interaction, and towards 0
+// for the sampled items the user has not interacted with.
+func (m *MatrixFactorization) train(i ab.Interaction) {
+	m.step(i.UserID, i.ItemID, 1)
+
+	for range m.opts.NegativeSamples {
+		item := m.ids[m.opts.Rand.IntN(len(m.ids))]
+		if m.seen[i.UserID][item] {
+			continue
+		}
+		m.step(i.UserID, item, 0)
+	}
+}
+
+func (m *MatrixFactorization) step(userID, itemID string, target float64) {
+	lr, reg := m.opts.LearningRate, m.opts.Regularization
+	p, q := m.users[userID], m.items[itemID]
+
+	err := target - m.score(userID, itemID)
+	for f := range p {
+		pf, qf := p[f], q[f]
+		p[f] += lr * (err*qf - reg*pf)
+		q[f] += lr * (err*pf - reg*qf)
+	}
+	m.bias[itemID] += lr * (err - reg*m.bias[itemID])
+}
+
+func (m *MatrixFactorization) score(userID, itemID string) float64 {
+	s := m.bias[itemID]
+	p, q := m.users[userID], m.items[itemID]
+	if p == nil || q == nil {
+		return s
+	}
+
+	for f := range p {
+		s += p[f] * q[f]
+	}
+
+	return s
+}
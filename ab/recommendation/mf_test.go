@@ -0,0 +1,65 @@
+package recommendation_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/ab/recommendation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrixFactorization(t *testing.T) {
+	// The readers interact with the books, and the gamers with the games.
+	var interactions []ab.Interaction
+	for i := range 20 {
+		items := []string{"book-1", "book-2", "book-3"}
+		if i%2 == 1 {
+			items = []string{"game-1", "game-2", "game-3"}
+		}
+		for _, item := range items {
+			interactions = append(interactions, ab.Interaction{
+				UserID: fmt.Sprint("user-", i),
+				ItemID: item,
+			})
+		}
+	}
+	interactions = append(interactions,
+		ab.Interaction{UserID: "reader", ItemID: "book-1"},
+		ab.Interaction{UserID: "reader", ItemID: "book-2"},
+	)
+
+	mf := recommendation.NewMatrixFactorization(&recommendation.Options{
+		Factors: 4,
+		Rand:    rand.New(rand.NewPCG(1, 1)),
+	})
+	mf.Fit(interactions)
+
+	is := assert.New(t)
+	is.Equal([]string{"book-3"}, mf.Recommend("reader", 1))
+
+	// The new user is learned incrementally.
+	for range 10 {
+		mf.RecordInteraction(ab.Interaction{UserID: "gamer", ItemID: "game-1"})
+		mf.RecordInteraction(ab.Interaction{UserID: "gamer", ItemID: "game-2"})
+	}
+	is.Equal([]string{"game-3"}, mf.Recommend("gamer", 1))
+
+	t.Run("persistence", func(t *testing.T) {
+		b, err := json.Marshal(mf)
+		is := assert.New(t)
+		is.Nil(err)
+
+		restored := recommendation.NewMatrixFactorization(&recommendation.Options{
+			Factors: 4,
+		})
+		is.Nil(json.Unmarshal(b, restored))
+		is.Equal(mf.Recommend("reader", 3), restored.Recommend("reader", 3))
+		is.Equal(mf.Score("gamer", "game-3"), restored.Score("gamer", "game-3"))
+
+		mismatch := recommendation.NewMatrixFactorization(nil)
+		is.ErrorIs(json.Unmarshal(b, mismatch), recommendation.ErrDimensionMismatch)
+	})
+}
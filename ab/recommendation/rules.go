@@ -0,0 +1,187 @@
+package recommendation
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/alextanhongpin/core/ab"
+)
+
+// Scorer is implemented by the recommenders that score the items, which is
+// used as the relevance for the diversity re-ranking.
+type Scorer interface {
+	Score(userID, itemID string) float64
+}
+
+type Rules struct {
+	// Exclude returns true if the item must not be recommended to the user,
+	// e.g. the item is already purchased.
+	Exclude func(userID, itemID string) bool
+
+	// Category returns the category of the item, which is required by the
+	// allow and deny lists.
+	Category func(itemID string) string
+
+	// AllowCategories are the only categories recommended, if set.
+	AllowCategories []string
+
+	// DenyCategories are the categories never recommended.
+	DenyCategories []string
+
+	// MaxImpressions caps the number of times the item is recommended to the
+	// same user, if set. The impressions are counted in memory.
+	MaxImpressions int
+
+	// Diversity trades the relevance for the diversity of the items with
+	// maximal marginal relevance re-ranking, from 0 (disabled) to 1.
+	Diversity float64
+
+	// Similarity returns the similarity of two items, from 0 to 1, for the
+	// diversity re-ranking. Defaults to 1 for the items of the same category,
+	// and 0 otherwise.
+	Similarity func(a, b string) float64
+
+	// Oversample is the number of candidates fetched from the recommender per
+	// item recommended, so that there are enough candidates left after
+	// filtering. Defaults to 5.
+	Oversample int
+}
+
+func (r *Rules) valid() *Rules {
+	r = cmp.Or(r, &Rules{})
+	r.Oversample = cmp.Or(r.Oversample, 5)
+	if r.Similarity == nil && r.Category != nil {
+		r.Similarity = func(a, b string) float64 {
+			if r.Category(a) == r.Category(b) {
+				return 1
+			}
+
+			return 0
+		}
+	}
+
+	return r
+}
+
+// Filtered applies the business rules to the recommendations of the
+// recommender, so that the callers do not have to post-process them.
+type Filtered struct {
+	rec   ab.Recommender
+	rules *Rules
+
+	mu          sync.Mutex
+	impressions map[string]map[string]int
+}
+
+var _ ab.Recommender = (*Filtered)(nil)
+
+func NewFiltered(rec ab.Recommender, rules *Rules) *Filtered {
+	return &Filtered{
+		rec:         rec,
+		rules:       rules.valid(),
+		impressions: make(map[string]map[string]int),
+	}
+}
+
+// Fit trains the underlying recommender.
+func (f *Filtered) Fit(interactions []ab.Interaction) {
+	f.rec.Fit(interactions)
+}
+
+// Recommend returns up to k items for the user that pass the rules.
+func (f *Filtered) Recommend(userID string, k int) []string {
+	candidates := f.rec.Recommend(userID, k*f.rules.Oversample)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make([]string, 0, len(candidates))
+	for _, item := range candidates {
+		if f.allow(userID, item) {
+			res = append(res, item)
+		}
+	}
+
+	if f.rules.Diversity > 0 && f.rules.Similarity != nil {
+		res = f.rerank(userID, res, k)
+	}
+	res = res[:min(k, len(res))]
+
+	if f.rules.MaxImpressions > 0 {
+		if f.impressions[userID] == nil {
+			f.impressions[userID] = make(map[string]int)
+		}
+		for _, item := range res {
+			f.impressions[userID][item]++
+		}
+	}
+
+	return res
+}
+
+func (f *Filtered) allow(userID, itemID string) bool {
+	r := f.rules
+	if r.Exclude != nil && r.Exclude(userID, itemID) {
+		return false
+	}
+	if r.MaxImpressions > 0 && f.impressions[userID][itemID] >= r.MaxImpressions {
+		return false
+	}
+	if r.Category == nil {
+		return true
+	}
+
+	c := r.Category(itemID)
+	if len(r.AllowCategories) > 0 && !slices.Contains(r.AllowCategories, c) {
+		return false
+	}
+
+	return !slices.Contains(r.DenyCategories, c)
+}
+
+// rerank selects the k items greedily by maximal marginal relevance, i.e.
+// the relevance penalized by the similarity to the items already selected.
+func (f *Filtered) rerank(userID string, candidates []string, k int) []string {
+	n := len(candidates)
+	if n == 0 {
+		return candidates
+	}
+
+	// Without scores, the relevance decreases linearly with the rank.
+	rel := make([]float64, n)
+	for i, item := range candidates {
+		if s, ok := f.rec.(Scorer); ok {
+			rel[i] = s.Score(userID, item)
+		} else {
+			rel[i] = 1 - float64(i)/float64(n)
+		}
+	}
+
+	lambda := f.rules.Diversity
+	selected := make([]string, 0, min(k, n))
+	used := make([]bool, n)
+	for len(selected) < cap(selected) {
+		best, top := -1, math.Inf(-1)
+		for i, item := range candidates {
+			if used[i] {
+				continue
+			}
+
+			var sim float64
+			for _, s := range selected {
+				sim = max(sim, f.rules.Similarity(item, s))
+			}
+
+			if mmr := (1-lambda)*rel[i] - lambda*sim; mmr > top {
+				best, top = i, mmr
+			}
+		}
+
+		used[best] = true
+		selected = append(selected, candidates[best])
+	}
+
+	return selected
+}
@@ -0,0 +1,71 @@
+package recommendation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/ab/recommendation"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRecommender []string
+
+func (s staticRecommender) Fit([]ab.Interaction) {}
+
+func (s staticRecommender) Recommend(userID string, k int) []string {
+	return s[:min(k, len(s))]
+}
+
+func TestFiltered(t *testing.T) {
+	rec := staticRecommender{"book-1", "book-2", "book-3", "game-1", "game-2", "toy-1"}
+	category := func(itemID string) string {
+		c, _, _ := strings.Cut(itemID, "-")
+		return c
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		f := recommendation.NewFiltered(rec, &recommendation.Rules{
+			Exclude: func(userID, itemID string) bool {
+				return userID == "alice" && itemID == "book-1"
+			},
+		})
+
+		is := assert.New(t)
+		is.Equal([]string{"book-2", "book-3"}, f.Recommend("alice", 2))
+		is.Equal([]string{"book-1", "book-2"}, f.Recommend("bob", 2))
+	})
+
+	t.Run("categories", func(t *testing.T) {
+		f := recommendation.NewFiltered(rec, &recommendation.Rules{
+			Category:        category,
+			AllowCategories: []string{"book", "game"},
+			DenyCategories:  []string{"book"},
+		})
+
+		is := assert.New(t)
+		is.Equal([]string{"game-1", "game-2"}, f.Recommend("alice", 3))
+	})
+
+	t.Run("frequency cap", func(t *testing.T) {
+		f := recommendation.NewFiltered(rec, &recommendation.Rules{
+			MaxImpressions: 2,
+		})
+
+		is := assert.New(t)
+		is.Equal([]string{"book-1", "book-2"}, f.Recommend("alice", 2))
+		is.Equal([]string{"book-1", "book-2"}, f.Recommend("alice", 2))
+		is.Equal([]string{"book-3", "game-1"}, f.Recommend("alice", 2))
+		is.Equal([]string{"book-1", "book-2"}, f.Recommend("bob", 2))
+	})
+
+	t.Run("diversity", func(t *testing.T) {
+		f := recommendation.NewFiltered(rec, &recommendation.Rules{
+			Category:  category,
+			Diversity: 0.5,
+		})
+
+		is := assert.New(t)
+		is.Equal([]string{"book-1", "game-1", "toy-1", "book-2"}, f.Recommend("alice", 4))
+	})
+}
@@ -0,0 +1,380 @@
+package ab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore stores the experiments, flags and segments in a hash each, the assignments in a hash per
+// experiment, the events in a list per experiment, and the metrics in a hash
+// per experiment and metric, with the fields prefixed by the variant.
+type RedisStore struct {
+	Prefix string
+	client *redis.Client
+}
+
+var (
+	_ Store       = (*RedisStore)(nil)
+	_ FlagWatcher = (*RedisStore)(nil)
+)
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		Prefix: DefaultPrefix,
+		client: client,
+	}
+}
+
+func (s *RedisStore) SaveExperiment(ctx context.Context, exp *Experiment) error {
+	b, err := json.Marshal(exp)
+	if err != nil {
+		return err
+	}
+
+	return s.client.HSet(ctx, s.key("experiments"), exp.ID, b).Err()
+}
+
+func (s *RedisStore) LoadExperiment(ctx context.Context, id string) (*Experiment, error) {
+	b, err := s.client.HGet(ctx, s.key("experiments"), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrExperimentNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var exp Experiment
+	if err := json.Unmarshal(b, &exp); err != nil {
+		return nil, err
+	}
+
+	return &exp, nil
+}
+
+func (s *RedisStore) ListExperiments(ctx context.Context) ([]*Experiment, error) {
+	m, err := s.client.HGetAll(ctx, s.key("experiments")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	exps := make([]*Experiment, 0, len(m))
+	for _, v := range m {
+		var exp Experiment
+		if err := json.Unmarshal([]byte(v), &exp); err != nil {
+			return nil, err
+		}
+		exps = append(exps, &exp)
+	}
+
+	return sortExperiments(exps), nil
+}
+
+// DeleteExperiment deletes the experiment together with its assignments,
+// events and metrics.
+func (s *RedisStore) DeleteExperiment(ctx context.Context, id string) error {
+	metrics, err := s.client.SMembers(ctx, s.key("metrics", id)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{
+		s.key("assignments", id),
+		s.key("exposures", id),
+		s.key("conversions", id),
+		s.key("metrics", id),
+	}
+	for _, m := range metrics {
+		keys = append(keys, s.key("metrics", id, m))
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, s.key("experiments"), id)
+		pipe.Del(ctx, keys...)
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) SaveFlag(ctx context.Context, f *Flag) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.key("flags"), f.ID, b)
+		pipe.Publish(ctx, s.key("flags"), f.ID)
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) LoadFlag(ctx context.Context, id string) (*Flag, error) {
+	b, err := s.client.HGet(ctx, s.key("flags"), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrFlagNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f Flag
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (s *RedisStore) ListFlags(ctx context.Context) ([]*Flag, error) {
+	m, err := s.client.HGetAll(ctx, s.key("flags")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make([]*Flag, 0, len(m))
+	for _, v := range m {
+		var f Flag
+		if err := json.Unmarshal([]byte(v), &f); err != nil {
+			return nil, err
+		}
+		flags = append(flags, &f)
+	}
+
+	return sortFlags(flags), nil
+}
+
+func (s *RedisStore) DeleteFlag(ctx context.Context, id string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, s.key("flags"), id)
+		pipe.Publish(ctx, s.key("flags"), id)
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) SaveSegment(ctx context.Context, seg *Segment) error {
+	b, err := json.Marshal(seg)
+	if err != nil {
+		return err
+	}
+
+	return s.client.HSet(ctx, s.key("segments"), seg.ID, b).Err()
+}
+
+func (s *RedisStore) LoadSegment(ctx context.Context, id string) (*Segment, error) {
+	b, err := s.client.HGet(ctx, s.key("segments"), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrSegmentNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var seg Segment
+	if err := json.Unmarshal(b, &seg); err != nil {
+		return nil, err
+	}
+
+	return &seg, nil
+}
+
+func (s *RedisStore) ListSegments(ctx context.Context) ([]*Segment, error) {
+	m, err := s.client.HGetAll(ctx, s.key("segments")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	segs := make([]*Segment, 0, len(m))
+	for _, v := range m {
+		var seg Segment
+		if err := json.Unmarshal([]byte(v), &seg); err != nil {
+			return nil, err
+		}
+		segs = append(segs, &seg)
+	}
+
+	return sortSegments(segs), nil
+}
+
+func (s *RedisStore) DeleteSegment(ctx context.Context, id string) error {
+	return s.client.HDel(ctx, s.key("segments"), id).Err()
+}
+
+// WatchFlags returns the ids of the flags saved or deleted, which are
+// published to the channel with the same name as the flags hash. Changes
+// published while reconnecting are lost.
+func (s *RedisStore) WatchFlags(ctx context.Context) (<-chan string, error) {
+	pubsub := s.client.Subscribe(ctx, s.key("flags"))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- msg.Payload:
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (s *RedisStore) LoadOrStoreAssignment(ctx context.Context, experimentID, userID, variant string) (string, bool, error) {
+	key := s.key("assignments", experimentID)
+
+	var stored *redis.BoolCmd
+	var actual *redis.StringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		stored = pipe.HSetNX(ctx, key, userID, variant)
+		actual = pipe.HGet(ctx, key, userID)
+
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return actual.Val(), !stored.Val(), nil
+}
+
+func (s *RedisStore) LoadAssignment(ctx context.Context, experimentID, userID string) (string, error) {
+	variant, err := s.client.HGet(ctx, s.key("assignments", experimentID), userID).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrAssignmentNotFound
+	}
+
+	return variant, err
+}
+
+func (s *RedisStore) SaveExposure(ctx context.Context, experimentID string, e Exposure) error {
+	return s.push(ctx, s.key("exposures", experimentID), e)
+}
+
+func (s *RedisStore) SaveConversion(ctx context.Context, experimentID string, c Conversion) error {
+	return s.push(ctx, s.key("conversions", experimentID), c)
+}
+
+func (s *RedisStore) ListExposures(ctx context.Context, experimentID string) ([]Exposure, error) {
+	return list[Exposure](ctx, s.client, s.key("exposures", experimentID))
+}
+
+func (s *RedisStore) ListConversions(ctx context.Context, experimentID string) ([]Conversion, error) {
+	return list[Conversion](ctx, s.client, s.key("conversions", experimentID))
+}
+
+func (s *RedisStore) AddMetric(ctx context.Context, experimentID, metric, variant string, value float64) error {
+	key := s.key("metrics", experimentID, metric)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// The metric names are tracked for the deletion.
+		pipe.SAdd(ctx, s.key("metrics", experimentID), metric)
+		pipe.HIncrBy(ctx, key, variant+":count", 1)
+		pipe.HIncrByFloat(ctx, key, variant+":sum", value)
+		pipe.HIncrByFloat(ctx, key, variant+":sum_squares", value*value)
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) LoadMetrics(ctx context.Context, experimentID, metric string) ([]MetricSummary, error) {
+	m, err := s.client.HGetAll(ctx, s.key("metrics", experimentID, metric)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	byVariant := make(map[string]*MetricSummary)
+	for k, v := range m {
+		i := strings.LastIndex(k, ":")
+		if i == -1 {
+			continue
+		}
+
+		variant, field := k[:i], k[i+1:]
+		ms, ok := byVariant[variant]
+		if !ok {
+			ms = &MetricSummary{Variant: variant}
+			byVariant[variant] = ms
+		}
+
+		switch field {
+		case "count":
+			ms.Count, err = strconv.ParseInt(v, 10, 64)
+		case "sum":
+			ms.Sum, err = strconv.ParseFloat(v, 64)
+		case "sum_squares":
+			ms.SumSquares, err = strconv.ParseFloat(v, 64)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]MetricSummary, 0, len(byVariant))
+	for _, ms := range byVariant {
+		res = append(res, *ms)
+	}
+
+	return sortMetrics(res), nil
+}
+
+func (s *RedisStore) push(ctx context.Context, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.client.RPush(ctx, key, b).Err()
+}
+
+func (s *RedisStore) key(parts ...string) string {
+	return strings.Join(append([]string{s.Prefix}, parts...), ":")
+}
+
+func list[T any](ctx context.Context, client *redis.Client, key string) ([]T, error) {
+	vals, err := client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]T, len(vals))
+	for i, v := range vals {
+		if err := json.Unmarshal([]byte(v), &res[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
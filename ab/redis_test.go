@@ -0,0 +1,106 @@
+package ab_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisStore(t *testing.T) {
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	ctx := context.Background()
+
+	t.Run("experiments", func(t *testing.T) {
+		is := assert.New(t)
+
+		exp := &ab.Experiment{
+			ID:      "checkout",
+			Seed:    42,
+			Rollout: 50,
+			Variants: []ab.Variant{
+				{Name: "control", Weight: 1},
+				{Name: "treatment", Weight: 1},
+			},
+			Default: "control",
+		}
+		is.Nil(store.SaveExperiment(ctx, exp))
+
+		got, err := store.LoadExperiment(ctx, "checkout")
+		is.Nil(err)
+		is.Equal(exp, got)
+
+		exps, err := store.ListExperiments(ctx)
+		is.Nil(err)
+		is.Equal([]*ab.Experiment{exp}, exps)
+
+		// The rules are kept when loading.
+		rules := []ab.Rule{{Name: "all", Match: func(context.Context, string) bool { return true }}}
+		a := ab.NewAssigner(&ab.Experiment{ID: "checkout", Rules: rules})
+		is.Nil(a.Load(ctx, store))
+		e, err := a.Explain(ctx, "checkout", "user-1")
+		is.Nil(err)
+		is.Equal([]ab.RuleResult{{Name: "all", Matched: true}}, e.Rules)
+
+		is.Nil(store.DeleteExperiment(ctx, "checkout"))
+		_, err = store.LoadExperiment(ctx, "checkout")
+		is.ErrorIs(err, ab.ErrExperimentNotFound)
+	})
+
+	t.Run("assignments", func(t *testing.T) {
+		is := assert.New(t)
+
+		_, err := store.LoadAssignment(ctx, "checkout", "user-1")
+		is.ErrorIs(err, ab.ErrAssignmentNotFound)
+
+		v, loaded, err := store.LoadOrStoreAssignment(ctx, "checkout", "user-1", "control")
+		is.Nil(err)
+		is.False(loaded)
+		is.Equal("control", v)
+
+		v, loaded, err = store.LoadOrStoreAssignment(ctx, "checkout", "user-1", "treatment")
+		is.Nil(err)
+		is.True(loaded)
+		is.Equal("control", v)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		is := assert.New(t)
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		is.Nil(store.SaveConversion(ctx, "signup", ab.Conversion{UserID: "a", Value: 10, At: now.Add(time.Hour)}))
+		is.Nil(store.SaveExposure(ctx, "signup", ab.Exposure{UserID: "a", Variant: "treatment", At: now}))
+		is.Nil(store.SaveExposure(ctx, "signup", ab.Exposure{UserID: "b", Variant: "control", At: now}))
+
+		attr, err := ab.LoadAttribution(ctx, store, "signup", nil)
+		is.Nil(err)
+
+		res := attr.Result()
+		is.Equal(0, res.Dropped)
+		is.Equal([]ab.VariantAttribution{
+			{Variant: "control", Exposures: 1, Pending: 1},
+			{Variant: "treatment", Exposures: 1, Conversions: 1, Converted: 1, Value: 10, Rate: 1, Pending: 1},
+		}, res.Variants)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		is := assert.New(t)
+
+		for _, v := range []float64{1, 2, 3} {
+			is.Nil(store.AddMetric(ctx, "pricing", "revenue", "control", v))
+		}
+		is.Nil(store.AddMetric(ctx, "pricing", "revenue", "treatment", 5))
+
+		ms, err := store.LoadMetrics(ctx, "pricing", "revenue")
+		is.Nil(err)
+		is.Equal([]ab.MetricSummary{
+			{Variant: "control", Count: 3, Sum: 6, SumSquares: 14},
+			{Variant: "treatment", Count: 1, Sum: 5, SumSquares: 25},
+		}, ms)
+		is.Equal(2.0, ms[0].Mean())
+		is.Equal(1.0, ms[0].Variance())
+	})
+}
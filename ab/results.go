@@ -0,0 +1,229 @@
+package ab
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Statuses of the experiment results.
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+)
+
+type ResultsOptions struct {
+	Attribution *AttributionOptions
+
+	// Metrics are the names of the metrics to include.
+	Metrics []string
+
+	// SRMAlpha is the significance level of the sample ratio mismatch check.
+	// Defaults to 0.001, since the check is repeated every time the results
+	// are fetched.
+	SRMAlpha float64
+
+	// OnQualityAlert is called when the results fail a quality check.
+	OnQualityAlert func(ctx context.Context, res *ExperimentResults)
+
+	// Power estimates the progress towards the sample size when set.
+	Power *PowerOptions
+
+	// Bayesian compares the variants to the control with the posterior
+	// distributions when set.
+	Bayesian *BayesianOptions
+
+	Now func() time.Time
+}
+
+func (o *ResultsOptions) valid() *ResultsOptions {
+	o = cmp.Or(o, &ResultsOptions{})
+	o.SRMAlpha = cmp.Or(o.SRMAlpha, 0.001)
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+
+	return o
+}
+
+type ExperimentResults struct {
+	ExperimentID string                     `json:"experiment_id"`
+	Status       string                     `json:"status"`
+	Warnings     []string                   `json:"warnings,omitempty"`
+	Attribution  *AttributionResult         `json:"attribution"`
+	SRM          *SRMResult                 `json:"srm,omitempty"`
+	Power        *PowerResult               `json:"power,omitempty"`
+	Bayesian     *BayesianResult            `json:"bayesian,omitempty"`
+	Metrics      map[string][]MetricSummary `json:"metrics,omitempty"`
+}
+
+// LoadResults computes the results of the experiment from the stored events
+// and metrics, and checks the quality of the results.
+func LoadResults(ctx context.Context, s Store, experimentID string, opts *ResultsOptions) (*ExperimentResults, error) {
+	opts = opts.valid()
+
+	exp, err := s.LoadExperiment(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	attr, err := LoadAttribution(ctx, s, experimentID, opts.Attribution)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ExperimentResults{
+		ExperimentID: experimentID,
+		Status:       StatusOK,
+		Attribution:  attr.Result(),
+	}
+
+	for _, metric := range opts.Metrics {
+		ms, err := s.LoadMetrics(ctx, experimentID, metric)
+		if err != nil {
+			return nil, err
+		}
+		if res.Metrics == nil {
+			res.Metrics = make(map[string][]MetricSummary)
+		}
+		res.Metrics[metric] = ms
+	}
+
+	observed := make(map[string]int)
+	for _, v := range res.Attribution.Variants {
+		observed[v.Variant] = v.Exposures
+	}
+
+	res.SRM = CheckSRM(exp.Variants, observed, opts.SRMAlpha)
+	if res.SRM != nil && res.SRM.Mismatch {
+		res.Status = StatusWarning
+		res.Warnings = append(res.Warnings, fmt.Sprintf("sample ratio mismatch: p-value %.2g < %g", res.SRM.PValue, opts.SRMAlpha))
+	}
+
+	if opts.Power != nil {
+		res.Power, err = power(exp, res.Attribution, opts.Power, opts.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Bayesian != nil {
+		res.Bayesian = bayesian(exp, res, opts.Bayesian)
+	}
+
+	if res.Status != StatusOK && opts.OnQualityAlert != nil {
+		opts.OnQualityAlert(ctx, res)
+	}
+
+	return res, nil
+}
+
+// SRMResult is the chi-square goodness of fit test of the users per variant
+// against the weights of the variants. A mismatch means the assignment or the
+// logging is broken, and the results cannot be trusted.
+type SRMResult struct {
+	Observed  map[string]int     `json:"observed"`
+	Expected  map[string]float64 `json:"expected"`
+	ChiSquare float64            `json:"chi_square"`
+	PValue    float64            `json:"p_value"`
+	Mismatch  bool               `json:"mismatch"`
+}
+
+// CheckSRM checks the observed users per variant for a sample ratio
+// mismatch, at the significance level alpha. It returns nil when there are
+// too few users for the test, i.e. less than 5 expected per variant.
+func CheckSRM(variants []Variant, observed map[string]int, alpha float64) *SRMResult {
+	var total, weights float64
+	for _, v := range variants {
+		total += float64(observed[v.Name])
+		weights += float64(v.Weight)
+	}
+	if len(variants) < 2 || weights == 0 {
+		return nil
+	}
+
+	res := &SRMResult{
+		Observed: make(map[string]int),
+		Expected: make(map[string]float64),
+	}
+	for _, v := range variants {
+		o := float64(observed[v.Name])
+		e := total * float64(v.Weight) / weights
+		if e < 5 {
+			return nil
+		}
+
+		res.Observed[v.Name] = observed[v.Name]
+		res.Expected[v.Name] = e
+		res.ChiSquare += (o - e) * (o - e) / e
+	}
+	res.PValue = chiSquareSF(res.ChiSquare, len(variants)-1)
+	res.Mismatch = res.PValue < alpha
+
+	return res
+}
+
+// chiSquareSF returns the survival function of the chi-square distribution,
+// i.e. the probability of a value at least x.
+func chiSquareSF(x float64, df int) float64 {
+	return gammaQ(float64(df)/2, x/2)
+}
+
+// gammaQ returns the regularized upper incomplete gamma function Q(a, x),
+// using the series expansion for x < a+1, and the continued fraction
+// otherwise, as in Numerical Recipes.
+func gammaQ(a, x float64) float64 {
+	const (
+		eps  = 1e-15
+		tiny = 1e-300
+		iter = 500
+	)
+
+	if x <= 0 {
+		return 1
+	}
+
+	lg, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lg)
+
+	if x < a+1 {
+		sum := 1 / a
+		del := sum
+		for n := 1; n < iter; n++ {
+			del *= x / (a + float64(n))
+			sum += del
+			if math.Abs(del) < math.Abs(sum)*eps {
+				break
+			}
+		}
+
+		return 1 - sum*prefix
+	}
+
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < iter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+
+	return prefix * h
+}
@@ -0,0 +1,307 @@
+package ab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+var (
+	ErrSegmentNotFound  = errors.New("ab: segment not found")
+	ErrInvalidCriterion = errors.New("ab: invalid criterion")
+)
+
+// Operators of the criteria.
+const (
+	OpEq     = "eq"
+	OpNeq    = "neq"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpIn     = "in"
+	OpRegex  = "regex"
+	OpExists = "exists"
+)
+
+// Attributes are the attributes of the user, e.g. the country, which are
+// matched by the segments.
+type Attributes map[string]any
+
+type attributesKey struct{}
+
+// WithAttributes returns a context with the attributes of the user.
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context {
+	return context.WithValue(ctx, attributesKey{}, attrs)
+}
+
+func AttributesFromContext(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(attributesKey{}).(Attributes)
+	return attrs
+}
+
+// Segment is a reusable group of users, which can be referenced by id by the
+// experiments and the flags.
+type Segment struct {
+	ID        string    `json:"id"`
+	Criterion Criterion `json:"criterion"`
+}
+
+// Criterion is either a combination of criteria with And, Or or Not, or a
+// comparison of the attribute with the value. The "user_id" attribute is
+// always set.
+//
+//	{"and": [
+//		{"attribute": "country", "op": "in", "value": ["MY", "SG"]},
+//		{"not": {"attribute": "age", "op": "lt", "value": 18}}
+//	]}
+type Criterion struct {
+	And []Criterion `json:"and,omitempty"`
+	Or  []Criterion `json:"or,omitempty"`
+	Not *Criterion  `json:"not,omitempty"`
+
+	Attribute string `json:"attribute,omitempty"`
+	Op        string `json:"op,omitempty"`
+	Value     any    `json:"value,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Validate checks the operators, and compiles the regular expressions.
+func (c *Criterion) Validate() error {
+	switch {
+	case len(c.And) > 0:
+		return validateAll(c.And)
+	case len(c.Or) > 0:
+		return validateAll(c.Or)
+	case c.Not != nil:
+		return c.Not.Validate()
+	case c.Attribute == "":
+		return fmt.Errorf("%w: attribute is required", ErrInvalidCriterion)
+	}
+
+	switch c.Op {
+	case OpEq, OpNeq, OpExists:
+	case OpLt, OpLte, OpGt, OpGte:
+		if _, ok := toFloat(c.Value); !ok {
+			return fmt.Errorf("%w: %s requires a number, got %v", ErrInvalidCriterion, c.Op, c.Value)
+		}
+	case OpIn:
+		if _, ok := c.Value.([]any); !ok {
+			return fmt.Errorf("%w: in requires a list, got %v", ErrInvalidCriterion, c.Value)
+		}
+	case OpRegex:
+		s, ok := c.Value.(string)
+		if !ok {
+			return fmt.Errorf("%w: regex requires a string, got %v", ErrInvalidCriterion, c.Value)
+		}
+
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidCriterion, err)
+		}
+		c.re = re
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidCriterion, c.Op)
+	}
+
+	return nil
+}
+
+// Match returns true if the attributes satisfy the criterion. The criterion
+// must be validated first.
+func (c *Criterion) Match(attrs Attributes) bool {
+	switch {
+	case len(c.And) > 0:
+		for i := range c.And {
+			if !c.And[i].Match(attrs) {
+				return false
+			}
+		}
+		return true
+	case len(c.Or) > 0:
+		for i := range c.Or {
+			if c.Or[i].Match(attrs) {
+				return true
+			}
+		}
+		return false
+	case c.Not != nil:
+		return !c.Not.Match(attrs)
+	}
+
+	v, ok := attrs[c.Attribute]
+	switch c.Op {
+	case OpExists:
+		return ok
+	case OpNeq:
+		return !ok || !equal(v, c.Value)
+	}
+	if !ok {
+		return false
+	}
+
+	switch c.Op {
+	case OpEq:
+		return equal(v, c.Value)
+	case OpIn:
+		vals, _ := c.Value.([]any)
+		return slices.ContainsFunc(vals, func(x any) bool {
+			return equal(v, x)
+		})
+	case OpRegex:
+		return c.re != nil && c.re.MatchString(fmt.Sprint(v))
+	}
+
+	a, ok := toFloat(v)
+	if !ok {
+		return false
+	}
+	b, _ := toFloat(c.Value)
+
+	switch c.Op {
+	case OpLt:
+		return a < b
+	case OpLte:
+		return a <= b
+	case OpGt:
+		return a > b
+	case OpGte:
+		return a >= b
+	default:
+		return false
+	}
+}
+
+// Match returns true if the user with the attributes in the context is in the
+// segment.
+func (s *Segment) Match(ctx context.Context, userID string) bool {
+	attrs := Attributes{"user_id": userID}
+	for k, v := range AttributesFromContext(ctx) {
+		attrs[k] = v
+	}
+
+	return s.Criterion.Match(attrs)
+}
+
+// Segments is a registry of the segments, which resolves the segments
+// referenced by the experiments and the flags, so that they are evaluated
+// the same way in both.
+type Segments struct {
+	mu       sync.RWMutex
+	segments map[string]*Segment
+}
+
+func NewSegments() *Segments {
+	return &Segments{
+		segments: make(map[string]*Segment),
+	}
+}
+
+// Add validates and adds or replaces the segment.
+func (s *Segments) Add(seg *Segment) error {
+	if err := seg.Criterion.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", seg.ID, err)
+	}
+
+	s.mu.Lock()
+	s.segments[seg.ID] = seg
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Rules returns the segments as rules. Unknown segments never match.
+func (s *Segments) Rules(ids []string) []Rule {
+	rules := make([]Rule, len(ids))
+	for i, id := range ids {
+		var seg *Segment
+		if s != nil {
+			s.mu.RLock()
+			seg = s.segments[id]
+			s.mu.RUnlock()
+		}
+
+		rules[i] = Rule{
+			Name: "segment:" + id,
+			Match: func(ctx context.Context, userID string) bool {
+				return seg != nil && seg.Match(ctx, userID)
+			},
+		}
+	}
+
+	return rules
+}
+
+// Match returns true if the user is in all the segments.
+func (s *Segments) Match(ctx context.Context, userID string, ids []string) bool {
+	for _, r := range s.Rules(ids) {
+		if !r.Match(ctx, userID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateAll(cs []Criterion) error {
+	for i := range cs {
+		if err := cs[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// equal compares the strings as is, the numbers by value, since the JSON
+// numbers are decoded as float64, and the other values by their string
+// representation.
+func equal(a, b any) bool {
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return x == y
+		}
+	}
+
+	x, okx := toFloat(a)
+	y, oky := toFloat(b)
+	if okx && oky {
+		return x == y
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case interface{ Float64() (float64, error) }:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
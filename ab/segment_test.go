@@ -0,0 +1,94 @@
+package ab_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriterion(t *testing.T) {
+	var c ab.Criterion
+	err := json.Unmarshal([]byte(`{"and": [
+		{"attribute": "country", "op": "in", "value": ["MY", "SG"]},
+		{"not": {"attribute": "age", "op": "lt", "value": 18}},
+		{"or": [
+			{"attribute": "email", "op": "regex", "value": "@example\\.com$"},
+			{"attribute": "beta", "op": "eq", "value": true}
+		]}
+	]}`), &c)
+
+	is := assert.New(t)
+	is.Nil(err)
+	is.Nil(c.Validate())
+
+	tests := []struct {
+		name  string
+		attrs ab.Attributes
+		want  bool
+	}{
+		{"match email", ab.Attributes{"country": "MY", "age": 20, "email": "a@example.com"}, true},
+		{"match beta", ab.Attributes{"country": "SG", "age": 18, "beta": true}, true},
+		{"under age", ab.Attributes{"country": "MY", "age": 17, "beta": true}, false},
+		{"other country", ab.Attributes{"country": "US", "age": 20, "beta": true}, false},
+		{"neither email nor beta", ab.Attributes{"country": "MY", "age": 20, "email": "a@test.com"}, false},
+		{"missing attributes", ab.Attributes{}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, c.Match(tc.attrs))
+		})
+	}
+
+	is.ErrorIs((&ab.Criterion{Attribute: "age", Op: "between"}).Validate(), ab.ErrInvalidCriterion)
+	is.ErrorIs((&ab.Criterion{Attribute: "age", Op: ab.OpGt, Value: "old"}).Validate(), ab.ErrInvalidCriterion)
+	is.ErrorIs((&ab.Criterion{Attribute: "email", Op: ab.OpRegex, Value: "("}).Validate(), ab.ErrInvalidCriterion)
+}
+
+func TestSegments(t *testing.T) {
+	segments := ab.NewSegments()
+
+	is := assert.New(t)
+	is.Nil(segments.Add(&ab.Segment{
+		ID:        "malaysia",
+		Criterion: ab.Criterion{Attribute: "country", Op: ab.OpEq, Value: "MY"},
+	}))
+
+	a := ab.NewAssigner(&ab.Experiment{
+		ID:       "checkout",
+		Rollout:  100,
+		Segments: []string{"malaysia"},
+		Variants: []ab.Variant{{Name: "treatment", Weight: 1}},
+		Default:  "control",
+	})
+	a.Segments = segments
+
+	f := &ab.Flag{
+		ID:       "new-checkout",
+		Enabled:  true,
+		Rollout:  100,
+		Segments: []string{"malaysia"},
+		Value:    json.RawMessage(`true`),
+		Default:  json.RawMessage(`false`),
+	}
+
+	// The segment is evaluated the same way for the experiments and flags.
+	for country, want := range map[string]bool{"MY": true, "SG": false} {
+		ctx := ab.WithAttributes(context.Background(), ab.Attributes{"country": country})
+
+		e, err := a.Explain(ctx, "checkout", "user-1")
+		is.Nil(err)
+		is.Equal([]ab.RuleResult{{Name: "segment:malaysia", Matched: want}}, e.Rules)
+
+		res := f.Evaluate(ctx, "user-1", segments)
+		is.Equal(want, res.Reason == ab.ReasonInRollout)
+	}
+
+	// Unknown segments never match.
+	f.Segments = []string{"unknown"}
+	res := f.Evaluate(context.Background(), "user-1", segments)
+	is.Equal(ab.ReasonRuleNotMatched, res.Reason)
+}
@@ -0,0 +1,415 @@
+package ab
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SQLStore is a Store backed by Postgres tables, which are prefixed with the
+// Prefix.
+type SQLStore struct {
+	Prefix string
+	db     *sql.DB
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Prefix: DefaultPrefix,
+		db:     db,
+	}
+}
+
+// Schema returns the statements to create the tables.
+func (s *SQLStore) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s_experiments (
+	id text PRIMARY KEY,
+	data jsonb NOT NULL,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS %[1]s_flags (
+	id text PRIMARY KEY,
+	data jsonb NOT NULL,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS %[1]s_segments (
+	id text PRIMARY KEY,
+	data jsonb NOT NULL,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS %[1]s_assignments (
+	experiment_id text NOT NULL,
+	user_id text NOT NULL,
+	variant text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (experiment_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS %[1]s_exposures (
+	experiment_id text NOT NULL,
+	user_id text NOT NULL,
+	variant text NOT NULL,
+	at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS %[1]s_exposures_experiment_id_idx ON %[1]s_exposures (experiment_id, at);
+
+CREATE TABLE IF NOT EXISTS %[1]s_conversions (
+	experiment_id text NOT NULL,
+	user_id text NOT NULL,
+	value double precision NOT NULL,
+	at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS %[1]s_conversions_experiment_id_idx ON %[1]s_conversions (experiment_id, at);
+
+CREATE TABLE IF NOT EXISTS %[1]s_metrics (
+	experiment_id text NOT NULL,
+	metric text NOT NULL,
+	variant text NOT NULL,
+	count bigint NOT NULL,
+	sum double precision NOT NULL,
+	sum_squares double precision NOT NULL,
+	PRIMARY KEY (experiment_id, metric, variant)
+);`, s.Prefix)
+}
+
+// Migrate creates the tables if they do not exist.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.Schema())
+	return err
+}
+
+func (s *SQLStore) SaveExperiment(ctx context.Context, exp *Experiment) error {
+	b, err := json.Marshal(exp)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`INSERT INTO %s_experiments (id, data) VALUES ($1, $2)
+	ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = now()`, s.Prefix)
+	_, err = s.db.ExecContext(ctx, q, exp.ID, b)
+	return err
+}
+
+func (s *SQLStore) LoadExperiment(ctx context.Context, id string) (*Experiment, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_experiments WHERE id = $1`, s.Prefix)
+
+	var b []byte
+	err := s.db.QueryRowContext(ctx, q, id).Scan(&b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrExperimentNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var exp Experiment
+	if err := json.Unmarshal(b, &exp); err != nil {
+		return nil, err
+	}
+
+	return &exp, nil
+}
+
+func (s *SQLStore) ListExperiments(ctx context.Context) ([]*Experiment, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_experiments ORDER BY id`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exps []*Experiment
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+
+		var exp Experiment
+		if err := json.Unmarshal(b, &exp); err != nil {
+			return nil, err
+		}
+		exps = append(exps, &exp)
+	}
+
+	return exps, rows.Err()
+}
+
+// DeleteExperiment deletes the experiment together with its assignments,
+// events and metrics.
+func (s *SQLStore) DeleteExperiment(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s_experiments WHERE id = $1`, s.Prefix), id); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"assignments", "exposures", "conversions", "metrics"} {
+		q := fmt.Sprintf(`DELETE FROM %s_%s WHERE experiment_id = $1`, s.Prefix, table)
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) SaveFlag(ctx context.Context, f *Flag) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`INSERT INTO %s_flags (id, data) VALUES ($1, $2)
+	ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = now()`, s.Prefix)
+	_, err = s.db.ExecContext(ctx, q, f.ID, b)
+	return err
+}
+
+func (s *SQLStore) LoadFlag(ctx context.Context, id string) (*Flag, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_flags WHERE id = $1`, s.Prefix)
+
+	var b []byte
+	err := s.db.QueryRowContext(ctx, q, id).Scan(&b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrFlagNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f Flag
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (s *SQLStore) ListFlags(ctx context.Context) ([]*Flag, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_flags ORDER BY id`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []*Flag
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+
+		var f Flag
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, err
+		}
+		flags = append(flags, &f)
+	}
+
+	return flags, rows.Err()
+}
+
+func (s *SQLStore) DeleteFlag(ctx context.Context, id string) error {
+	q := fmt.Sprintf(`DELETE FROM %s_flags WHERE id = $1`, s.Prefix)
+	_, err := s.db.ExecContext(ctx, q, id)
+	return err
+}
+
+func (s *SQLStore) SaveSegment(ctx context.Context, seg *Segment) error {
+	b, err := json.Marshal(seg)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`INSERT INTO %s_segments (id, data) VALUES ($1, $2)
+	ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = now()`, s.Prefix)
+	_, err = s.db.ExecContext(ctx, q, seg.ID, b)
+	return err
+}
+
+func (s *SQLStore) LoadSegment(ctx context.Context, id string) (*Segment, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_segments WHERE id = $1`, s.Prefix)
+
+	var b []byte
+	err := s.db.QueryRowContext(ctx, q, id).Scan(&b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrSegmentNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var seg Segment
+	if err := json.Unmarshal(b, &seg); err != nil {
+		return nil, err
+	}
+
+	return &seg, nil
+}
+
+func (s *SQLStore) ListSegments(ctx context.Context) ([]*Segment, error) {
+	q := fmt.Sprintf(`SELECT data FROM %s_segments ORDER BY id`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segs []*Segment
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+
+		var seg Segment
+		if err := json.Unmarshal(b, &seg); err != nil {
+			return nil, err
+		}
+		segs = append(segs, &seg)
+	}
+
+	return segs, rows.Err()
+}
+
+func (s *SQLStore) DeleteSegment(ctx context.Context, id string) error {
+	q := fmt.Sprintf(`DELETE FROM %s_segments WHERE id = $1`, s.Prefix)
+	_, err := s.db.ExecContext(ctx, q, id)
+	return err
+}
+
+func (s *SQLStore) LoadOrStoreAssignment(ctx context.Context, experimentID, userID, variant string) (string, bool, error) {
+	q := fmt.Sprintf(`INSERT INTO %s_assignments (experiment_id, user_id, variant) VALUES ($1, $2, $3)
+	ON CONFLICT (experiment_id, user_id) DO NOTHING`, s.Prefix)
+	res, err := s.db.ExecContext(ctx, q, experimentID, userID, variant)
+	if err != nil {
+		return "", false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", false, err
+	}
+	if n == 1 {
+		return variant, false, nil
+	}
+
+	actual, err := s.LoadAssignment(ctx, experimentID, userID)
+	if err != nil {
+		return "", false, err
+	}
+
+	return actual, true, nil
+}
+
+func (s *SQLStore) LoadAssignment(ctx context.Context, experimentID, userID string) (string, error) {
+	q := fmt.Sprintf(`SELECT variant FROM %s_assignments WHERE experiment_id = $1 AND user_id = $2`, s.Prefix)
+
+	var variant string
+	err := s.db.QueryRowContext(ctx, q, experimentID, userID).Scan(&variant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAssignmentNotFound
+	}
+
+	return variant, err
+}
+
+func (s *SQLStore) SaveExposure(ctx context.Context, experimentID string, e Exposure) error {
+	q := fmt.Sprintf(`INSERT INTO %s_exposures (experiment_id, user_id, variant, at) VALUES ($1, $2, $3, $4)`, s.Prefix)
+	_, err := s.db.ExecContext(ctx, q, experimentID, e.UserID, e.Variant, e.At)
+	return err
+}
+
+func (s *SQLStore) SaveConversion(ctx context.Context, experimentID string, c Conversion) error {
+	q := fmt.Sprintf(`INSERT INTO %s_conversions (experiment_id, user_id, value, at) VALUES ($1, $2, $3, $4)`, s.Prefix)
+	_, err := s.db.ExecContext(ctx, q, experimentID, c.UserID, c.Value, c.At)
+	return err
+}
+
+func (s *SQLStore) ListExposures(ctx context.Context, experimentID string) ([]Exposure, error) {
+	q := fmt.Sprintf(`SELECT user_id, variant, at FROM %s_exposures WHERE experiment_id = $1 ORDER BY at`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Exposure
+	for rows.Next() {
+		var e Exposure
+		if err := rows.Scan(&e.UserID, &e.Variant, &e.At); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *SQLStore) ListConversions(ctx context.Context, experimentID string) ([]Conversion, error) {
+	q := fmt.Sprintf(`SELECT user_id, value, at FROM %s_conversions WHERE experiment_id = $1 ORDER BY at`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Conversion
+	for rows.Next() {
+		var c Conversion
+		if err := rows.Scan(&c.UserID, &c.Value, &c.At); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *SQLStore) AddMetric(ctx context.Context, experimentID, metric, variant string, value float64) error {
+	q := fmt.Sprintf(`INSERT INTO %[1]s_metrics AS m (experiment_id, metric, variant, count, sum, sum_squares)
+	VALUES ($1, $2, $3, 1, $4, $4 * $4)
+	ON CONFLICT (experiment_id, metric, variant) DO UPDATE SET
+		count = m.count + 1,
+		sum = m.sum + excluded.sum,
+		sum_squares = m.sum_squares + excluded.sum_squares`, s.Prefix)
+	_, err := s.db.ExecContext(ctx, q, experimentID, metric, variant, value)
+	return err
+}
+
+func (s *SQLStore) LoadMetrics(ctx context.Context, experimentID, metric string) ([]MetricSummary, error) {
+	q := fmt.Sprintf(`SELECT variant, count, sum, sum_squares FROM %s_metrics
+	WHERE experiment_id = $1 AND metric = $2
+	ORDER BY variant`, s.Prefix)
+	rows, err := s.db.QueryContext(ctx, q, experimentID, metric)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []MetricSummary
+	for rows.Next() {
+		var m MetricSummary
+		if err := rows.Scan(&m.Variant, &m.Count, &m.Sum, &m.SumSquares); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,76 @@
+package ab_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/alextanhongpin/core/ab"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSRM(t *testing.T) {
+	variants := []ab.Variant{
+		{Name: "control", Weight: 1},
+		{Name: "treatment", Weight: 1},
+	}
+
+	is := assert.New(t)
+	res := ab.CheckSRM(variants, map[string]int{"control": 5000, "treatment": 5000}, 0.001)
+	is.Equal(0.0, res.ChiSquare)
+	is.Equal(1.0, res.PValue)
+	is.False(res.Mismatch)
+
+	res = ab.CheckSRM(variants, map[string]int{"control": 5000, "treatment": 5500}, 0.001)
+	// The chi-square distribution with 1 degree of freedom.
+	is.InDelta(math.Erfc(math.Sqrt(res.ChiSquare/2)), res.PValue, 1e-12)
+	is.True(res.Mismatch)
+
+	// The expected counts follow the weights.
+	variants = append(variants, ab.Variant{Name: "holdout", Weight: 2})
+	res = ab.CheckSRM(variants, map[string]int{"control": 250, "treatment": 260, "holdout": 490}, 0.001)
+	is.Equal(map[string]float64{"control": 250, "treatment": 250, "holdout": 500}, res.Expected)
+	// The chi-square distribution with 2 degrees of freedom.
+	is.InDelta(math.Exp(-res.ChiSquare/2), res.PValue, 1e-12)
+	is.False(res.Mismatch)
+
+	// Too few users for the test.
+	is.Nil(ab.CheckSRM(variants, map[string]int{"control": 3, "treatment": 2}, 0.001))
+}
+
+func TestLoadResultsSRM(t *testing.T) {
+	store := ab.NewRedisStore(redistest.New(t).Client())
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(store.SaveExperiment(ctx, &ab.Experiment{
+		ID:      "checkout",
+		Rollout: 100,
+		Variants: []ab.Variant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+	}))
+
+	for i := range 300 {
+		variant := "control"
+		if i%3 == 0 {
+			variant = "treatment"
+		}
+		is.Nil(store.SaveExposure(ctx, "checkout", ab.Exposure{UserID: fmt.Sprint("user-", i), Variant: variant}))
+	}
+
+	var alerted *ab.ExperimentResults
+	res, err := ab.LoadResults(ctx, store, "checkout", &ab.ResultsOptions{
+		OnQualityAlert: func(ctx context.Context, res *ab.ExperimentResults) {
+			alerted = res
+		},
+	})
+	is.Nil(err)
+	is.Equal(ab.StatusWarning, res.Status)
+	is.True(res.SRM.Mismatch)
+	is.Len(res.Warnings, 1)
+	is.Equal(res, alerted)
+}
@@ -0,0 +1,201 @@
+package ab
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+)
+
+// DefaultPrefix is the prefix of the keys and tables used by the stores.
+const DefaultPrefix = "ab"
+
+var ErrAssignmentNotFound = errors.New("ab: assignment not found")
+
+// Store persists the experiments and their results, so that they survive
+// restarts and are shared between instances.
+type Store interface {
+	ExperimentStore
+	FlagStore
+	SegmentStore
+	AssignmentStore
+	EventStore
+	MetricStore
+}
+
+// ExperimentStore persists the experiments. The rules are not persisted, and
+// must be set again after loading.
+type ExperimentStore interface {
+	SaveExperiment(ctx context.Context, exp *Experiment) error
+	LoadExperiment(ctx context.Context, id string) (*Experiment, error)
+	ListExperiments(ctx context.Context) ([]*Experiment, error)
+	DeleteExperiment(ctx context.Context, id string) error
+}
+
+// FlagStore persists the flags.
+type FlagStore interface {
+	SaveFlag(ctx context.Context, f *Flag) error
+	LoadFlag(ctx context.Context, id string) (*Flag, error)
+	ListFlags(ctx context.Context) ([]*Flag, error)
+	DeleteFlag(ctx context.Context, id string) error
+}
+
+// SegmentStore persists the segments.
+type SegmentStore interface {
+	SaveSegment(ctx context.Context, seg *Segment) error
+	LoadSegment(ctx context.Context, id string) (*Segment, error)
+	ListSegments(ctx context.Context) ([]*Segment, error)
+	DeleteSegment(ctx context.Context, id string) error
+}
+
+// LoadSegments returns the registry of the stored segments.
+func LoadSegments(ctx context.Context, s SegmentStore) (*Segments, error) {
+	segs, err := s.ListSegments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := NewSegments()
+	for _, seg := range segs {
+		if err := res.Add(seg); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// AssignmentStore persists the variant assigned to the users, so that the
+// users keep their variant when the experiment changes.
+type AssignmentStore interface {
+	// LoadOrStoreAssignment returns the existing variant of the user if any,
+	// and otherwise stores the given variant. The loaded result is true if the
+	// variant was loaded.
+	LoadOrStoreAssignment(ctx context.Context, experimentID, userID, variant string) (actual string, loaded bool, err error)
+	LoadAssignment(ctx context.Context, experimentID, userID string) (string, error)
+}
+
+// EventStore persists the exposures and conversions for the attribution.
+type EventStore interface {
+	SaveExposure(ctx context.Context, experimentID string, e Exposure) error
+	SaveConversion(ctx context.Context, experimentID string, c Conversion) error
+	ListExposures(ctx context.Context, experimentID string) ([]Exposure, error)
+	ListConversions(ctx context.Context, experimentID string) ([]Conversion, error)
+}
+
+// MetricStore aggregates the metric values per variant, e.g. the revenue per
+// user.
+type MetricStore interface {
+	AddMetric(ctx context.Context, experimentID, metric, variant string, value float64) error
+	LoadMetrics(ctx context.Context, experimentID, metric string) ([]MetricSummary, error)
+}
+
+// MetricSummary is the running aggregate of a metric for a variant, which is
+// sufficient to compute the mean and variance.
+type MetricSummary struct {
+	Variant    string  `json:"variant"`
+	Count      int64   `json:"count"`
+	Sum        float64 `json:"sum"`
+	SumSquares float64 `json:"sum_squares"`
+}
+
+func (m MetricSummary) Mean() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+
+	return m.Sum / float64(m.Count)
+}
+
+// Variance returns the sample variance.
+func (m MetricSummary) Variance() float64 {
+	if m.Count < 2 {
+		return 0
+	}
+
+	n := float64(m.Count)
+	v := (m.SumSquares - m.Sum*m.Sum/n) / (n - 1)
+
+	// Guard against the rounding errors.
+	return math.Max(v, 0)
+}
+
+// StdErr returns the standard error of the mean.
+func (m MetricSummary) StdErr() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+
+	return math.Sqrt(m.Variance() / float64(m.Count))
+}
+
+// LoadAttribution replays the stored events of the experiment in time order,
+// so that none of them are dropped as late events.
+func LoadAttribution(ctx context.Context, s EventStore, experimentID string, opts *AttributionOptions) (*Attribution, error) {
+	exposures, err := s.ListExposures(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversions, err := s.ListConversions(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(exposures, func(a, b Exposure) int {
+		return a.At.Compare(b.At)
+	})
+	slices.SortFunc(conversions, func(a, b Conversion) int {
+		return a.At.Compare(b.At)
+	})
+
+	a := NewAttribution(opts)
+	var i, j int
+	for i < len(exposures) || j < len(conversions) {
+		if j == len(conversions) || (i < len(exposures) && !exposures[i].At.After(conversions[j].At)) {
+			err = a.Expose(exposures[i])
+			i++
+		} else {
+			err = a.Convert(conversions[j])
+			j++
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+func sortExperiments(exps []*Experiment) []*Experiment {
+	slices.SortFunc(exps, func(a, b *Experiment) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return exps
+}
+
+func sortFlags(flags []*Flag) []*Flag {
+	slices.SortFunc(flags, func(a, b *Flag) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return flags
+}
+
+func sortSegments(segs []*Segment) []*Segment {
+	slices.SortFunc(segs, func(a, b *Segment) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return segs
+}
+
+func sortMetrics(ms []MetricSummary) []MetricSummary {
+	slices.SortFunc(ms, func(a, b MetricSummary) int {
+		return cmp.Compare(a.Variant, b.Variant)
+	})
+
+	return ms
+}
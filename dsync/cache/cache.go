@@ -19,11 +19,21 @@ type Cacheable interface {
 	Store(ctx context.Context, key string, value []byte, ttl time.Duration) error
 }
 
+// Batcher is implemented by caches that can load and store multiple keys in a
+// single round trip.
+type Batcher interface {
+	LoadMany(ctx context.Context, keys ...string) (map[string][]byte, error)
+	StoreMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+}
+
 type Cache struct {
 	client *redis.Client
 }
 
-var _ Cacheable = (*Cache)(nil)
+var (
+	_ Cacheable = (*Cache)(nil)
+	_ Batcher   = (*Cache)(nil)
+)
 
 func New(client *redis.Client) *Cache {
 	return &Cache{
@@ -142,3 +152,44 @@ func (c *Cache) CompareAndSwap(ctx context.Context, key string, old, value []byt
 
 	return true, nil
 }
+
+// LoadMany returns the values for the keys in a single round trip. Keys that
+// do not exist are omitted from the result.
+func (c *Cache) LoadMany(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	if len(keys) == 0 {
+		return make(map[string][]byte), nil
+	}
+
+	vals, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]byte, len(keys))
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		res[keys[i]] = []byte(s)
+	}
+
+	return res, nil
+}
+
+// StoreMany stores the values with the same ttl in a single pipeline.
+func (c *Cache) StoreMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, v := range values {
+			pipe.Set(ctx, k, v, ttl)
+		}
+
+		return nil
+	})
+
+	return err
+}
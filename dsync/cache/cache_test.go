@@ -187,3 +187,21 @@ func newClient(t *testing.T) *redis.Client {
 
 	return client
 }
+
+func TestCacheMany(t *testing.T) {
+	c := cache.New(newClient(t))
+
+	is := assert.New(t)
+	err := c.StoreMany(ctx, map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+	}, time.Second)
+	is.Nil(err)
+
+	vals, err := c.LoadMany(ctx, "a", "b", "c")
+	is.Nil(err)
+	is.Equal(map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+	}, vals)
+}
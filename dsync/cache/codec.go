@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes the values stored in the cache.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(b []byte, v any) error
+}
+
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(b []byte, v any) error {
+	return json.Unmarshal(b, v)
+}
+
+// msgpackCodec sorts the map keys, so that the equal values are encoded to
+// the same bytes, e.g. for CompareAndSwap. Note that msgpack only sorts the
+// keys of map[string]any, map[string]string and map[string]bool.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(b []byte, v any) error {
+	return msgpack.Unmarshal(b, v)
+}
+
+// Gzip compresses the output of the codec with gzip.
+// Values that are not gzip-compressed are passed to the codec as is, so that
+// existing entries can still be read after enabling compression.
+func Gzip(c Codec) Codec {
+	return &gzipCodec{codec: c}
+}
+
+type gzipCodec struct {
+	codec Codec
+}
+
+func (g *gzipCodec) Marshal(v any) ([]byte, error) {
+	b, err := g.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g *gzipCodec) Unmarshal(b []byte, v any) error {
+	// The gzip magic number.
+	if !bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		return g.codec.Unmarshal(b, v)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	b, err = io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return g.codec.Unmarshal(b, v)
+}
+
+// Snappy compresses the output of the codec with snappy, which is faster
+// than gzip at the cost of a lower compression ratio.
+// Values that cannot be decoded by snappy are passed to the codec as is, like
+// Gzip.
+func Snappy(c Codec) Codec {
+	return &snappyCodec{codec: c}
+}
+
+type snappyCodec struct {
+	codec Codec
+}
+
+func (s *snappyCodec) Marshal(v any) ([]byte, error) {
+	b, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return snappy.Encode(nil, b), nil
+}
+
+func (s *snappyCodec) Unmarshal(b []byte, v any) error {
+	// Snappy has no magic number, so the uncompressed values are detected by
+	// the failure to decode.
+	d, err := snappy.Decode(nil, b)
+	if err != nil {
+		return s.codec.Unmarshal(b, v)
+	}
+
+	return s.codec.Unmarshal(d, v)
+}
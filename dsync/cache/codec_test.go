@@ -0,0 +1,94 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	codecs := map[string]cache.Codec{
+		"json":           cache.JSONCodec,
+		"msgpack":        cache.MsgpackCodec,
+		"gzip json":      cache.Gzip(cache.JSONCodec),
+		"snappy msgpack": cache.Snappy(cache.MsgpackCodec),
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewCodec(newClient(t), codec)
+			key := t.Name()
+
+			is := assert.New(t)
+			is.Nil(c.Store(ctx, key, john, time.Second))
+
+			var u *User
+			is.Nil(c.Load(ctx, key, &u))
+			is.Equal(john, u)
+		})
+	}
+}
+
+func TestGzipUncompressed(t *testing.T) {
+	b, err := cache.JSONCodec.Marshal(john)
+
+	is := assert.New(t)
+	is.Nil(err)
+
+	var u *User
+	is.Nil(cache.Gzip(cache.JSONCodec).Unmarshal(b, &u))
+	is.Equal(john, u)
+}
+
+func TestSnappyUncompressed(t *testing.T) {
+	b, err := cache.MsgpackCodec.Marshal(john)
+
+	is := assert.New(t)
+	is.Nil(err)
+
+	var u *User
+	is.Nil(cache.Snappy(cache.MsgpackCodec).Unmarshal(b, &u))
+	is.Equal(john, u)
+}
+
+func TestMsgpackSortMapKeys(t *testing.T) {
+	m := make(map[string]any)
+	for i := range 100 {
+		m[fmt.Sprint(i)] = i
+	}
+
+	is := assert.New(t)
+	want, err := cache.MsgpackCodec.Marshal(m)
+	is.Nil(err)
+	for range 10 {
+		got, err := cache.MsgpackCodec.Marshal(m)
+		is.Nil(err)
+		is.Equal(want, got)
+	}
+}
+
+func TestCodecNotComparable(t *testing.T) {
+	c := cache.NewCodec(newClient(t), codecs{cache.JSONCodec})
+	key := t.Name()
+
+	is := assert.New(t)
+	is.Nil(c.Store(ctx, key, john, time.Second))
+
+	var u *User
+	is.Nil(c.Load(ctx, key, &u))
+	is.Equal(john, u)
+}
+
+// codecs is not comparable.
+type codecs []cache.Codec
+
+func (c codecs) Marshal(v any) ([]byte, error) {
+	return c[0].Marshal(v)
+}
+
+func (c codecs) Unmarshal(b []byte, v any) error {
+	return c[0].Unmarshal(b, v)
+}
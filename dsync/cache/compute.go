@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// computeLockTTL is how long the miss-lock is held after the last
+	// extension. The lock is extended every half of the ttl while the value
+	// is computed, so fn may take longer, and another caller takes over
+	// within the ttl when the computing caller crashed.
+	computeLockTTL = 10 * time.Second
+
+	// computeRetry is how often the waiting callers retry, in case the
+	// notification is lost, e.g. when the computing caller crashed.
+	computeRetry = time.Second
+)
+
+// LoadOrCompute returns the cached value for the key. Otherwise, exactly one
+// caller across the cluster computes the value with fn and stores it for the
+// ttl, while the other callers wait for the value, preventing a cache
+// stampede.
+// The waiting callers are notified with pub/sub when the value is stored, or
+// when fn fails, in which case the next caller computes the value instead.
+// The miss-lock is extended while fn runs, so fn is not computed concurrently
+// however long it takes.
+func (c *Cache) LoadOrCompute(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	v, err := c.Load(ctx, key)
+	if !errors.Is(err, ErrNotExist) {
+		return v, err
+	}
+
+	// Subscribe before acquiring the lock, so that the notification is not
+	// missed.
+	sub := c.client.Subscribe(ctx, computeChannel(key))
+	defer sub.Close()
+
+	// Wait for the subscription to be confirmed.
+	if _, err := sub.Receive(ctx); err != nil {
+		return nil, err
+	}
+	ch := sub.Channel()
+
+	for {
+		v, l, err := c.GetOrLock(ctx, key, computeLockTTL)
+		if err == nil {
+			if l == nil {
+				return v, nil
+			}
+
+			return c.compute(ctx, l, ttl, fn)
+		}
+		if !errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+
+		t := time.NewTimer(computeRetry)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, context.Cause(ctx)
+		case <-ch:
+		case <-t.C:
+		}
+		t.Stop()
+	}
+}
+
+func (c *Cache) compute(ctx context.Context, l *MissLock, ttl time.Duration, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	// Notify the waiting callers to load the value, or to take over.
+	defer c.client.Publish(context.WithoutCancel(ctx), computeChannel(l.Key), "")
+
+	stop := keepAlive(ctx, l, computeLockTTL)
+	v, err := fn(ctx)
+	stop()
+	if err != nil {
+		return nil, errors.Join(err, l.Release(ctx))
+	}
+
+	// The value is still valid when the lock is lost, only it may not be
+	// stored.
+	if err := l.Fill(ctx, v, ttl); err != nil && !errors.Is(err, ErrLockLost) {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// keepAlive extends the lock every half of the ttl, until the returned
+// function is called, or the lock is lost.
+func keepAlive(ctx context.Context, l *MissLock, ttl time.Duration) func() {
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t := time.NewTicker(ttl / 2)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := l.Extend(ctx, ttl); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return sync.OnceFunc(func() {
+		close(done)
+		wg.Wait()
+	})
+}
+
+func computeChannel(key string) string {
+	return key + ":compute"
+}
@@ -0,0 +1,66 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadOrCompute(t *testing.T) {
+	client := newClient(t)
+
+	t.Run("stampede", func(t *testing.T) {
+		key := t.Name()
+
+		var calls atomic.Int64
+		fn := func(ctx context.Context) ([]byte, error) {
+			calls.Add(1)
+			time.Sleep(50 * time.Millisecond)
+
+			return []byte("hello"), nil
+		}
+
+		is := assert.New(t)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				// Each caller is a different instance.
+				v, err := cache.New(client).LoadOrCompute(ctx, key, time.Second, fn)
+				is.Nil(err)
+				is.Equal([]byte("hello"), v)
+			}()
+		}
+		wg.Wait()
+
+		is.Equal(int64(1), calls.Load())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		key := t.Name()
+		c := cache.New(client)
+
+		wantErr := errors.New("want error")
+		is := assert.New(t)
+		_, err := c.LoadOrCompute(ctx, key, time.Second, func(ctx context.Context) ([]byte, error) {
+			return nil, wantErr
+		})
+		is.ErrorIs(err, wantErr)
+
+		// The lock is released for the next caller.
+		v, err := c.LoadOrCompute(ctx, key, time.Second, func(ctx context.Context) ([]byte, error) {
+			return []byte("hello"), nil
+		})
+		is.Nil(err)
+		is.Equal([]byte("hello"), v)
+	})
+}
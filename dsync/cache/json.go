@@ -2,14 +2,18 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
 )
 
+// JSON caches values serialized with the Codec, which defaults to JSON.
 type JSON struct {
 	Cache Cacheable
+	Codec Codec
 }
 
 func NewJSON(client *redis.Client) *JSON {
@@ -18,17 +22,26 @@ func NewJSON(client *redis.Client) *JSON {
 	}
 }
 
+// NewCodec returns a cache that serializes the values with the codec, e.g.
+// Gzip(MsgpackCodec).
+func NewCodec(client *redis.Client, codec Codec) *JSON {
+	return &JSON{
+		Cache: New(client),
+		Codec: codec,
+	}
+}
+
 func (s *JSON) Load(ctx context.Context, key string, v any) error {
-	str, err := s.Cache.Load(ctx, key)
+	b, err := s.Cache.Load(ctx, key)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal([]byte(str), &v)
+	return s.codec().Unmarshal(b, v)
 }
 
 func (s *JSON) Store(ctx context.Context, key string, value any, ttl time.Duration) error {
-	b, err := json.Marshal(value)
+	b, err := s.codec().Marshal(value)
 	if err != nil {
 		return err
 	}
@@ -37,7 +50,7 @@ func (s *JSON) Store(ctx context.Context, key string, value any, ttl time.Durati
 }
 
 func (s *JSON) LoadAndDelete(ctx context.Context, key string, value any) (loaded bool, err error) {
-	str, loaded, err := s.Cache.LoadAndDelete(ctx, key)
+	b, loaded, err := s.Cache.LoadAndDelete(ctx, key)
 	if err != nil {
 		return false, err
 	}
@@ -45,7 +58,7 @@ func (s *JSON) LoadAndDelete(ctx context.Context, key string, value any) (loaded
 		return false, nil
 	}
 
-	err = json.Unmarshal([]byte(str), &value)
+	err = s.codec().Unmarshal(b, value)
 	if err != nil {
 		return false, err
 	}
@@ -54,7 +67,7 @@ func (s *JSON) LoadAndDelete(ctx context.Context, key string, value any) (loaded
 }
 
 func (s *JSON) CompareAndDelete(ctx context.Context, key string, old any) (deleted bool, err error) {
-	b, err := json.Marshal(old)
+	b, err := s.codec().Marshal(old)
 	if err != nil {
 		return false, err
 	}
@@ -63,14 +76,105 @@ func (s *JSON) CompareAndDelete(ctx context.Context, key string, old any) (delet
 }
 
 func (s *JSON) CompareAndSwap(ctx context.Context, key string, old, value any, ttl time.Duration) (swapped bool, err error) {
-	a, err := json.Marshal(old)
+	a, err := s.codec().Marshal(old)
 	if err != nil {
 		return false, err
 	}
-	b, err := json.Marshal(value)
+	b, err := s.codec().Marshal(value)
 	if err != nil {
 		return false, err
 	}
 
 	return s.Cache.CompareAndSwap(ctx, key, a, b, ttl)
 }
+
+// LoadMany loads the keys into v, which must be a pointer to a map keyed by
+// string, e.g. *map[string]User. Keys that do not exist are omitted.
+// The keys are loaded in a single round trip if the cache implements
+// Batcher.
+func (s *JSON) LoadMany(ctx context.Context, v any, keys ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Map || rv.Elem().Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cache: LoadMany expects a pointer to map[string]T, got %T", v)
+	}
+
+	vals, err := s.loadMany(ctx, keys...)
+	if err != nil {
+		return err
+	}
+
+	m := rv.Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMapWithSize(m.Type(), len(vals)))
+	}
+
+	for k, b := range vals {
+		e := reflect.New(m.Type().Elem())
+		if err := s.codec().Unmarshal(b, e.Interface()); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(k).Convert(m.Type().Key()), e.Elem())
+	}
+
+	return nil
+}
+
+// StoreMany stores the entries of values, which must be a map keyed by string,
+// with the same ttl.
+func (s *JSON) StoreMany(ctx context.Context, values any, ttl time.Duration) error {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cache: StoreMany expects a map[string]T, got %T", values)
+	}
+
+	vals := make(map[string][]byte, rv.Len())
+	for it := rv.MapRange(); it.Next(); {
+		b, err := s.codec().Marshal(it.Value().Interface())
+		if err != nil {
+			return err
+		}
+		vals[it.Key().String()] = b
+	}
+
+	if b, ok := s.Cache.(Batcher); ok {
+		return b.StoreMany(ctx, vals, ttl)
+	}
+
+	for k, v := range vals {
+		if err := s.Cache.Store(ctx, k, v, ttl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *JSON) loadMany(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	if b, ok := s.Cache.(Batcher); ok {
+		return b.LoadMany(ctx, keys...)
+	}
+
+	vals := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		v, err := s.Cache.Load(ctx, k)
+		if errors.Is(err, ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		vals[k] = v
+	}
+
+	return vals, nil
+}
+
+// codec returns the Codec, or JSONCodec if nil. cmp.Or is not used, since it
+// panics when the Codec is not comparable.
+func (s *JSON) codec() Codec {
+	if s.Codec == nil {
+		return JSONCodec
+	}
+
+	return s.Codec
+}
@@ -152,3 +152,22 @@ func TestJSON(t *testing.T) {
 		is.Equal(newValue, loaded)
 	})
 }
+
+func TestJSONMany(t *testing.T) {
+	c := cache.NewJSON(newClient(t))
+
+	is := assert.New(t)
+	err := c.StoreMany(ctx, map[string]*User{
+		"john": john,
+		"jane": jane,
+	}, time.Second)
+	is.Nil(err)
+
+	var users map[string]*User
+	err = c.LoadMany(ctx, &users, "john", "jane", "unknown")
+	is.Nil(err)
+	is.Equal(map[string]*User{
+		"john": john,
+		"jane": jane,
+	}, users)
+}
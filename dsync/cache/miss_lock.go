@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLocked is returned when the key is not cached, and another caller
+	// holds the miss-lock.
+	ErrLocked = errors.New("cache: locked")
+
+	// ErrLockLost is returned when the miss-lock expired, or is held by
+	// another caller.
+	ErrLockLost = errors.New("cache: lock lost")
+)
+
+var getOrLock = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- KEYS[2]: The lock key
+	-- ARGV[1]: The lock token
+	-- ARGV[2]: The lock duration in milliseconds
+	local val = redis.call('GET', KEYS[1])
+	if val then
+		return {1, val}
+	end
+
+	if redis.call('SET', KEYS[2], ARGV[1], 'NX', 'PX', ARGV[2]) then
+		return {0}
+	end
+
+	return {2}
+`)
+
+var fill = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- KEYS[2]: The lock key
+	-- ARGV[1]: The lock token
+	-- ARGV[2]: The value
+	-- ARGV[3]: The period in milliseconds
+	if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+		return nil
+	end
+
+	redis.call('DEL', KEYS[2])
+	return redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+`)
+
+var extend = redis.NewScript(`
+	-- KEYS[1]: The lock key
+	-- ARGV[1]: The lock token
+	-- ARGV[2]: The lock duration in milliseconds
+	if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+		return nil
+	end
+
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+`)
+
+// GetOrLock returns the cached value for the key. If the key is not cached,
+// it acquires an exclusive miss-lock for the key instead, which the caller
+// must either Fill or Release.
+// ErrLocked is returned if the miss-lock is held by another caller.
+func (c *Cache) GetOrLock(ctx context.Context, key string, lockTTL time.Duration) ([]byte, *MissLock, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockKey := missLockKey(key)
+	keys := []string{key, lockKey}
+	argv := []any{token, lockTTL.Milliseconds()}
+	res, err := getOrLock.Run(ctx, c.client, keys, argv...).Slice()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch res[0].(int64) {
+	case 1:
+		return []byte(res[1].(string)), nil, nil
+	case 0:
+		return nil, newMissLock(c.client, key, token, lockTTL), nil
+	default:
+		return nil, nil, ErrLocked
+	}
+}
+
+// MissLock is an exclusive lock on a key that is not cached.
+type MissLock struct {
+	Key       string
+	ExpiresAt time.Time
+
+	client *redis.Client
+	token  string
+	done   chan struct{}
+	once   sync.Once
+	timer  *time.Timer
+}
+
+func newMissLock(client *redis.Client, key, token string, ttl time.Duration) *MissLock {
+	l := &MissLock{
+		Key:       key,
+		ExpiresAt: time.Now().Add(ttl),
+		client:    client,
+		token:     token,
+		done:      make(chan struct{}),
+	}
+	l.timer = time.AfterFunc(ttl, l.expire)
+
+	return l
+}
+
+// Done returns a channel that is closed when the lock is filled, released,
+// or expired.
+func (l *MissLock) Done() <-chan struct{} {
+	return l.done
+}
+
+// Fill stores the value and releases the lock. ErrLockLost is returned if
+// the lock expired before the value is stored.
+func (l *MissLock) Fill(ctx context.Context, value []byte, ttl time.Duration) error {
+	defer l.close()
+
+	keys := []string{l.Key, missLockKey(l.Key)}
+	argv := []any{l.token, value, ttl.Milliseconds()}
+	err := fill.Run(ctx, l.client, keys, argv...).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrLockLost
+	}
+
+	return err
+}
+
+// Extend extends the lock by the ttl from now. ErrLockLost is returned if
+// the lock expired, or is filled or released.
+func (l *MissLock) Extend(ctx context.Context, ttl time.Duration) error {
+	select {
+	case <-l.done:
+		return ErrLockLost
+	default:
+	}
+
+	keys := []string{missLockKey(l.Key)}
+	argv := []any{l.token, ttl.Milliseconds()}
+	err := extend.Run(ctx, l.client, keys, argv...).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrLockLost
+	}
+	if err != nil {
+		return err
+	}
+
+	l.ExpiresAt = time.Now().Add(ttl)
+	l.timer.Reset(ttl)
+
+	return nil
+}
+
+// Release releases the lock without storing any value.
+func (l *MissLock) Release(ctx context.Context) error {
+	defer l.close()
+
+	keys := []string{missLockKey(l.Key)}
+	argv := []any{l.token}
+	err := compareAndDelete.Run(ctx, l.client, keys, argv...).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrLockLost
+	}
+
+	return err
+}
+
+func (l *MissLock) close() {
+	l.timer.Stop()
+	l.expire()
+}
+
+func (l *MissLock) expire() {
+	l.once.Do(func() {
+		close(l.done)
+	})
+}
+
+func missLockKey(key string) string {
+	return key + ":lock"
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrLock(t *testing.T) {
+	c := cache.New(newClient(t))
+
+	t.Run("fill", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		v, l, err := c.GetOrLock(ctx, key, time.Second)
+		is.Nil(err)
+		is.Nil(v)
+		is.NotNil(l)
+
+		// Another caller cannot acquire the lock.
+		_, _, err = c.GetOrLock(ctx, key, time.Second)
+		is.ErrorIs(err, cache.ErrLocked)
+
+		is.Nil(l.Fill(ctx, []byte("hello"), time.Second))
+		<-l.Done()
+
+		v, l, err = c.GetOrLock(ctx, key, time.Second)
+		is.Nil(err)
+		is.Nil(l)
+		is.Equal([]byte("hello"), v)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		_, l, err := c.GetOrLock(ctx, key, time.Second)
+		is.Nil(err)
+		is.Nil(l.Release(ctx))
+
+		_, l, err = c.GetOrLock(ctx, key, time.Second)
+		is.Nil(err)
+		is.NotNil(l)
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		_, l, err := c.GetOrLock(ctx, key, 50*time.Millisecond)
+		is.Nil(err)
+		is.Nil(l.Extend(ctx, time.Second))
+
+		// The lock is still held after the initial ttl.
+		time.Sleep(100 * time.Millisecond)
+		_, _, err = c.GetOrLock(ctx, key, time.Second)
+		is.ErrorIs(err, cache.ErrLocked)
+		is.Nil(l.Fill(ctx, []byte("hello"), time.Second))
+
+		is.ErrorIs(l.Extend(ctx, time.Second), cache.ErrLockLost)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		_, l, err := c.GetOrLock(ctx, key, 10*time.Millisecond)
+		is.Nil(err)
+
+		<-l.Done()
+		time.Sleep(10 * time.Millisecond)
+		is.ErrorIs(l.Fill(ctx, []byte("hello"), time.Second), cache.ErrLockLost)
+	})
+}
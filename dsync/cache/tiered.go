@@ -0,0 +1,369 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel is the Redis channel used by Tiered to broadcast the
+// keys that are modified.
+const InvalidationChannel = "cache:invalidate"
+
+// Tiered is a two-tier cache, with a small in-process LRU in front of Redis.
+// Writes and deletes are published to the InvalidationChannel, so that other
+// instances evict their local copies.
+//
+// Since pub/sub delivery is best effort, local entries are also expired after
+// LocalTTL to bound the staleness.
+type Tiered struct {
+	// LocalTTL is the maximum duration a value is kept locally.
+	// Defaults to 1m.
+	LocalTTL time.Duration
+
+	cache  *Cache
+	client *redis.Client
+	id     string
+	local  *lru
+}
+
+var (
+	_ Cacheable = (*Tiered)(nil)
+	_ Batcher   = (*Tiered)(nil)
+)
+
+// NewTiered returns a two-tier cache holding up to localSize entries in
+// memory. The returned function stops listening for invalidations.
+func NewTiered(localSize int, client *redis.Client) (*Tiered, func()) {
+	id, err := newToken()
+	if err != nil {
+		panic(err)
+	}
+
+	t := &Tiered{
+		LocalTTL: time.Minute,
+		cache:    New(client),
+		client:   client,
+		id:       id,
+		local:    newLRU(localSize),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pubsub := client.Subscribe(ctx, InvalidationChannel)
+	// Wait for the subscription to be confirmed, so that the invalidations
+	// published after NewTiered returns are not missed. On error, the
+	// subscription is retried in the background.
+	_, _ = pubsub.Receive(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t.subscribe(ctx, pubsub)
+	}()
+
+	return t, sync.OnceFunc(func() {
+		cancel()
+		pubsub.Close()
+		wg.Wait()
+	})
+}
+
+func (t *Tiered) Load(ctx context.Context, key string) ([]byte, error) {
+	if v, ok := t.local.get(key); ok {
+		return v, nil
+	}
+
+	v, ttl, err := t.load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	t.local.set(key, v, t.localTTL(ttl))
+
+	return v, nil
+}
+
+// load returns the value together with the remaining ttl in Redis, so that
+// the local copy does not outlive the key.
+func (t *Tiered) load(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, ErrNotExist
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []byte(get.Val()), pttl.Val(), nil
+}
+
+func (t *Tiered) Store(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := t.cache.Store(ctx, key, value, ttl); err != nil {
+		t.local.delete(key)
+		return err
+	}
+	t.local.set(key, value, t.localTTL(ttl))
+
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered) LoadOrStore(ctx context.Context, key string, value []byte, ttl time.Duration) (old []byte, loaded bool, err error) {
+	var set *redis.Cmd
+	var pttl *redis.DurationCmd
+	_, err = t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		set = pipe.Do(ctx, "SET", key, value, "NX", "GET", "PX", ttl.Milliseconds())
+		pttl = pipe.PTTL(ctx, key)
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, err
+	}
+	// If the previous value does not exist when GET, then it will be nil.
+	if old, err := set.Text(); err == nil {
+		t.local.set(key, []byte(old), t.localTTL(pttl.Val()))
+		return []byte(old), true, nil
+	}
+
+	t.local.set(key, value, t.localTTL(ttl))
+
+	return value, false, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) LoadAndDelete(ctx context.Context, key string) (value []byte, loaded bool, err error) {
+	t.local.delete(key)
+
+	value, loaded, err = t.cache.LoadAndDelete(ctx, key)
+	if err != nil || !loaded {
+		return value, loaded, err
+	}
+
+	return value, true, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) CompareAndDelete(ctx context.Context, key string, old []byte) (deleted bool, err error) {
+	t.local.delete(key)
+
+	deleted, err = t.cache.CompareAndDelete(ctx, key, old)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+
+	return true, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (swapped bool, err error) {
+	t.local.delete(key)
+
+	swapped, err = t.cache.CompareAndSwap(ctx, key, old, value, ttl)
+	if err != nil || !swapped {
+		return swapped, err
+	}
+
+	return true, t.invalidate(ctx, key)
+}
+
+// LoadMany returns the values from the local cache, and loads the remaining
+// keys with their remaining ttl from Redis in a single round trip.
+func (t *Tiered) LoadMany(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+
+	var misses []string
+	for _, k := range keys {
+		if v, ok := t.local.get(k); ok {
+			res[k] = v
+		} else {
+			misses = append(misses, k)
+		}
+	}
+
+	if len(misses) == 0 {
+		return res, nil
+	}
+
+	var mget *redis.SliceCmd
+	pttls := make([]*redis.DurationCmd, len(misses))
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		mget = pipe.MGet(ctx, misses...)
+		for i, k := range misses {
+			pttls[i] = pipe.PTTL(ctx, k)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range mget.Val() {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		k := misses[i]
+		t.local.set(k, []byte(s), t.localTTL(pttls[i].Val()))
+		res[k] = []byte(s)
+	}
+
+	return res, nil
+}
+
+func (t *Tiered) StoreMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if err := t.cache.StoreMany(ctx, values, ttl); err != nil {
+		for k := range values {
+			t.local.delete(k)
+		}
+
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for k, v := range values {
+		t.local.set(k, v, t.localTTL(ttl))
+		keys = append(keys, k)
+	}
+
+	return t.invalidate(ctx, keys...)
+}
+
+// localTTL caps the ttl to LocalTTL. A ttl of zero, or the PTTL of -1, means
+// the key does not expire in Redis.
+func (t *Tiered) localTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return t.LocalTTL
+	}
+
+	return min(ttl, t.LocalTTL)
+}
+
+// invalidate publishes the keys, prefixed with the instance id so that the
+// instance can ignore its own messages.
+func (t *Tiered) invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 1 {
+		return t.client.Publish(ctx, InvalidationChannel, t.id+":"+keys[0]).Err()
+	}
+
+	_, err := t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			pipe.Publish(ctx, InvalidationChannel, t.id+":"+k)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (t *Tiered) subscribe(ctx context.Context, pubsub *redis.PubSub) {
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			id, key, ok := strings.Cut(msg.Payload, ":")
+			if !ok || id == t.id {
+				continue
+			}
+			t.local.delete(key)
+		}
+	}
+}
+
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  max(size, 1),
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	ent := e.Value.(*lruEntry)
+	if !time.Now().Before(ent.expiresAt) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+
+	return ent.value, true
+}
+
+func (c *lru) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		c.delete(key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ent := &lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	}
+	if e, ok := c.items[key]; ok {
+		e.Value = ent
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(ent)
+	if c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lru) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
@@ -0,0 +1,84 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiered(t *testing.T) {
+	client := newClient(t)
+	a, stopA := cache.NewTiered(10, client)
+	t.Cleanup(stopA)
+	b, stopB := cache.NewTiered(10, client)
+	t.Cleanup(stopB)
+
+	key := t.Name()
+
+	is := assert.New(t)
+	is.Nil(a.Store(ctx, key, []byte("v1"), time.Minute))
+
+	// Populates the local cache of b.
+	v, err := b.Load(ctx, key)
+	is.Nil(err)
+	is.Equal([]byte("v1"), v)
+
+	is.Nil(a.Store(ctx, key, []byte("v2"), time.Minute))
+	is.Eventually(func() bool {
+		v, err := b.Load(ctx, key)
+		return err == nil && string(v) == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	_, loaded, err := a.LoadAndDelete(ctx, key)
+	is.Nil(err)
+	is.True(loaded)
+	is.Eventually(func() bool {
+		_, err := b.Load(ctx, key)
+		return err == cache.ErrNotExist
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTieredLocalTTL(t *testing.T) {
+	client := newClient(t)
+	c, stop := cache.NewTiered(10, client)
+	t.Cleanup(stop)
+
+	// Populates the local cache.
+	tests := map[string]func(key string) ([]byte, error){
+		"load": func(key string) ([]byte, error) {
+			return c.Load(ctx, key)
+		},
+		"load or store": func(key string) ([]byte, error) {
+			v, _, err := c.LoadOrStore(ctx, key, []byte("new"), time.Minute)
+			return v, err
+		},
+		"load many": func(key string) ([]byte, error) {
+			vals, err := c.LoadMany(ctx, key, key+":missing")
+			return vals[key], err
+		},
+	}
+
+	for name, load := range tests {
+		t.Run(name, func(t *testing.T) {
+			key := t.Name()
+
+			is := assert.New(t)
+			is.Nil(client.Set(ctx, key, "v1", 50*time.Millisecond).Err())
+
+			v, err := load(key)
+			is.Nil(err)
+			is.Equal([]byte("v1"), v)
+
+			// The change is not published, so the local copy is only evicted
+			// once the key expires in Redis.
+			is.Nil(client.Set(ctx, key, "v2", time.Minute).Err())
+			time.Sleep(100 * time.Millisecond)
+
+			v, err = c.Load(ctx, key)
+			is.Nil(err)
+			is.Equal([]byte("v2"), v)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Typed is a cache for values of type T, serialized with the codec of the
+// underlying JSON cache.
+type Typed[T any] struct {
+	JSON *JSON
+}
+
+func NewTyped[T any](client *redis.Client) *Typed[T] {
+	return &Typed[T]{
+		JSON: NewJSON(client),
+	}
+}
+
+// NewTypedFrom returns a typed cache backed by the given cache, e.g. Tiered.
+func NewTypedFrom[T any](c Cacheable) *Typed[T] {
+	return &Typed[T]{
+		JSON: &JSON{Cache: c},
+	}
+}
+
+func (t *Typed[T]) Load(ctx context.Context, key string) (v T, err error) {
+	err = t.JSON.Load(ctx, key, &v)
+	return
+}
+
+func (t *Typed[T]) Store(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return t.JSON.Store(ctx, key, value, ttl)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it
+// stores and returns the given value. The loaded result is true if the value
+// was loaded, false if stored.
+func (t *Typed[T]) LoadOrStore(ctx context.Context, key string, value T, ttl time.Duration) (old T, loaded bool, err error) {
+	b, err := t.JSON.codec().Marshal(value)
+	if err != nil {
+		return old, false, err
+	}
+
+	b, loaded, err = t.JSON.Cache.LoadOrStore(ctx, key, b, ttl)
+	if err != nil {
+		return old, false, err
+	}
+	if !loaded {
+		return value, false, nil
+	}
+
+	err = t.JSON.codec().Unmarshal(b, &old)
+	if err != nil {
+		return old, false, err
+	}
+
+	return old, true, nil
+}
+
+func (t *Typed[T]) LoadAndDelete(ctx context.Context, key string) (value T, loaded bool, err error) {
+	loaded, err = t.JSON.LoadAndDelete(ctx, key, &value)
+	return
+}
+
+func (t *Typed[T]) CompareAndDelete(ctx context.Context, key string, old T) (deleted bool, err error) {
+	return t.JSON.CompareAndDelete(ctx, key, old)
+}
+
+func (t *Typed[T]) CompareAndSwap(ctx context.Context, key string, old, value T, ttl time.Duration) (swapped bool, err error) {
+	return t.JSON.CompareAndSwap(ctx, key, old, value, ttl)
+}
+
+// LoadMany returns the values for the keys. Keys that do not exist are
+// omitted.
+func (t *Typed[T]) LoadMany(ctx context.Context, keys ...string) (map[string]T, error) {
+	var m map[string]T
+	if err := t.JSON.LoadMany(ctx, &m, keys...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (t *Typed[T]) StoreMany(ctx context.Context, values map[string]T, ttl time.Duration) error {
+	return t.JSON.StoreMany(ctx, values, ttl)
+}
@@ -0,0 +1,47 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTyped(t *testing.T) {
+	c := cache.NewTyped[User](newClient(t))
+
+	t.Run("empty", func(t *testing.T) {
+		u, err := c.Load(ctx, t.Name())
+
+		is := assert.New(t)
+		is.ErrorIs(err, cache.ErrNotExist)
+		is.Zero(u)
+	})
+
+	t.Run("exist", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		is.Nil(c.Store(ctx, key, *john, time.Second))
+
+		u, err := c.Load(ctx, key)
+		is.Nil(err)
+		is.Equal(*john, u)
+	})
+
+	t.Run("load or store", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		u, loaded, err := c.LoadOrStore(ctx, key, *john, time.Second)
+		is.Nil(err)
+		is.False(loaded)
+		is.Equal(*john, u)
+
+		u, loaded, err = c.LoadOrStore(ctx, key, *jane, time.Second)
+		is.Nil(err)
+		is.True(loaded)
+		is.Equal(*john, u)
+	})
+}
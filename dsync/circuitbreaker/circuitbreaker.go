@@ -90,7 +90,21 @@ type CircuitBreaker struct {
 }
 
 func New(client *redis.Client, channel string) (*CircuitBreaker, func()) {
-	b := &CircuitBreaker{
+	b := newBreaker(client, channel)
+	return b, b.init()
+}
+
+// disabled returns a breaker that runs the functions as is, without
+// subscribing to the status changes.
+func disabled(client *redis.Client, channel string) *CircuitBreaker {
+	b := newBreaker(client, channel)
+	b.status = Disabled
+
+	return b
+}
+
+func newBreaker(client *redis.Client, channel string) *CircuitBreaker {
+	return &CircuitBreaker{
 		BreakDuration:    breakDuration,
 		FailureRatio:     failureRatio,
 		FailureThreshold: failureThreshold,
@@ -118,23 +132,37 @@ func New(client *redis.Client, channel string) (*CircuitBreaker, func()) {
 		client:  client,
 		Counter: rate.NewErrors(samplingDuration),
 	}
-	return b, b.init()
 }
 
+// init subscribes to the status changes published by other instances, so
+// that the decisions are made locally without calling Redis.
 func (b *CircuitBreaker) init() func() {
 	ctx := context.Background()
-	status, _ := b.client.Get(ctx, b.channel).Result()
-	b.transition(NewStatus(status))
 
+	// Subscribe before loading the current status, so that no changes are
+	// missed in between.
 	pubsub := b.client.Subscribe(ctx, b.channel)
+	_, _ = pubsub.Receive(ctx)
+
+	status, _ := b.client.Get(ctx, b.channel).Result()
+	b.transition(NewStatus(status))
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		for msg := range pubsub.Channel() {
-			b.transition(NewStatus(msg.Payload))
+		for msg := range pubsub.ChannelWithSubscriptions() {
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				// The connection was re-established, and the changes published
+				// while disconnected are lost.
+				if msg.Kind == "subscribe" {
+					b.sync(ctx)
+				}
+			case *redis.Message:
+				b.transition(NewStatus(msg.Payload))
+			}
 		}
 	}()
 
@@ -144,6 +172,17 @@ func (b *CircuitBreaker) init() func() {
 	}
 }
 
+// sync loads the status from Redis. The status is only stored in Redis while
+// the circuit is opened, so the local status is kept when it does not exist.
+func (b *CircuitBreaker) sync(ctx context.Context) {
+	status, err := b.client.Get(ctx, b.channel).Result()
+	if err != nil {
+		return
+	}
+
+	b.transition(NewStatus(status))
+}
+
 func (b *CircuitBreaker) Do(ctx context.Context, fn func() error) error {
 	switch status := b.Status(); status {
 	case Open:
@@ -104,6 +104,27 @@ func TestSlowCall(t *testing.T) {
 	is.Equal(circuitbreaker.Open, cb.Status())
 }
 
+func TestLateSubscriber(t *testing.T) {
+	client := newClient(t)
+	cb, stop := circuitbreaker.New(client, t.Name())
+	defer stop()
+
+	for range cb.FailureThreshold {
+		_ = cb.Do(ctx, func() error {
+			return wantErr
+		})
+	}
+
+	is := assert.New(t)
+	is.Equal(circuitbreaker.Open, cb.Status())
+
+	// The instance started after the circuit is opened loads the status from
+	// Redis.
+	cb2, stop2 := circuitbreaker.New(client, t.Name())
+	defer stop2()
+	is.Equal(circuitbreaker.Open, cb2.Status())
+}
+
 func newClient(t *testing.T) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr: redistest.Addr(),
@@ -0,0 +1,222 @@
+package circuitbreaker
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+type Metrics struct {
+	Status      Status
+	Success     float64
+	Failure     float64
+	FailureRate float64
+}
+
+// Metrics returns the status and the error rate of the current sampling
+// window.
+func (b *CircuitBreaker) Metrics() Metrics {
+	r := b.Counter.Rate()
+
+	return Metrics{
+		Status:      b.Status(),
+		Success:     r.Success(),
+		Failure:     r.Failure(),
+		FailureRate: failureRate(r.Success(), r.Failure()),
+	}
+}
+
+// Registry manages the circuit breakers by name, e.g. "payment:charge" or
+// host and route. The breakers are created lazily, and share the Redis
+// client. The name is used as the Redis channel of the breaker.
+type Registry struct {
+	client *redis.Client
+
+	mu       sync.Mutex
+	breakers map[string]*registryEntry
+	configs  []registryConfig
+	stopped  bool
+}
+
+type registryEntry struct {
+	// ready is closed once the breaker is created.
+	ready chan struct{}
+	cb    *CircuitBreaker
+	stop  func()
+}
+
+type registryConfig struct {
+	pattern string
+	fn      func(*CircuitBreaker)
+}
+
+// NewRegistry returns a registry, and a function that stops all the breakers
+// created by the registry.
+func NewRegistry(client *redis.Client) (*Registry, func()) {
+	r := &Registry{
+		client:   client,
+		breakers: make(map[string]*registryEntry),
+	}
+
+	return r, sync.OnceFunc(r.stop)
+}
+
+// Configure registers the function that configures the breakers matching the
+// pattern when they are created. The pattern is either the exact name, or
+// contains "*" that matches any sequence of characters, e.g. "search:*".
+// When multiple patterns match, the most specific one is applied, that is the
+// exact name, or the longest pattern.
+// Configure does not affect the breakers that are already created.
+func (r *Registry) Configure(pattern string, fn func(*CircuitBreaker)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configs = slices.DeleteFunc(r.configs, func(c registryConfig) bool {
+		return c.pattern == pattern
+	})
+	r.configs = append(r.configs, registryConfig{
+		pattern: pattern,
+		fn:      fn,
+	})
+}
+
+// Get returns the breaker with the name, creating it if it does not exist.
+// The breaker is created without holding the lock, since it loads the status
+// from Redis, and the concurrent calls with the same name wait for it.
+// After the registry is stopped, the new breakers are disabled, and run the
+// functions without tracking the failures.
+func (r *Registry) Get(name string) *CircuitBreaker {
+	r.mu.Lock()
+	if e, ok := r.breakers[name]; ok {
+		r.mu.Unlock()
+		<-e.ready
+
+		return e.cb
+	}
+	if r.stopped {
+		r.mu.Unlock()
+
+		return disabled(r.client, name)
+	}
+
+	e := &registryEntry{
+		ready: make(chan struct{}),
+	}
+	r.breakers[name] = e
+	c, ok := r.match(name)
+	r.mu.Unlock()
+
+	e.cb, e.stop = New(r.client, name)
+	if ok {
+		c.fn(e.cb)
+	}
+	close(e.ready)
+
+	return e.cb
+}
+
+// Do runs the function with the breaker with the name.
+func (r *Registry) Do(ctx context.Context, name string, fn func() error) error {
+	return r.Get(name).Do(ctx, fn)
+}
+
+// Status returns the status of the breakers, keyed by name.
+func (r *Registry) Status() map[string]Status {
+	res := make(map[string]Status)
+	for name, cb := range r.list() {
+		res[name] = cb.Status()
+	}
+
+	return res
+}
+
+// Metrics returns the metrics of the breakers, keyed by name.
+func (r *Registry) Metrics() map[string]Metrics {
+	res := make(map[string]Metrics)
+	for name, cb := range r.list() {
+		res[name] = cb.Metrics()
+	}
+
+	return res
+}
+
+func (r *Registry) list() map[string]*CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make(map[string]*CircuitBreaker, len(r.breakers))
+	for name, e := range r.breakers {
+		// Skip the breakers that are still being created.
+		select {
+		case <-e.ready:
+			res[name] = e.cb
+		default:
+		}
+	}
+
+	return res
+}
+
+// match returns the most specific config matching the name.
+// Must be called with the lock held.
+func (r *Registry) match(name string) (registryConfig, bool) {
+	var (
+		best  registryConfig
+		found bool
+	)
+	for _, c := range r.configs {
+		if c.pattern == name {
+			return c, true
+		}
+		if !matchWildcard(c.pattern, name) {
+			continue
+		}
+		if !found || len(c.pattern) > len(best.pattern) {
+			best = c
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+func (r *Registry) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	breakers := maps.Clone(r.breakers)
+	r.mu.Unlock()
+
+	for _, e := range breakers {
+		<-e.ready
+		e.stop()
+	}
+}
+
+// matchWildcard reports whether the name matches the pattern, where "*"
+// matches any sequence of characters.
+func matchWildcard(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == name
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, p)
+		if i == -1 {
+			return false
+		}
+		name = name[i+len(p):]
+	}
+
+	return strings.HasSuffix(name, last)
+}
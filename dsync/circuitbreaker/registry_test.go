@@ -0,0 +1,84 @@
+package circuitbreaker_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alextanhongpin/core/dsync/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r, stop := circuitbreaker.NewRegistry(newClient(t))
+	defer stop()
+
+	r.Configure("*", func(cb *circuitbreaker.CircuitBreaker) {
+		cb.FailureThreshold = 3
+	})
+	r.Configure("search:*", func(cb *circuitbreaker.CircuitBreaker) {
+		cb.FailureThreshold = 1
+	})
+	r.Configure("search:users", func(cb *circuitbreaker.CircuitBreaker) {
+		cb.FailureThreshold = 2
+	})
+
+	is := assert.New(t)
+	is.Equal(1, r.Get("search:orders").FailureThreshold)
+	is.Equal(2, r.Get("search:users").FailureThreshold)
+	is.Equal(3, r.Get("payment:charge").FailureThreshold)
+	is.Same(r.Get("search:orders"), r.Get("search:orders"))
+
+	err := r.Do(ctx, "search:orders", func() error {
+		return wantErr
+	})
+	is.ErrorIs(err, wantErr)
+
+	is.Equal(map[string]circuitbreaker.Status{
+		"search:orders":  circuitbreaker.Open,
+		"search:users":   circuitbreaker.Closed,
+		"payment:charge": circuitbreaker.Closed,
+	}, r.Status())
+
+	m := r.Metrics()
+	is.Len(m, 3)
+	is.Equal(circuitbreaker.Open, m["search:orders"].Status)
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r, stop := circuitbreaker.NewRegistry(newClient(t))
+	defer stop()
+
+	cbs := make([]*circuitbreaker.CircuitBreaker, 10)
+
+	var wg sync.WaitGroup
+	for i := range cbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cbs[i] = r.Get("search:orders")
+		}()
+	}
+	wg.Wait()
+
+	is := assert.New(t)
+	for _, cb := range cbs {
+		is.Same(cbs[0], cb)
+	}
+}
+
+func TestRegistryStopped(t *testing.T) {
+	r, stop := circuitbreaker.NewRegistry(newClient(t))
+	stop()
+
+	cb := r.Get("search:orders")
+
+	is := assert.New(t)
+	is.Equal(circuitbreaker.Disabled, cb.Status())
+
+	err := r.Do(ctx, "search:orders", func() error {
+		return wantErr
+	})
+	is.ErrorIs(err, wantErr)
+	is.Empty(r.Status())
+}
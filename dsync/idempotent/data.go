@@ -1,8 +1,16 @@
 package idempotent
 
+import "time"
+
 type data struct {
 	Request  string `json:"request,omitempty"`
 	Response string `json:"response,omitempty"`
+
+	// Failed is set when the response is a Failure.
+	Failed bool `json:"failed,omitempty"`
+
+	// FirstSeen is the time the request was first made.
+	FirstSeen time.Time `json:"first_seen"`
 }
 
 func makeData(req, res []byte) data {
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
@@ -21,6 +22,12 @@ type Store interface {
 type HandlerOptions struct {
 	LockTTL time.Duration
 	KeepTTL time.Duration
+
+	// FailTTL enables storing the errors of the handler, which are replayed
+	// as a Failure with the error message until the FailTTL expires. The
+	// errors are not stored when zero, and the request can be retried
+	// immediately.
+	FailTTL time.Duration
 }
 
 type Handler[T, V any] struct {
@@ -34,13 +41,25 @@ func NewHandler[T, V any](client *redis.Client, fn func(ctx context.Context, req
 	opts.LockTTL = cmp.Or(opts.LockTTL, lockTTL)
 	opts.KeepTTL = cmp.Or(opts.KeepTTL, keepTTL)
 
+	s := NewRedisStore(client)
+	s.FailTTL = opts.FailTTL
+
 	return &Handler[T, V]{
-		s:    NewRedisStore(client),
+		s:    s,
 		fn:   fn,
 		opts: opts,
 	}
 }
 
+// Result is the response of the handler, with the replay metadata when the
+// response is replayed, e.g. to set the Idempotent-Replayed header.
+type Result[V any] struct {
+	Data V
+
+	// Replay is set when the response is replayed.
+	Replay *Replay
+}
+
 func (h *Handler[T, V]) Handle(ctx context.Context, key string, req T) (res V, shared bool, err error) {
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
@@ -56,11 +75,19 @@ func (h *Handler[T, V]) Handle(ctx context.Context, key string, req T) (res V, s
 
 		res, err := h.fn(ctx, req)
 		if err != nil {
+			if h.opts.FailTTL > 0 {
+				return nil, &Failure{Response: []byte(err.Error()), Err: err}
+			}
+
 			return nil, err
 		}
 
 		return json.Marshal(res)
 	}, reqBytes, h.opts.LockTTL, h.opts.KeepTTL)
+	// The cause of the replayed failure is not stored, only the message.
+	if f := (*Failure)(nil); errors.As(err, &f) && f.Err == nil {
+		err = &Failure{Response: f.Response, Err: errors.New(string(f.Response))}
+	}
 	if err != nil {
 		return
 	}
@@ -68,3 +95,30 @@ func (h *Handler[T, V]) Handle(ctx context.Context, key string, req T) (res V, s
 	err = json.Unmarshal(resBytes, &res)
 	return
 }
+
+// HandleResult is like Handle, but returns the replay metadata of the
+// replayed response. A replayed Failure is returned together with the Result
+// carrying the replay metadata.
+func (h *Handler[T, V]) HandleResult(ctx context.Context, key string, req T) (*Result[V], error) {
+	res, shared, err := h.Handle(ctx, key, req)
+	if err != nil && !(shared && isFailure(err)) {
+		return nil, err
+	}
+
+	r := &Result[V]{Data: res}
+	if !shared {
+		return r, nil
+	}
+
+	if s, ok := h.s.(interface {
+		Replay(ctx context.Context, key string) (*Replay, error)
+	}); ok {
+		var rerr error
+		r.Replay, rerr = s.Replay(ctx, key)
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+
+	return r, err
+}
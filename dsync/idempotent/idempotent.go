@@ -2,6 +2,7 @@
 package idempotent
 
 import (
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -34,6 +35,9 @@ type locker interface {
 }
 
 type RedisStore struct {
+	// FailTTL is how long a Failure is stored. Defaults to the keepTTL.
+	FailTTL time.Duration
+
 	Locker locker
 	client *redis.Client
 	group  *promise.Group[[]byte]
@@ -80,7 +84,12 @@ func (s *RedisStore) loadOrStore(ctx context.Context, key string, req []byte, lo
 
 	// 1)
 	if loaded {
-		return s.parse(req, []byte(v))
+		res, err := s.parse(req, []byte(v))
+		if err == nil || isFailure(err) {
+			err = errors.Join(err, s.replayed(ctx, key))
+		}
+
+		return res, err
 	}
 
 	// 2)
@@ -121,21 +130,36 @@ func (s *RedisStore) runInLock(ctx context.Context, key, token string, fn func(c
 			}
 
 			res, err := d.unwrap()
-			if err != nil {
+			var f *Failure
+			if errors.As(err, &f) {
+				res = f.Response
+			} else if err != nil {
 				return nil, err
 			}
 
-			b, err := json.Marshal(makeData(req, res))
+			dat := makeData(req, res)
+			dat.Failed = f != nil
+			dat.FirstSeen = tokenTime(token)
+			b, err := json.Marshal(dat)
 			if err != nil {
 				return nil, err
 			}
 
+			ttl := keepTTL
+			if f != nil {
+				ttl = cmp.Or(s.FailTTL, keepTTL)
+			}
+
 			// Replace the token with the response.
-			if err := s.Locker.Replace(ctx, key, token, string(b), keepTTL); err != nil {
+			if err := s.Locker.Replace(ctx, key, token, string(b), ttl); err != nil {
 				return nil, err
 			}
 
 			// Return the response.
+			if f != nil {
+				return res, f
+			}
+
 			return []byte(res), nil
 		case <-t.C:
 			// Extend the lock to prevent the token from expiring.
@@ -149,7 +173,7 @@ func (s *RedisStore) runInLock(ctx context.Context, key, token string, fn func(c
 func (s *RedisStore) do(ctx context.Context, key string, fn func(context.Context, []byte) ([]byte, error), req []byte, lockTTL, keepTTL time.Duration) (res []byte, loaded bool, err error) {
 	res, err = s.loadOrStore(ctx, key, req, lockTTL)
 	if !errors.Is(err, errors.ErrUnsupported) {
-		return res, err == nil, err
+		return res, err == nil || isFailure(err), err
 	}
 
 	token := string(res)
@@ -162,7 +186,8 @@ func (s *RedisStore) do(ctx context.Context, key string, fn func(context.Context
 //  1. The value is a UUID, which means the request is in flight.
 //  2. The value is a JSON object, which means the request has been processed.
 //     2.1) The request does not match, return an error.
-//     2.2) The request matches, return the response.
+//     2.2) The request matches, return the response, or the Failure if the
+//     request failed.
 func (s *RedisStore) parse(req, value []byte) ([]byte, error) {
 	// 1)
 	if isPending(value) {
@@ -181,6 +206,10 @@ func (s *RedisStore) parse(req, value []byte) ([]byte, error) {
 	}
 
 	// 2.2)
+	if d.Failed {
+		return []byte(d.Response), &Failure{Response: []byte(d.Response)}
+	}
+
 	return []byte(d.Response), nil
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/alextanhongpin/core/dsync/idempotent"
+	"github.com/alextanhongpin/core/storage/pg/pgtest"
 	"github.com/alextanhongpin/core/storage/redis/redistest"
 )
 
@@ -21,6 +22,9 @@ func TestMain(m *testing.M) {
 	stop := redistest.Init()
 	defer stop()
 
+	stopPG := pgtest.Init()
+	defer stopPG()
+
 	m.Run()
 }
 
@@ -165,3 +169,86 @@ func TestExtendLock(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestFailTTL(t *testing.T) {
+	client := redistest.Client(t)
+
+	invoked := new(atomic.Int64)
+	fn := func(ctx context.Context, req string) (string, error) {
+		invoked.Add(1)
+		return "", errors.New("insufficient balance")
+	}
+
+	h := idempotent.NewHandler(client, fn, &idempotent.HandlerOptions{
+		KeepTTL: time.Hour,
+		FailTTL: time.Minute,
+	})
+
+	is := assert.New(t)
+	_, shared, err := h.Handle(ctx, t.Name(), "hello")
+	is.False(shared)
+	is.ErrorContains(err, "insufficient balance")
+
+	// The failure is replayed.
+	var f *idempotent.Failure
+	_, shared, err = h.Handle(ctx, t.Name(), "hello")
+	is.True(shared)
+	is.ErrorAs(err, &f)
+	is.Equal("insufficient balance", string(f.Response))
+	is.Equal(int64(1), invoked.Load())
+
+	ttl, err := client.PTTL(ctx, t.Name()).Result()
+	is.Nil(err)
+	is.LessOrEqual(ttl, time.Minute)
+}
+
+func TestReplay(t *testing.T) {
+	fn := func(ctx context.Context, req string) (string, error) {
+		return "world", nil
+	}
+
+	client := redistest.Client(t)
+	h := idempotent.NewHandler(client, fn, nil)
+
+	is := assert.New(t)
+	res, err := h.HandleResult(ctx, t.Name(), "hello")
+	is.Nil(err)
+	is.Equal("world", res.Data)
+	is.Nil(res.Replay)
+
+	for i := range 2 {
+		res, err = h.HandleResult(ctx, t.Name(), "hello")
+		is.Nil(err)
+		is.Equal("world", res.Data)
+		is.Equal(int64(i+1), res.Replay.Count)
+		is.WithinDuration(time.Now(), res.Replay.FirstSeen, time.Second)
+		is.False(res.Replay.Failed)
+	}
+
+	replay, err := idempotent.NewRedisStore(client).Replay(ctx, "unknown")
+	is.Nil(err)
+	is.Nil(replay)
+}
+
+func TestReplayFailure(t *testing.T) {
+	fn := func(ctx context.Context, req string) (string, error) {
+		return "", errors.New("insufficient balance")
+	}
+
+	h := idempotent.NewHandler(redistest.Client(t), fn, &idempotent.HandlerOptions{
+		FailTTL: time.Minute,
+	})
+
+	is := assert.New(t)
+	res, err := h.HandleResult(ctx, t.Name(), "hello")
+	is.ErrorContains(err, "insufficient balance")
+	is.Nil(res)
+
+	// The replayed failure is returned with the replay metadata.
+	var f *idempotent.Failure
+	res, err = h.HandleResult(ctx, t.Name(), "hello")
+	is.ErrorAs(err, &f)
+	is.Equal("insufficient balance", string(f.Response))
+	is.Equal(int64(1), res.Replay.Count)
+	is.True(res.Replay.Failed)
+}
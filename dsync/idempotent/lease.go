@@ -0,0 +1,165 @@
+package idempotent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Lease is an exclusive claim on an idempotency key, for requests that
+// complete asynchronously, e.g. via a webhook. The lease can be serialized
+// and completed by another process.
+type Lease struct {
+	Key   string `json:"key"`
+	Token string `json:"token"`
+	// Request is the hash of the request.
+	Request string `json:"request"`
+}
+
+// Begin acquires a lease on the key for the duration of lockTTL.
+// If the request is already completed, the lease is nil and the stored
+// response is returned instead.
+// ErrRequestInFlight is returned if the key is leased, and ErrRequestMismatch
+// if the key was completed with a different request.
+func (s *RedisStore) Begin(ctx context.Context, key string, req []byte, lockTTL time.Duration) (*Lease, []byte, error) {
+	res, err := s.loadOrStore(ctx, key, req, lockTTL)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		return nil, res, err
+	}
+
+	return &Lease{
+		Key:     key,
+		Token:   string(res),
+		Request: hash(req),
+	}, nil, nil
+}
+
+// Extend extends the lease by lockTTL.
+func (s *RedisStore) Extend(ctx context.Context, lease *Lease, lockTTL time.Duration) error {
+	return s.Locker.Extend(ctx, lease.Key, lease.Token, lockTTL)
+}
+
+// Heartbeat extends the lease periodically until stopped, for leases that
+// are processed in the same process. The returned context is canceled with
+// ErrLeaseLost if the lease cannot be extended.
+//
+//	ctx, stop := store.Heartbeat(ctx, lease, lockTTL)
+//	defer stop()
+func (s *RedisStore) Heartbeat(ctx context.Context, lease *Lease, lockTTL time.Duration) (context.Context, func()) {
+	return heartbeat(ctx, lockTTL, func(ctx context.Context) error {
+		return s.Extend(ctx, lease, lockTTL)
+	})
+}
+
+// Complete stores the response for the duration of keepTTL, and releases the
+// lease.
+func (s *RedisStore) Complete(ctx context.Context, lease *Lease, res []byte, keepTTL time.Duration) error {
+	b, err := json.Marshal(data{
+		Request:   lease.Request,
+		Response:  string(res),
+		FirstSeen: tokenTime(lease.Token),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Locker.Replace(ctx, lease.Key, lease.Token, string(b), keepTTL)
+}
+
+// Fail releases the lease, so that the request can be retried. The cause is
+// returned, joined with the error releasing the lease if any:
+//
+//	if err != nil {
+//		return store.Fail(ctx, lease, err)
+//	}
+func (s *RedisStore) Fail(ctx context.Context, lease *Lease, cause error) error {
+	return errors.Join(cause, s.Locker.Unlock(ctx, lease.Key, lease.Token))
+}
+
+// Begin acquires a lease on the key for the duration of lockTTL.
+// If the request is already completed, the lease is nil and the stored
+// response is returned instead.
+// ErrRequestInFlight is returned if the key is leased, and ErrRequestMismatch
+// if the key was completed with a different request.
+func (s *SQLStore) Begin(ctx context.Context, key string, req []byte, lockTTL time.Duration) (*Lease, []byte, error) {
+	token := newToken()
+	acquired, err := s.acquire(ctx, key, token, req, lockTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !acquired {
+		res, err := s.load(ctx, key, req)
+		return nil, res, err
+	}
+
+	return &Lease{
+		Key:     key,
+		Token:   token,
+		Request: hash(req),
+	}, nil, nil
+}
+
+// Extend extends the lease by lockTTL.
+func (s *SQLStore) Extend(ctx context.Context, lease *Lease, lockTTL time.Duration) error {
+	return s.extend(ctx, lease.Key, lease.Token, lockTTL)
+}
+
+// Heartbeat extends the lease periodically until stopped. See
+// RedisStore.Heartbeat.
+func (s *SQLStore) Heartbeat(ctx context.Context, lease *Lease, lockTTL time.Duration) (context.Context, func()) {
+	return heartbeat(ctx, lockTTL, func(ctx context.Context) error {
+		return s.Extend(ctx, lease, lockTTL)
+	})
+}
+
+// Complete stores the response for the duration of keepTTL, and releases the
+// lease.
+func (s *SQLStore) Complete(ctx context.Context, lease *Lease, res []byte, keepTTL time.Duration) error {
+	return s.complete(ctx, lease.Key, lease.Token, res, false, keepTTL)
+}
+
+// Fail releases the lease, so that the request can be retried. The cause is
+// returned, joined with the error releasing the lease if any.
+func (s *SQLStore) Fail(ctx context.Context, lease *Lease, cause error) error {
+	return errors.Join(cause, s.release(ctx, lease.Key, lease.Token))
+}
+
+// heartbeat calls extend at 70% of the lockTTL, the same interval as Do.
+func heartbeat(ctx context.Context, lockTTL time.Duration, extend func(context.Context) error) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		t := time.NewTicker(lockTTL * 7 / 10)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := extend(ctx); err != nil && ctx.Err() == nil {
+					cancel(fmt.Errorf("%w: %w", ErrLeaseLost, err))
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			cancel(nil)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package idempotent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/idempotent"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLease(t *testing.T) {
+	store := idempotent.NewRedisStore(redistest.Client(t))
+	key := t.Name()
+	req := []byte("hello")
+
+	is := assert.New(t)
+
+	lease, res, err := store.Begin(ctx, key, req, time.Minute)
+	is.Nil(err)
+	is.Nil(res)
+	is.NotNil(lease)
+
+	_, _, err = store.Begin(ctx, key, req, time.Minute)
+	is.ErrorIs(err, idempotent.ErrRequestInFlight)
+
+	// Failing releases the lease.
+	cause := errors.New("webhook failed")
+	is.ErrorIs(store.Fail(ctx, lease, cause), cause)
+
+	lease, _, err = store.Begin(ctx, key, req, time.Minute)
+	is.Nil(err)
+	is.Nil(store.Complete(ctx, lease, []byte("world"), time.Hour))
+
+	lease, res, err = store.Begin(ctx, key, req, time.Minute)
+	is.Nil(err)
+	is.Nil(lease)
+	is.Equal([]byte("world"), res)
+
+	_, _, err = store.Begin(ctx, key, []byte("bye"), time.Minute)
+	is.ErrorIs(err, idempotent.ErrRequestMismatch)
+}
+
+func TestLeaseHeartbeat(t *testing.T) {
+	store := idempotent.NewRedisStore(redistest.Client(t))
+	key := t.Name()
+	req := []byte("hello")
+
+	is := assert.New(t)
+
+	status, err := store.Status(ctx, key)
+	is.Nil(err)
+	is.Equal(idempotent.Absent, status.State)
+
+	lease, _, err := store.Begin(ctx, key, req, 100*time.Millisecond)
+	is.Nil(err)
+
+	hbCtx, stop := store.Heartbeat(ctx, lease, 100*time.Millisecond)
+	defer stop()
+
+	// The lease outlives the lockTTL.
+	time.Sleep(200 * time.Millisecond)
+	is.Nil(hbCtx.Err())
+
+	status, err = store.Status(ctx, key)
+	is.Nil(err)
+	is.Equal(idempotent.InFlight, status.State)
+	is.GreaterOrEqual(status.Age, 200*time.Millisecond)
+	is.LessOrEqual(status.ExpiresIn, 100*time.Millisecond)
+
+	stop()
+	is.Nil(store.Complete(ctx, lease, []byte("world"), time.Hour))
+
+	status, err = store.Status(ctx, key)
+	is.Nil(err)
+	is.Equal(idempotent.Completed, status.State)
+	is.NotEmpty(status.Checksum)
+	is.Zero(status.Age)
+}
+
+func TestLeaseHeartbeat_Lost(t *testing.T) {
+	store := idempotent.NewRedisStore(redistest.Client(t))
+	key := t.Name()
+
+	lease, _, err := store.Begin(ctx, key, []byte("hello"), time.Minute)
+	is := assert.New(t)
+	is.Nil(err)
+	is.Nil(store.Fail(ctx, lease, nil))
+
+	hbCtx, stop := store.Heartbeat(ctx, lease, 100*time.Millisecond)
+	defer stop()
+
+	<-hbCtx.Done()
+	is.ErrorIs(context.Cause(hbCtx), idempotent.ErrLeaseLost)
+}
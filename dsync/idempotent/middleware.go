@@ -0,0 +1,162 @@
+package idempotent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	// HeaderKey is the request header carrying the idempotency key.
+	HeaderKey = "Idempotency-Key"
+
+	// HeaderReplayed is set on responses that are replayed from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// Middleware makes the requests with the Idempotency-Key header idempotent.
+// The request is fingerprinted by its method, path and body. Retries with the
+// same key and fingerprint replay the stored status, headers and body, while
+// retries with a different fingerprint, or while the first request is still
+// in flight, fail with 409 Conflict.
+//
+// Client errors (4xx) are stored as a Failure, for the FailTTL of the store.
+// Server errors (5xx) are not stored, so that the request can be retried.
+// Requests without the header are passed through.
+func Middleware(store Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+
+		req := fingerprint(r, body)
+		b, loaded, err := store.Do(r.Context(), key, func(ctx context.Context, _ []byte) ([]byte, error) {
+			r := r.WithContext(ctx)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			rec := newRecorder()
+			next.ServeHTTP(rec, r)
+
+			if rec.Status >= 500 {
+				return nil, &serverError{res: rec.response}
+			}
+
+			b, err := json.Marshal(rec.response)
+			if err != nil {
+				return nil, err
+			}
+			if rec.Status >= 400 {
+				return nil, &Failure{Response: b}
+			}
+
+			return b, nil
+		}, req, lockTTL, keepTTL)
+
+		var se *serverError
+		var f *Failure
+		switch {
+		case errors.As(err, &se):
+			se.res.write(w)
+			return
+		case errors.As(err, &f):
+			b = f.Response
+		case errors.Is(err, ErrRequestMismatch), errors.Is(err, ErrRequestInFlight):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var res response
+		if err := json.Unmarshal(b, &res); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if loaded {
+			w.Header().Set(HeaderReplayed, "true")
+		}
+		res.write(w)
+	})
+}
+
+func fingerprint(r *http.Request, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.Path)
+	b.WriteByte('\n')
+	b.Write(body)
+
+	return b.Bytes()
+}
+
+type response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func (res *response) write(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range res.Header {
+		h[k] = v
+	}
+	w.WriteHeader(res.Status)
+	w.Write(res.Body)
+}
+
+type serverError struct {
+	res response
+}
+
+func (e *serverError) Error() string {
+	return http.StatusText(e.res.Status)
+}
+
+// recorder records the response of the next handler, so that it can be
+// stored and replayed.
+type recorder struct {
+	response
+	wroteHeader bool
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		response: response{
+			Status: http.StatusOK,
+			Header: make(http.Header),
+		},
+	}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.response.Header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.Status = status
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	r.Body = append(r.Body, b...)
+
+	return len(b), nil
+}
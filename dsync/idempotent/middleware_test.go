@@ -0,0 +1,79 @@
+package idempotent_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/idempotent"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	invoked := new(atomic.Int64)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		invoked.Add(1)
+		w.Header().Set("X-Order", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	store := idempotent.NewRedisStore(redistest.Client(t))
+	h := idempotent.Middleware(store, next)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+		r.Header.Set(idempotent.HeaderKey, t.Name())
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	is := assert.New(t)
+
+	w := do("hello")
+	is.Equal(http.StatusCreated, w.Code)
+	is.Equal("1", w.Header().Get("X-Order"))
+	is.Equal("created", w.Body.String())
+	is.Empty(w.Header().Get(idempotent.HeaderReplayed))
+
+	w = do("hello")
+	is.Equal(http.StatusCreated, w.Code)
+	is.Equal("1", w.Header().Get("X-Order"))
+	is.Equal("created", w.Body.String())
+	is.Equal("true", w.Header().Get(idempotent.HeaderReplayed))
+
+	w = do("world")
+	is.Equal(http.StatusConflict, w.Code)
+	is.Equal(int64(1), invoked.Load())
+}
+
+func TestMiddlewareFailure(t *testing.T) {
+	invoked := new(atomic.Int64)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		invoked.Add(1)
+		http.Error(w, "invalid amount", http.StatusUnprocessableEntity)
+	})
+
+	store := idempotent.NewRedisStore(redistest.Client(t))
+	store.FailTTL = time.Minute
+	h := idempotent.Middleware(store, next)
+
+	is := assert.New(t)
+	for _, replayed := range []string{"", "true"} {
+		r := httptest.NewRequest("POST", "/orders", strings.NewReader("hello"))
+		r.Header.Set(idempotent.HeaderKey, t.Name())
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		is.Equal(http.StatusUnprocessableEntity, w.Code)
+		is.Equal("invalid amount\n", w.Body.String())
+		is.Equal(replayed, w.Header().Get(idempotent.HeaderReplayed))
+	}
+	is.Equal(int64(1), invoked.Load())
+}
@@ -0,0 +1,155 @@
+package idempotent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Failure is returned by the function to store the response of a failed
+// request, e.g. a validation error, so that the retries replay the failure
+// instead of executing the request again. The failure is stored for the
+// FailTTL of the store, which is usually shorter than the keepTTL of the
+// successful responses.
+// Other errors are not stored, and the request can be retried immediately.
+type Failure struct {
+	Response []byte
+
+	// Err is the cause, which is not stored.
+	Err error
+}
+
+func (f *Failure) Error() string {
+	if f.Err != nil {
+		return "idempotent: failed: " + f.Err.Error()
+	}
+
+	return "idempotent: failed"
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Replay is the metadata of a completed request, e.g. to audit the duplicate
+// submissions.
+type Replay struct {
+	// FirstSeen is the time the request was first made.
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Count is the number of times the response was replayed.
+	Count int64 `json:"count"`
+
+	// Request is the hash of the request.
+	Request string `json:"request"`
+
+	// Failed is set when the stored response is a Failure.
+	Failed bool `json:"failed,omitempty"`
+}
+
+var replayed = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- KEYS[2]: The replay count key
+	local n = redis.call('INCR', KEYS[2])
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[2], ttl)
+	end
+
+	return n
+`)
+
+// Replay returns the replay metadata of the key, or nil if the request is not
+// completed.
+func (s *RedisStore) Replay(ctx context.Context, key string) (*Replay, error) {
+	var get, count *redis.StringCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		count = pipe.Get(ctx, replaysKey(key))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	v, err := get.Bytes()
+	if errors.Is(err, redis.Nil) || isPending(v) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var d data
+	if err := json.Unmarshal(v, &d); err != nil {
+		return nil, err
+	}
+
+	n, err := count.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	return &Replay{
+		FirstSeen: d.FirstSeen,
+		Count:     n,
+		Request:   d.Request,
+		Failed:    d.Failed,
+	}, nil
+}
+
+// replayed increments the replay count of the key, which expires together
+// with the key.
+func (s *RedisStore) replayed(ctx context.Context, key string) error {
+	return replayed.Run(ctx, s.client, []string{key, replaysKey(key)}).Err()
+}
+
+// Replay returns the replay metadata of the key, or nil if the request is not
+// completed.
+func (s *SQLStore) Replay(ctx context.Context, key string) (*Replay, error) {
+	q := fmt.Sprintf(`
+		SELECT first_seen, replays, request, failed
+		FROM %s
+		WHERE key = $1
+		AND token IS NULL
+		AND expires_at >= now()`, s.Table)
+
+	var r Replay
+	err := s.db.QueryRowContext(ctx, q, key).Scan(&r.FirstSeen, &r.Count, &r.Request, &r.Failed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func replaysKey(key string) string {
+	return key + ":replays"
+}
+
+// tokenTime returns the creation time of the token. The tokens are UUID v7,
+// which are prefixed with the creation time.
+func tokenTime(token string) time.Time {
+	u, err := uuid.Parse(token)
+	if err != nil || u.Version() != 7 {
+		return time.Time{}
+	}
+
+	return time.Unix(u.Time().UnixTime())
+}
+
+// isFailure reports whether the error is a stored failure, which is loaded
+// like a response.
+func isFailure(err error) bool {
+	var f *Failure
+	return errors.As(err, &f)
+}
@@ -0,0 +1,288 @@
+package idempotent
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/promise"
+)
+
+// ErrLeaseLost indicates that the lease on the key expired, and may have been
+// acquired by another request.
+var ErrLeaseLost = errors.New("idempotent: lease lost")
+
+// DefaultTable is the table used by SQLStore.
+const DefaultTable = "idempotent_keys"
+
+// SQLStore is a Store backed by a Postgres table. While the request is
+// processed, the row holds a lease token that is extended periodically. Once
+// completed, the token is cleared and the response is stored with the request
+// hash.
+type SQLStore struct {
+	Table string
+
+	// FailTTL is how long a Failure is stored. Defaults to the keepTTL.
+	FailTTL time.Duration
+
+	db    *sql.DB
+	group *promise.Group[[]byte]
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Table: DefaultTable,
+		db:    db,
+		group: promise.NewGroup[[]byte](),
+	}
+}
+
+// Schema returns the statement to create the table.
+func (s *SQLStore) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	key text PRIMARY KEY,
+	token text,
+	request text NOT NULL,
+	response bytea,
+	failed boolean NOT NULL DEFAULT false,
+	first_seen timestamptz NOT NULL DEFAULT now(),
+	replays bigint NOT NULL DEFAULT 0,
+	expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at);`, s.Table)
+}
+
+// Migrate creates the table if it does not exist.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.Schema())
+	return err
+}
+
+// DeleteExpired deletes the expired rows, and returns the number of rows
+// deleted. Expired rows are otherwise overwritten by the next request with the
+// same key.
+func (s *SQLStore) DeleteExpired(ctx context.Context) (int64, error) {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < now()`, s.Table)
+	res, err := s.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Do executes the provided function idempotently, using the specified key and
+// request.
+func (s *SQLStore) Do(ctx context.Context, key string, fn func(ctx context.Context, req []byte) ([]byte, error), req []byte, lockTTL, keepTTL time.Duration) (res []byte, loaded bool, err error) {
+	b := new(atomic.Bool)
+	b.Store(true)
+	res, err = s.group.DoAndForget(key, func() ([]byte, error) {
+		res, loaded, err := s.do(ctx, key, fn, req, lockTTL, keepTTL)
+		if !loaded {
+			b.Store(loaded)
+		}
+
+		return res, err
+	})
+	loaded = b.Load()
+
+	return
+}
+
+func (s *SQLStore) do(ctx context.Context, key string, fn func(context.Context, []byte) ([]byte, error), req []byte, lockTTL, keepTTL time.Duration) ([]byte, bool, error) {
+	token := newToken()
+	acquired, err := s.acquire(ctx, key, token, req, lockTTL)
+	if err != nil {
+		return nil, false, err
+	}
+	if !acquired {
+		res, err := s.load(ctx, key, req)
+		return res, err == nil || isFailure(err), err
+	}
+
+	res, err := s.runInLease(ctx, key, token, fn, req, lockTTL, keepTTL)
+	return res, false, err
+}
+
+// acquire inserts the row with the lease token, or takes over the row if it
+// has expired.
+func (s *SQLStore) acquire(ctx context.Context, key, token string, req []byte, lockTTL time.Duration) (bool, error) {
+	q := fmt.Sprintf(`
+		INSERT INTO %[1]s (key, token, request, expires_at)
+		VALUES ($1, $2, $3, now() + $4 * interval '1 millisecond')
+		ON CONFLICT (key) DO UPDATE
+		SET token = excluded.token,
+			request = excluded.request,
+			response = NULL,
+			failed = false,
+			first_seen = now(),
+			replays = 0,
+			expires_at = excluded.expires_at
+		WHERE %[1]s.expires_at < now()
+		RETURNING token`, s.Table)
+
+	var t string
+	err := s.db.QueryRowContext(ctx, q, key, token, hash(req), lockTTL.Milliseconds()).Scan(&t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return t == token, nil
+}
+
+// load returns the stored response if the request matches, and increments
+// the replay count.
+func (s *SQLStore) load(ctx context.Context, key string, req []byte) ([]byte, error) {
+	q := fmt.Sprintf(`
+		SELECT token, request, response, failed
+		FROM %s
+		WHERE key = $1
+		AND expires_at >= now()`, s.Table)
+
+	var (
+		token    sql.NullString
+		request  string
+		response []byte
+		failed   bool
+	)
+	err := s.db.QueryRowContext(ctx, q, key).Scan(&token, &request, &response, &failed)
+	// The row expired or was released after the failed insert.
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestInFlight
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Valid {
+		return nil, ErrRequestInFlight
+	}
+
+	if request != hash(req) {
+		return nil, ErrRequestMismatch
+	}
+
+	q = fmt.Sprintf(`UPDATE %s SET replays = replays + 1 WHERE key = $1 AND token IS NULL`, s.Table)
+	if _, err := s.db.ExecContext(ctx, q, key); err != nil {
+		return nil, err
+	}
+
+	if failed {
+		return response, &Failure{Response: response}
+	}
+
+	return response, nil
+}
+
+func (s *SQLStore) runInLease(ctx context.Context, key, token string, fn func(context.Context, []byte) ([]byte, error), req []byte, lockTTL, keepTTL time.Duration) ([]byte, error) {
+	// Releases the lease on failure. context.WithoutCancel ensures that the
+	// release is always called. On success, the token is already cleared, so
+	// this is a no-op.
+	defer s.release(context.WithoutCancel(ctx), key, token)
+
+	ch := make(chan result[[]byte], 1)
+	go func() {
+		res, err := fn(ctx, req)
+		ch <- result[[]byte]{
+			err:  err,
+			data: res,
+		}
+
+		close(ch)
+	}()
+
+	t := time.NewTicker(lockTTL * 7 / 10)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case d := <-ch:
+			res, err := d.unwrap()
+			var f *Failure
+			if errors.As(err, &f) {
+				res = f.Response
+			} else if err != nil {
+				return nil, err
+			}
+
+			ttl := keepTTL
+			if f != nil {
+				ttl = cmp.Or(s.FailTTL, keepTTL)
+			}
+
+			if err := s.complete(ctx, key, token, res, f != nil, ttl); err != nil {
+				return nil, err
+			}
+
+			if f != nil {
+				return res, f
+			}
+
+			return res, nil
+		case <-t.C:
+			if err := s.extend(ctx, key, token, lockTTL); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (s *SQLStore) extend(ctx context.Context, key, token string, lockTTL time.Duration) error {
+	q := fmt.Sprintf(`
+		UPDATE %s
+		SET expires_at = now() + $3 * interval '1 millisecond'
+		WHERE key = $1
+		AND token = $2`, s.Table)
+
+	return s.exec(ctx, q, key, token, lockTTL.Milliseconds())
+}
+
+// complete clears the token and stores the response.
+func (s *SQLStore) complete(ctx context.Context, key, token string, res []byte, failed bool, keepTTL time.Duration) error {
+	q := fmt.Sprintf(`
+		UPDATE %s
+		SET token = NULL,
+			response = $3,
+			failed = $4,
+			expires_at = now() + $5 * interval '1 millisecond'
+		WHERE key = $1
+		AND token = $2`, s.Table)
+
+	return s.exec(ctx, q, key, token, res, failed, keepTTL.Milliseconds())
+}
+
+func (s *SQLStore) release(ctx context.Context, key, token string) error {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE key = $1 AND token = $2`, s.Table)
+	_, err := s.db.ExecContext(ctx, q, key, token)
+	return err
+}
+
+// exec returns ErrLeaseLost if no rows are updated.
+func (s *SQLStore) exec(ctx context.Context, q string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
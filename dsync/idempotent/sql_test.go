@@ -0,0 +1,130 @@
+package idempotent_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/idempotent"
+	"github.com/alextanhongpin/core/storage/pg/pgtest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLStore(t *testing.T) {
+	store, _ := newSQLStore(t)
+
+	fn := func(ctx context.Context, req []byte) ([]byte, error) {
+		return []byte("world"), nil
+	}
+
+	is := assert.New(t)
+	res, shared, err := store.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, time.Hour)
+	is.Nil(err)
+	is.False(shared)
+	is.Equal([]byte("world"), res)
+
+	res, shared, err = store.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, time.Hour)
+	is.Nil(err)
+	is.True(shared)
+	is.Equal([]byte("world"), res)
+
+	replay, err := store.Replay(ctx, t.Name())
+	is.Nil(err)
+	is.Equal(int64(1), replay.Count)
+}
+
+func TestSQLStoreInFlight(t *testing.T) {
+	store, db := newSQLStore(t)
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	fn := func(ctx context.Context, req []byte) ([]byte, error) {
+		close(started)
+		<-done
+
+		return []byte("world"), nil
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := store.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, time.Hour)
+		errCh <- err
+	}()
+	<-started
+
+	// Another process, which does not share the in-process deduplication.
+	other := idempotent.NewSQLStore(db)
+	other.Table = store.Table
+
+	is := assert.New(t)
+	_, _, err := other.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, time.Hour)
+	is.ErrorIs(err, idempotent.ErrRequestInFlight)
+
+	close(done)
+	is.Nil(<-errCh)
+}
+
+func TestSQLStoreMismatch(t *testing.T) {
+	store, _ := newSQLStore(t)
+
+	fn := func(ctx context.Context, req []byte) ([]byte, error) {
+		return []byte("world"), nil
+	}
+
+	is := assert.New(t)
+	_, _, err := store.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, time.Hour)
+	is.Nil(err)
+
+	_, _, err = store.Do(ctx, t.Name(), fn, []byte("bye"), time.Minute, time.Hour)
+	is.ErrorIs(err, idempotent.ErrRequestMismatch)
+}
+
+func TestSQLStoreExpired(t *testing.T) {
+	store, _ := newSQLStore(t)
+
+	invoked := new(atomic.Int64)
+	fn := func(ctx context.Context, req []byte) ([]byte, error) {
+		invoked.Add(1)
+		return []byte("world"), nil
+	}
+
+	is := assert.New(t)
+	_, shared, err := store.Do(ctx, t.Name(), fn, []byte("hello"), time.Minute, 100*time.Millisecond)
+	is.Nil(err)
+	is.False(shared)
+
+	time.Sleep(150 * time.Millisecond)
+
+	// The expired row is taken over by the next request, even if the request
+	// is different.
+	_, shared, err = store.Do(ctx, t.Name(), fn, []byte("bye"), time.Minute, time.Hour)
+	is.Nil(err)
+	is.False(shared)
+	is.Equal(int64(2), invoked.Load())
+
+	n, err := store.DeleteExpired(ctx)
+	is.Nil(err)
+	is.Equal(int64(0), n)
+}
+
+// newSQLStore returns a store with a table of its own, since the rows are
+// not rolled back with pgtest.DB, and now() is fixed within pgtest.Tx.
+func newSQLStore(t *testing.T) (*idempotent.SQLStore, *sql.DB) {
+	t.Helper()
+
+	db := pgtest.DB(t)
+	store := idempotent.NewSQLStore(db)
+	store.Table = fmt.Sprintf("idempotent_%x", uuid.New().ID())
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(fmt.Sprintf("DROP TABLE %s", store.Table))
+	})
+
+	return store, db
+}
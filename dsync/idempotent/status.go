@@ -0,0 +1,130 @@
+package idempotent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+type State string
+
+const (
+	// Absent indicates that the request was never made, or that the lease or
+	// the response expired.
+	Absent State = "absent"
+
+	// InFlight indicates that the request is being processed.
+	InFlight State = "in_flight"
+
+	// Completed indicates that the response is stored.
+	Completed State = "completed"
+)
+
+// Status is the status of an idempotency key. Clients can poll the status to
+// distinguish a request that is still processing from a request that is
+// lost, e.g. when the process holding the lease crashed, which becomes
+// absent once the lease expires.
+type Status struct {
+	State State `json:"state"`
+
+	// Age is the duration since the request started, when in flight.
+	Age time.Duration `json:"age,omitempty"`
+
+	// ExpiresIn is the remaining duration of the lease when in flight, or of
+	// the stored response when completed.
+	ExpiresIn time.Duration `json:"expires_in,omitempty"`
+
+	// Checksum is the hash of the response, when completed.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// Status returns the status of the key.
+func (s *RedisStore) Status(ctx context.Context, key string) (*Status, error) {
+	var (
+		get  *redis.StringCmd
+		pttl *redis.DurationCmd
+	)
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return &Status{State: Absent}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v := []byte(get.Val())
+	if isPending(v) {
+		return &Status{
+			State:     InFlight,
+			Age:       age(string(v)),
+			ExpiresIn: pttl.Val(),
+		}, nil
+	}
+
+	var d data
+	if err := json.Unmarshal(v, &d); err != nil {
+		return nil, err
+	}
+
+	return &Status{
+		State:     Completed,
+		ExpiresIn: pttl.Val(),
+		Checksum:  hash([]byte(d.Response)),
+	}, nil
+}
+
+// Status returns the status of the key.
+func (s *SQLStore) Status(ctx context.Context, key string) (*Status, error) {
+	q := fmt.Sprintf(`
+		SELECT token, response, (extract(epoch FROM expires_at - now()) * 1000)::bigint
+		FROM %s
+		WHERE key = $1
+		AND expires_at >= now()`, s.Table)
+
+	var (
+		token    sql.NullString
+		response []byte
+		ms       int64
+	)
+	err := s.db.QueryRowContext(ctx, q, key).Scan(&token, &response, &ms)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Status{State: Absent}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := time.Duration(ms) * time.Millisecond
+	if token.Valid {
+		return &Status{
+			State:     InFlight,
+			Age:       age(token.String),
+			ExpiresIn: expiresIn,
+		}, nil
+	}
+
+	return &Status{
+		State:     Completed,
+		ExpiresIn: expiresIn,
+		Checksum:  hash(response),
+	}, nil
+}
+
+// age returns the duration since the token was created.
+func age(token string) time.Duration {
+	t := tokenTime(token)
+	if t.IsZero() {
+		return 0
+	}
+
+	return time.Since(t)
+}
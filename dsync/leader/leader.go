@@ -0,0 +1,206 @@
+// Package leader implements leader election with Redis, so that singleton
+// jobs can run safely across replicas.
+package leader
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var renew = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- ARGV[1]: The candidate id
+	-- ARGV[2]: The lease duration in milliseconds
+	if redis.call('GET', KEYS[1]) == ARGV[1] then
+		return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	end
+
+	return 0
+`)
+
+var resign = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- ARGV[1]: The candidate id
+	if redis.call('GET', KEYS[1]) == ARGV[1] then
+		return redis.call('DEL', KEYS[1])
+	end
+
+	return 0
+`)
+
+type Options struct {
+	// TTL is the duration of the lease. The lease is renewed every TTL/3, and
+	// another candidate takes over when the leader fails to renew it.
+	// Defaults to 10s.
+	TTL time.Duration
+
+	// RetryInterval is how often the followers try to acquire the lease.
+	// Defaults to TTL/3.
+	RetryInterval time.Duration
+
+	// OnElected is called when the candidate becomes the leader for the key.
+	// The context is cancelled when the leadership is lost, and the candidate
+	// does not campaign again until OnElected returns.
+	OnElected func(ctx context.Context, key string)
+
+	// OnResigned is called after the candidate is no longer the leader for
+	// the key.
+	OnResigned func(key string)
+}
+
+// Elector campaigns for the leadership of keys.
+type Elector struct {
+	client *redis.Client
+	id     string
+	opts   *Options
+
+	mu      sync.Mutex
+	leading map[string]bool
+}
+
+func New(client *redis.Client, opts *Options) *Elector {
+	opts = cmp.Or(opts, &Options{})
+	opts.TTL = cmp.Or(opts.TTL, 10*time.Second)
+	opts.RetryInterval = cmp.Or(opts.RetryInterval, opts.TTL/3)
+
+	return &Elector{
+		client:  client,
+		id:      newID(),
+		opts:    opts,
+		leading: make(map[string]bool),
+	}
+}
+
+// ID returns the id of the candidate, which is stored as the value of the key
+// when elected.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether the candidate is the leader for the key.
+func (e *Elector) IsLeader(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leading[key]
+}
+
+// Campaign campaigns for the leadership of the key until the context is
+// done. When the leadership is lost, e.g. due to network partition, the
+// candidate campaigns again.
+// When the context is done, the candidate resigns so that another candidate
+// can take over immediately.
+func (e *Elector) Campaign(ctx context.Context, key string) error {
+	t := time.NewTicker(e.opts.RetryInterval)
+	defer t.Stop()
+
+	for {
+		// Redis may be temporarily unavailable, so errors are retried.
+		ok, _ := e.client.SetNX(ctx, key, e.id, e.opts.TTL).Result()
+		if ok {
+			e.lead(ctx, key)
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-t.C:
+		}
+	}
+}
+
+// Leader returns the id of the current leader for the key.
+func Leader(ctx context.Context, client *redis.Client, key string) (string, error) {
+	id, err := client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+
+	return id, err
+}
+
+// lead renews the lease until the context is done, or the lease is lost.
+func (e *Elector) lead(ctx context.Context, key string) {
+	e.setLeading(key, true)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if e.opts.OnElected != nil {
+			e.opts.OnElected(leaderCtx, key)
+		}
+	}()
+
+	t := time.NewTicker(e.opts.TTL / 3)
+	defer t.Stop()
+
+	renewedAt := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-t.C:
+			ok, err := e.renew(ctx, key)
+			if ok {
+				renewedAt = time.Now()
+				continue
+			}
+
+			// Tolerate a transient error, but step down before the lease
+			// expires, so that there is at most one leader.
+			if err == nil || time.Since(renewedAt) >= e.opts.TTL*2/3 {
+				break loop
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	// Resign with a new context, since the campaign context may be done.
+	_ = resign.Run(context.WithoutCancel(ctx), e.client, []string{key}, e.id).Err()
+
+	e.setLeading(key, false)
+	if e.opts.OnResigned != nil {
+		e.opts.OnResigned(key)
+	}
+}
+
+// renew returns false without error if the lease is held by another
+// candidate.
+func (e *Elector) renew(ctx context.Context, key string) (bool, error) {
+	n, err := renew.Run(ctx, e.client, []string{key}, e.id, e.opts.TTL.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (e *Elector) setLeading(key string, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok {
+		e.leading[key] = true
+	} else {
+		delete(e.leading, key)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
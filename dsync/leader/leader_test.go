@@ -0,0 +1,88 @@
+package leader_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/leader"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	stop := redistest.Init()
+	defer stop()
+
+	m.Run()
+}
+
+func TestCampaign(t *testing.T) {
+	client := redistest.Client(t)
+	key := t.Name()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(name string) *leader.Options {
+		return &leader.Options{
+			TTL: 300 * time.Millisecond,
+			OnElected: func(ctx context.Context, key string) {
+				mu.Lock()
+				events = append(events, name+" elected")
+				mu.Unlock()
+				<-ctx.Done()
+			},
+			OnResigned: func(key string) {
+				mu.Lock()
+				events = append(events, name+" resigned")
+				mu.Unlock()
+			},
+		}
+	}
+
+	a := leader.New(client, record("a"))
+	b := leader.New(client, record("b"))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		a.Campaign(ctxA, key)
+	}()
+
+	is := assert.New(t)
+	is.Eventually(func() bool {
+		return a.IsLeader(key)
+	}, time.Second, 10*time.Millisecond)
+
+	go func() {
+		defer wg.Done()
+		b.Campaign(ctxB, key)
+	}()
+
+	// b stays a follower while a renews the lease.
+	time.Sleep(500 * time.Millisecond)
+	is.False(b.IsLeader(key))
+
+	id, err := leader.Leader(context.Background(), client, key)
+	is.Nil(err)
+	is.Equal(a.ID(), id)
+
+	// a resigns, and b takes over.
+	cancelA()
+	is.Eventually(func() bool {
+		return b.IsLeader(key)
+	}, time.Second, 10*time.Millisecond)
+
+	cancelB()
+	wg.Wait()
+
+	is.Equal([]string{"a elected", "a resigned", "b elected", "b resigned"}, events)
+}
@@ -0,0 +1,148 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Hooks are optional observability hooks of the locker, to alert on the lock
+// contention and the failed refreshes.
+type Hooks struct {
+	// OnAcquired is called after the lock is acquired, with the duration
+	// waited for the lock.
+	OnAcquired func(ctx context.Context, key string, wait time.Duration)
+
+	// OnReleased is called after the lock is released.
+	OnReleased func(ctx context.Context, key string)
+
+	// OnExtendFailed is called when the lock cannot be extended, e.g. it
+	// expired and was acquired by another process.
+	OnExtendFailed func(ctx context.Context, key string, err error)
+
+	// OnWaitTimeout is called when the lock is not acquired within the wait
+	// duration.
+	OnWaitTimeout func(ctx context.Context, key string, wait time.Duration)
+
+	// Metrics records the events when set.
+	Metrics *Metrics
+
+	// KeyClass maps the key to the "class" label of the metrics, e.g. the
+	// resource type. The key itself should not be used as the label, since
+	// it has unbounded cardinality. Defaults to "default".
+	KeyClass func(key string) string
+}
+
+func (h *Hooks) class(key string) string {
+	if h.KeyClass == nil {
+		return "default"
+	}
+
+	return h.KeyClass(key)
+}
+
+func (h *Hooks) acquired(ctx context.Context, key string, wait time.Duration) {
+	if h.Metrics != nil {
+		class := h.class(key)
+		h.Metrics.acquired.WithLabelValues(class).Inc()
+		h.Metrics.wait.WithLabelValues(class).Observe(wait.Seconds())
+	}
+	if h.OnAcquired != nil {
+		h.OnAcquired(ctx, key, wait)
+	}
+}
+
+func (h *Hooks) released(ctx context.Context, key string) {
+	if h.Metrics != nil {
+		h.Metrics.released.WithLabelValues(h.class(key)).Inc()
+	}
+	if h.OnReleased != nil {
+		h.OnReleased(ctx, key)
+	}
+}
+
+func (h *Hooks) extendFailed(ctx context.Context, key string, err error) {
+	if h.Metrics != nil {
+		h.Metrics.extendFailed.WithLabelValues(h.class(key)).Inc()
+	}
+	if h.OnExtendFailed != nil {
+		h.OnExtendFailed(ctx, key, err)
+	}
+}
+
+func (h *Hooks) waitTimeout(ctx context.Context, key string, wait time.Duration) {
+	if h.Metrics != nil {
+		class := h.class(key)
+		h.Metrics.waitTimeouts.WithLabelValues(class).Inc()
+		h.Metrics.wait.WithLabelValues(class).Observe(wait.Seconds())
+	}
+	if h.OnWaitTimeout != nil {
+		h.OnWaitTimeout(ctx, key, wait)
+	}
+}
+
+// Metrics is a prometheus.Collector for the lockers. The same Metrics can be
+// shared by multiple lockers.
+//
+//	m := lock.NewMetrics("api")
+//	prometheus.MustRegister(m)
+//	locker.Metrics = m
+type Metrics struct {
+	acquired     *prometheus.CounterVec
+	released     *prometheus.CounterVec
+	extendFailed *prometheus.CounterVec
+	waitTimeouts *prometheus.CounterVec
+	wait         *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+func NewMetrics(namespace string) *Metrics {
+	labels := []string{"class"}
+
+	return &Metrics{
+		acquired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquired_total",
+			Help:      "The number of locks acquired.",
+		}, labels),
+		released: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_released_total",
+			Help:      "The number of locks released.",
+		}, labels),
+		extendFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_extend_failed_total",
+			Help:      "The number of locks that failed to be extended.",
+		}, labels),
+		waitTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_wait_timeouts_total",
+			Help:      "The number of locks not acquired within the wait duration.",
+		}, labels),
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lock_wait_duration_seconds",
+			Help:      "The duration waited for the locks, acquired or not.",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, labels),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.acquired.Describe(ch)
+	m.released.Describe(ch)
+	m.extendFailed.Describe(ch)
+	m.waitTimeouts.Describe(ch)
+	m.wait.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.acquired.Collect(ch)
+	m.released.Collect(ch)
+	m.extendFailed.Collect(ch)
+	m.waitTimeouts.Collect(ch)
+	m.wait.Collect(ch)
+}
@@ -0,0 +1,128 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	redis "github.com/redis/go-redis/v9"
+)
+
+var ErrNotLocked = errors.New("lock: not locked")
+
+// LockInfo is the state of a held lock, e.g. for an admin endpoint to find
+// out who holds a wedged lock.
+type LockInfo struct {
+	Key   string        `json:"key"`
+	Token string        `json:"token"`
+	TTL   time.Duration `json:"ttl"`
+
+	// FencingToken is the last fencing token issued for the key, which is the
+	// fencing token of the holder when the lock is acquired with fencing.
+	FencingToken int64 `json:"fencing_token,omitempty"`
+
+	// AcquiredAt is the time encoded in the token, and is zero when the token
+	// is not generated by the Locker, e.g. when set through LoadOrStore.
+	AcquiredAt time.Time `json:"acquired_at,omitempty"`
+}
+
+// Inspect returns the state of the lock, or ErrNotLocked if the key is not
+// locked.
+func (l *Locker) Inspect(ctx context.Context, key string) (*LockInfo, error) {
+	infos, err := l.inspect(ctx, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, ErrNotLocked
+	}
+
+	return &infos[0], nil
+}
+
+// List returns the state of the locks with keys matching the pattern, e.g.
+// "jobs:*". The fencing token keys are hashes, so they are excluded by the
+// type.
+func (l *Locker) List(ctx context.Context, pattern string) ([]LockInfo, error) {
+	var res []LockInfo
+
+	var cursor uint64
+	for {
+		keys, next, err := l.client.ScanType(ctx, cursor, pattern, 100, "string").Result()
+		if err != nil {
+			return nil, err
+		}
+
+		infos, err := l.inspect(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, infos...)
+
+		cursor = next
+		if cursor == 0 {
+			return res, nil
+		}
+	}
+}
+
+// inspect returns the state of the keys that are locked.
+func (l *Locker) inspect(ctx context.Context, keys []string) ([]LockInfo, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	type cmds struct {
+		token *redis.StringCmd
+		ttl   *redis.DurationCmd
+		fence *redis.StringCmd
+	}
+
+	res := make([]cmds, len(keys))
+	_, err := l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			res[i] = cmds{
+				token: pipe.Get(ctx, key),
+				ttl:   pipe.PTTL(ctx, key),
+				fence: pipe.HGet(ctx, fencingKey(key), "token"),
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	infos := make([]LockInfo, 0, len(keys))
+	for i, key := range keys {
+		token, err := res[i].token.Result()
+		// Unlocked or expired since.
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fence, err := res[i].fence.Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+
+		info := LockInfo{
+			Key:          key,
+			Token:        token,
+			TTL:          res[i].ttl.Val(),
+			FencingToken: fence,
+		}
+		if u, err := uuid.Parse(token); err == nil && u.Version() == 7 {
+			info.AcquiredAt = time.Unix(u.Time().UnixTime())
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
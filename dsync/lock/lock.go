@@ -25,6 +25,8 @@ var (
 // Locker represents a distributed lock implementation using Redis.
 // Works on with a single redis node.
 type Locker struct {
+	Hooks
+
 	client *redis.Client
 }
 
@@ -79,10 +81,12 @@ func (l *Locker) DoTimeout(ctx context.Context, key string, fn func(ctx context.
 	defer cancel()
 
 	// Generate a random uuid as the lock value.
-	_, err := l.TryLock(ctx, key, lockTTL, waitTTL)
+	_, fence, err := l.TryLockFenced(ctx, key, lockTTL, waitTTL)
 	if err != nil {
 		return err
 	}
+	ctx = withFencingToken(ctx, fence)
+
 	ch := make(chan error, 1)
 	go func() {
 		ch <- fn(ctx)
@@ -99,11 +103,12 @@ func (l *Locker) DoTimeout(ctx context.Context, key string, fn func(ctx context.
 // Do locks the given key until the function completes.
 // If the lock cannot be acquired within the given wait, it will error.
 // The lock is released after the function completes.
+// The fencing token of the lock is available through FencingToken(ctx).
 // LockTTL: The duration the lock is held. Renewed every 7/10 of the LockTTL. Set it to at least 5s to ensure the lock has enough time to be renewed.
 // WaitTTL: The duration to wait for the lock to be acquired. If set to 0, it will not wait and will return the error immediately.
 func (l *Locker) Do(ctx context.Context, key string, fn func(ctx context.Context) error, lockTTL, waitTTL time.Duration) error {
 	// Generate a random uuid as the lock value.
-	token, err := l.TryLock(ctx, key, lockTTL, waitTTL)
+	token, fence, err := l.TryLockFenced(ctx, key, lockTTL, waitTTL)
 	if err != nil {
 		return err
 	}
@@ -111,7 +116,7 @@ func (l *Locker) Do(ctx context.Context, key string, fn func(ctx context.Context
 	// To ensure the unlock is called, we avoid using the same context.
 	defer l.Unlock(context.WithoutCancel(ctx), key, token)
 
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := context.WithCancel(withFencingToken(ctx, fence))
 	defer cancel()
 
 	// Create a channel with a buffer of 1 to prevent goroutine leak.
@@ -143,9 +148,35 @@ func (l *Locker) Do(ctx context.Context, key string, fn func(ctx context.Context
 // will wait for the lock to be released.
 // If the wait is less than or equal to 0, it will not wait.
 func (l *Locker) TryLock(ctx context.Context, key string, ttl, wait time.Duration) (string, error) {
+	token, _, err := l.tryLock(ctx, key, ttl, wait, false)
+	return token, err
+}
+
+// TryLockFenced is like TryLock, but also returns the fencing token.
+// See LockFenced.
+func (l *Locker) TryLockFenced(ctx context.Context, key string, ttl, wait time.Duration) (string, int64, error) {
+	return l.tryLock(ctx, key, ttl, wait, true)
+}
+
+func (l *Locker) tryLock(ctx context.Context, key string, ttl, wait time.Duration, fenced bool) (string, int64, error) {
+	start := time.Now()
+	token, fence, err := l.waitLock(ctx, key, ttl, wait, fenced)
+	switch {
+	case err == nil:
+		l.acquired(ctx, key, time.Since(start))
+	case errors.Is(err, ErrLockWaitTimeout):
+		l.waitTimeout(ctx, key, time.Since(start))
+	}
+
+	return token, fence, err
+}
+
+// waitLock acquires the lock, waiting for the lock to be released up to the
+// wait duration.
+func (l *Locker) waitLock(ctx context.Context, key string, ttl, wait time.Duration, fenced bool) (string, int64, error) {
 	nowait := wait <= 0
 	if nowait {
-		return l.Lock(ctx, key, ttl)
+		return l.lock(ctx, key, ttl, fenced)
 	}
 
 	// Fire at the timeout moment before the wait duration.
@@ -165,46 +196,62 @@ func (l *Locker) TryLock(ctx context.Context, key string, ttl, wait time.Duratio
 				continue
 			}
 
-			token, err := l.Lock(ctx, key, ttl)
+			token, fence, err := l.lock(ctx, key, ttl, fenced)
 			if errors.Is(err, ErrLocked) {
 				continue
 			}
 
-			return token, err
+			return token, fence, err
 		case <-ctx.Done():
-			return "", context.Cause(ctx)
+			return "", 0, context.Cause(ctx)
 		case <-timeout:
-			token, err := l.Lock(ctx, key, ttl)
+			token, fence, err := l.lock(ctx, key, ttl, fenced)
 			if errors.Is(err, ErrLocked) {
-				return "", ErrLockWaitTimeout
+				return "", 0, ErrLockWaitTimeout
 			}
 
-			return token, err
+			return token, fence, err
 		case <-time.After(sleep):
-			token, err := l.Lock(ctx, key, ttl)
+			token, fence, err := l.lock(ctx, key, ttl, fenced)
 			if errors.Is(err, ErrLocked) {
 				i++
 				continue
 			}
 
-			return token, err
+			return token, fence, err
 		}
 	}
 }
 
-// Lock the key with the given ttl and returns a fencing token.
+// Lock the key with the given ttl and returns the lock token. The lock never
+// expires when the ttl is 0.
 // If the lock is already acquired, it will return an error.
 func (l *Locker) Lock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	token, _, err := l.lock(ctx, key, ttl, false)
+	if err == nil {
+		l.acquired(ctx, key, 0)
+	}
+
+	return token, err
+}
+
+// lock acquires the lock without waiting. Only the fenced locks increment
+// the fencing token, since the fencing token key never expires.
+func (l *Locker) lock(ctx context.Context, key string, ttl time.Duration, fenced bool) (string, int64, error) {
+	if fenced {
+		return l.lockFenced(ctx, key, ttl)
+	}
+
 	token := newToken()
 	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
 	if err != nil {
-		return "", fmt.Errorf("lock: %w", err)
+		return "", 0, fmt.Errorf("lock: %w", err)
 	}
 	if !ok {
-		return "", ErrLocked
+		return "", 0, ErrLocked
 	}
 
-	return token, nil
+	return token, 0, nil
 }
 
 // Unlocks the key with the given token.
@@ -219,11 +266,21 @@ func (l *Locker) Unlock(ctx context.Context, key, token string) error {
 	if err != nil {
 		return fmt.Errorf("unlock: %w", err)
 	}
+	l.released(ctx, key)
 
 	return l.client.Publish(ctx, key, payload).Err()
 }
 
 func (l *Locker) Extend(ctx context.Context, key, val string, ttl time.Duration) error {
+	err := l.extend(ctx, key, val, ttl)
+	if err != nil {
+		l.extendFailed(ctx, key, err)
+	}
+
+	return err
+}
+
+func (l *Locker) extend(ctx context.Context, key, val string, ttl time.Duration) error {
 	keys := []string{key}
 	argv := []any{val, ttl.Milliseconds()}
 	err := extend.Run(ctx, l.client, keys, argv...).Err()
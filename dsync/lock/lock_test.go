@@ -9,6 +9,7 @@ import (
 
 	"github.com/alextanhongpin/core/dsync/lock"
 	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
@@ -352,3 +353,155 @@ func assertNoKey(t *testing.T, client *redis.Client, key string) {
 	is := assert.New(t)
 	is.ErrorIs(err, redis.Nil, "expected key to be deleted")
 }
+
+func TestLock_FencingToken(t *testing.T) {
+	var (
+		client = redistest.Client(t)
+		is     = assert.New(t)
+		key    = t.Name()
+		locker = lock.New(client)
+	)
+
+	var fences []int64
+	for range 2 {
+		err := locker.Do(ctx, key, func(ctx context.Context) error {
+			fence, ok := lock.FencingToken(ctx)
+			is.True(ok)
+			fences = append(fences, fence)
+
+			return nil
+		}, time.Second, 0)
+		is.Nil(err)
+	}
+	is.Equal([]int64{1, 2}, fences)
+
+	// The fencing token keeps increasing after the lock expires.
+	_, fence, err := locker.LockFenced(ctx, key, 10*time.Millisecond)
+	is.Nil(err)
+	is.Equal(int64(3), fence)
+
+	time.Sleep(20 * time.Millisecond)
+	_, fence, err = locker.TryLockFenced(ctx, key, time.Second, 0)
+	is.Nil(err)
+	is.Equal(int64(4), fence)
+}
+
+func TestLock_Hooks(t *testing.T) {
+	var (
+		client = redistest.Client(t)
+		is     = assert.New(t)
+		key    = t.Name()
+		events []string
+	)
+
+	locker := lock.New(client)
+	locker.Metrics = lock.NewMetrics("test")
+	locker.OnAcquired = func(ctx context.Context, key string, wait time.Duration) {
+		events = append(events, "acquired")
+	}
+	locker.OnReleased = func(ctx context.Context, key string) {
+		events = append(events, "released")
+	}
+	locker.OnExtendFailed = func(ctx context.Context, key string, err error) {
+		is.ErrorIs(err, lock.ErrConflict)
+		events = append(events, "extend failed")
+	}
+	locker.OnWaitTimeout = func(ctx context.Context, key string, wait time.Duration) {
+		is.GreaterOrEqual(wait, 100*time.Millisecond)
+		events = append(events, "wait timeout")
+	}
+
+	token, err := locker.Lock(ctx, key, time.Second)
+	is.Nil(err)
+
+	_, err = locker.TryLock(ctx, key, time.Second, 100*time.Millisecond)
+	is.ErrorIs(err, lock.ErrLockWaitTimeout)
+
+	is.ErrorIs(locker.Extend(ctx, key, "invalid", time.Second), lock.ErrConflict)
+	is.Nil(locker.Unlock(ctx, key, token))
+	is.Equal([]string{
+		"acquired",
+		"wait timeout",
+		"extend failed",
+		"released",
+	}, events)
+
+	// One series for each of the counters and the histogram.
+	is.Equal(5, testutil.CollectAndCount(locker.Metrics))
+}
+
+func TestLock_Inspect(t *testing.T) {
+	var (
+		client = redistest.Client(t)
+		is     = assert.New(t)
+		key    = t.Name()
+		locker = lock.New(client)
+	)
+
+	_, err := locker.Inspect(ctx, key)
+	is.ErrorIs(err, lock.ErrNotLocked)
+
+	token, fence, err := locker.LockFenced(ctx, key+":a", time.Minute)
+	is.Nil(err)
+	_, err = locker.Lock(ctx, key+":b", time.Minute)
+	is.Nil(err)
+
+	info, err := locker.Inspect(ctx, key+":a")
+	is.Nil(err)
+	is.Equal(token, info.Token)
+	is.Equal(fence, info.FencingToken)
+	is.LessOrEqual(info.TTL, time.Minute)
+	is.Greater(info.TTL, time.Duration(0))
+	is.WithinDuration(time.Now(), info.AcquiredAt, time.Second)
+
+	// The locks named like the fencing token keys are listed, but not the
+	// fencing token keys.
+	_, err = locker.Lock(ctx, key+":c:fence", time.Minute)
+	is.Nil(err)
+
+	infos, err := locker.List(ctx, key+":*")
+	is.Nil(err)
+
+	var keys []string
+	for _, info := range infos {
+		keys = append(keys, info.Key)
+	}
+	is.ElementsMatch([]string{key + ":a", key + ":b", key + ":c:fence"}, keys)
+}
+
+func TestLock_NoExpiry(t *testing.T) {
+	var (
+		client = redistest.Client(t)
+		is     = assert.New(t)
+		key    = t.Name()
+		locker = lock.New(client)
+	)
+	t.Cleanup(func() {
+		client.Del(ctx, key, key+":fence")
+	})
+
+	token, err := locker.Lock(ctx, key, 0)
+	is.Nil(err)
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	is.Nil(err)
+	is.Equal(time.Duration(-1), ttl)
+
+	// The plain lock does not create the fencing token key.
+	n, err := client.Exists(ctx, key+":fence").Result()
+	is.Nil(err)
+	is.Equal(int64(0), n)
+
+	_, err = locker.Lock(ctx, key, 0)
+	is.ErrorIs(err, lock.ErrLocked)
+	is.Nil(locker.Unlock(ctx, key, token))
+
+	// The fenced lock never expires either when the ttl is 0.
+	_, fence, err := locker.LockFenced(ctx, key, 0)
+	is.Nil(err)
+	is.Equal(int64(1), fence)
+
+	ttl, err = client.PTTL(ctx, key).Result()
+	is.Nil(err)
+	is.Equal(time.Duration(-1), ttl)
+}
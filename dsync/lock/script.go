@@ -47,3 +47,22 @@ var replace = redis.NewScript(`
 
 	return nil
 `)
+
+// lock acquires the lock, and increments the fencing token of the key.
+var lock = redis.NewScript(`
+	-- KEYS[1]: The lock key
+	-- KEYS[2]: The fencing token hash
+	-- ARGV[1]: The lock value
+	-- ARGV[2]: The lock duration in milliseconds, or 0 to never expire
+	local ok
+	if tonumber(ARGV[2]) > 0 then
+		ok = redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2])
+	else
+		ok = redis.call('SET', KEYS[1], ARGV[1], 'NX')
+	end
+	if ok then
+		return redis.call('HINCRBY', KEYS[2], 'token', 1)
+	end
+
+	return nil
+`)
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLimitExceeded is returned when there are no more permits.
+	ErrLimitExceeded = errors.New("ratelimit: limit exceeded")
+
+	// ErrPermitExpired is returned when the permit expired, and may have been
+	// given to another holder.
+	ErrPermitExpired = errors.New("ratelimit: permit expired")
+)
+
+//go:embed concurrency.lua
+var concurrencyScript string
+
+var concurrency = redis.NewScript(concurrencyScript)
+
+var extendPermit = redis.NewScript(`
+	-- KEYS[1]: The key
+	-- ARGV[1]: The token
+	-- ARGV[2]: The ttl in milliseconds
+	-- ARGV[3]: The current time in milliseconds
+	local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+	if not score or tonumber(score) <= tonumber(ARGV[3]) then
+		return 0
+	end
+
+	redis.call('ZADD', KEYS[1], 'XX', ARGV[3] + ARGV[2], ARGV[1])
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+`)
+
+// Concurrency limits the number of concurrent holders per key, e.g. the
+// number of exports running per tenant.
+// Each permit expires after the ttl, so that crashed holders do not hold the
+// permit forever. Long running holders should Extend the permit.
+type Concurrency struct {
+	Now    func() time.Time
+	client *redis.Client
+	limit  int
+	ttl    int64
+}
+
+func NewConcurrency(client *redis.Client, n int, ttl time.Duration) *Concurrency {
+	return &Concurrency{
+		Now:    time.Now,
+		client: client,
+		limit:  n,
+		ttl:    ttl.Milliseconds(),
+	}
+}
+
+// Acquire acquires a permit for the key, returning the token that must be
+// passed to Release. ErrLimitExceeded is returned if there are no more
+// permits.
+func (c *Concurrency) Acquire(ctx context.Context, key string) (string, error) {
+	token, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	keys := []string{key}
+	argv := []any{
+		c.limit,
+		c.ttl,
+		c.Now().UnixMilli(),
+		token,
+	}
+	ok, err := concurrency.Run(ctx, c.client, keys, argv...).Bool()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrLimitExceeded
+	}
+
+	return token, nil
+}
+
+// Release releases the permit.
+func (c *Concurrency) Release(ctx context.Context, key, token string) error {
+	return c.client.ZRem(ctx, key, token).Err()
+}
+
+// Extend extends the permit by the ttl. ErrPermitExpired is returned if the
+// permit expired.
+func (c *Concurrency) Extend(ctx context.Context, key, token string) error {
+	keys := []string{key}
+	argv := []any{
+		token,
+		c.ttl,
+		c.Now().UnixMilli(),
+	}
+	ok, err := extendPermit.Run(ctx, c.client, keys, argv...).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPermitExpired
+	}
+
+	return nil
+}
+
+// InUse returns the number of permits held for the key.
+func (c *Concurrency) InUse(ctx context.Context, key string) (int, error) {
+	start := "(" + strconv.FormatInt(c.Now().UnixMilli(), 10)
+	n, err := c.client.ZCount(ctx, key, start, "+inf").Result()
+	return int(n), err
+}
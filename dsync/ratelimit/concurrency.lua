@@ -0,0 +1,18 @@
+local key = KEYS[1]
+
+local limit = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local token = ARGV[4]
+
+-- Evict the holders that did not release or extend before expiring.
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+
+local count = redis.call('ZCARD', key)
+if count >= limit then
+	return 0
+end
+
+redis.call('ZADD', key, now + ttl, token)
+redis.call('PEXPIRE', key, ttl)
+return 1
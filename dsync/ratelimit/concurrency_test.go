@@ -0,0 +1,53 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	c := ratelimit.NewConcurrency(newClient(t), 2, time.Second)
+	c.Now = func() time.Time {
+		return now
+	}
+	key := t.Name()
+
+	is := assert.New(t)
+	a, err := c.Acquire(ctx, key)
+	is.Nil(err)
+	b, err := c.Acquire(ctx, key)
+	is.Nil(err)
+
+	_, err = c.Acquire(ctx, key)
+	is.ErrorIs(err, ratelimit.ErrLimitExceeded)
+
+	n, err := c.InUse(ctx, key)
+	is.Nil(err)
+	is.Equal(2, n)
+
+	is.Nil(c.Release(ctx, key, a))
+	_, err = c.Acquire(ctx, key)
+	is.Nil(err)
+
+	// The holder of b extends the permit, while the others expire.
+	now = now.Add(500 * time.Millisecond)
+	is.Nil(c.Extend(ctx, key, b))
+
+	now = now.Add(600 * time.Millisecond)
+	n, err = c.InUse(ctx, key)
+	is.Nil(err)
+	is.Equal(1, n)
+
+	_, err = c.Acquire(ctx, key)
+	is.Nil(err)
+
+	now = now.Add(time.Second)
+	is.ErrorIs(c.Extend(ctx, key, b), ratelimit.ErrPermitExpired)
+}
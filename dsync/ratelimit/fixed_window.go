@@ -16,6 +16,9 @@ var fixedWindow = redis.NewScript(fixedWindowScript)
 
 // FixedWindow implements the Fixed Window algorithm.
 type FixedWindow struct {
+	Hooks
+	// Quotas overrides the limit and period per key when set.
+	Quotas QuotaProvider
 	client *redis.Client
 	limit  int
 	period int64
@@ -30,17 +33,37 @@ func NewFixedWindow(client *redis.Client, limit int, period time.Duration) *Fixe
 }
 
 func (r *FixedWindow) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	res, err := r.AllowDetail(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Allow, nil
+}
+
+// AllowDetail is like AllowN, but returns the remaining requests and the
+// retry after duration.
+func (r *FixedWindow) AllowDetail(ctx context.Context, key string, n int) (*Result, error) {
+	q, err := r.quota(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
 	keys := []string{key}
 	argv := []any{
-		r.limit,
-		r.period,
+		q.Limit,
+		q.Period.Milliseconds(),
 		n,
 	}
-	ok, err := fixedWindow.Run(ctx, r.client, keys, argv...).Int()
+	vals, err := fixedWindow.Run(ctx, r.client, keys, argv...).Int64Slice()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return ok == 1, nil
+
+	res := toResult(vals)
+	r.observe(ctx, "fixed_window", key, res)
+
+	return &res, nil
 }
 
 func (r *FixedWindow) Allow(ctx context.Context, key string) (bool, error) {
@@ -48,15 +71,20 @@ func (r *FixedWindow) Allow(ctx context.Context, key string) (bool, error) {
 }
 
 func (r *FixedWindow) Remaining(ctx context.Context, key string) (int, error) {
+	q, err := r.quota(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
 	n, err := r.client.Get(ctx, key).Int()
 	if errors.Is(err, redis.Nil) {
-		return r.limit, nil
+		return q.Limit, nil
 	}
 	if err != nil {
 		return 0, err
 	}
 
-	return r.limit - n, nil
+	return q.Limit - n, nil
 }
 
 func (r *FixedWindow) ResetAfter(ctx context.Context, key string) (time.Duration, error) {
@@ -74,3 +102,15 @@ func (r *FixedWindow) ResetAfter(ctx context.Context, key string) (time.Duration
 	}
 	return d, err
 }
+
+func (r *FixedWindow) capacity(ctx context.Context, key string) (int, error) {
+	q, err := r.quota(ctx, key)
+	return q.Limit, err
+}
+
+func (r *FixedWindow) quota(ctx context.Context, key string) (Quota, error) {
+	return quota(ctx, r.Quotas, key, Quota{
+		Limit:  r.limit,
+		Period: time.Duration(r.period) * time.Millisecond,
+	})
+}
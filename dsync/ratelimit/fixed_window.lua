@@ -6,9 +6,10 @@ local token = tonumber(ARGV[3])
 
 local count = tonumber(redis.call('GET', key) or 0)
 
+-- Returns {allowed, remaining, retry after in milliseconds}.
 if count + token <= limit then
 	redis.call('SET', key, count + token, 'PX', period)
-	return 1
+	return {1, limit - count - token, 0}
 end
 
-return 0
+return {0, math.max(limit - count, 0), math.max(redis.call('PTTL', key), 0)}
@@ -14,6 +14,9 @@ var gcraScript string
 var gcra = redis.NewScript(gcraScript)
 
 type GCRA struct {
+	Hooks
+	// Quotas overrides the limit, period and burst per key when set.
+	Quotas QuotaProvider
 	Now    func() time.Time
 	burst  int
 	client *redis.Client
@@ -36,10 +39,26 @@ func (g *GCRA) Allow(ctx context.Context, key string) (bool, error) {
 }
 
 func (g *GCRA) AllowN(ctx context.Context, key string, n int) (bool, error) {
-	burst := g.burst
-	limit := g.limit
+	res, err := g.AllowDetail(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Allow, nil
+}
+
+// AllowDetail is like AllowN, but returns the remaining requests and the
+// retry after duration.
+func (g *GCRA) AllowDetail(ctx context.Context, key string, n int) (*Result, error) {
+	q, err := g.quota(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	burst := q.Burst
+	limit := q.Limit
 	now := g.Now()
-	period := g.period
+	period := q.Period.Milliseconds()
 
 	interval := period / int64(limit)
 
@@ -51,10 +70,28 @@ func (g *GCRA) AllowN(ctx context.Context, key string, n int) (bool, error) {
 		period,
 		n,
 	}
-	ok, err := gcra.Run(ctx, g.client, keys, argv...).Int()
+	vals, err := gcra.Run(ctx, g.client, keys, argv...).Int64Slice()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return ok == 1, nil
+	res := toResult(vals)
+	g.observe(ctx, "gcra", key, res)
+
+	return &res, nil
+}
+
+// capacity returns the number of tokens allowed at once when no tokens are
+// used, which is the burst plus one.
+func (g *GCRA) capacity(ctx context.Context, key string) (int, error) {
+	q, err := g.quota(ctx, key)
+	return min(q.Limit, q.Burst+1), err
+}
+
+func (g *GCRA) quota(ctx context.Context, key string) (Quota, error) {
+	return quota(ctx, g.Quotas, key, Quota{
+		Limit:  g.limit,
+		Period: time.Duration(g.period) * time.Millisecond,
+		Burst:  g.burst,
+	})
 }
@@ -10,10 +10,16 @@ local token = tonumber(ARGV[5])
 local ts = tonumber(redis.call('GET', key) or 0)
 ts = math.max(ts, now)
 
+-- Returns {allowed, remaining, retry after in milliseconds}.
 if ts - burst*interval <= now then
 	ts = ts + token*interval
 	redis.call('SET', key, ts, 'PX', period)
-	return 1
+
+	local remaining = 0
+	if now + burst*interval - ts >= 0 then
+		remaining = math.floor((now + burst*interval - ts) / interval) + 1
+	end
+	return {1, remaining, 0}
 end
 
-return 0
+return {0, 0, ts - burst*interval - now}
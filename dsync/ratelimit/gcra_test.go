@@ -2,10 +2,12 @@ package ratelimit_test
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/alextanhongpin/core/dsync/ratelimit"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestGCRA(t *testing.T) {
@@ -275,3 +277,43 @@ func TestGCRABurstTotal(t *testing.T) {
 		t.Fatalf("want %d, got %d", want, got)
 	}
 }
+
+func TestGCRAHooks(t *testing.T) {
+	ctx := context.Background()
+
+	client := newClient(t)
+	rl := ratelimit.NewGCRA(client, 5, time.Second, 0)
+	rl.Metrics = ratelimit.NewMetrics("test")
+
+	var allowed, denied []ratelimit.Result
+	rl.OnAllow = func(ctx context.Context, key string, res ratelimit.Result) {
+		allowed = append(allowed, res)
+	}
+	rl.OnDeny = func(ctx context.Context, key string, res ratelimit.Result) {
+		denied = append(denied, res)
+	}
+
+	key := t.Name()
+	now := time.Now().Truncate(time.Second)
+	for _, p := range []time.Duration{0, 100 * time.Millisecond} {
+		rl.Now = func() time.Time {
+			return now.Add(p)
+		}
+
+		if _, err := rl.Allow(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := []ratelimit.Result{{Allow: true}}; !reflect.DeepEqual(want, allowed) {
+		t.Fatalf("want %v, got %v", want, allowed)
+	}
+
+	if want := []ratelimit.Result{{RetryAfter: 100 * time.Millisecond}}; !reflect.DeepEqual(want, denied) {
+		t.Fatalf("want %v, got %v", want, denied)
+	}
+
+	if n := testutil.CollectAndCount(rl.Metrics); n != 3 {
+		t.Fatalf("want 3 metrics, got %d", n)
+	}
+}
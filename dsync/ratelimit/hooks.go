@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Result struct {
+	Allow bool
+	// Remaining is the number of requests that can still be made.
+	Remaining int
+	// RetryAfter is the duration until the next request is allowed, when
+	// the request is denied.
+	RetryAfter time.Duration
+}
+
+// Hooks are optional observability hooks shared by the limiters.
+type Hooks struct {
+	OnAllow func(ctx context.Context, key string, res Result)
+	OnDeny  func(ctx context.Context, key string, res Result)
+
+	// Metrics records the allowed and denied requests when set.
+	Metrics *Metrics
+
+	// KeyClass maps the key to the "class" label of the metrics, e.g. the
+	// plan of the user. The key itself should not be used as the label,
+	// since it has unbounded cardinality. Defaults to "default".
+	KeyClass func(key string) string
+}
+
+func (h *Hooks) observe(ctx context.Context, limiter, key string, res Result) {
+	if h.Metrics != nil {
+		class := "default"
+		if h.KeyClass != nil {
+			class = h.KeyClass(key)
+		}
+		h.Metrics.observe(limiter, class, res)
+	}
+
+	if res.Allow {
+		if h.OnAllow != nil {
+			h.OnAllow(ctx, key, res)
+		}
+	} else if h.OnDeny != nil {
+		h.OnDeny(ctx, key, res)
+	}
+}
+
+// Metrics is a prometheus.Collector for the limiters. The same Metrics can
+// be shared by multiple limiters.
+//
+//	m := ratelimit.NewMetrics("api")
+//	prometheus.MustRegister(m)
+//	rl.Metrics = m
+type Metrics struct {
+	allowed   *prometheus.CounterVec
+	denied    *prometheus.CounterVec
+	remaining *prometheus.GaugeVec
+}
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+func NewMetrics(namespace string) *Metrics {
+	labels := []string{"limiter", "class"}
+
+	return &Metrics{
+		allowed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_allowed_total",
+			Help:      "The number of allowed requests.",
+		}, labels),
+		denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_denied_total",
+			Help:      "The number of denied requests.",
+		}, labels),
+		remaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_remaining",
+			Help:      "The remaining requests of the last key checked in the class.",
+		}, labels),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.allowed.Describe(ch)
+	m.denied.Describe(ch)
+	m.remaining.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.allowed.Collect(ch)
+	m.denied.Collect(ch)
+	m.remaining.Collect(ch)
+}
+
+func (m *Metrics) observe(limiter, class string, res Result) {
+	if res.Allow {
+		m.allowed.WithLabelValues(limiter, class).Inc()
+	} else {
+		m.denied.WithLabelValues(limiter, class).Inc()
+	}
+	m.remaining.WithLabelValues(limiter, class).Set(float64(res.Remaining))
+}
+
+func toResult(vals []int64) Result {
+	return Result{
+		Allow:      vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+	}
+}
@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"cmp"
+	"context"
+	"sync"
+	"time"
+)
+
+type PrefetchOptions struct {
+	// Batch is the number of tokens leased from Redis at a time. It is capped
+	// at the most tokens the limiter allows at once for the key, e.g. the
+	// limit of FixedWindow, or the burst of GCRA. Defaults to 100.
+	Batch int
+
+	// MaxStale is the duration the leased tokens, or a denial, are served
+	// locally. Unused tokens are discarded after that, so that a process does
+	// not hold on to tokens other processes could use. Defaults to 100ms.
+	MaxStale time.Duration
+
+	Now func() time.Time
+}
+
+// Prefetch leases tokens from the limiter in batches, and serves the requests
+// locally until the tokens are exhausted, saving a round trip per request.
+//
+// Since the tokens are consumed when they are leased, the leased but unused
+// tokens are lost when they go stale, so the limit can be reached earlier
+// under low traffic across many processes.
+//
+// The limit may still be exceeded with GCRA, which allows a request as long
+// as a single token is available, by up to the burst, or with the limiters
+// outside this package, where the batch is not capped.
+type Prefetch struct {
+	rl   RateLimiter
+	opts *PrefetchOptions
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweepAt int
+}
+
+var _ RateLimiter = (*Prefetch)(nil)
+
+func NewPrefetch(rl RateLimiter, opts *PrefetchOptions) *Prefetch {
+	opts = cmp.Or(opts, &PrefetchOptions{})
+	opts.Batch = cmp.Or(opts.Batch, 100)
+	opts.MaxStale = cmp.Or(opts.MaxStale, 100*time.Millisecond)
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &Prefetch{
+		rl:      rl,
+		opts:    opts,
+		buckets: make(map[string]*bucket),
+		sweepAt: 1024,
+	}
+}
+
+func (p *Prefetch) Allow(ctx context.Context, key string) (bool, error) {
+	return p.AllowN(ctx, key, 1)
+}
+
+func (p *Prefetch) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	res, err := p.AllowDetail(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Allow, nil
+}
+
+// AllowDetail is like AllowN, but returns the tokens remaining locally and the
+// retry after duration.
+// Requests for more than a batch of tokens are passed to the limiter.
+func (p *Prefetch) AllowDetail(ctx context.Context, key string, n int) (*Result, error) {
+	if n > p.opts.Batch {
+		return p.rl.AllowDetail(ctx, key, n)
+	}
+
+	b := p.bucket(key)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := p.opts.Now()
+	if now.After(b.expiresAt) {
+		if err := p.lease(ctx, key, b, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.denied {
+		return &Result{
+			RetryAfter: b.retryAt.Sub(now),
+		}, nil
+	}
+
+	if b.tokens < n {
+		// Discard the remaining tokens, since the request cannot be served
+		// partially.
+		if err := p.lease(ctx, key, b, now); err != nil {
+			return nil, err
+		}
+		if b.denied || b.tokens < n {
+			return &Result{
+				Remaining:  b.tokens,
+				RetryAfter: max(b.retryAt.Sub(now), 0),
+			}, nil
+		}
+	}
+
+	b.tokens -= n
+
+	return &Result{
+		Allow:     true,
+		Remaining: b.tokens,
+	}, nil
+}
+
+func (p *Prefetch) bucket(key string) *bucket {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b, ok := p.buckets[key]
+	if !ok {
+		if len(p.buckets) >= p.sweepAt {
+			p.sweep()
+		}
+		b = new(bucket)
+		p.buckets[key] = b
+	}
+
+	return b
+}
+
+// sweep removes the stale buckets, so that the buckets do not grow unbounded
+// with the number of keys. A caller may still hold a removed bucket, which
+// at worst leases an extra batch, since the limit is enforced by Redis.
+// Must be called with the lock held.
+func (p *Prefetch) sweep() {
+	now := p.opts.Now()
+	for k, b := range p.buckets {
+		// Skip the buckets in use.
+		if !b.mu.TryLock() {
+			continue
+		}
+		if now.After(b.expiresAt) {
+			delete(p.buckets, k)
+		}
+		b.mu.Unlock()
+	}
+
+	p.sweepAt = max(2*len(p.buckets), 1024)
+}
+
+// lease leases a batch of tokens, or the remaining tokens if there are less
+// than a batch.
+// Must be called with the bucket lock held.
+func (p *Prefetch) lease(ctx context.Context, key string, b *bucket, now time.Time) error {
+	n, err := p.batch(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.rl.AllowDetail(ctx, key, n)
+	if err != nil {
+		return err
+	}
+
+	if !res.Allow && res.Remaining > 0 {
+		n = res.Remaining
+		res, err = p.rl.AllowDetail(ctx, key, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.expiresAt = now.Add(p.opts.MaxStale)
+	if !res.Allow {
+		b.denied = true
+		b.tokens = 0
+		b.retryAt = now.Add(res.RetryAfter)
+		// Do not cache the denial for longer than necessary.
+		if b.retryAt.Before(b.expiresAt) {
+			b.expiresAt = b.retryAt
+		}
+
+		return nil
+	}
+
+	b.denied = false
+	b.tokens = n
+	b.retryAt = time.Time{}
+
+	return nil
+}
+
+// capacity is implemented by the limiters in this package, and returns the
+// most tokens that are allowed at once for the key.
+type capacity interface {
+	capacity(ctx context.Context, key string) (int, error)
+}
+
+// batch returns the batch size for the key.
+func (p *Prefetch) batch(ctx context.Context, key string) (int, error) {
+	c, ok := p.rl.(capacity)
+	if !ok {
+		return p.opts.Batch, nil
+	}
+
+	n, err := c.capacity(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(min(p.opts.Batch, n), 1), nil
+}
+
+type bucket struct {
+	mu        sync.Mutex
+	tokens    int
+	denied    bool
+	retryAt   time.Time
+	expiresAt time.Time
+}
@@ -0,0 +1,90 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	client := newClient(t)
+	rl := ratelimit.NewFixedWindow(client, 25, time.Second)
+	p := ratelimit.NewPrefetch(rl, &ratelimit.PrefetchOptions{
+		Batch:    10,
+		MaxStale: time.Second,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	key := t.Name()
+
+	is := assert.New(t)
+
+	var count int
+	for range 30 {
+		allow, err := p.Allow(ctx, key)
+		is.Nil(err)
+		if allow {
+			count++
+		}
+	}
+	// Two batches of 10, and the remaining 5.
+	is.Equal(25, count)
+
+	remaining, err := rl.Remaining(ctx, key)
+	is.Nil(err)
+	is.Equal(0, remaining)
+}
+
+func TestPrefetchBatchAboveLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	client := newClient(t)
+
+	tests := []struct {
+		name string
+		rl   ratelimit.RateLimiter
+		want int
+	}{
+		{"fixed window", ratelimit.NewFixedWindow(client, 5, time.Second), 5},
+		{"sliding window log", ratelimit.NewSlidingWindowLog(client, 5, time.Second), 5},
+		{"gcra", ratelimit.NewGCRA(client, 5, time.Second, 2), 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if g, ok := tc.rl.(*ratelimit.GCRA); ok {
+				g.Now = func() time.Time {
+					return now
+				}
+			}
+
+			p := ratelimit.NewPrefetch(tc.rl, &ratelimit.PrefetchOptions{
+				Batch:    10,
+				MaxStale: time.Second,
+				Now: func() time.Time {
+					return now
+				},
+			})
+			key := t.Name()
+
+			is := assert.New(t)
+
+			var count int
+			for range 20 {
+				allow, err := p.Allow(ctx, key)
+				is.Nil(err)
+				if allow {
+					count++
+				}
+			}
+			is.Equal(tc.want, count)
+		})
+	}
+}
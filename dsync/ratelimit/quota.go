@@ -0,0 +1,278 @@
+package ratelimit
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidQuota is returned when the limit or the period of the quota is
+// not positive.
+var ErrInvalidQuota = errors.New("ratelimit: invalid quota")
+
+// Quota is the limit of a key.
+type Quota struct {
+	Limit  int           `json:"limit"`
+	Period time.Duration `json:"period"`
+	// Burst is only used by GCRA.
+	Burst int `json:"burst,omitempty"`
+}
+
+func (q Quota) MarshalJSON() ([]byte, error) {
+	type alias Quota
+
+	if err := q.valid(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		alias
+		Period string `json:"period"`
+	}{
+		alias:  alias(q),
+		Period: q.Period.String(),
+	})
+}
+
+func (q *Quota) UnmarshalJSON(b []byte) error {
+	type alias Quota
+
+	var v struct {
+		alias
+		Period string `json:"period"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	period, err := time.ParseDuration(v.Period)
+	if err != nil {
+		return err
+	}
+
+	*q = Quota(v.alias)
+	q.Period = period
+
+	return q.valid()
+}
+
+func (q Quota) valid() error {
+	if q.Limit <= 0 || q.Period <= 0 {
+		return fmt.Errorf("%w: limit %d, period %s", ErrInvalidQuota, q.Limit, q.Period)
+	}
+
+	return nil
+}
+
+// QuotaProvider returns the quota of the key, so that the limit can vary per
+// key, e.g. premium API keys have higher limits, without a limiter per tier.
+// A nil quota means the default limit of the limiter.
+type QuotaProvider interface {
+	Quota(ctx context.Context, key string) (*Quota, error)
+}
+
+// quota returns the quota of the key, or the default.
+func quota(ctx context.Context, p QuotaProvider, key string, def Quota) (Quota, error) {
+	if p == nil {
+		return def, nil
+	}
+
+	q, err := p.Quota(ctx, key)
+	if err != nil || q == nil {
+		return def, err
+	}
+	if err := q.valid(); err != nil {
+		return def, err
+	}
+
+	return *q, nil
+}
+
+type RedisQuotasOptions struct {
+	// KeyClass maps the key to the field of the hash, e.g. the plan of the
+	// API key. Defaults to the key itself, in which case the cache holds an
+	// entry per key until it expires.
+	KeyClass func(key string) string
+
+	// TTL is the duration the quotas are cached locally. Defaults to 10s.
+	TTL time.Duration
+
+	Now func() time.Time
+}
+
+// RedisQuotas loads the quotas from a Redis hash, with the class of the key
+// as the field, and the quota as JSON:
+//
+//	HSET quotas premium '{"limit":1000,"period":"1m0s"}'
+//
+// The quotas are cached locally for the TTL, including the missing ones.
+// Changes made through Set and Delete are published, and invalidate the
+// cache of the processes running Watch.
+type RedisQuotas struct {
+	client *redis.Client
+	key    string
+	opts   *RedisQuotasOptions
+
+	mu      sync.Mutex
+	cache   map[string]cachedQuota
+	pruneAt time.Time
+}
+
+type cachedQuota struct {
+	quota     *Quota
+	expiresAt time.Time
+}
+
+var _ QuotaProvider = (*RedisQuotas)(nil)
+
+func NewRedisQuotas(client *redis.Client, key string, opts *RedisQuotasOptions) *RedisQuotas {
+	opts = cmp.Or(opts, &RedisQuotasOptions{})
+	opts.TTL = cmp.Or(opts.TTL, 10*time.Second)
+	if opts.KeyClass == nil {
+		opts.KeyClass = func(key string) string {
+			return key
+		}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &RedisQuotas{
+		client: client,
+		key:    key,
+		opts:   opts,
+		cache:  make(map[string]cachedQuota),
+	}
+}
+
+func (r *RedisQuotas) Quota(ctx context.Context, key string) (*Quota, error) {
+	class := r.opts.KeyClass(key)
+	now := r.opts.Now()
+
+	r.mu.Lock()
+	c, ok := r.cache[class]
+	r.mu.Unlock()
+	if ok && now.Before(c.expiresAt) {
+		return c.quota, nil
+	}
+
+	var q *Quota
+	b, err := r.client.HGet(ctx, r.key, class).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+	case err != nil:
+		return nil, err
+	default:
+		q = new(Quota)
+		if err := json.Unmarshal(b, q); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	r.prune(now)
+	r.cache[class] = cachedQuota{
+		quota:     q,
+		expiresAt: now.Add(r.opts.TTL),
+	}
+	r.mu.Unlock()
+
+	return q, nil
+}
+
+// prune removes the expired quotas at most once per TTL, so that the cache
+// does not grow with the keys that are no longer seen.
+func (r *RedisQuotas) prune(now time.Time) {
+	if now.Before(r.pruneAt) {
+		return
+	}
+	r.pruneAt = now.Add(r.opts.TTL)
+
+	for class, c := range r.cache {
+		if !now.Before(c.expiresAt) {
+			delete(r.cache, class)
+		}
+	}
+}
+
+// Len returns the number of the cached quotas.
+func (r *RedisQuotas) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.cache)
+}
+
+// Set sets the quota of the class.
+func (r *RedisQuotas) Set(ctx context.Context, class string, q Quota) error {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.HSet(ctx, r.key, class, b).Err(); err != nil {
+		return err
+	}
+
+	return r.publish(ctx, class)
+}
+
+// Delete deletes the quota of the class, so that the default limit applies.
+func (r *RedisQuotas) Delete(ctx context.Context, class string) error {
+	if err := r.client.HDel(ctx, r.key, class).Err(); err != nil {
+		return err
+	}
+
+	return r.publish(ctx, class)
+}
+
+// Invalidate removes the cached quota of the class.
+func (r *RedisQuotas) Invalidate(class string) {
+	r.mu.Lock()
+	delete(r.cache, class)
+	r.mu.Unlock()
+}
+
+// Watch invalidates the cached quotas when they are changed by any process,
+// until the context is canceled.
+//
+//	go quotas.Watch(ctx)
+func (r *RedisQuotas) Watch(ctx context.Context) error {
+	pubsub := r.client.Subscribe(ctx, r.channel())
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			r.Invalidate(msg.Payload)
+		}
+	}
+}
+
+func (r *RedisQuotas) publish(ctx context.Context, class string) error {
+	r.Invalidate(class)
+
+	return r.client.Publish(ctx, r.channel(), class).Err()
+}
+
+func (r *RedisQuotas) channel() string {
+	return r.key + ":invalidate"
+}
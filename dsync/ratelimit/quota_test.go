@@ -0,0 +1,121 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisQuotas(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	newQuotas := func() *ratelimit.RedisQuotas {
+		return ratelimit.NewRedisQuotas(client, "quotas", &ratelimit.RedisQuotasOptions{
+			KeyClass: func(key string) string {
+				plan, _, _ := strings.Cut(key, ":")
+				return plan
+			},
+			TTL: time.Hour,
+		})
+	}
+
+	// The writer and the reader are in different processes.
+	writer, reader := newQuotas(), newQuotas()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go reader.Watch(ctx)
+
+	is := assert.New(t)
+	is.Nil(writer.Set(ctx, "premium", ratelimit.Quota{Limit: 3, Period: time.Minute}))
+
+	rl := ratelimit.NewFixedWindow(client, 1, time.Minute)
+	rl.Quotas = reader
+
+	count := func(key string) int {
+		var n int
+		for range 5 {
+			ok, err := rl.Allow(ctx, key)
+			is.Nil(err)
+			if ok {
+				n++
+			}
+		}
+
+		return n
+	}
+	is.Equal(1, count("free:a"), "default limit")
+	is.Equal(3, count("premium:a"))
+
+	// The cached quota is invalidated by the change.
+	is.Nil(writer.Set(ctx, "premium", ratelimit.Quota{Limit: 4, Period: time.Minute}))
+	is.Eventually(func() bool {
+		q, err := reader.Quota(ctx, "premium:b")
+		return err == nil && q != nil && q.Limit == 4
+	}, time.Second, 10*time.Millisecond)
+	is.Equal(4, count("premium:b"))
+
+	is.Nil(writer.Delete(ctx, "premium"))
+	is.Eventually(func() bool {
+		q, err := reader.Quota(ctx, "premium:c")
+		return err == nil && q == nil
+	}, time.Second, 10*time.Millisecond)
+	is.Equal(1, count("premium:c"))
+}
+
+func TestQuotaInvalid(t *testing.T) {
+	is := assert.New(t)
+
+	var q ratelimit.Quota
+	err := json.Unmarshal([]byte(`{"limit":0,"period":"1m0s"}`), &q)
+	is.ErrorIs(err, ratelimit.ErrInvalidQuota)
+
+	_, err = json.Marshal(ratelimit.Quota{Limit: 1})
+	is.ErrorIs(err, ratelimit.ErrInvalidQuota)
+
+	rl := ratelimit.NewGCRA(nil, 1, time.Second, 0)
+	rl.Quotas = quotaFunc(func(ctx context.Context, key string) (*ratelimit.Quota, error) {
+		return &ratelimit.Quota{Limit: 1}, nil
+	})
+	_, err = rl.Allow(context.Background(), "key")
+	is.ErrorIs(err, ratelimit.ErrInvalidQuota)
+}
+
+func TestRedisQuotasPrune(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	now := time.Now()
+	quotas := ratelimit.NewRedisQuotas(client, "quotas", &ratelimit.RedisQuotasOptions{
+		TTL: time.Minute,
+		Now: func() time.Time {
+			return now
+		},
+	})
+
+	is := assert.New(t)
+	for i := range 10 {
+		_, err := quotas.Quota(ctx, fmt.Sprint("key:", i))
+		is.Nil(err)
+	}
+	is.Equal(10, quotas.Len())
+
+	// The expired quotas are removed when the next quota is cached.
+	now = now.Add(time.Minute)
+	_, err := quotas.Quota(ctx, "key:a")
+	is.Nil(err)
+	is.Equal(1, quotas.Len())
+}
+
+type quotaFunc func(ctx context.Context, key string) (*ratelimit.Quota, error)
+
+func (f quotaFunc) Quota(ctx context.Context, key string) (*ratelimit.Quota, error) {
+	return f(ctx, key)
+}
@@ -0,0 +1,17 @@
+// Package ratelimit implements distributed rate limiters backed by Redis.
+package ratelimit
+
+import "context"
+
+// RateLimiter is implemented by all the limiters in this package.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+	AllowN(ctx context.Context, key string, n int) (bool, error)
+	AllowDetail(ctx context.Context, key string, n int) (*Result, error)
+}
+
+var (
+	_ RateLimiter = (*FixedWindow)(nil)
+	_ RateLimiter = (*GCRA)(nil)
+	_ RateLimiter = (*SlidingWindowLog)(nil)
+)
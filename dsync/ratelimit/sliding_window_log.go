@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+//go:embed sliding_window_log.lua
+var slidingWindowLogScript string
+
+var slidingWindowLog = redis.NewScript(slidingWindowLogScript)
+
+// SlidingWindowLog implements the Sliding Window Log algorithm. Every allowed
+// request is logged in a sorted set, so the requests are counted exactly over
+// the trailing period, at the cost of memory proportional to the limit.
+type SlidingWindowLog struct {
+	Hooks
+	// Quotas overrides the limit and period per key when set.
+	Quotas QuotaProvider
+	Now    func() time.Time
+	client *redis.Client
+	limit  int
+	period int64
+}
+
+func NewSlidingWindowLog(client *redis.Client, limit int, period time.Duration) *SlidingWindowLog {
+	return &SlidingWindowLog{
+		Now:    time.Now,
+		client: client,
+		limit:  limit,
+		period: period.Milliseconds(),
+	}
+}
+
+func (r *SlidingWindowLog) Allow(ctx context.Context, key string) (bool, error) {
+	return r.AllowN(ctx, key, 1)
+}
+
+func (r *SlidingWindowLog) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	res, err := r.AllowDetail(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Allow, nil
+}
+
+// AllowDetail is like AllowN, but returns the remaining requests and the
+// retry after duration.
+func (r *SlidingWindowLog) AllowDetail(ctx context.Context, key string, n int) (*Result, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := r.quota(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{key}
+	argv := []any{
+		q.Limit,
+		q.Period.Milliseconds(),
+		r.Now().UnixMilli(),
+		n,
+		id,
+	}
+	vals, err := slidingWindowLog.Run(ctx, r.client, keys, argv...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	res := toResult(vals)
+	r.observe(ctx, "sliding_window_log", key, res)
+
+	return &res, nil
+}
+
+func (r *SlidingWindowLog) Remaining(ctx context.Context, key string) (int, error) {
+	q, err := r.quota(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := r.client.ZCount(ctx, key, r.windowStart(q.Period), "+inf").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return max(q.Limit-int(n), 0), nil
+}
+
+// ResetAfter returns the duration until the next request is allowed.
+func (r *SlidingWindowLog) ResetAfter(ctx context.Context, key string) (time.Duration, error) {
+	remaining, err := r.Remaining(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if remaining > 0 {
+		return 0, nil
+	}
+
+	q, err := r.quota(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	zs, err := r.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   r.windowStart(q.Period),
+		Max:   "+inf",
+		Count: 1,
+	}).Result()
+	if errors.Is(err, redis.Nil) || len(zs) == 0 {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ms := int64(zs[0].Score) + q.Period.Milliseconds() - r.Now().UnixMilli()
+	return time.Duration(max(ms, 0)) * time.Millisecond, nil
+}
+
+// windowStart returns the exclusive lower bound of the window.
+func (r *SlidingWindowLog) windowStart(period time.Duration) string {
+	return "(" + strconv.FormatInt(r.Now().UnixMilli()-period.Milliseconds(), 10)
+}
+
+func (r *SlidingWindowLog) capacity(ctx context.Context, key string) (int, error) {
+	q, err := r.quota(ctx, key)
+	return q.Limit, err
+}
+
+func (r *SlidingWindowLog) quota(ctx context.Context, key string) (Quota, error) {
+	return quota(ctx, r.Quotas, key, Quota{
+		Limit:  r.limit,
+		Period: time.Duration(r.period) * time.Millisecond,
+	})
+}
+
+// newID returns a unique id for the log entries of a request, since
+// requests made in the same millisecond have the same score.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,29 @@
+local key = KEYS[1]
+
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local token = tonumber(ARGV[4])
+local id = ARGV[5]
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - period)
+local count = redis.call('ZCARD', key)
+
+-- Returns {allowed, remaining, retry after in milliseconds}.
+if count + token <= limit then
+	for i = 1, token do
+		redis.call('ZADD', key, now, id .. ':' .. i)
+	end
+	redis.call('PEXPIRE', key, period)
+	return {1, limit - count - token, 0}
+end
+
+local remaining = math.max(limit - count, 0)
+if token > limit then
+	return {0, remaining, period}
+end
+
+-- The request is allowed once enough of the oldest requests fall out of the
+-- window.
+local oldest = redis.call('ZRANGE', key, count + token - limit - 1, count + token - limit - 1, 'WITHSCORES')
+return {0, remaining, math.max(tonumber(oldest[2]) + period - now, 0)}
@@ -0,0 +1,58 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowLog(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	rl := ratelimit.NewSlidingWindowLog(newClient(t), 5, time.Second)
+	rl.Now = func() time.Time {
+		return now
+	}
+	key := t.Name()
+
+	is := assert.New(t)
+	for i := range 5 {
+		res, err := rl.AllowDetail(ctx, key, 1)
+		is.Nil(err)
+		is.True(res.Allow)
+		is.Equal(4-i, res.Remaining)
+
+		// Spread the requests over the window.
+		now = now.Add(100 * time.Millisecond)
+	}
+
+	res, err := rl.AllowDetail(ctx, key, 1)
+	is.Nil(err)
+	is.False(res.Allow)
+	is.Equal(0, res.Remaining)
+	// The first request falls out of the window.
+	is.Equal(500*time.Millisecond, res.RetryAfter)
+
+	resetAfter, err := rl.ResetAfter(ctx, key)
+	is.Nil(err)
+	is.Equal(500*time.Millisecond, resetAfter)
+
+	// Unlike the fixed window, only the first request falls out of the
+	// window.
+	now = now.Add(500 * time.Millisecond)
+	remaining, err := rl.Remaining(ctx, key)
+	is.Nil(err)
+	is.Equal(1, remaining)
+
+	allow, err := rl.AllowN(ctx, key, 2)
+	is.Nil(err)
+	is.False(allow)
+
+	allow, err = rl.Allow(ctx, key)
+	is.Nil(err)
+	is.True(allow)
+}
@@ -0,0 +1,231 @@
+// Package semaphore implements a distributed weighted semaphore with Redis.
+package semaphore
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNoPermits is returned by TryAcquire when the weight cannot be
+	// acquired without waiting.
+	ErrNoPermits = errors.New("semaphore: no permits")
+
+	// ErrWeightExceeded is returned when the weight is larger than the limit,
+	// and can never be acquired.
+	ErrWeightExceeded = errors.New("semaphore: weight exceeds limit")
+
+	// ErrInvalidWeight is returned when the weight is not positive.
+	ErrInvalidWeight = errors.New("semaphore: weight must be positive")
+
+	// ErrExpired is returned when the permit expired, and may have been given
+	// to another holder.
+	ErrExpired = errors.New("semaphore: permit expired")
+)
+
+//go:embed semaphore.lua
+var acquireScript string
+
+var acquire = redis.NewScript(acquireScript)
+
+var extend = redis.NewScript(`
+	-- KEYS[1]: The holders key
+	-- ARGV[1]: The token
+	-- ARGV[2]: The ttl in milliseconds
+	-- ARGV[3]: The current time in milliseconds
+	local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+	if not score or tonumber(score) <= tonumber(ARGV[3]) then
+		return 0
+	end
+
+	redis.call('ZADD', KEYS[1], 'XX', ARGV[3] + ARGV[2], ARGV[1])
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+`)
+
+var dequeue = redis.NewScript(`
+	-- KEYS[1]: The queue key
+	-- KEYS[2]: The queue expiry key
+	-- ARGV[1]: The token
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	return redis.call('ZREM', KEYS[2], ARGV[1])
+`)
+
+type Options struct {
+	// TTL is the duration after which a permit is released if the holder
+	// crashed. Long running holders should Extend the permit.
+	// Defaults to 30s.
+	TTL time.Duration
+
+	// PollInterval is how often the waiters retry. A waiter that stops
+	// polling for 5 intervals is removed from the queue.
+	// Defaults to 100ms.
+	PollInterval time.Duration
+
+	Now func() time.Time
+}
+
+// Semaphore limits the total weight held per key across processes. Waiters
+// are served in FIFO order, so that heavy waiters are not starved by light
+// ones.
+type Semaphore struct {
+	client *redis.Client
+	limit  int64
+	opts   *Options
+}
+
+func New(client *redis.Client, limit int64, opts *Options) *Semaphore {
+	opts = cmp.Or(opts, &Options{})
+	opts.TTL = cmp.Or(opts.TTL, 30*time.Second)
+	opts.PollInterval = cmp.Or(opts.PollInterval, 100*time.Millisecond)
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &Semaphore{
+		client: client,
+		limit:  limit,
+		opts:   opts,
+	}
+}
+
+// Acquire acquires the weight for the key, waiting in the queue until it is
+// available or the context is done. The returned token must be passed to
+// Release.
+func (s *Semaphore) Acquire(ctx context.Context, key string, weight int64) (string, error) {
+	token, err := s.newToken(weight)
+	if err != nil {
+		return "", err
+	}
+
+	t := time.NewTicker(s.opts.PollInterval)
+	defer t.Stop()
+
+	for {
+		ok, err := s.acquire(ctx, key, token, weight, true)
+		if err != nil {
+			return "", errors.Join(err, s.dequeue(ctx, key, token))
+		}
+		if ok {
+			return token, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.Join(context.Cause(ctx), s.dequeue(ctx, key, token))
+		case <-t.C:
+		}
+	}
+}
+
+// TryAcquire acquires the weight for the key without waiting.
+// ErrNoPermits is returned if the weight is not available, or there are
+// waiters in the queue.
+func (s *Semaphore) TryAcquire(ctx context.Context, key string, weight int64) (string, error) {
+	token, err := s.newToken(weight)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := s.acquire(ctx, key, token, weight, false)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNoPermits
+	}
+
+	return token, nil
+}
+
+// Release releases the weight held by the token.
+func (s *Semaphore) Release(ctx context.Context, key, token string) error {
+	return s.client.ZRem(ctx, key, token).Err()
+}
+
+// Extend extends the permit by the TTL. ErrExpired is returned if the permit
+// expired.
+func (s *Semaphore) Extend(ctx context.Context, key, token string) error {
+	keys := []string{key}
+	argv := []any{
+		token,
+		s.opts.TTL.Milliseconds(),
+		s.opts.Now().UnixMilli(),
+	}
+	ok, err := extend.Run(ctx, s.client, keys, argv...).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+func (s *Semaphore) acquire(ctx context.Context, key, token string, weight int64, wait bool) (bool, error) {
+	if weight > s.limit {
+		return false, ErrWeightExceeded
+	}
+
+	var w int
+	if wait {
+		w = 1
+	}
+
+	keys := []string{
+		key,
+		queueKey(key),
+		queueExpiryKey(key),
+		key + ":seq",
+	}
+	argv := []any{
+		s.limit,
+		s.opts.Now().UnixMilli(),
+		s.opts.TTL.Milliseconds(),
+		token,
+		weight,
+		w,
+		(5 * s.opts.PollInterval).Milliseconds(),
+	}
+
+	return acquire.Run(ctx, s.client, keys, argv...).Bool()
+}
+
+// dequeue removes the waiter from the queue, so that it does not block the
+// waiters behind it.
+func (s *Semaphore) dequeue(ctx context.Context, key, token string) error {
+	keys := []string{queueKey(key), queueExpiryKey(key)}
+	return dequeue.Run(context.WithoutCancel(ctx), s.client, keys, token).Err()
+}
+
+// newToken returns a unique token, suffixed with the weight so that the
+// script can sum the weights of the holders.
+func (s *Semaphore) newToken(weight int64) (string, error) {
+	if weight <= 0 {
+		return "", ErrInvalidWeight
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b) + ":" + strconv.FormatInt(weight, 10), nil
+}
+
+func queueKey(key string) string {
+	return key + ":queue"
+}
+
+func queueExpiryKey(key string) string {
+	return key + ":queue:expiry"
+}
@@ -0,0 +1,50 @@
+local holders = KEYS[1]
+local queue = KEYS[2]
+local queue_expiry = KEYS[3]
+local seq = KEYS[4]
+
+local limit = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local token = ARGV[4]
+local weight = tonumber(ARGV[5])
+local wait = tonumber(ARGV[6])
+local wait_ttl = tonumber(ARGV[7])
+
+-- Evict the crashed holders and waiters.
+redis.call('ZREMRANGEBYSCORE', holders, '-inf', now)
+local expired = redis.call('ZRANGEBYSCORE', queue_expiry, '-inf', now)
+for _, member in ipairs(expired) do
+	redis.call('ZREM', queue, member)
+	redis.call('ZREM', queue_expiry, member)
+end
+
+local used = 0
+for _, member in ipairs(redis.call('ZRANGE', holders, 0, -1)) do
+	used = used + tonumber(string.match(member, ':(%d+)$'))
+end
+
+-- Waiters are served in FIFO order, so only the head of the queue, or a new
+-- caller when there are no waiters, may acquire.
+local head = redis.call('ZRANGE', queue, 0, 0)[1]
+local turn = head == nil or head == token
+
+if turn and used + weight <= limit then
+	redis.call('ZADD', holders, now + ttl, token)
+	redis.call('ZREM', queue, token)
+	redis.call('ZREM', queue_expiry, token)
+	redis.call('PEXPIRE', holders, ttl)
+	return 1
+end
+
+if wait == 1 then
+	if not redis.call('ZSCORE', queue, token) then
+		redis.call('ZADD', queue, redis.call('INCR', seq), token)
+	end
+	redis.call('ZADD', queue_expiry, now + wait_ttl, token)
+	redis.call('PEXPIRE', queue, wait_ttl)
+	redis.call('PEXPIRE', queue_expiry, wait_ttl)
+	redis.call('PEXPIRE', seq, wait_ttl)
+end
+
+return 0
@@ -0,0 +1,90 @@
+package semaphore_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/semaphore"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func TestMain(m *testing.M) {
+	stop := redistest.Init()
+	defer stop()
+
+	m.Run()
+}
+
+func TestSemaphore(t *testing.T) {
+	sem := semaphore.New(redistest.Client(t), 3, &semaphore.Options{
+		PollInterval: 10 * time.Millisecond,
+	})
+	key := t.Name()
+
+	is := assert.New(t)
+	a, err := sem.TryAcquire(ctx, key, 2)
+	is.Nil(err)
+
+	_, err = sem.TryAcquire(ctx, key, 2)
+	is.ErrorIs(err, semaphore.ErrNoPermits)
+
+	_, err = sem.TryAcquire(ctx, key, 4)
+	is.ErrorIs(err, semaphore.ErrWeightExceeded)
+
+	var (
+		mu    sync.Mutex
+		order []int64
+		wg    sync.WaitGroup
+	)
+
+	// The heavy waiter queues first, and is served before the light waiter,
+	// even though the light waiter could acquire immediately.
+	for _, w := range []int64{3, 1} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			token, err := sem.Acquire(ctx, key, w)
+			is.Nil(err)
+
+			mu.Lock()
+			order = append(order, w)
+			mu.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+			is.Nil(sem.Release(ctx, key, token))
+		}()
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	is.Nil(sem.Release(ctx, key, a))
+	wg.Wait()
+
+	is.Equal([]int64{3, 1}, order)
+}
+
+func TestSemaphore_Expired(t *testing.T) {
+	now := time.Now()
+	sem := semaphore.New(redistest.Client(t), 1, &semaphore.Options{
+		TTL: time.Second,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	key := t.Name()
+
+	is := assert.New(t)
+	a, err := sem.TryAcquire(ctx, key, 1)
+	is.Nil(err)
+
+	// The holder crashed, and the permit is released after the TTL.
+	now = now.Add(time.Second)
+	_, err = sem.TryAcquire(ctx, key, 1)
+	is.Nil(err)
+	is.ErrorIs(sem.Extend(ctx, key, a), semaphore.ErrExpired)
+}
@@ -1,6 +1,7 @@
 package singleflight
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,12 +11,41 @@ import (
 	redis "github.com/redis/go-redis/v9"
 )
 
+// ErrNotFound is returned by the getter when the value does not exist in the
+// source, e.g. fmt.Errorf("%w: user %s", singleflight.ErrNotFound, id).
+// When NotFoundTTL is set, the miss is cached, and subsequent calls return
+// ErrNotFound without calling the getter.
+var ErrNotFound = errors.New("singleflight: not found")
+
+// notFound is the value of the cached misses. It is not valid JSON, so it
+// cannot collide with a cached value.
+var notFound = []byte("\x00not-found")
+
 type Cache[T any] struct {
 	Client  *redis.Client
 	Group   *Group
 	LockTTL time.Duration
 	WaitTTL time.Duration
 	Suffix  string
+
+	// NotFoundTTL is the duration ErrNotFound returned by the getter is
+	// cached, so that requests for the missing values, e.g. scrapers probing
+	// for ids, do not hit the source. It should be short, since the value may
+	// be created later. Zero disables the caching of misses.
+	NotFoundTTL time.Duration
+}
+
+type LoadOrStoreOption func(*loadOrStoreOptions)
+
+type loadOrStoreOptions struct {
+	notFoundTTL time.Duration
+}
+
+// CacheNotFound overrides the NotFoundTTL of the cache for the call.
+func CacheNotFound(ttl time.Duration) LoadOrStoreOption {
+	return func(o *loadOrStoreOptions) {
+		o.notFoundTTL = ttl
+	}
 }
 
 func NewCache[T any](client *redis.Client) *Cache[T] {
@@ -28,11 +58,25 @@ func NewCache[T any](client *redis.Client) *Cache[T] {
 	}
 }
 
-func (c *Cache[T]) LoadOrStore(ctx context.Context, key string, getter func(ctx context.Context) (T, error), ttl time.Duration) (T, bool, error) {
+// LoadOrStore returns the cached value for the key, or stores the value
+// returned by the getter. Only one caller across the processes calls the
+// getter, while the rest wait for the value to be stored.
+// ErrNotFound is returned if the miss is cached, see NotFoundTTL.
+func (c *Cache[T]) LoadOrStore(ctx context.Context, key string, getter func(ctx context.Context) (T, error), ttl time.Duration, opts ...LoadOrStoreOption) (T, bool, error) {
+	o := &loadOrStoreOptions{
+		notFoundTTL: c.NotFoundTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	t, err := c.load(ctx, key)
 	if err == nil {
 		return t, true, nil
 	}
+	if errors.Is(err, ErrNotFound) {
+		return t, true, err
+	}
 
 	if !errors.Is(err, redis.Nil) {
 		return t, false, err
@@ -40,6 +84,9 @@ func (c *Cache[T]) LoadOrStore(ctx context.Context, key string, getter func(ctx
 
 	did, err := c.Group.Do(ctx, fmt.Sprintf("%s:%s", key, c.Suffix), func(ctx context.Context) error {
 		v, err := getter(ctx)
+		if errors.Is(err, ErrNotFound) && o.notFoundTTL > 0 {
+			return c.Client.Set(ctx, key, notFound, o.notFoundTTL).Err()
+		}
 		if err != nil {
 			return err
 		}
@@ -63,6 +110,9 @@ func (c *Cache[T]) load(ctx context.Context, key string) (t T, err error) {
 	if err != nil {
 		return t, err
 	}
+	if bytes.Equal(b, notFound) {
+		return t, ErrNotFound
+	}
 
 	err = json.Unmarshal(b, &t)
 	return t, err
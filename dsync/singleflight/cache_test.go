@@ -0,0 +1,49 @@
+package singleflight_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/singleflight"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheNotFound(t *testing.T) {
+	cache := singleflight.NewCache[string](redistest.New(t).Client())
+	cache.NotFoundTTL = time.Minute
+
+	var calls int
+	getter := func(ctx context.Context) (string, error) {
+		calls++
+		return "", fmt.Errorf("%w: user 1", singleflight.ErrNotFound)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		key := t.Name()
+
+		is := assert.New(t)
+		_, loaded, err := cache.LoadOrStore(ctx, key, getter, time.Minute)
+		is.ErrorIs(err, singleflight.ErrNotFound)
+		is.False(loaded)
+
+		_, loaded, err = cache.LoadOrStore(ctx, key, getter, time.Minute)
+		is.ErrorIs(err, singleflight.ErrNotFound)
+		is.True(loaded)
+		is.Equal(1, calls)
+	})
+
+	t.Run("disabled per call", func(t *testing.T) {
+		calls = 0
+		key := t.Name()
+
+		is := assert.New(t)
+		for range 2 {
+			_, _, err := cache.LoadOrStore(ctx, key, getter, time.Minute, singleflight.CacheNotFound(0))
+			is.ErrorIs(err, singleflight.ErrNotFound)
+		}
+		is.Equal(2, calls)
+	})
+}
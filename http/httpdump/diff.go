@@ -0,0 +1,58 @@
+package httpdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Diff returns the difference between the dumps, or an empty string if they
+// are equal. The JSON bodies are compared by value, so the order of the
+// fields and the whitespaces do not matter.
+func Diff(want, got *Dump) string {
+	return cmp.Diff(view(want), view(got))
+}
+
+type messageView struct {
+	Line   string
+	Header http.Header
+	Body   any
+}
+
+type dumpView struct {
+	Request  messageView
+	Response messageView
+}
+
+func view(d *Dump) dumpView {
+	return dumpView{
+		Request:  d.Request.view(),
+		Response: d.Response.view(),
+	}
+}
+
+func (m *Message) view() messageView {
+	var body any = string(m.Body)
+	if isJSON(m.Header) {
+		dec := json.NewDecoder(bytes.NewReader(m.Body))
+		dec.UseNumber()
+
+		var v any
+		if err := dec.Decode(&v); err == nil {
+			body = v
+		}
+	}
+
+	header := m.Header
+	if len(header) == 0 {
+		header = nil
+	}
+
+	return messageView{
+		Line:   m.Line,
+		Header: header,
+		Body:   body,
+	}
+}
@@ -0,0 +1,265 @@
+// Package httpdump formats a request and its response as text, for snapshot
+// testing of the handlers.
+//
+// The format is the same as the .http files in the testdata:
+//
+//	-- request.http --
+//	POST /users HTTP/1.1
+//	Host: example.com
+//	Content-Type: application/json
+//
+//	{
+//	  "name": "john"
+//	}
+//	-- response.http --
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{
+//	  "id": "[MASKED]"
+//	}
+//
+// The Host header comes first, followed by the other headers sorted by name.
+// The Content-Length header is omitted, and the JSON bodies are indented, so
+// that the diffs are readable. The trailing newlines of the bodies are not
+// preserved. Otherwise, parsing and formatting the dump returns the same
+// text.
+package httpdump
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+const (
+	requestSection  = "-- request.http --\n"
+	responseSection = "-- response.http --\n"
+)
+
+var ErrInvalidFormat = errors.New("httpdump: invalid format")
+
+// Dump is the request and the response of a round trip.
+type Dump struct {
+	Request  Message
+	Response Message
+}
+
+// Message is either the request or the response.
+type Message struct {
+	// Line is the request line, e.g. "GET / HTTP/1.1", or the status line,
+	// e.g. "HTTP/1.1 200 OK".
+	Line   string
+	Header http.Header
+	Body   []byte
+}
+
+// New dumps the request and the response. The bodies are read and restored,
+// so they can be read again.
+func New(r *http.Request, w *http.Response) (*Dump, error) {
+	req, err := readBody(&r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := readBody(&w.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if host := cmp.Or(r.Host, r.URL.Host); host != "" {
+		header.Set("Host", host)
+	}
+
+	d := &Dump{
+		Request: Message{
+			Line:   fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), cmp.Or(r.Proto, "HTTP/1.1")),
+			Header: header,
+			Body:   req,
+		},
+		Response: Message{
+			Line:   fmt.Sprintf("%s %d %s", cmp.Or(w.Proto, "HTTP/1.1"), w.StatusCode, http.StatusText(w.StatusCode)),
+			Header: w.Header.Clone(),
+			Body:   res,
+		},
+	}
+	d.normalize()
+
+	return d, nil
+}
+
+// Parse parses the text formatted by MarshalText.
+func Parse(b []byte) (*Dump, error) {
+	d := new(Dump)
+	if err := d.UnmarshalText(b); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Mask applies the masks to the dump.
+func (d *Dump) Mask(masks ...Mask) {
+	for _, mask := range masks {
+		mask(d)
+	}
+	d.normalize()
+}
+
+func (d *Dump) MarshalText() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(requestSection)
+	d.Request.format(&b)
+	b.WriteString(responseSection)
+	d.Response.format(&b)
+
+	return b.Bytes(), nil
+}
+
+func (d *Dump) UnmarshalText(b []byte) error {
+	s := string(b)
+	s, ok := strings.CutPrefix(s, requestSection)
+	if !ok {
+		return fmt.Errorf("%w: missing %q", ErrInvalidFormat, strings.TrimSpace(requestSection))
+	}
+
+	req, res, ok := strings.Cut(s, responseSection)
+	if !ok {
+		return fmt.Errorf("%w: missing %q", ErrInvalidFormat, strings.TrimSpace(responseSection))
+	}
+
+	var err error
+	d.Request, err = parseMessage(req)
+	if err != nil {
+		return err
+	}
+
+	d.Response, err = parseMessage(res)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalize removes the headers and the whitespaces that are not part of the
+// format, so that the dumps are equal after a round trip.
+func (d *Dump) normalize() {
+	for _, m := range []*Message{&d.Request, &d.Response} {
+		if m.Header == nil {
+			m.Header = make(http.Header)
+		}
+		m.Header.Del("Content-Length")
+
+		if isJSON(m.Header) {
+			var b bytes.Buffer
+			if err := json.Indent(&b, m.Body, "", "  "); err == nil {
+				m.Body = b.Bytes()
+			}
+		}
+		m.Body = bytes.TrimRight(m.Body, "\n")
+		if len(m.Body) == 0 {
+			m.Body = nil
+		}
+	}
+}
+
+func (m *Message) format(b *bytes.Buffer) {
+	b.WriteString(m.Line)
+	b.WriteString("\n")
+
+	keys := make([]string, 0, len(m.Header))
+	for k := range m.Header {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		// Host comes first, like in the request.
+		switch {
+		case a == b:
+			return 0
+		case a == "Host":
+			return -1
+		case b == "Host":
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+
+	for _, k := range keys {
+		for _, v := range m.Header[k] {
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+	b.WriteString("\n")
+
+	if len(m.Body) > 0 {
+		b.Write(m.Body)
+		b.WriteString("\n")
+	}
+}
+
+func parseMessage(s string) (Message, error) {
+	head, body, _ := strings.Cut(s, "\n\n")
+
+	tp := textproto.NewReader(bufio.NewReader(strings.NewReader(head + "\n\n")))
+	line, err := tp.ReadLine()
+	if err != nil {
+		return Message{}, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return Message{}, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	var b []byte
+	if body = strings.TrimRight(body, "\n"); body != "" {
+		b = []byte(body)
+	}
+
+	return Message{
+		Line:   line,
+		Header: http.Header(header),
+		Body:   b,
+	}, nil
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	b, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(b))
+
+	return b, nil
+}
+
+func isJSON(h http.Header) bool {
+	mt, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
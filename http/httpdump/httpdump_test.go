@@ -0,0 +1,107 @@
+package httpdump_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/core/http/httpdump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "7f9c")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":"7f9c","user":%s,"items":[{"id":1},{"id":2}]}`+"\n", b)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/users?debug=true", strings.NewReader(`{"name":"john"}`))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, r)
+
+	r = httptest.NewRequest(http.MethodPost, "/users?debug=true", strings.NewReader(`{"name":"john"}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	is := assert.New(t)
+	d, err := httpdump.New(r, w.Result())
+	is.Nil(err)
+
+	d.Mask(
+		httpdump.MaskResponseHeaders("X-Request-Id"),
+		httpdump.MaskResponseBody("id", "items.id"),
+	)
+
+	b, err := d.MarshalText()
+	is.Nil(err)
+	is.Equal(`-- request.http --
+POST /users?debug=true HTTP/1.1
+Host: example.com
+Content-Type: application/json
+
+{
+  "name": "john"
+}
+-- response.http --
+HTTP/1.1 201 Created
+Content-Type: application/json
+X-Request-Id: [MASKED]
+
+{
+  "id": "[MASKED]",
+  "items": [
+    {
+      "id": "[MASKED]"
+    },
+    {
+      "id": "[MASKED]"
+    }
+  ],
+  "user": {
+    "name": "john"
+  }
+}
+`, string(b))
+
+	// The body can be read again.
+	body, err := io.ReadAll(r.Body)
+	is.Nil(err)
+	is.Equal(`{"name":"john"}`, string(body))
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := httpdump.Parse(b)
+		is := assert.New(t)
+		is.Nil(err)
+		is.Empty(httpdump.Diff(d, got))
+
+		text, err := got.MarshalText()
+		is.Nil(err)
+		is.Equal(string(b), string(text))
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		got, err := httpdump.Parse([]byte(strings.Replace(string(b), "201 Created", "200 OK", 1)))
+		is := assert.New(t)
+		is.Nil(err)
+		is.Contains(httpdump.Diff(d, got), "200 OK")
+
+		// The JSON bodies are compared by value.
+		got, err = httpdump.Parse(b)
+		is.Nil(err)
+		got.Request.Body = []byte(`{"name": "john"}`)
+		is.Empty(httpdump.Diff(d, got))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := httpdump.Parse([]byte("GET / HTTP/1.1\n"))
+		is := assert.New(t)
+		is.ErrorIs(err, httpdump.ErrInvalidFormat)
+	})
+}
@@ -0,0 +1,95 @@
+package httpdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Masked is the value of the masked headers and fields.
+const Masked = "[MASKED]"
+
+// Mask replaces the volatile values of the dump, e.g. the dates and the ids,
+// so that the snapshots are stable.
+type Mask func(d *Dump)
+
+// MaskRequestHeaders masks the values of the request headers.
+func MaskRequestHeaders(names ...string) Mask {
+	return func(d *Dump) {
+		maskHeaders(d.Request.Header, names)
+	}
+}
+
+// MaskResponseHeaders masks the values of the response headers.
+func MaskResponseHeaders(names ...string) Mask {
+	return func(d *Dump) {
+		maskHeaders(d.Response.Header, names)
+	}
+}
+
+// MaskRequestBody masks the fields of the JSON request body. The fields are
+// dot-separated paths, e.g. "user.id", and the arrays are traversed, so
+// "items.id" masks the id of every item.
+func MaskRequestBody(fields ...string) Mask {
+	return func(d *Dump) {
+		d.Request.Body = maskBody(d.Request.Body, fields)
+	}
+}
+
+// MaskResponseBody is like MaskRequestBody, but for the response body.
+func MaskResponseBody(fields ...string) Mask {
+	return func(d *Dump) {
+		d.Response.Body = maskBody(d.Response.Body, fields)
+	}
+}
+
+func maskHeaders(h http.Header, names []string) {
+	for _, name := range names {
+		vs := h.Values(name)
+		for i := range vs {
+			vs[i] = Masked
+		}
+	}
+}
+
+// maskBody returns the body as is if it is not JSON.
+func maskBody(body []byte, fields []string) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+
+	for _, field := range fields {
+		maskField(v, strings.Split(field, "."))
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return b
+}
+
+func maskField(v any, path []string) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			maskField(e, path)
+		}
+	case map[string]any:
+		e, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = Masked
+			return
+		}
+		maskField(e, path[1:])
+	}
+}
@@ -0,0 +1,149 @@
+// Package testutil provides golden file testing for the handlers and the
+// JSON values.
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/alextanhongpin/core/http/httpdump"
+)
+
+// UpdateEnv is the environment variable that overwrites the golden files
+// when true, e.g. UPDATE_GOLDEN=true go test ./...
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// TB is the subset of testing.TB used by the dumps.
+type TB interface {
+	Helper()
+	Name() string
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+type options struct {
+	file   string
+	masks  []httpdump.Mask
+	fields []string
+	types  []reflect.Type
+}
+
+type Option func(o *options)
+
+// File sets the name of the golden file, relative to the test directory in
+// the testdata. Defaults to the name of the test.
+func File(name string) Option {
+	return func(o *options) {
+		o.file = name
+	}
+}
+
+// IgnoreHeaders ignores the values of the request and response headers,
+// e.g. the dates and the request ids.
+func IgnoreHeaders(names ...string) Option {
+	return func(o *options) {
+		o.masks = append(o.masks,
+			httpdump.MaskRequestHeaders(names...),
+			httpdump.MaskResponseHeaders(names...),
+		)
+	}
+}
+
+// IgnoreBodyFields ignores the values of the fields of the JSON request and
+// response bodies. The fields are dot-separated paths, e.g. "user.id".
+func IgnoreBodyFields(fields ...string) Option {
+	return func(o *options) {
+		o.masks = append(o.masks,
+			httpdump.MaskRequestBody(fields...),
+			httpdump.MaskResponseBody(fields...),
+		)
+	}
+}
+
+// DumpHTTP serves the request with the handler, and compares the round trip
+// with the golden file at testdata/<test name>.http. The golden file is
+// created if it does not exist, and overwritten if UPDATE_GOLDEN is true.
+// The response is returned for further assertions.
+func DumpHTTP(t TB, h http.Handler, r *http.Request, opts ...Option) *http.Response {
+	t.Helper()
+
+	o := &options{file: t.Name()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Keep the request body for the dump, since the handler consumes it.
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("testutil: read request body: %v", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	req := r.Clone(r.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	got, err := httpdump.New(req, w.Result())
+	if err != nil {
+		t.Fatalf("testutil: dump: %v", err)
+	}
+	got.Mask(o.masks...)
+
+	b, err := got.MarshalText()
+	if err != nil {
+		t.Fatalf("testutil: dump: %v", err)
+	}
+
+	path := filepath.Join("testdata", o.file+".http")
+	want, err := readGolden(path, b)
+	if err != nil {
+		t.Fatalf("testutil: golden file: %v", err)
+	}
+
+	wantDump, err := httpdump.Parse(want)
+	if err != nil {
+		t.Fatalf("testutil: %s: %v", path, err)
+	}
+
+	if diff := httpdump.Diff(wantDump, got); diff != "" {
+		t.Errorf("testutil: %s mismatch (-want +got):\n%s", path, diff)
+	}
+
+	return w.Result()
+}
+
+// readGolden returns the content of the golden file, after writing the got
+// content if the file does not exist or must be updated.
+func readGolden(path string, got []byte) ([]byte, error) {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+
+	want, err := os.ReadFile(path)
+	if err == nil && !update {
+		return want, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, got, 0o644); err != nil {
+		return nil, err
+	}
+
+	return got, nil
+}
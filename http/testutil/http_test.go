@@ -0,0 +1,79 @@
+package testutil_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/core/http/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the errors, so that the failures can be asserted.
+type recorder struct {
+	*testing.T
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestDumpHTTP(t *testing.T) {
+	var status = http.StatusCreated
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", fmt.Sprint(status))
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":   status,
+			"name": req.Name,
+		})
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"john"}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	t.Run("match", func(t *testing.T) {
+		res := testutil.DumpHTTP(t, h, newRequest(),
+			testutil.IgnoreHeaders("X-Request-Id"),
+			testutil.IgnoreBodyFields("id"),
+		)
+
+		is := assert.New(t)
+		is.Equal(http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		status = http.StatusAccepted
+		defer func() {
+			status = http.StatusCreated
+		}()
+
+		rec := &recorder{T: t}
+		testutil.DumpHTTP(rec, h, newRequest(),
+			testutil.File("TestDumpHTTP/match"),
+			testutil.IgnoreHeaders("X-Request-Id"),
+			testutil.IgnoreBodyFields("id"),
+		)
+
+		is := assert.New(t)
+		is.Len(rec.errors, 1)
+		is.Contains(rec.errors[0], "testdata/TestDumpHTTP/match.http mismatch")
+		is.Contains(rec.errors[0], "202 Accepted")
+	})
+}
@@ -0,0 +1,198 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// IgnoreFields ignores the fields of the JSON value in DumpJSON. The fields
+// are dot-separated paths, e.g. "address.updated_at", where "*" matches any
+// element of an array or any key of an object, e.g. "items.*.id".
+func IgnoreFields(paths ...string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, paths...)
+	}
+}
+
+// IgnoreTypes ignores the fields of the same types as the values in
+// DumpJSON, e.g. IgnoreTypes(time.Time{}) ignores all the timestamps.
+func IgnoreTypes(vs ...any) Option {
+	return func(o *options) {
+		for _, v := range vs {
+			o.types = append(o.types, reflect.TypeOf(v))
+		}
+	}
+}
+
+// DumpJSON compares the value, encoded as indented JSON, with the golden
+// file at testdata/<test name>.json. The golden file is created if it does
+// not exist, and overwritten if UPDATE_GOLDEN is true.
+//
+// Besides the options, the struct fields tagged with `cmp:",ignore"` are
+// ignored:
+//
+//	type User struct {
+//		ID        string    `json:"id" cmp:",ignore"`
+//		CreatedAt time.Time `json:"created_at" cmp:",ignore"`
+//	}
+//
+// The ignored fields are still written to the golden file, but are not
+// compared.
+func DumpJSON(t TB, v any, opts ...Option) {
+	t.Helper()
+
+	o := &options{file: t.Name()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("testutil: encode: %v", err)
+	}
+
+	path := filepath.Join("testdata", o.file+".json")
+	want, err := readGolden(path, b)
+	if err != nil {
+		t.Fatalf("testutil: golden file: %v", err)
+	}
+
+	ignore := slices.Clone(o.fields)
+	ignore = append(ignore, ignoredPaths(reflect.ValueOf(v), "", o.types)...)
+
+	wantJSON, err := decodeJSON(want, ignore)
+	if err != nil {
+		t.Fatalf("testutil: %s: %v", path, err)
+	}
+
+	gotJSON, err := decodeJSON(b, ignore)
+	if err != nil {
+		t.Fatalf("testutil: decode: %v", err)
+	}
+
+	if diff := cmp.Diff(wantJSON, gotJSON); diff != "" {
+		t.Errorf("testutil: %s mismatch (-want +got):\n%s", path, diff)
+	}
+}
+
+// ignoredPaths returns the paths of the fields tagged with `cmp:",ignore"`,
+// and of the fields of the ignored types.
+func ignoredPaths(v reflect.Value, path string, types []reflect.Type) []string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if path != "" && slices.Contains(types, v.Type()) {
+		return []string{path}
+	}
+
+	var res []string
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			res = append(res, ignoredPaths(v.Index(i), join(path, "*"), types)...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			res = append(res, ignoredPaths(iter.Value(), join(path, "*"), types)...)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+
+			// The embedded structs without a name are flattened.
+			fieldPath := path
+			switch {
+			case name != "":
+				fieldPath = join(path, name)
+			case !f.Anonymous:
+				fieldPath = join(path, f.Name)
+			}
+
+			_, opts, _ := strings.Cut(f.Tag.Get("cmp"), ",")
+			if slices.Contains(strings.Split(opts, ","), "ignore") {
+				res = append(res, fieldPath)
+				continue
+			}
+
+			res = append(res, ignoredPaths(v.Field(i), fieldPath, types)...)
+		}
+	}
+
+	slices.Sort(res)
+
+	return slices.Compact(res)
+}
+
+// decodeJSON decodes the JSON, and removes the ignored paths.
+func decodeJSON(b []byte, ignore []string) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	for _, path := range ignore {
+		v = ignorePath(v, strings.Split(path, "."))
+	}
+
+	return v, nil
+}
+
+func ignorePath(v any, path []string) any {
+	if len(path) == 0 {
+		return nil
+	}
+
+	key, rest := path[0], path[1:]
+	switch v := v.(type) {
+	case []any:
+		if key != "*" {
+			return v
+		}
+		for i := range v {
+			v[i] = ignorePath(v[i], rest)
+		}
+	case map[string]any:
+		for k, e := range v {
+			if key != "*" && key != k {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(v, k)
+				continue
+			}
+			v[k] = ignorePath(e, rest)
+		}
+	}
+
+	return v
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,76 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/http/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type Address struct {
+	City      string    `json:"city"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Item struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Order struct {
+	ID        string            `json:"id" cmp:",ignore"`
+	Address   Address           `json:"address"`
+	Items     []Item            `json:"items"`
+	Labels    map[string]string `json:"labels"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func TestDumpJSON(t *testing.T) {
+	newOrder := func(id string, at time.Time) Order {
+		return Order{
+			ID: id,
+			Address: Address{
+				City:      "Kuala Lumpur",
+				UpdatedAt: at,
+			},
+			Items: []Item{
+				{ID: id + "-1", Name: "book"},
+				{ID: id + "-2", Name: "pen"},
+			},
+			Labels: map[string]string{
+				"trace": id,
+			},
+			CreatedAt: at,
+		}
+	}
+
+	opts := []testutil.Option{
+		testutil.File("TestDumpJSON"),
+		testutil.IgnoreFields("items.*.id", "labels.*"),
+		testutil.IgnoreTypes(time.Time{}),
+	}
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testutil.DumpJSON(t, newOrder("a", at), opts...)
+
+	t.Run("ignored", func(t *testing.T) {
+		rec := &recorder{T: t}
+		testutil.DumpJSON(rec, newOrder("b", at.Add(time.Hour)), opts...)
+
+		is := assert.New(t)
+		is.Empty(rec.errors)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		o := newOrder("b", at)
+		o.Address.City = "Penang"
+
+		rec := &recorder{T: t}
+		testutil.DumpJSON(rec, o, opts...)
+
+		is := assert.New(t)
+		is.Len(rec.errors, 1)
+		is.Contains(rec.errors[0], "Penang")
+	})
+}
@@ -45,6 +45,7 @@ func TrackerHandler(h http.Handler, tracker *Tracker, userFn func(r *http.Reques
 		path := fmt.Sprintf("%s - %d", r.Pattern, wr.StatusCode())
 		user := userFn(r)
 		took := time.Since(start)
+		r = tracker.tenantRequest(r)
 		err := tracker.Record(r.Context(), path, user, took)
 		if err != nil {
 			logger.Error(err.Error())
@@ -52,8 +53,31 @@ func TrackerHandler(h http.Handler, tracker *Tracker, userFn func(r *http.Reques
 	})
 }
 
+// TrackerStatsHandler reports the stats of the day, which defaults to today:
+//
+//	GET /?at=2024-05-01
+//
+// Given the tenant, the stats of the tenant are reported instead:
+//
+//	GET /?tenant=acme&at=2024-05-01
+//
+// Given any of action, from or to, the hourly or daily rollups with the top
+// users are reported instead. The from and to accept either a date or
+// RFC3339, and default to the last 24 hours:
+//
+//	GET /?action=GET /users - 200&from=2024-05-01T10:00:00Z&to=2024-05-01T12:00:00Z&interval=hour
 func TrackerStatsHandler(tracker *Tracker) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant := r.URL.Query().Get("tenant"); tenant != "" {
+			r = r.WithContext(WithTenant(r.Context(), tenant))
+		}
+
+		if q := r.URL.Query(); q.Has("action") || q.Has("from") || q.Has("to") {
+			trackerQuery(w, r, tracker)
+
+			return
+		}
+
 		now := time.Now()
 		if at := r.URL.Query().Get("at"); at != "" {
 			t, err := time.Parse(time.DateOnly, at)
@@ -66,6 +90,10 @@ func TrackerStatsHandler(tracker *Tracker) http.Handler {
 		}
 
 		var sb strings.Builder
+		if InWarmup() {
+			sb.WriteString(fmt.Sprintf("warmup: %s remaining, latency excluded\n\n", WarmupRemaining().Round(time.Second)))
+		}
+
 		ctx := r.Context()
 		stats, err := tracker.Stats(ctx, now)
 		if err != nil {
@@ -82,45 +110,174 @@ func TrackerStatsHandler(tracker *Tracker) http.Handler {
 			}
 		}
 
+		if tracker.SessionGap > 0 {
+			sessions, err := tracker.Sessions(ctx, now)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+			sb.WriteString(fmt.Sprintf("at: %s\n%s\n\n", now, sessions.String()))
+		}
+
 		fmt.Fprint(w, sb.String())
 	})
 }
 
+func trackerQuery(w http.ResponseWriter, r *http.Request, tracker *Tracker) {
+	params := r.URL.Query()
+	now := time.Now()
+	q := Query{
+		Action: params.Get("action"),
+		From:   now.Add(-24 * time.Hour),
+		To:     now,
+	}
+
+	var err error
+	if from := params.Get("from"); from != "" {
+		q.From, err = parseTime(from)
+	}
+	if to := params.Get("to"); to != "" && err == nil {
+		q.To, err = parseTime(to)
+	}
+	switch params.Get("interval") {
+	case "":
+	case "hour":
+		q.Interval = time.Hour
+	case "day":
+		q.Interval = 24 * time.Hour
+	default:
+		err = fmt.Errorf("%w: interval must be hour or day", ErrInvalidQuery)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rollups, err := tracker.Query(r.Context(), q)
+	if errors.Is(err, ErrInvalidQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	var sb strings.Builder
+	for _, r := range rollups {
+		sb.WriteString(r.String())
+		sb.WriteString("\n\n")
+	}
+
+	fmt.Fprint(w, sb.String())
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
 type Tracker struct {
 	Name string
 	Now  func() time.Time
-	cms  *probs.CountMinSketch // Track frequency of API calls.
-	hll  *probs.HyperLogLog    // Track unique page views by user.
-	td   *probs.TDigest        // Track API latency.
-	topK *probs.TopK           // Track top-10 requests.
+
+	// SessionGap enables stitching of the user's requests into sessions. A
+	// new session starts when the user is inactive for longer than the gap.
+	SessionGap time.Duration
+
+	// Rolling enables the in-process latency percentiles over sliding
+	// windows, e.g. the last 1m, 5m and 1h, which are reported by Stats.
+	Rolling *Rolling
+
+	// HashTag wraps the name of the keys in a hash tag, e.g. {name}:cms, so
+	// that all the keys of the tracker are in the same Redis Cluster slot.
+	HashTag bool
+
+	// Retention expires the daily and hourly keys after the day ends, so that
+	// the memory does not grow unbounded. The keys never expire when zero.
+	Retention time.Duration
+
+	// TenantFn returns the tenant of the request, e.g. the tenant ID of the
+	// API key, for TrackerHandler to partition the stats by tenant. The
+	// tenant must have a bounded number of values. See WithTenant.
+	TenantFn func(r *http.Request) string
+
+	client *redis.Client
+	cms    *probs.CountMinSketch // Track frequency of API calls.
+	hll    *probs.HyperLogLog    // Track unique page views by user.
+	td     *probs.TDigest        // Track API latency.
+	topK   *probs.TopK           // Track top-10 requests.
 }
 
 func NewTracker(name string, client *redis.Client) *Tracker {
 	return &Tracker{
-		Name: name,
-		Now:  time.Now,
-		cms:  probs.NewCountMinSketch(client),
-		hll:  probs.NewHyperLogLog(client),
-		td:   probs.NewTDigest(client),
-		topK: probs.NewTopK(client),
+		Name:   name,
+		Now:    time.Now,
+		client: client,
+		cms:    probs.NewCountMinSketch(client),
+		hll:    probs.NewHyperLogLog(client),
+		td:     probs.NewTDigest(client),
+		topK:   probs.NewTopK(client),
 	}
 }
 
+// Record records the request. When the context has a tenant, see
+// WithTenant, the request is also recorded in the partition of the tenant.
 func (t *Tracker) Record(ctx context.Context, path, userID string, duration time.Duration) error {
-	day := t.Now().Format(time.DateOnly)
-	key := t.Name
+	writes.add()
+	defer writes.done()
+
+	// The request may be cancelled after the response is written, but the
+	// record should not be lost.
+	ctx = context.WithoutCancel(ctx)
+
+	now := t.Now()
+	// Latency during warmup is not representative, and skews the
+	// percentiles.
+	if !InWarmup() && t.Rolling != nil {
+		t.Rolling.Observe(path, duration)
+	}
+
+	err := t.record(WithTenant(ctx, ""), now, path, userID, duration)
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		err = errors.Join(err, t.record(ctx, now, path, userID, duration))
+	}
+
+	return err
+}
 
-	return errors.Join(
+func (t *Tracker) record(ctx context.Context, now time.Time, path, userID string, duration time.Duration) error {
+	day := now.Format(time.DateOnly)
+	hour := now.Format(hourFormat)
+	key := t.prefix(ctx)
+
+	errs := []error{
 		// We calculate the all-time rank.
 		t.rank(ctx, join(key, "top_k"), path),
 		t.countOccurences(ctx, join(key, "cms", day), path),
 		t.countUnique(ctx, join(key, "hll", day, path), userID),
-		t.recordLatency(ctx, join(key, "td", day, path), duration),
-	)
+		t.recordRollup(ctx, day, hour, path, userID),
+		t.recordLatency(ctx, latencyKey(key, day, path), duration),
+	}
+	if t.SessionGap > 0 {
+		errs = append(errs, t.stitch(ctx, day, path, userID))
+	}
+	if t.Retention > 0 {
+		errs = append(errs, t.expire(ctx, now, path))
+	}
+
+	return errors.Join(errs...)
 }
 
 func (t *Tracker) Stats(ctx context.Context, at time.Time) ([]Stats, error) {
-	key := t.Name
+	key := t.prefix(ctx)
 	day := at.Format(time.DateOnly)
 	paths, err := t.rankings(ctx, join(key, "top_k"))
 	if err != nil {
@@ -152,11 +309,32 @@ func (t *Tracker) Stats(ctx context.Context, at time.Time) ([]Stats, error) {
 			Total:  occurences,
 			Unique: unique,
 		}
+		// The rolling stats are in-process, and not partitioned by tenant.
+		if t.Rolling != nil && tenantFromContext(ctx) == "" {
+			stats[i].Rolling = t.Rolling.ActionStats(path)
+		}
 	}
 
 	return stats, nil
 }
 
+// latencyKey returns the key of the latency of the path. The latency during
+// warmup is not representative, and skews the percentiles, so it is recorded
+// separately, and excluded from Stats. See WarmupLatency.
+func latencyKey(key, day, path string) string {
+	if InWarmup() {
+		return join(key, "warmup", "td", day, path)
+	}
+
+	return join(key, "td", day, path)
+}
+
+// WarmupLatency returns the p50, p90 and p95 latency in seconds of the path,
+// recorded during the warmup window of the day.
+func (t *Tracker) WarmupLatency(ctx context.Context, at time.Time, path string) ([]float64, error) {
+	return t.latency(ctx, join(t.prefix(ctx), "warmup", "td", at.Format(time.DateOnly), path))
+}
+
 func (t *Tracker) recordLatency(ctx context.Context, path string, duration time.Duration) error {
 	_, err := t.td.Add(ctx, path, duration.Seconds())
 	return err
@@ -207,10 +385,13 @@ type Stats struct {
 	P95    float64
 	Unique int64
 	Total  int64
+
+	// Rolling is set when Tracker.Rolling is enabled.
+	Rolling []RollingStats
 }
 
 func (s *Stats) String() string {
-	return fmt.Sprintf(`%s
+	str := fmt.Sprintf(`%s
 unique/total: %d/%d
 p50/p90/p95 (in seconds): %v, %s, %s`,
 		s.Path,
@@ -220,6 +401,11 @@ p50/p90/p95 (in seconds): %v, %s, %s`,
 		seconds(s.P90),
 		seconds(s.P95),
 	)
+	for _, r := range s.Rolling {
+		str += "\n" + r.String()
+	}
+
+	return str
 }
 
 type Prefix string
@@ -43,6 +43,59 @@ func TestTracker(t *testing.T) {
 	}
 }
 
+func TestTrackerWarmup(t *testing.T) {
+	metrics.SetWarmup(time.Hour)
+	defer metrics.SetWarmup(0)
+
+	tracker := metrics.NewTracker(t.Name(), redistest.Client(t))
+	ctx := context.Background()
+	now := time.Now()
+
+	is := assert.New(t)
+	is.Nil(tracker.Record(ctx, "GET /foo", "user", 10*time.Second))
+
+	// The latency during warmup is recorded, but excluded from the stats.
+	vals, err := tracker.WarmupLatency(ctx, now, "GET /foo")
+	is.Nil(err)
+	is.Equal([]float64{10, 10, 10}, vals)
+
+	metrics.SetWarmup(0)
+	is.Nil(tracker.Record(ctx, "GET /foo", "user", time.Second))
+
+	stats, err := tracker.Stats(ctx, now)
+	is.Nil(err)
+	is.Len(stats, 1)
+	is.Equal(int64(2), stats[0].Total)
+	is.Equal(1.0, stats[0].P95)
+}
+
+func TestTrackerSessions(t *testing.T) {
+	now := time.Now()
+	tracker := metrics.NewTracker(t.Name(), redistest.Client(t))
+	tracker.SessionGap = 30 * time.Minute
+	tracker.Now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(tracker.Record(ctx, "GET /login", "user-1", time.Second))
+
+	now = now.Add(10 * time.Minute)
+	is.Nil(tracker.Record(ctx, "GET /checkout", "user-1", time.Second))
+
+	// Inactive for longer than the session gap, starts a new session.
+	now = now.Add(time.Hour)
+	is.Nil(tracker.Record(ctx, "GET /login", "user-1", time.Second))
+
+	stats, err := tracker.Sessions(ctx, now)
+	is.Nil(err)
+	is.Equal(int64(2), stats.Total)
+	is.Equal(int64(2), stats.Entry["GET /login"])
+	is.Equal(int64(1), stats.Exit["GET /checkout"])
+	is.Equal(10*time.Minute, time.Duration(stats.P50*float64(time.Second)))
+}
+
 func TestTrackerHandler(t *testing.T) {
 	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "hello world")
@@ -80,6 +133,141 @@ func TestTrackerHandler(t *testing.T) {
 	}
 }
 
+func TestTrackerQuery(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	tracker := metrics.NewTracker(t.Name(), redistest.Client(t))
+	tracker.Now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	is := assert.New(t)
+	for range 10 {
+		is.Nil(tracker.Record(ctx, "GET /foo", "user-1", time.Second))
+	}
+	is.Nil(tracker.Record(ctx, "GET /foo", "user-2", time.Second))
+
+	now = now.Add(time.Hour)
+	is.Nil(tracker.Record(ctx, "GET /foo", "user-2", time.Second))
+
+	rollups, err := tracker.Query(ctx, metrics.Query{
+		Action: "GET /foo",
+		From:   time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		To:     now,
+	})
+	is.Nil(err)
+	is.Len(rollups, 3)
+
+	// No traffic.
+	is.Equal(int64(0), rollups[0].Total)
+
+	is.Equal(int64(11), rollups[1].Total)
+	is.Equal(int64(2), rollups[1].Unique)
+	is.Equal(int64(10), rollups[1].TopUsers["user-1"])
+
+	is.Equal(int64(1), rollups[2].Total)
+	is.Equal(int64(1), rollups[2].Unique)
+
+	{
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/?from=2024-05-01&to=2024-05-01&interval=day", nil)
+		h := metrics.TrackerStatsHandler(tracker)
+		h.ServeHTTP(w, r)
+		res := w.Result()
+		is.Equal(200, res.StatusCode)
+		b, err := io.ReadAll(res.Body)
+		is.Nil(err)
+		is.Contains(string(b), "unique/total: 2/12")
+	}
+
+	_, err = tracker.Query(ctx, metrics.Query{
+		From: now,
+		To:   now.Add(-time.Hour),
+	})
+	is.ErrorIs(err, metrics.ErrInvalidQuery)
+}
+
+func TestTrackerTenant(t *testing.T) {
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello world")
+	})
+
+	userFn := func(r *http.Request) string {
+		return "user-id"
+	}
+
+	tracker := metrics.NewTracker(t.Name(), redistest.Client(t))
+	tracker.TenantFn = func(r *http.Request) string {
+		return r.Header.Get("X-Tenant-ID")
+	}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	mux := http.NewServeMux()
+	mux.Handle("GET /", metrics.TrackerHandler(h, tracker, userFn, logger))
+
+	is := assert.New(t)
+	for _, tenant := range []string{"acme", "acme", "globex", ""} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Tenant-ID", tenant)
+		mux.ServeHTTP(w, r)
+		is.Equal(200, w.Code)
+	}
+
+	ctx := context.Background()
+	stats, err := tracker.Stats(ctx, time.Now())
+	is.Nil(err)
+	is.Len(stats, 1)
+	is.Equal(int64(4), stats[0].Total)
+
+	stats, err = tracker.Stats(metrics.WithTenant(ctx, "acme"), time.Now())
+	is.Nil(err)
+	is.Len(stats, 1)
+	is.Equal(int64(2), stats[0].Total)
+
+	{
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/?tenant=globex", nil)
+		metrics.TrackerStatsHandler(tracker).ServeHTTP(w, r)
+		is.Equal(200, w.Code)
+		is.Contains(w.Body.String(), "unique/total: 1/1")
+	}
+}
+
+func TestTrackerRetention(t *testing.T) {
+	now := time.Now()
+	client := redistest.Client(t)
+	tracker := metrics.NewTracker(t.Name(), client)
+	tracker.HashTag = true
+	tracker.Retention = 24 * time.Hour
+	tracker.Now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	is := assert.New(t)
+	is.Nil(tracker.Record(ctx, "GET /foo", "user-1", time.Second))
+
+	// The keys expire a day after the day ends.
+	ttl, err := client.TTL(ctx, "{"+t.Name()+"}:cms:"+now.Format(time.DateOnly)).Result()
+	is.Nil(err)
+	is.Greater(ttl, 24*time.Hour)
+	is.LessOrEqual(ttl, 48*time.Hour)
+
+	// The all-time keys do not expire.
+	ttl, err = client.TTL(ctx, "{"+t.Name()+"}:top_k").Result()
+	is.Nil(err)
+	is.Equal(time.Duration(-1), ttl)
+
+	now = now.Add(48 * time.Hour)
+	n, err := tracker.Cleanup(ctx, time.Hour)
+	is.Nil(err)
+	is.Equal(int64(7), n)
+
+	n, err = client.Exists(ctx, "{"+t.Name()+"}:top_k").Result()
+	is.Nil(err)
+	is.Equal(int64(1), n)
+}
+
 func randDuration(duration time.Duration) time.Duration {
 	return time.Duration(rand.Int64N(duration.Milliseconds())) * time.Millisecond
 }
@@ -1,12 +1,9 @@
 package metrics
 
 import (
-	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/alextanhongpin/core/http/httputil"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -74,6 +71,7 @@ type REDTracker struct {
 	action  string
 	status  string
 	Now     time.Time
+	otel    *OTel
 }
 
 func NewRED(service, action string) *REDTracker {
@@ -86,9 +84,23 @@ func NewRED(service, action string) *REDTracker {
 }
 
 func (r *REDTracker) Done() {
-	RED.
+	took := time.Since(r.Now)
+	if !InWarmup() {
+		REDRolling.Observe(join(r.service, r.action), took)
+	}
+	if r.otel != nil {
+		r.otel.recordRED(r, took)
+		return
+	}
+
+	red := RED
+	if InWarmup() {
+		red = REDWarmup
+	}
+
+	red.
 		WithLabelValues(r.service, r.action, r.status).
-		Observe(float64(time.Since(r.Now).Milliseconds()))
+		Observe(float64(took.Milliseconds()))
 }
 
 func (r *REDTracker) Fail() {
@@ -99,22 +111,11 @@ func (r *REDTracker) SetStatus(status string) {
 	r.status = status
 }
 
+// RequestDurationHandler records the request duration by the route pattern
+// matched by http.ServeMux. See RouteHandler for other routers.
 func RequestDurationHandler(version string, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		wr := httputil.NewResponseWriterRecorder(w)
-
-		defer func(start time.Time) {
-			// Remove prefix http method, if any.
-			method := r.Method
-			path := tail(strings.Fields(r.Pattern))
-			code := fmt.Sprintf("%d", wr.StatusCode())
-
-			RequestDuration.
-				WithLabelValues(method, path, code, version).
-				Observe(float64(time.Since(start).Seconds()))
-		}(time.Now())
-
-		next.ServeHTTP(wr, r)
+	return RouteHandler(next, &RouteOptions{
+		Version: version,
 	})
 }
 
@@ -1,10 +1,13 @@
 package metrics_test
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/alextanhongpin/core/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -148,3 +151,73 @@ red_count{action="login",service="user_service",status="ok"} 1
 `
 	is.Equal(want, string(b))
 }
+
+func TestREDWarmup(t *testing.T) {
+	metrics.SetWarmup(time.Hour)
+	defer metrics.SetWarmup(0)
+
+	is := assert.New(t)
+	is.True(metrics.InWarmup())
+	is.Equal(1.0, testutil.ToFloat64(metrics.WarmupGauge))
+
+	n := testutil.CollectAndCount(metrics.RED, "red")
+	red := metrics.NewRED("warmup_service", "login")
+	red.Done()
+
+	is.Equal(1, testutil.CollectAndCount(metrics.REDWarmup, "red_warmup"))
+	is.Equal(n, testutil.CollectAndCount(metrics.RED, "red"), "excluded from RED")
+
+	metrics.SetWarmup(0)
+	is.False(metrics.InWarmup())
+	is.Equal(0.0, testutil.ToFloat64(metrics.WarmupGauge))
+}
+
+func TestWarmupVar(t *testing.T) {
+	is := assert.New(t)
+	is.NotNil(expvar.Get(metrics.WarmupVar))
+}
+
+func TestShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	is := assert.New(t)
+	is.Nil(metrics.Shutdown(ctx))
+	is.GreaterOrEqual(time.Since(start), 10*time.Millisecond, "blocks until deadline")
+	is.True(metrics.Draining())
+	is.Equal(1.0, testutil.ToFloat64(metrics.DrainingGauge))
+}
+
+func TestRouteHandler(t *testing.T) {
+	metrics.RequestsByStatus.Reset()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// The route is extracted after the request is served, so the handler
+	// can wrap the mux.
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", h)
+	srv := httptest.NewServer(metrics.RouteHandler(mux, nil))
+	defer srv.Close()
+
+	is := assert.New(t)
+	for _, id := range []string{"0", "1", "2"} {
+		resp, err := srv.Client().Get(srv.URL + "/users/" + id)
+		is.Nil(err)
+		resp.Body.Close()
+	}
+
+	b, err := testutil.CollectAndFormat(metrics.RequestsByStatus, expfmt.TypeTextPlain, "http_requests_total")
+	is.Nil(err)
+	want := `# HELP http_requests_total A counter of requests by route and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/users/{id}",status="200"} 2
+http_requests_total{method="GET",path="/users/{id}",status="404"} 1
+`
+	is.Equal(want, string(b))
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alextanhongpin/core/http/httputil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTel records the same metrics as the Prometheus helpers, as OpenTelemetry
+// instruments. The warmup window is recorded as the "warmup" attribute
+// instead of a separate instrument.
+//
+//	meter := otel.Meter("github.com/alextanhongpin/core/metrics")
+//	o, err := metrics.NewOTel(meter)
+//
+//	red := o.NewRED("user", "create")
+//	defer red.Done()
+type OTel struct {
+	inFlight        metric.Int64UpDownCounter
+	requestDuration metric.Float64Histogram
+	responseSize    metric.Int64Histogram
+	red             metric.Float64Histogram
+}
+
+func NewOTel(meter metric.Meter) (*OTel, error) {
+	inFlight, err := meter.Int64UpDownCounter("in_flight_requests",
+		metric.WithDescription("The requests currently being served by the wrapped handler."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestDuration, err := meter.Float64Histogram("request_duration",
+		metric.WithDescription("The latencies for requests."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	responseSize, err := meter.Int64Histogram("response_size",
+		metric.WithDescription("The response sizes for requests."),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(200, 500, 900, 1500),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	red, err := meter.Float64Histogram("red",
+		metric.WithDescription("RED metrics"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTel{
+		inFlight:        inFlight,
+		requestDuration: requestDuration,
+		responseSize:    responseSize,
+		red:             red,
+	}, nil
+}
+
+// NewRED returns a REDTracker that records to the OTel instrument.
+func (o *OTel) NewRED(service, action string) *REDTracker {
+	r := NewRED(service, action)
+	r.otel = o
+
+	return r
+}
+
+// RequestDurationHandler is like the package-level RequestDurationHandler, but
+// records to the OTel meter and also tracks the requests in flight.
+func (o *OTel) RequestDurationHandler(version string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		wr := httputil.NewResponseWriterRecorder(w)
+
+		o.inFlight.Add(ctx, 1)
+		defer func(start time.Time) {
+			o.inFlight.Add(ctx, -1)
+
+			o.requestDuration.Record(ctx, time.Since(start).Seconds(),
+				metric.WithAttributes(
+					attribute.String("method", r.Method),
+					attribute.String("path", Pattern(r)),
+					attribute.String("status", fmt.Sprint(wr.StatusCode())),
+					attribute.String("version", version),
+					attribute.Bool("warmup", InWarmup()),
+				),
+			)
+		}(time.Now())
+
+		next.ServeHTTP(wr, r)
+	})
+}
+
+func (o *OTel) ObserveResponseSize(r *http.Request) int {
+	size := computeApproximateRequestSize(r)
+	o.responseSize.Record(r.Context(), int64(size))
+	return size
+}
+
+func (o *OTel) recordRED(r *REDTracker, took time.Duration) {
+	o.red.Record(context.Background(), float64(took.Milliseconds()),
+		metric.WithAttributes(
+			attribute.String("service", r.service),
+			attribute.String("action", r.action),
+			attribute.String("status", r.status),
+			attribute.Bool("warmup", InWarmup()),
+		),
+	)
+}
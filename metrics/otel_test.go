@@ -0,0 +1,57 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alextanhongpin/core/metrics"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOTel(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	is := assert.New(t)
+	o, err := metrics.NewOTel(provider.Meter(t.Name()))
+	is.Nil(err)
+
+	red := o.NewRED("user", "create")
+	red.Fail()
+	red.Done()
+
+	h := o.RequestDurationHandler("v1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	mux := http.NewServeMux()
+	mux.Handle("POST /users/{id}", h)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/1", nil))
+
+	var rm metricdata.ResourceMetrics
+	is.Nil(reader.Collect(context.Background(), &rm))
+	is.Len(rm.ScopeMetrics, 1)
+
+	got := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	redData := got["red"].(metricdata.Histogram[float64]).DataPoints
+	is.Len(redData, 1)
+	status, _ := redData[0].Attributes.Value("status")
+	is.Equal(metrics.Err, status.AsString())
+
+	duration := got["request_duration"].(metricdata.Histogram[float64]).DataPoints
+	is.Len(duration, 1)
+	path, _ := duration[0].Attributes.Value("path")
+	is.Equal("/users/{id}", path.AsString())
+	code, _ := duration[0].Attributes.Value("status")
+	is.Equal("201", code.AsString())
+
+	inFlight := got["in_flight_requests"].(metricdata.Sum[int64]).DataPoints
+	is.Equal(int64(0), inFlight[0].Value)
+}
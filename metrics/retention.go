@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// name returns the name of the keys.
+func (t *Tracker) name() string {
+	if t.HashTag {
+		return "{" + t.Name + "}"
+	}
+
+	return t.Name
+}
+
+// prefix returns the prefix of the keys, which is partitioned by the tenant
+// in the context.
+func (t *Tracker) prefix(ctx context.Context) string {
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		return join(t.name(), "tenant", tenant)
+	}
+
+	return t.name()
+}
+
+// expire sets the expiry of the daily and hourly keys written for the path,
+// which is the end of the day plus the retention.
+func (t *Tracker) expire(ctx context.Context, now time.Time, path string) error {
+	key := t.prefix(ctx)
+	day := now.Format(time.DateOnly)
+	hour := now.Format(hourFormat)
+
+	keys := []string{
+		join(key, "cms", day),
+		join(key, "hll", day, path),
+		join(key, "td", day, path),
+		join(key, "warmup", "td", day, path),
+		join(key, "cms", hour),
+		join(key, "hll", hour, path),
+		join(key, "top_k", hour, path),
+		join(key, "top_k", day, path),
+	}
+	if t.SessionGap > 0 {
+		keys = append(keys,
+			join(key, "session", "count", day),
+			join(key, "session", "entry", day),
+			join(key, "session", "exit", day),
+			join(key, "session", "td", day),
+		)
+	}
+
+	y, m, d := now.Date()
+	at := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(t.Retention)
+
+	_, err := t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			pipe.ExpireAt(ctx, k, at)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// Cleanup deletes the daily and hourly keys of the buckets that ended before
+// olderThan ago, including the keys of the tenants. This removes the keys
+// without an expiry, e.g. those written before the Retention is set.
+// It returns the number of keys deleted.
+func (t *Tracker) Cleanup(ctx context.Context, olderThan time.Duration) (int64, error) {
+	now := t.Now()
+	cutoff := now.Add(-olderThan)
+	// Includes the keys of all the tenants.
+	prefix := t.name() + ":"
+
+	var n int64
+	iter := t.client.Scan(ctx, 0, escape(prefix)+"*", 1_000).Iterator()
+
+	var keys []string
+	del := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		deleted, err := t.client.Unlink(ctx, keys...).Result()
+		n += deleted
+		keys = keys[:0]
+
+		return err
+	}
+
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		end, ok := bucketEnd(strings.TrimPrefix(key, prefix), now.Location())
+		if !ok || end.After(cutoff) {
+			continue
+		}
+
+		keys = append(keys, key)
+		if len(keys) >= 1_000 {
+			if err := del(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+
+	return n, del()
+}
+
+// bucketEnd returns the end of the day or hour in the key, e.g.
+// cms:2024-05-01 or session:td:2024-05-01. The keys without a bucket, e.g.
+// the all-time top_k, are never deleted.
+func bucketEnd(key string, loc *time.Location) (time.Time, bool) {
+	for _, s := range strings.Split(key, ":") {
+		if t, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+			return t.AddDate(0, 0, 1), true
+		}
+		if t, err := time.ParseInLocation(hourFormat, s, loc); err == nil {
+			return t.Add(time.Hour), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// escape escapes the glob characters of the SCAN pattern.
+func escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,274 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"math/bits"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWindows are the windows tracked by NewRolling when none are given.
+var DefaultWindows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// REDRolling tracks the RED latency per service and action in-process, so
+// that the percentiles are available without a Prometheus server.
+var REDRolling = NewRolling()
+
+// slots is the number of slots per window. The window slides one slot at a
+// time, e.g. every 5s for a 1m window.
+const slots = 12
+
+// subBuckets is the number of linear buckets per power of two, which bounds
+// the relative error of the quantiles to about 3%.
+const subBuckets = 16
+
+// Rolling tracks the latency percentiles per action over sliding time
+// windows, using a log-linear histogram similar to HDR histogram.
+type Rolling struct {
+	Now func() time.Time
+
+	windows []time.Duration
+
+	mu      sync.Mutex
+	actions map[string][]*ring
+}
+
+func NewRolling(windows ...time.Duration) *Rolling {
+	if len(windows) == 0 {
+		windows = DefaultWindows
+	}
+
+	return &Rolling{
+		Now:     time.Now,
+		windows: slices.Clone(windows),
+		actions: make(map[string][]*ring),
+	}
+}
+
+// Observe records the duration for the action in every window.
+func (r *Rolling) Observe(action string, d time.Duration) {
+	now := r.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rings, ok := r.actions[action]
+	if !ok {
+		rings = make([]*ring, len(r.windows))
+		for i, w := range r.windows {
+			rings[i] = newRing(w)
+		}
+		r.actions[action] = rings
+	}
+
+	idx := bucket(d)
+	for _, ring := range rings {
+		ring.add(now, idx)
+	}
+}
+
+// Quantiles returns the quantiles of the action within the window, which must
+// be one of the tracked windows. The durations are zero if there are no
+// observations.
+func (r *Rolling) Quantiles(action string, window time.Duration, qs ...float64) []time.Duration {
+	res := make([]time.Duration, len(qs))
+
+	i := slices.Index(r.windows, window)
+	if i == -1 {
+		return res
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rings, ok := r.actions[action]
+	if !ok {
+		return res
+	}
+
+	h, n := rings[i].merge(r.Now())
+	for j, q := range qs {
+		res[j] = quantile(h, n, q)
+	}
+
+	return res
+}
+
+// Stats returns the p50/p95/p99 of every action for every window, sorted by
+// action.
+func (r *Rolling) Stats() []RollingStats {
+	now := r.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stats []RollingStats
+	for _, action := range slices.Sorted(maps.Keys(r.actions)) {
+		stats = append(stats, r.stats(action, now)...)
+	}
+
+	return stats
+}
+
+// ActionStats is like Stats, but for a single action.
+func (r *Rolling) ActionStats(action string) []RollingStats {
+	now := r.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats(action, now)
+}
+
+// Must be called with the lock held.
+func (r *Rolling) stats(action string, now time.Time) []RollingStats {
+	rings, ok := r.actions[action]
+	if !ok {
+		return nil
+	}
+
+	stats := make([]RollingStats, len(rings))
+	for i, ring := range rings {
+		h, n := ring.merge(now)
+		stats[i] = RollingStats{
+			Action: action,
+			Window: r.windows[i],
+			Count:  n,
+			P50:    quantile(h, n, 0.5),
+			P95:    quantile(h, n, 0.95),
+			P99:    quantile(h, n, 0.99),
+		}
+	}
+
+	return stats
+}
+
+type RollingStats struct {
+	Action string
+	Window time.Duration
+	Count  int64
+	P50    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+func (s *RollingStats) String() string {
+	return fmt.Sprintf("last %s: count=%d p50/p95/p99: %s, %s, %s",
+		formatWindow(s.Window),
+		s.Count,
+		s.P50,
+		s.P95,
+		s.P99,
+	)
+}
+
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+// ring is a circular buffer of histograms, one per slot of the window.
+type ring struct {
+	size  time.Duration
+	slots [slots]slot
+}
+
+type slot struct {
+	epoch  int64
+	counts map[int]int64
+}
+
+func newRing(window time.Duration) *ring {
+	return &ring{
+		size: max(window/slots, 1),
+	}
+}
+
+func (r *ring) add(now time.Time, idx int) {
+	epoch := now.UnixNano() / int64(r.size)
+	s := &r.slots[epoch%slots]
+	if s.epoch != epoch || s.counts == nil {
+		s.epoch = epoch
+		s.counts = make(map[int]int64)
+	}
+	s.counts[idx]++
+}
+
+// merge returns the histogram of the slots within the window, and the total
+// count.
+func (r *ring) merge(now time.Time) (map[int]int64, int64) {
+	epoch := now.UnixNano() / int64(r.size)
+
+	var n int64
+	h := make(map[int]int64)
+	for _, s := range r.slots {
+		if s.counts == nil || epoch-s.epoch >= slots || s.epoch > epoch {
+			continue
+		}
+		for k, v := range s.counts {
+			h[k] += v
+			n += v
+		}
+	}
+
+	return h, n
+}
+
+func quantile(h map[int]int64, n int64, q float64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+
+	rank := int64(q * float64(n))
+	rank = min(max(rank, 1), n)
+
+	var c int64
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		c += h[k]
+		if c >= rank {
+			return value(k)
+		}
+	}
+
+	return 0
+}
+
+// bucket returns the log-linear bucket of the duration in microseconds.
+// Values below 2*subBuckets are exact, and the larger values are split into
+// subBuckets linear buckets per power of two.
+func bucket(d time.Duration) int {
+	v := uint64(max(d.Microseconds(), 0))
+	if v < 2*subBuckets {
+		return int(v)
+	}
+
+	e := bits.Len64(v) - 1
+	shift := e - bits.Len64(subBuckets) + 1
+	sub := int(v>>shift) - subBuckets
+
+	return (shift+1)*subBuckets + sub
+}
+
+// value returns the midpoint of the bucket.
+func value(idx int) time.Duration {
+	if idx < 2*subBuckets {
+		return time.Duration(idx) * time.Microsecond
+	}
+
+	shift := idx/subBuckets - 1
+	sub := idx % subBuckets
+	lo := uint64(subBuckets+sub) << shift
+	hi := uint64(subBuckets+sub+1) << shift
+
+	return time.Duration((lo+hi)/2) * time.Microsecond
+}
@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolling(t *testing.T) {
+	now := time.Now()
+	r := metrics.NewRolling()
+	r.Now = func() time.Time {
+		return now
+	}
+
+	for i := range 100 {
+		r.Observe("GET /foo", time.Duration(i+1)*time.Millisecond)
+	}
+
+	is := assert.New(t)
+	qs := r.Quantiles("GET /foo", time.Minute, 0.5, 0.95, 0.99)
+	is.InEpsilon(50*time.Millisecond, qs[0], 0.05)
+	is.InEpsilon(95*time.Millisecond, qs[1], 0.05)
+	is.InEpsilon(99*time.Millisecond, qs[2], 0.05)
+
+	// The observations expire from the 1m window, but not the 5m and 1h
+	// windows.
+	now = now.Add(2 * time.Minute)
+	r.Observe("GET /foo", time.Second)
+
+	stats := r.Stats()
+	is.Len(stats, 3)
+	is.Equal(int64(1), stats[0].Count)
+	is.InEpsilon(time.Second, stats[0].P50, 0.05)
+	is.Equal(int64(101), stats[1].Count)
+	is.Equal(int64(101), stats[2].Count)
+	is.Equal("last 1h: count=101 p50/p95/p99: 50.176ms, 96.256ms, 100.352ms", stats[2].String())
+
+	is.Equal([]time.Duration{0}, r.Quantiles("GET /bar", time.Minute, 0.5))
+}
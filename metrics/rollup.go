@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/probs"
+)
+
+// hourFormat formats the hourly rollup keys.
+const hourFormat = "2006-01-02T15"
+
+// ErrInvalidQuery is returned when the query range is invalid.
+var ErrInvalidQuery = errors.New("metrics: invalid query")
+
+// maxRollups bounds the number of rollups returned per action, since each
+// rollup requires a few round trips.
+const maxRollups = 24 * 31
+
+type Query struct {
+	// Action filters the rollups by action. Defaults to the top actions.
+	Action string
+
+	// From and To are the inclusive range of the rollups.
+	From time.Time
+	To   time.Time
+
+	// Interval is either time.Hour or 24*time.Hour. Defaults to hourly for
+	// ranges up to two days, and daily otherwise.
+	Interval time.Duration
+}
+
+// Rollup is the traffic of an action within an hour or a day.
+type Rollup struct {
+	Action   string
+	Start    time.Time
+	Interval time.Duration
+	Total    int64
+	Unique   int64
+
+	// TopUsers are the heavy hitters, with their approximate counts.
+	TopUsers map[string]int64
+}
+
+func (r *Rollup) String() string {
+	format := hourFormat
+	if r.Interval != time.Hour {
+		format = time.DateOnly
+	}
+
+	return fmt.Sprintf(`%s %s
+unique/total: %d/%d
+top users: %s`,
+		r.Start.Format(format),
+		r.Action,
+		r.Unique,
+		r.Total,
+		ranked(r.TopUsers),
+	)
+}
+
+// Query returns the hourly or daily rollups of the actions within the range.
+func (t *Tracker) Query(ctx context.Context, q Query) ([]Rollup, error) {
+	if q.From.IsZero() || q.To.Before(q.From) {
+		return nil, fmt.Errorf("%w: from %s, to %s", ErrInvalidQuery, q.From, q.To)
+	}
+
+	q.Interval = cmp.Or(q.Interval, interval(q.From, q.To))
+	if q.Interval != time.Hour && q.Interval != 24*time.Hour {
+		return nil, fmt.Errorf("%w: interval %s", ErrInvalidQuery, q.Interval)
+	}
+
+	actions := []string{q.Action}
+	if q.Action == "" {
+		var err error
+		actions, err = t.rankings(ctx, join(t.prefix(ctx), "top_k"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var rollups []Rollup
+	for _, action := range actions {
+		start := q.From.Truncate(q.Interval)
+		if q.Interval != time.Hour {
+			y, m, d := q.From.Date()
+			start = time.Date(y, m, d, 0, 0, 0, 0, q.From.Location())
+		}
+
+		for i := 0; !start.After(q.To) && i < maxRollups; i++ {
+			r, err := t.rollup(ctx, action, start, q.Interval)
+			if err != nil {
+				return nil, err
+			}
+			rollups = append(rollups, *r)
+
+			start = start.Add(q.Interval)
+		}
+	}
+
+	return rollups, nil
+}
+
+// recordRollup records the request in the hourly and daily rollups.
+func (t *Tracker) recordRollup(ctx context.Context, day, hour, path, userID string) error {
+	key := t.prefix(ctx)
+
+	return errors.Join(
+		t.countOccurences(ctx, join(key, "cms", hour), path),
+		t.countUnique(ctx, join(key, "hll", hour, path), userID),
+		t.rank(ctx, join(key, "top_k", hour, path), userID),
+		t.rank(ctx, join(key, "top_k", day, path), userID),
+	)
+}
+
+func (t *Tracker) rollup(ctx context.Context, action string, start time.Time, interval time.Duration) (*Rollup, error) {
+	key := t.prefix(ctx)
+	bucket := start.Format(hourFormat)
+	if interval != time.Hour {
+		bucket = start.Format(time.DateOnly)
+	}
+
+	r := &Rollup{
+		Action:   action,
+		Start:    start,
+		Interval: interval,
+	}
+
+	// The sketches are created on first write, so missing keys mean no
+	// traffic.
+	total, err := t.totalOccurences(ctx, join(key, "cms", bucket), action)
+	if err != nil && !probs.KeyDoesNotExistError(err) {
+		return nil, err
+	}
+	r.Total = total
+
+	r.Unique, err = t.totalUnique(ctx, join(key, "hll", bucket, action))
+	if err != nil {
+		return nil, err
+	}
+
+	r.TopUsers, err = t.topK.ListWithCount(ctx, join(key, "top_k", bucket, action))
+	if err != nil && !probs.KeyDoesNotExistError(err) {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func interval(from, to time.Time) time.Duration {
+	if to.Sub(from) <= 48*time.Hour {
+		return time.Hour
+	}
+
+	return 24 * time.Hour
+}
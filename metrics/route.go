@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"cmp"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alextanhongpin/core/http/httputil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestsByStatus counts the requests by route and status code.
+var RequestsByStatus = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "A counter of requests by route and status code.",
+	},
+	[]string{"method", "path", "status"},
+)
+
+type RouteOptions struct {
+	Version string
+
+	// Route returns the route template of the request, e.g. /users/{id},
+	// which must have a bounded number of values. It is called after the
+	// request is served, so that routers that set the template on the
+	// request can be used.
+	// Defaults to the http.ServeMux pattern, without the method.
+	Route func(r *http.Request) string
+}
+
+// RouteHandler records the RequestDuration and RequestsByStatus by route
+// template instead of the raw path, so that /users/1 and /users/2 are
+// aggregated under the same label.
+func RouteHandler(next http.Handler, opts *RouteOptions) http.Handler {
+	opts = cmp.Or(opts, &RouteOptions{})
+	if opts.Route == nil {
+		opts.Route = Pattern
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wr := httputil.NewResponseWriterRecorder(w)
+
+		defer func(start time.Time) {
+			path := opts.Route(r)
+			code := strconv.Itoa(wr.StatusCode())
+
+			RequestDuration.
+				WithLabelValues(r.Method, path, code, opts.Version).
+				Observe(time.Since(start).Seconds())
+			RequestsByStatus.
+				WithLabelValues(r.Method, path, code).
+				Inc()
+		}(time.Now())
+
+		next.ServeHTTP(wr, r)
+	})
+}
+
+// Pattern returns the pattern matched by http.ServeMux without the method,
+// e.g. "GET /users/{id}" returns "/users/{id}".
+func Pattern(r *http.Request) string {
+	return tail(strings.Fields(r.Pattern))
+}
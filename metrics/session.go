@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/probs"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// stitch extends the user's current session, or starts a new one if the gap
+// since the last request exceeds the session gap.
+// When a new session starts, the previous session is returned.
+var stitch = redis.NewScript(`
+	-- KEYS[1]: The session key
+	-- ARGV[1]: The current time in milliseconds
+	-- ARGV[2]: The session gap in milliseconds
+	-- ARGV[3]: The action
+	-- ARGV[4]: How long to keep the session in milliseconds
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local gap = tonumber(ARGV[2])
+	local action = ARGV[3]
+	local ttl = tonumber(ARGV[4])
+
+	local session = redis.call('HMGET', key, 'start', 'last', 'exit')
+	local start = tonumber(session[1])
+	local last = tonumber(session[2])
+	local exit = session[3]
+
+	if last ~= nil and now - last <= gap then
+		redis.call('HSET', key, 'last', now, 'exit', action)
+		redis.call('PEXPIRE', key, ttl)
+		return {0}
+	end
+
+	redis.call('HSET', key, 'start', now, 'last', now, 'exit', action)
+	redis.call('PEXPIRE', key, ttl)
+
+	if last == nil then
+		return {1}
+	end
+
+	return {1, last - start, exit}
+`)
+
+// sessionTTL is how long the session is kept after the last request. Sessions
+// of users that do not return within this duration are never closed.
+const sessionTTL = 24 * time.Hour
+
+type SessionStats struct {
+	Total int64
+	P50   float64
+	P90   float64
+	P95   float64
+	Entry map[string]int64
+	Exit  map[string]int64
+}
+
+func (s *SessionStats) String() string {
+	return fmt.Sprintf(`sessions: %d
+p50/p90/p95 duration (in seconds): %s, %s, %s
+entry: %s
+exit: %s`,
+		s.Total,
+		seconds(s.P50),
+		seconds(s.P90),
+		seconds(s.P95),
+		ranked(s.Entry),
+		ranked(s.Exit),
+	)
+}
+
+// Sessions returns the session stats for the given day.
+// A session is only included in the duration and exit stats once it ends,
+// that is when the same user makes a request after the session gap.
+func (t *Tracker) Sessions(ctx context.Context, at time.Time) (*SessionStats, error) {
+	key := t.prefix(ctx)
+	day := at.Format(time.DateOnly)
+
+	total, err := t.client.Get(ctx, join(key, "session", "count", day)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if total == 0 {
+		return &SessionStats{Total: total}, nil
+	}
+
+	entry, err := t.topK.ListWithCount(ctx, join(key, "session", "entry", day))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &SessionStats{
+		Total: total,
+		Entry: entry,
+	}
+
+	// The duration and exits are only recorded once the first session of the
+	// day ends.
+	exit, err := t.topK.ListWithCount(ctx, join(key, "session", "exit", day))
+	if err != nil {
+		if probs.KeyDoesNotExistError(err) {
+			return stats, nil
+		}
+
+		return nil, err
+	}
+	stats.Exit = exit
+
+	vals, err := t.latency(ctx, join(key, "session", "td", day))
+	if err != nil {
+		return nil, err
+	}
+	stats.P50 = vals[0]
+	stats.P90 = vals[1]
+	stats.P95 = vals[2]
+
+	return stats, nil
+}
+
+func (t *Tracker) stitch(ctx context.Context, day, path, userID string) error {
+	key := t.prefix(ctx)
+	now := t.Now()
+	res, err := stitch.Run(ctx, t.client, []string{join(key, "session", userID)},
+		now.UnixMilli(),
+		t.SessionGap.Milliseconds(),
+		path,
+		(t.SessionGap + sessionTTL).Milliseconds(),
+	).Slice()
+	if err != nil {
+		return err
+	}
+
+	// Session extended.
+	if res[0].(int64) == 0 {
+		return nil
+	}
+
+	errs := []error{
+		t.client.Incr(ctx, join(key, "session", "count", day)).Err(),
+		t.rank(ctx, join(key, "session", "entry", day), path),
+	}
+
+	// Previous session ended.
+	if len(res) == 3 {
+		took := time.Duration(res[1].(int64)) * time.Millisecond
+		errs = append(errs,
+			t.rank(ctx, join(key, "session", "exit", day), res[2].(string)),
+			t.recordLatency(ctx, join(key, "session", "td", day), took),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+func ranked(m map[string]int64) string {
+	keys := slices.SortedFunc(maps.Keys(m), func(a, b string) int {
+		return int(m[b] - m[a])
+	})
+
+	vals := make([]string, len(keys))
+	for i, k := range keys {
+		vals[i] = fmt.Sprintf("%s (%d)", k, m[k])
+	}
+
+	return strings.Join(vals, ", ")
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// DrainingGauge is 1 once Shutdown is called. Alerting rules can use it
+	// to ignore the instance during rolling deploys.
+	DrainingGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "draining",
+			Help: "Whether the process is shutting down.",
+		},
+	)
+
+	draining atomic.Bool
+	writes   pendingWrites
+)
+
+// Shutdown marks the process as draining, and waits for the pending Tracker
+// writes to complete. It then blocks until the context is done, so that the
+// /metrics endpoint can serve the final values to the last scrape. If the
+// context has no deadline, it returns once the writes complete.
+//
+//	ctx, cancel := context.WithTimeout(ctx, 2*scrapeInterval)
+//	defer cancel()
+//	metrics.Shutdown(ctx)
+//	srv.Shutdown(ctx)
+func Shutdown(ctx context.Context) error {
+	draining.Store(true)
+	DrainingGauge.Set(1)
+
+	if err := writes.wait(ctx); err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		<-ctx.Done()
+	}
+
+	return nil
+}
+
+// Draining returns true once Shutdown is called.
+func Draining() bool {
+	return draining.Load()
+}
+
+type pendingWrites struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (p *pendingWrites) add() {
+	p.mu.Lock()
+	if p.n == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.n++
+	p.mu.Unlock()
+}
+
+func (p *pendingWrites) done() {
+	p.mu.Lock()
+	p.n--
+	if p.n == 0 {
+		close(p.idle)
+	}
+	p.mu.Unlock()
+}
+
+func (p *pendingWrites) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if p.n == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	idle := p.idle
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-idle:
+		return nil
+	}
+}
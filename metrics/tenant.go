@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+)
+
+type tenantKey struct{}
+
+// WithTenant sets the tenant of the request, so that Tracker.Record also
+// records the request in the partition of the tenant, and Tracker.Stats,
+// Tracker.Query and Tracker.Sessions return the stats of the tenant:
+//
+//	ctx = metrics.WithTenant(ctx, "acme")
+//	stats, err := tracker.Stats(ctx, time.Now())
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenantRequest returns the request with the tenant extracted by
+// Tracker.TenantFn.
+func (t *Tracker) tenantRequest(r *http.Request) *http.Request {
+	if t.TenantFn == nil {
+		return r
+	}
+
+	tenant := t.TenantFn(r)
+	if tenant == "" {
+		return r
+	}
+
+	return r.WithContext(WithTenant(r.Context(), tenant))
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strings"
+)
+
+type routeKey struct{}
+
+// WithRoute sets the route template of the outbound request for Transport,
+// e.g. /users/{id}:
+//
+//	ctx = metrics.WithRoute(ctx, "/users/{id}")
+//	req, err := http.NewRequestWithContext(ctx, "GET", "/users/"+id, nil)
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// Route returns the route template set by WithRoute.
+func Route(r *http.Request) string {
+	route, _ := r.Context().Value(routeKey{}).(string)
+	return route
+}
+
+type TransportOptions struct {
+	// Route returns the route template of the outbound request, which must
+	// have a bounded number of values.
+	// Defaults to the route set by WithRoute. Requests without a route are
+	// aggregated by the method.
+	Route func(r *http.Request) string
+
+	// OTel records to the OTel instrument instead of RED.
+	OTel *OTel
+}
+
+// Transport records the RED metrics of the outbound requests, with the host
+// as the service and the method and route as the action, so that the health
+// of the dependencies shows up next to our own endpoints. Transport errors
+// and 5xx responses are recorded as errors.
+//
+//	client := &http.Client{
+//		Transport: metrics.NewTransport(http.DefaultTransport, nil),
+//	}
+type Transport struct {
+	Base http.RoundTripper
+	opts *TransportOptions
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport wraps the base transport, which defaults to
+// http.DefaultTransport.
+func NewTransport(base http.RoundTripper, opts *TransportOptions) *Transport {
+	opts = cmp.Or(opts, &TransportOptions{})
+	if opts.Route == nil {
+		opts.Route = Route
+	}
+
+	return &Transport{
+		Base: cmp.Or[http.RoundTripper](base, http.DefaultTransport),
+		opts: opts,
+	}
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	service := r.URL.Host
+	action := strings.TrimSpace(r.Method + " " + t.opts.Route(r))
+
+	var red *REDTracker
+	if t.opts.OTel != nil {
+		red = t.opts.OTel.NewRED(service, action)
+	} else {
+		red = NewRED(service, action)
+	}
+	defer red.Done()
+
+	resp, err := t.Base.RoundTrip(r)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		red.Fail()
+	}
+
+	return resp, err
+}
@@ -0,0 +1,46 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/core/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/0" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{
+		Transport: metrics.NewTransport(srv.Client().Transport, nil),
+	}
+
+	is := assert.New(t)
+	for _, id := range []string{"0", "1", "2"} {
+		ctx := metrics.WithRoute(context.Background(), "/users/{id}")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/users/"+id, nil)
+		is.Nil(err)
+
+		resp, err := client.Do(req)
+		is.Nil(err)
+		resp.Body.Close()
+	}
+
+	b, err := testutil.CollectAndFormat(metrics.RED, expfmt.TypeTextPlain, "red")
+	is.Nil(err)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	is.Contains(string(b), fmt.Sprintf(`red_count{action="GET /users/{id}",service=%q,status="err"} 1`, host))
+	is.Contains(string(b), fmt.Sprintf(`red_count{action="GET /users/{id}",service=%q,status="ok"} 2`, host))
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// processStart approximates the process start time.
+var processStart = time.Now()
+
+var warmup atomic.Int64
+
+var (
+	// WarmupGauge is 1 during the warmup window, and 0 after. Alerting rules
+	// can exclude the warmup window with e.g. `... and on() warmup == 0`.
+	WarmupGauge = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "warmup",
+			Help: "Whether the process is in the warmup window after start.",
+		},
+		func() float64 {
+			if InWarmup() {
+				return 1
+			}
+
+			return 0
+		},
+	)
+
+	// REDWarmup records the RED metrics during the warmup window, so that
+	// they are kept out of the RED histogram used for SLOs.
+	REDWarmup = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "red_warmup",
+			Help:    "RED metrics during warmup",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"service", "action", "status"},
+	)
+)
+
+// WarmupVar is the name of the expvar that reports the warmup window.
+const WarmupVar = "metrics_warmup"
+
+func init() {
+	// expvar.Publish panics if the name is taken.
+	if expvar.Get(WarmupVar) != nil {
+		return
+	}
+
+	expvar.Publish(WarmupVar, expvar.Func(func() any {
+		return map[string]any{
+			"active":    InWarmup(),
+			"remaining": WarmupRemaining().String(),
+			"started":   processStart,
+		}
+	}))
+}
+
+// SetWarmup sets the warmup window after process start. During the window,
+// metrics are still recorded, but excluded from the latency and RED
+// evaluations, since cold caches and connection pools are expected to be
+// slow after every deploy.
+func SetWarmup(d time.Duration) {
+	warmup.Store(int64(d))
+}
+
+// InWarmup returns true if the process is in the warmup window.
+func InWarmup() bool {
+	return WarmupRemaining() > 0
+}
+
+// WarmupRemaining returns the time left in the warmup window.
+func WarmupRemaining() time.Duration {
+	return max(time.Duration(warmup.Load())-time.Since(processStart), 0)
+}
@@ -0,0 +1,341 @@
+// Package delay implements a queue of jobs delivered at a future time, backed
+// by a Redis sorted set scored by the due time.
+//
+// A claimed job is rescheduled after the visibility timeout, so that the job
+// is redelivered if the worker crashes before acknowledging it.
+package delay
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ErrJobLost is returned when the job was redelivered to another worker after
+// the visibility timeout, before the handler completed.
+var ErrJobLost = errors.New("delay: job lost")
+
+var claim = redis.NewScript(`
+	-- KEYS[1]: The due set
+	-- KEYS[2]: The jobs hash
+	-- KEYS[3]: The attempts hash
+	-- ARGV[1]: The current time in milliseconds
+	-- ARGV[2]: The visibility timeout in milliseconds
+	-- ARGV[3]: The limit
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+	local res = {}
+	for _, id in ipairs(ids) do
+		redis.call('ZADD', KEYS[1], ARGV[1] + ARGV[2], id)
+		local attempt = redis.call('HINCRBY', KEYS[3], id, 1)
+		table.insert(res, id)
+		table.insert(res, redis.call('HGET', KEYS[2], id) or '')
+		table.insert(res, attempt)
+	end
+
+	return res
+`)
+
+var ack = redis.NewScript(`
+	-- KEYS[1]: The due set
+	-- KEYS[2]: The jobs hash
+	-- KEYS[3]: The attempts hash
+	-- ARGV[1]: The id
+	-- ARGV[2]: The attempt
+	if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+		return 0
+	end
+
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('HDEL', KEYS[3], ARGV[1])
+	return 1
+`)
+
+var reschedule = redis.NewScript(`
+	-- KEYS[1]: The due set
+	-- KEYS[2]: The attempts hash
+	-- ARGV[1]: The id
+	-- ARGV[2]: The attempt
+	-- ARGV[3]: The due time in milliseconds
+	if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+		return 0
+	end
+
+	redis.call('ZADD', KEYS[1], 'XX', ARGV[3], ARGV[1])
+	return 1
+`)
+
+type Job struct {
+	ID      string
+	Payload []byte
+	// Attempt is the number of times the job has been delivered, starting
+	// from 1.
+	Attempt int
+}
+
+type Handler func(ctx context.Context, job *Job) error
+
+type Options struct {
+	// Visibility is the duration a claimed job is hidden from the other
+	// workers. The job is redelivered if it is not handled within the
+	// duration. Defaults to 30s.
+	Visibility time.Duration
+
+	// MaxRetries is the number of retries after the first attempt fails.
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff returns the delay before the given retry, starting from 1.
+	// Defaults to exponential backoff starting from 1s, capped at 1m.
+	Backoff func(attempt int) time.Duration
+
+	// OnDeadLetter is called with the job that failed all the retries, before
+	// it is deleted.
+	OnDeadLetter func(ctx context.Context, job *Job, err error)
+
+	// BatchSize is the maximum number of jobs claimed at a time, which are
+	// handled concurrently. Defaults to 10.
+	BatchSize int
+
+	// PollInterval is the interval to poll for due jobs when the queue is
+	// idle. Defaults to 1s.
+	PollInterval time.Duration
+
+	Now func() time.Time
+}
+
+func (o *Options) valid() *Options {
+	o = cmp.Or(o, &Options{})
+	o.Visibility = cmp.Or(o.Visibility, 30*time.Second)
+	o.MaxRetries = cmp.Or(o.MaxRetries, 3)
+	o.BatchSize = cmp.Or(o.BatchSize, 10)
+	o.PollInterval = cmp.Or(o.PollInterval, time.Second)
+	if o.Backoff == nil {
+		o.Backoff = exponentialBackoff
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+
+	return o
+}
+
+// Queue stores the jobs under the keys prefixed with the name.
+type Queue struct {
+	client *redis.Client
+	keys   []string
+	opts   *Options
+}
+
+func New(client *redis.Client, name string, opts *Options) *Queue {
+	// The hash tag keeps the keys in the same slot for Redis Cluster.
+	prefix := "{" + name + "}"
+
+	return &Queue{
+		client: client,
+		keys:   []string{prefix + ":due", prefix + ":jobs", prefix + ":attempts"},
+		opts:   opts.valid(),
+	}
+}
+
+// Enqueue schedules the payload to be delivered at the given time, and
+// returns the job id.
+func (q *Queue) Enqueue(ctx context.Context, payload []byte, at time.Time) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.keys[1], id, payload)
+		pipe.ZAdd(ctx, q.keys[0], redis.Z{
+			Score:  float64(at.UnixMilli()),
+			Member: id,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Cancel deletes the job, and returns false if the job does not exist.
+func (q *Queue) Cancel(ctx context.Context, id string) (bool, error) {
+	var n *redis.IntCmd
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		n = pipe.ZRem(ctx, q.keys[0], id)
+		pipe.HDel(ctx, q.keys[1], id)
+		pipe.HDel(ctx, q.keys[2], id)
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return n.Val() > 0, nil
+}
+
+// Len returns the number of jobs, including those being handled.
+func (q *Queue) Len(ctx context.Context) (int64, error) {
+	return q.client.ZCard(ctx, q.keys[0]).Result()
+}
+
+// Receive handles the jobs as they become due. A job is deleted once handled
+// successfully, and otherwise retried with backoff until Options.MaxRetries.
+// The errors are sent to the channel, which must be drained.
+func (q *Queue) Receive(ctx context.Context, h Handler) (func(), <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error)
+	send := func(err error) {
+		select {
+		case <-ctx.Done():
+		case errCh <- err:
+		}
+	}
+	stop := func() {
+		cancel()
+
+		wg.Wait()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(errCh)
+		defer cancel()
+
+		t := time.NewTimer(0)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			n, err := q.receive(ctx, h, send)
+			if err != nil {
+				send(err)
+			}
+
+			// Poll again immediately while there are due jobs.
+			if n == q.opts.BatchSize {
+				t.Reset(0)
+			} else {
+				t.Reset(q.opts.PollInterval)
+			}
+		}
+	}()
+
+	return stop, errCh
+}
+
+// receive handles a batch of jobs, and returns the number of jobs claimed.
+func (q *Queue) receive(ctx context.Context, h Handler, send func(error)) (int, error) {
+	jobs, err := q.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := q.handle(ctx, h, job); err != nil {
+				send(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+func (q *Queue) claim(ctx context.Context) ([]*Job, error) {
+	now := q.opts.Now().UnixMilli()
+	res, err := claim.Run(ctx, q.client, q.keys, now, q.opts.Visibility.Milliseconds(), q.opts.BatchSize).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(res)/3)
+	for i := 0; i+2 < len(res); i += 3 {
+		id, _ := res[i].(string)
+		payload, _ := res[i+1].(string)
+		attempt, _ := res[i+2].(int64)
+		jobs = append(jobs, &Job{
+			ID:      id,
+			Payload: []byte(payload),
+			Attempt: int(attempt),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (q *Queue) handle(ctx context.Context, h Handler, job *Job) error {
+	err := h(ctx, job)
+	if err == nil {
+		return q.ack(ctx, job)
+	}
+
+	if job.Attempt > q.opts.MaxRetries {
+		if q.opts.OnDeadLetter != nil {
+			q.opts.OnDeadLetter(ctx, job, err)
+		}
+
+		return errors.Join(err, q.ack(ctx, job))
+	}
+
+	at := q.opts.Now().Add(q.opts.Backoff(job.Attempt)).UnixMilli()
+	n, rerr := reschedule.Run(ctx, q.client, []string{q.keys[0], q.keys[2]}, job.ID, job.Attempt, at).Int()
+	if rerr == nil && n == 0 {
+		rerr = ErrJobLost
+	}
+
+	return errors.Join(err, rerr)
+}
+
+// ack deletes the job, unless it has been redelivered.
+func (q *Queue) ack(ctx context.Context, job *Job) error {
+	n, err := ack.Run(ctx, q.client, q.keys, job.ID, strconv.Itoa(job.Attempt)).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrJobLost
+	}
+
+	return nil
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	return min(time.Second<<(attempt-1), time.Minute)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,196 @@
+package delay_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alextanhongpin/core/queue/delay"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+)
+
+var ctx = context.Background()
+
+func TestMain(m *testing.M) {
+	stop := redistest.Init()
+	defer stop()
+
+	m.Run()
+}
+
+func TestSchedule(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Now().UnixMilli())
+
+	q := delay.New(redistest.Client(t), t.Name(), &delay.Options{
+		PollInterval: 10 * time.Millisecond,
+		Now: func() time.Time {
+			return time.UnixMilli(now.Load())
+		},
+	})
+
+	is := assert.New(t)
+	id, err := q.Enqueue(ctx, []byte("hello"), time.UnixMilli(now.Load()).Add(time.Minute))
+	is.Nil(err)
+
+	jobs := make(chan *delay.Job)
+	stop, errCh := q.Receive(ctx, func(ctx context.Context, job *delay.Job) error {
+		jobs <- job
+		return nil
+	})
+	defer stop()
+	go drain(t, errCh)
+
+	// Not delivered before the due time.
+	select {
+	case job := <-jobs:
+		t.Fatalf("delivered before due: %v", job)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	now.Add(time.Minute.Milliseconds())
+
+	job := <-jobs
+	is.Equal(id, job.ID)
+	is.Equal("hello", string(job.Payload))
+	is.Equal(1, job.Attempt)
+
+	// Acknowledged once handled.
+	is.Eventually(func() bool {
+		n, err := q.Len(ctx)
+		return err == nil && n == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestScheduleOrder(t *testing.T) {
+	q := delay.New(redistest.Client(t), t.Name(), &delay.Options{
+		BatchSize:    1,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	is := assert.New(t)
+	now := time.Now()
+	for _, s := range []struct {
+		payload string
+		at      time.Time
+	}{
+		{"2", now.Add(-2 * time.Second)},
+		{"3", now.Add(-1 * time.Second)},
+		{"1", now.Add(-3 * time.Second)},
+	} {
+		_, err := q.Enqueue(ctx, []byte(s.payload), s.at)
+		is.Nil(err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	stop, errCh := q.Receive(ctx, func(ctx context.Context, job *delay.Job) error {
+		mu.Lock()
+		got = append(got, string(job.Payload))
+		mu.Unlock()
+
+		return nil
+	})
+	defer stop()
+	go drain(t, errCh)
+
+	is.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(got) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// Delivered by the due time, not the enqueue order.
+	is.Equal([]string{"1", "2", "3"}, got)
+}
+
+func TestCancel(t *testing.T) {
+	q := delay.New(redistest.Client(t), t.Name(), &delay.Options{
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	is := assert.New(t)
+	now := time.Now()
+	canceled, err := q.Enqueue(ctx, []byte("canceled"), now.Add(-time.Second))
+	is.Nil(err)
+	_, err = q.Enqueue(ctx, []byte("kept"), now)
+	is.Nil(err)
+
+	ok, err := q.Cancel(ctx, canceled)
+	is.Nil(err)
+	is.True(ok)
+
+	// The job no longer exists.
+	ok, err = q.Cancel(ctx, canceled)
+	is.Nil(err)
+	is.False(ok)
+
+	n, err := q.Len(ctx)
+	is.Nil(err)
+	is.Equal(int64(1), n)
+
+	jobs := make(chan *delay.Job, 2)
+	stop, errCh := q.Receive(ctx, func(ctx context.Context, job *delay.Job) error {
+		jobs <- job
+		return nil
+	})
+	go drain(t, errCh)
+
+	job := <-jobs
+	is.Equal("kept", string(job.Payload))
+
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	is.Len(jobs, 0)
+}
+
+func TestRetry(t *testing.T) {
+	var dead atomic.Pointer[delay.Job]
+	q := delay.New(redistest.Client(t), t.Name(), &delay.Options{
+		MaxRetries:   2,
+		PollInterval: 10 * time.Millisecond,
+		Backoff: func(attempt int) time.Duration {
+			return 0
+		},
+		OnDeadLetter: func(ctx context.Context, job *delay.Job, err error) {
+			dead.Store(job)
+		},
+	})
+
+	is := assert.New(t)
+	id, err := q.Enqueue(ctx, []byte("hello"), time.Now())
+	is.Nil(err)
+
+	wantErr := errors.New("want error")
+	var attempts atomic.Int64
+	stop, errCh := q.Receive(ctx, func(ctx context.Context, job *delay.Job) error {
+		attempts.Add(1)
+		return wantErr
+	})
+	defer stop()
+
+	for range 3 {
+		is.ErrorIs(<-errCh, wantErr)
+	}
+
+	// Deleted after the retries are exhausted.
+	is.Equal(int64(3), attempts.Load())
+	is.Equal(id, dead.Load().ID)
+	is.Equal(3, dead.Load().Attempt)
+
+	n, err := q.Len(ctx)
+	is.Nil(err)
+	is.Equal(int64(0), n)
+}
+
+func drain(t *testing.T, errCh <-chan error) {
+	for err := range errCh {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/alextanhongpin/core/dsync/idempotent"
+	"github.com/alextanhongpin/core/queue/pubsub"
+)
+
+type DedupOptions struct {
+	// Prefix is prepended to the message id to form the idempotency key.
+	// Defaults to "outbox:".
+	Prefix string
+
+	// LockTTL is the duration the message is locked while being handled.
+	// Defaults to 10s.
+	LockTTL time.Duration
+
+	// KeepTTL is the duration the handled message id is remembered, which
+	// should be longer than the broker redelivers for. Defaults to 24h.
+	KeepTTL time.Duration
+}
+
+// Dedup returns a subscriber middleware that handles each relayed message
+// once, keyed by the HeaderID. A duplicate received while the first is still
+// being handled fails with idempotent.ErrRequestInFlight, so that it is
+// redelivered. Messages without the header are passed through.
+func Dedup(store idempotent.Store, opts *DedupOptions) pubsub.Middleware {
+	opts = cmp.Or(opts, &DedupOptions{})
+	opts.Prefix = cmp.Or(opts.Prefix, "outbox:")
+	opts.LockTTL = cmp.Or(opts.LockTTL, 10*time.Second)
+	opts.KeepTTL = cmp.Or(opts.KeepTTL, 24*time.Hour)
+
+	return func(next pubsub.Handler) pubsub.Handler {
+		return func(ctx context.Context, msg pubsub.Message) error {
+			id := pubsub.Header(msg, HeaderID)
+			if id == "" {
+				return next(ctx, msg)
+			}
+
+			_, _, err := store.Do(ctx, opts.Prefix+id, func(ctx context.Context, _ []byte) ([]byte, error) {
+				return []byte{}, next(ctx, msg)
+			}, msg.Value(), opts.LockTTL, opts.KeepTTL)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package outbox implements the transactional outbox pattern. The messages
+// are written to a table in the same transaction as the business change, and
+// relayed to the broker afterwards, so that a message is published if and only
+// if the transaction commits.
+//
+// Messages are published at least once, since the relay may publish a batch
+// but fail to mark it as published. Use Dedup on the subscriber to skip the
+// duplicates.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+)
+
+// DefaultTable is the table used by Outbox.
+const DefaultTable = "outbox"
+
+// HeaderID carries the id of the outbox row, which uniquely identifies the
+// message.
+const HeaderID = "outbox-id"
+
+// Outbox stores the messages in a Postgres table.
+type Outbox struct {
+	Table string
+	db    *sql.DB
+}
+
+func New(db *sql.DB) *Outbox {
+	return &Outbox{
+		Table: DefaultTable,
+		db:    db,
+	}
+}
+
+// Schema returns the statement to create the table.
+func (o *Outbox) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id bigserial PRIMARY KEY,
+	key bytea,
+	value bytea NOT NULL,
+	headers jsonb NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL DEFAULT now(),
+	published_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS %[1]s_unpublished_idx ON %[1]s (id) WHERE published_at IS NULL;`, o.Table)
+}
+
+// Migrate creates the table if it does not exist.
+func (o *Outbox) Migrate(ctx context.Context) error {
+	_, err := o.db.ExecContext(ctx, o.Schema())
+	return err
+}
+
+// Enqueue writes the messages within the transaction. The headers of the
+// messages implementing pubsub.HeaderCarrier are stored too.
+func (o *Outbox) Enqueue(ctx context.Context, tx *sql.Tx, msgs ...pubsub.Message) error {
+	q := fmt.Sprintf(`INSERT INTO %s (key, value, headers) VALUES ($1, $2, $3)`, o.Table)
+	for _, msg := range msgs {
+		headers := make(map[string]string)
+		if h, ok := msg.(pubsub.HeaderCarrier); ok {
+			for _, k := range h.HeaderKeys() {
+				headers[k] = h.GetHeader(k)
+			}
+		}
+
+		b, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, q, msg.Key(), msg.Value(), b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeletePublished deletes the published messages, and returns the number of
+// rows deleted.
+func (o *Outbox) DeletePublished(ctx context.Context) (int64, error) {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE published_at IS NOT NULL`, o.Table)
+	res, err := o.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// placeholders returns "$1, $2, ..., $n".
+func placeholders(n int) string {
+	s := make([]string, n)
+	for i := range n {
+		s[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return strings.Join(s, ", ")
+}
+
+var (
+	_ pubsub.Message       = (*message)(nil)
+	_ pubsub.HeaderCarrier = (*message)(nil)
+)
+
+type message struct {
+	id      int64
+	key     []byte
+	value   []byte
+	headers map[string]string
+}
+
+func (m *message) Key() []byte {
+	return m.key
+}
+
+func (m *message) Value() []byte {
+	return m.value
+}
+
+func (m *message) GetHeader(key string) string {
+	return m.headers[key]
+}
+
+func (m *message) SetHeader(key, value string) {
+	m.headers[key] = value
+}
+
+func (m *message) HeaderKeys() []string {
+	keys := make([]string, 0, len(m.headers))
+	for k := range m.headers {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/alextanhongpin/core/sync/poll"
+)
+
+type RelayOptions struct {
+	// Limit is the number of messages published at a time. Defaults to 100.
+	Limit int
+
+	// Poll schedules the relay. Defaults to poll.New with MaxConcurrency of 1,
+	// which preserves the order of the messages within a process.
+	Poll *poll.Poll
+}
+
+// Relay publishes the unpublished messages in order, and marks them as
+// published. The events must be drained, and the returned function stops the
+// relay.
+//
+// The rows are locked with SKIP LOCKED, so multiple relays can run
+// concurrently, at the cost of ordering across relays.
+func (o *Outbox) Relay(pub pubsub.Producer, opts *RelayOptions) (<-chan poll.Event, func()) {
+	opts = cmp.Or(opts, &RelayOptions{})
+	opts.Limit = cmp.Or(opts.Limit, 100)
+	if opts.Poll == nil {
+		opts.Poll = poll.New()
+		opts.Poll.MaxConcurrency = 1
+	}
+
+	return opts.Poll.Poll(func(ctx context.Context) error {
+		n, err := o.relay(ctx, pub, opts.Limit)
+		if err != nil {
+			return err
+		}
+		if n < opts.Limit {
+			return poll.EOQ
+		}
+
+		return nil
+	})
+}
+
+// relay publishes a batch of messages, and returns the number of messages
+// published.
+func (o *Outbox) relay(ctx context.Context, pub pubsub.Producer, limit int) (int, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	msgs, err := o.lock(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	m := make([]pubsub.Message, len(msgs))
+	ids := make([]any, len(msgs))
+	for i, msg := range msgs {
+		m[i] = msg
+		ids[i] = msg.id
+	}
+
+	if err := pub.Publish(ctx, m...); err != nil {
+		return 0, err
+	}
+
+	q := fmt.Sprintf(`UPDATE %s SET published_at = now() WHERE id IN (%s)`, o.Table, placeholders(len(ids)))
+	if _, err := tx.ExecContext(ctx, q, ids...); err != nil {
+		return 0, err
+	}
+
+	return len(msgs), tx.Commit()
+}
+
+// lock selects the unpublished messages, skipping those locked by other
+// relays.
+func (o *Outbox) lock(ctx context.Context, tx *sql.Tx, limit int) ([]*message, error) {
+	q := fmt.Sprintf(`
+		SELECT id, key, value, headers
+		FROM %s
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, o.Table)
+
+	rows, err := tx.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*message
+	for rows.Next() {
+		var (
+			msg     message
+			headers []byte
+		)
+		if err := rows.Scan(&msg.id, &msg.key, &msg.value, &headers); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(headers, &msg.headers); err != nil {
+			return nil, err
+		}
+		if msg.headers == nil {
+			msg.headers = make(map[string]string)
+		}
+		msg.headers[HeaderID] = strconv.FormatInt(msg.id, 10)
+
+		msgs = append(msgs, &msg)
+	}
+
+	return msgs, rows.Err()
+}
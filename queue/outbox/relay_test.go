@@ -0,0 +1,209 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/outbox"
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/alextanhongpin/core/storage/pg/pgtest"
+	"github.com/alextanhongpin/core/sync/poll"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func TestMain(m *testing.M) {
+	stop := pgtest.Init()
+	defer stop()
+
+	m.Run()
+}
+
+func TestRelay(t *testing.T) {
+	o, db := newOutbox(t)
+
+	is := assert.New(t)
+	is.Nil(enqueue(db, o, "1", "2", "3"))
+
+	pub := new(publisher)
+	events, stop := o.Relay(pub, &outbox.RelayOptions{
+		Limit: 2,
+		Poll:  newPoll(),
+	})
+	defer stop()
+	go drain(t, events)
+
+	is.Eventually(func() bool {
+		return len(pub.published()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Published in order, with the headers and the id of the row.
+	msgs := pub.published()
+	ids := make(map[string]bool)
+	for i, msg := range msgs {
+		is.Equal(fmt.Sprint(i+1), string(msg.Value()))
+		is.Equal("key", string(msg.Key()))
+		is.Equal("bar", pubsub.Header(msg, "foo"))
+
+		id := pubsub.Header(msg, outbox.HeaderID)
+		is.NotEmpty(id)
+		is.False(ids[id])
+		ids[id] = true
+	}
+
+	is.Eventually(func() bool {
+		return unpublished(t, db, o) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	n, err := o.DeletePublished(ctx)
+	is.Nil(err)
+	is.Equal(int64(3), n)
+}
+
+func TestRelayRollback(t *testing.T) {
+	o, db := newOutbox(t)
+
+	is := assert.New(t)
+	tx, err := db.BeginTx(ctx, nil)
+	is.Nil(err)
+	is.Nil(o.Enqueue(ctx, tx, newMessage("1")))
+	is.Nil(tx.Rollback())
+
+	// Nothing is written when the transaction is rolled back.
+	is.Equal(0, unpublished(t, db, o))
+}
+
+func TestRelayPublishFailed(t *testing.T) {
+	o, db := newOutbox(t)
+
+	is := assert.New(t)
+	is.Nil(enqueue(db, o, "1"))
+
+	wantErr := errors.New("want error")
+	pub := &publisher{err: wantErr}
+	events, stop := o.Relay(pub, &outbox.RelayOptions{
+		Poll: newPoll(),
+	})
+
+	for e := range events {
+		if e.Name == "error" {
+			is.ErrorIs(e.Err, wantErr)
+			break
+		}
+	}
+	go func() {
+		for range events {
+		}
+	}()
+	stop()
+
+	// The messages are published again on the next relay.
+	is.Equal(1, unpublished(t, db, o))
+}
+
+type publisher struct {
+	err error
+
+	mu   sync.Mutex
+	msgs []pubsub.Message
+}
+
+func (p *publisher) Publish(ctx context.Context, msgs ...pubsub.Message) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.mu.Lock()
+	p.msgs = append(p.msgs, msgs...)
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *publisher) published() []pubsub.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]pubsub.Message(nil), p.msgs...)
+}
+
+// newOutbox returns an outbox with a table of its own, since the rows are not
+// rolled back with pgtest.DB.
+func newOutbox(t *testing.T) (*outbox.Outbox, *sql.DB) {
+	t.Helper()
+
+	db := pgtest.DB(t)
+	o := outbox.New(db)
+	o.Table = fmt.Sprintf("outbox_%x", uuid.New().ID())
+	if err := o.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(fmt.Sprintf("DROP TABLE %s", o.Table))
+	})
+
+	return o, db
+}
+
+func newPoll() *poll.Poll {
+	p := poll.New()
+	p.BatchSize = 1
+	p.MaxConcurrency = 1
+	p.BackOff = poll.Interval(10 * time.Millisecond)
+
+	return p
+}
+
+func newMessage(value string) pubsub.Message {
+	return pubsub.NewMessage(kafka.Message{
+		Key:     []byte("key"),
+		Value:   []byte(value),
+		Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+	})
+}
+
+func enqueue(db *sql.DB, o *outbox.Outbox, values ...string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	msgs := make([]pubsub.Message, len(values))
+	for i, v := range values {
+		msgs[i] = newMessage(v)
+	}
+	if err := o.Enqueue(ctx, tx, msgs...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func unpublished(t *testing.T, db *sql.DB, o *outbox.Outbox) int {
+	t.Helper()
+
+	var n int
+	q := fmt.Sprintf(`SELECT count(*) FROM %s WHERE published_at IS NULL`, o.Table)
+	if err := db.QueryRowContext(ctx, q).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func drain(t *testing.T, events <-chan poll.Event) {
+	for e := range events {
+		if e.Name == "error" {
+			t.Error(e.Err)
+		}
+	}
+}
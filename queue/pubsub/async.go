@@ -0,0 +1,254 @@
+package pubsub
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrBufferFull = errors.New("pubsub: buffer full")
+	ErrClosed     = errors.New("pubsub: publisher closed")
+)
+
+type batchPublisher interface {
+	Publish(ctx context.Context, msgs ...Message) error
+}
+
+type AsyncOptions struct {
+	// BufferSize is the maximum number of messages waiting to be published.
+	// Defaults to 1000.
+	BufferSize int
+
+	// BatchSize is the maximum number of messages published at once.
+	// Defaults to 100.
+	BatchSize int
+
+	// Linger is how long to wait for the batch to fill up before publishing.
+	// Defaults to 100ms.
+	Linger time.Duration
+
+	// DropWhenFull returns ErrBufferFull instead of blocking when the buffer
+	// is full.
+	DropWhenFull bool
+
+	// OnError is called with the messages when publishing a batch fails.
+	// Failed messages are not retried.
+	OnError func(msgs []Message, err error)
+}
+
+type AsyncMetrics struct {
+	// Depth is the number of messages buffered, but not published yet.
+	Depth int64
+	// Published is the number of messages published.
+	Published int64
+	// Dropped is the number of messages rejected because the buffer is full.
+	Dropped int64
+	// Failed is the number of messages that failed to publish.
+	Failed int64
+	// Latency is the time taken to publish the last batch.
+	Latency time.Duration
+}
+
+// AsyncPublisher buffers the messages in memory, and publishes them in
+// batches in the background.
+// Messages that are buffered are lost if the process crashes.
+type AsyncPublisher struct {
+	pub  batchPublisher
+	opts *AsyncOptions
+
+	buf     chan Message
+	flushCh chan chan error
+	done    chan struct{}
+
+	depth     atomic.Int64
+	published atomic.Int64
+	dropped   atomic.Int64
+	failed    atomic.Int64
+	latency   atomic.Int64
+}
+
+// NewAsyncPublisher returns an AsyncPublisher that publishes through the
+// given publisher, e.g. *Publisher. The returned function stops the
+// publisher after publishing the remaining buffered messages.
+func NewAsyncPublisher(pub batchPublisher, opts *AsyncOptions) (*AsyncPublisher, func()) {
+	opts = cmp.Or(opts, &AsyncOptions{})
+	opts.BufferSize = cmp.Or(opts.BufferSize, 1_000)
+	opts.BatchSize = cmp.Or(opts.BatchSize, 100)
+	opts.Linger = cmp.Or(opts.Linger, 100*time.Millisecond)
+
+	p := &AsyncPublisher{
+		pub:     pub,
+		opts:    opts,
+		buf:     make(chan Message, opts.BufferSize),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		p.loop()
+	}()
+
+	return p, sync.OnceFunc(func() {
+		close(p.done)
+		wg.Wait()
+	})
+}
+
+// Publish adds the messages to the buffer. When the buffer is full, it blocks
+// until there is space, or returns ErrBufferFull if DropWhenFull is set.
+func (p *AsyncPublisher) Publish(ctx context.Context, msgs ...Message) error {
+	for _, msg := range msgs {
+		if err := p.enqueue(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush publishes all the buffered messages, and returns the first error.
+func (p *AsyncPublisher) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-p.done:
+		return ErrClosed
+	case p.flushCh <- reply:
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case err := <-reply:
+		return err
+	}
+}
+
+func (p *AsyncPublisher) Metrics() AsyncMetrics {
+	return AsyncMetrics{
+		Depth:     p.depth.Load(),
+		Published: p.published.Load(),
+		Dropped:   p.dropped.Load(),
+		Failed:    p.failed.Load(),
+		Latency:   time.Duration(p.latency.Load()),
+	}
+}
+
+func (p *AsyncPublisher) enqueue(ctx context.Context, msg Message) error {
+	// Checked first, since select picks randomly when the buffer has space.
+	select {
+	case <-p.done:
+		return ErrClosed
+	default:
+	}
+
+	if p.opts.DropWhenFull {
+		select {
+		case p.buf <- msg:
+			p.depth.Add(1)
+			return nil
+		default:
+			p.dropped.Add(1)
+			return ErrBufferFull
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-p.done:
+		return ErrClosed
+	case p.buf <- msg:
+		p.depth.Add(1)
+		return nil
+	}
+}
+
+func (p *AsyncPublisher) loop() {
+	batch := make([]Message, 0, p.opts.BatchSize)
+
+	t := time.NewTimer(p.opts.Linger)
+	t.Stop()
+
+	// linger is nil when there is no pending batch.
+	var linger <-chan time.Time
+
+	send := func() error {
+		linger = nil
+		t.Stop()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := p.send(batch)
+		batch = batch[:0]
+
+		return err
+	}
+
+	// drain publishes all the buffered messages.
+	drain := func() error {
+		var errs []error
+		for {
+			select {
+			case msg := <-p.buf:
+				batch = append(batch, msg)
+				if len(batch) >= p.opts.BatchSize {
+					errs = append(errs, send())
+				}
+			default:
+				errs = append(errs, send())
+				return errors.Join(errs...)
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-p.done:
+			_ = drain()
+			return
+		case reply := <-p.flushCh:
+			reply <- drain()
+		case msg := <-p.buf:
+			batch = append(batch, msg)
+			if len(batch) >= p.opts.BatchSize {
+				_ = send()
+			} else if linger == nil {
+				t.Reset(p.opts.Linger)
+				linger = t.C
+			}
+		case <-linger:
+			_ = send()
+		}
+	}
+}
+
+func (p *AsyncPublisher) send(msgs []Message) error {
+	start := time.Now()
+	err := p.pub.Publish(context.Background(), msgs...)
+	p.latency.Store(int64(time.Since(start)))
+	p.depth.Add(-int64(len(msgs)))
+
+	if err != nil {
+		p.failed.Add(int64(len(msgs)))
+		if p.opts.OnError != nil {
+			p.opts.OnError(append([]Message(nil), msgs...), err)
+		}
+
+		return err
+	}
+	p.published.Add(int64(len(msgs)))
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncPublisherBatchSize(t *testing.T) {
+	pub := new(publisher)
+	p, stop := pubsub.NewAsyncPublisher(pub, &pubsub.AsyncOptions{
+		BatchSize: 3,
+		Linger:    time.Hour,
+	})
+	defer stop()
+
+	is := assert.New(t)
+	is.Nil(p.Publish(ctx, newMessages(0, 7)...))
+	is.Eventually(func() bool {
+		return len(pub.published()) == 2
+	}, time.Second, 10*time.Millisecond)
+	is.Equal([][]string{{"0", "1", "2"}, {"3", "4", "5"}}, pub.published())
+	is.Equal(int64(1), p.Metrics().Depth)
+}
+
+func TestAsyncPublisherLinger(t *testing.T) {
+	pub := new(publisher)
+	p, stop := pubsub.NewAsyncPublisher(pub, &pubsub.AsyncOptions{
+		Linger: 20 * time.Millisecond,
+	})
+	defer stop()
+
+	is := assert.New(t)
+	start := time.Now()
+	is.Nil(p.Publish(ctx, newMessages(0, 2)...))
+	is.Eventually(func() bool {
+		return len(pub.published()) == 1
+	}, time.Second, 5*time.Millisecond)
+	is.GreaterOrEqual(time.Since(start), 20*time.Millisecond)
+	is.Equal([][]string{{"0", "1"}}, pub.published())
+
+	m := p.Metrics()
+	is.Equal(int64(0), m.Depth)
+	is.Equal(int64(2), m.Published)
+}
+
+func TestAsyncPublisherFlush(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	var failed []pubsub.Message
+	pub := new(publisher)
+	p, stop := pubsub.NewAsyncPublisher(pub, &pubsub.AsyncOptions{
+		Linger: time.Hour,
+		OnError: func(msgs []pubsub.Message, err error) {
+			failed = msgs
+		},
+	})
+	defer stop()
+
+	is := assert.New(t)
+	is.Nil(p.Publish(ctx, newMessages(0, 1)...))
+	is.Nil(p.Flush(ctx))
+	is.Equal([][]string{{"0"}}, pub.published())
+
+	pub.fail(wantErr)
+	is.Nil(p.Publish(ctx, newMessages(1, 3)...))
+	is.ErrorIs(p.Flush(ctx), wantErr)
+	is.Len(failed, 2)
+	is.Equal(int64(2), p.Metrics().Failed)
+	is.Equal(int64(0), p.Metrics().Depth)
+}
+
+func TestAsyncPublisherStop(t *testing.T) {
+	pub := new(publisher)
+	p, stop := pubsub.NewAsyncPublisher(pub, &pubsub.AsyncOptions{
+		BatchSize: 2,
+		Linger:    time.Hour,
+	})
+
+	is := assert.New(t)
+	is.Nil(p.Publish(ctx, newMessages(0, 5)...))
+
+	// The buffered messages are published on stop.
+	stop()
+	is.Equal([][]string{{"0", "1"}, {"2", "3"}, {"4"}}, pub.published())
+
+	is.ErrorIs(p.Publish(ctx, newMessages(5, 6)...), pubsub.ErrClosed)
+	is.ErrorIs(p.Flush(ctx), pubsub.ErrClosed)
+}
+
+func TestAsyncPublisherDropWhenFull(t *testing.T) {
+	pub := new(publisher)
+	pub.block = make(chan struct{})
+	pub.started = make(chan struct{}, 1)
+
+	p, stop := pubsub.NewAsyncPublisher(pub, &pubsub.AsyncOptions{
+		BufferSize:   1,
+		BatchSize:    1,
+		DropWhenFull: true,
+	})
+	defer stop()
+
+	is := assert.New(t)
+	is.Nil(p.Publish(ctx, newMessages(0, 1)...))
+	<-pub.started
+
+	// The first message is being published, and the second fills the buffer.
+	is.Nil(p.Publish(ctx, newMessages(1, 2)...))
+	is.ErrorIs(p.Publish(ctx, newMessages(2, 3)...), pubsub.ErrBufferFull)
+	is.Equal(int64(1), p.Metrics().Dropped)
+
+	close(pub.block)
+}
+
+var ctx = context.Background()
+
+func newMessages(from, to int) []pubsub.Message {
+	var msgs []pubsub.Message
+	for i := from; i < to; i++ {
+		msgs = append(msgs, pubsub.NewMessage(kafka.Message{
+			Value: []byte(strconv.Itoa(i)),
+		}))
+	}
+
+	return msgs
+}
+
+type publisher struct {
+	// block blocks the publish until closed, and started is sent before.
+	block   chan struct{}
+	started chan struct{}
+
+	mu      sync.Mutex
+	err     error
+	batches [][]string
+}
+
+func (p *publisher) Publish(ctx context.Context, msgs ...pubsub.Message) error {
+	if p.block != nil {
+		select {
+		case p.started <- struct{}{}:
+		default:
+		}
+		<-p.block
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	// The batch is reused by the caller.
+	batch := make([]string, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = string(msg.Value())
+	}
+	p.batches = append(p.batches, batch)
+
+	return nil
+}
+
+func (p *publisher) fail(err error) {
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+}
+
+func (p *publisher) published() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([][]string(nil), p.batches...)
+}
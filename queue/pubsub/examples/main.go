@@ -2,12 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/alextanhongpin/core/queue/pubsub"
 	"github.com/segmentio/kafka-go"
@@ -30,28 +30,32 @@ var EventPublisher = pubsub.NewPublisher(
 	},
 )
 
-var EventRetryPublisher = pubsub.NewPublisher(
-	&kafka.Writer{
+// The failed messages are republished to the retry topic, and to the
+// dead-letter topic "events-dlq" once the retries are exhausted.
+var retryOptions = &pubsub.Options{
+	Writer: &kafka.Writer{
 		Addr:     kafka.TCP(kafkaHost),
-		Topic:    eventsRetryTopic,
 		Balancer: &kafka.Hash{},
 	},
-)
+	MaxRetries: 3,
+}
 
-var EventSubscriber = pubsub.NewSubscriber(
+var EventSubscriber = pubsub.NewSubscriberWithOptions(
 	kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{kafkaHost},
 		GroupID: consumerGroup,
 		Topic:   eventsTopic,
 	}),
+	retryOptions,
 )
 
-var EventRetrySubscriber = pubsub.NewSubscriber(
+var EventRetrySubscriber = pubsub.NewSubscriberWithOptions(
 	kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{kafkaHost},
 		GroupID: consumerGroup,
 		Topic:   eventsRetryTopic,
 	}),
+	retryOptions,
 )
 
 func main() {
@@ -67,23 +71,19 @@ func main() {
 		ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
-		stop, errCh := EventSubscriber.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
-			fmt.Printf("received: key=%s value=%s\n", msg.Key(), msg.Value())
-			return EventRetryPublisher.Publish(ctx, msg)
-		})
+		handler := func(ctx context.Context, msg pubsub.Message) error {
+			kmsg := pubsub.AsKafkaMessage(msg)
+			fmt.Printf("received: topic=%s key=%s value=%s\n", kmsg.Topic, msg.Key(), msg.Value())
+
+			return errors.New("failed")
+		}
+
+		stop, errCh := EventSubscriber.Receive(ctx, handler)
 		defer stop()
 
-		stopRetry, retryErrCh := EventRetrySubscriber.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
-			kmsg := pubsub.AsKafkaMessage(msg)
-			// Retry after 10 seconds.
-			sleep := kmsg.Time.Add(10 * time.Second).Sub(time.Now())
-			fmt.Printf("received dead letter: key=%s value=%s retry-after=%s\n", msg.Key(), msg.Value(), sleep)
-			time.Sleep(sleep)
-
-			fmt.Println("retried successfully")
-			// TODO: Save to database if still fails.
-			return nil
-		})
+		// The retry topic is consumed with the same handler. Messages are
+		// delivered after the backoff.
+		stopRetry, retryErrCh := EventRetrySubscriber.Receive(ctx, handler)
 		defer stopRetry()
 
 		for {
@@ -0,0 +1,165 @@
+package pubsub
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// HeaderSchema identifies the schema and version of the message value, e.g.
+// "order.created/v1".
+const HeaderSchema = "pubsub-schema"
+
+var ErrSchemaMismatch = errors.New("pubsub: schema mismatch")
+
+type JSONOptions struct {
+	// Schema is set on the published messages, and checked on the received
+	// messages. Received messages without the header are accepted. Empty
+	// disables the check.
+	Schema string
+
+	// OnDecodeError is called when the message cannot be decoded, or has a
+	// different schema. Returning nil skips the message. Defaults to returning
+	// the error.
+	OnDecodeError func(ctx context.Context, msg Message, err error) error
+}
+
+func (o *JSONOptions) valid() *JSONOptions {
+	o = cmp.Or(o, &JSONOptions{})
+	if o.OnDecodeError == nil {
+		o.OnDecodeError = func(_ context.Context, _ Message, err error) error {
+			return err
+		}
+	}
+
+	return o
+}
+
+var (
+	_ Message       = (*JSONMessage[any])(nil)
+	_ HeaderCarrier = (*JSONMessage[any])(nil)
+)
+
+// JSONMessage is a message with the value encoded as JSON.
+type JSONMessage[T any] struct {
+	Data    T
+	Headers map[string]string
+	key     []byte
+	value   []byte
+}
+
+func NewJSONMessage[T any](key []byte, data T) *JSONMessage[T] {
+	return &JSONMessage[T]{
+		Data:    data,
+		Headers: make(map[string]string),
+		key:     key,
+	}
+}
+
+func (m *JSONMessage[T]) Key() []byte {
+	return m.key
+}
+
+// Value returns the encoded data. It is only set once the message is
+// published or received.
+func (m *JSONMessage[T]) Value() []byte {
+	return m.value
+}
+
+func (m *JSONMessage[T]) GetHeader(key string) string {
+	return m.Headers[key]
+}
+
+func (m *JSONMessage[T]) SetHeader(key, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[key] = value
+}
+
+func (m *JSONMessage[T]) HeaderKeys() []string {
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// JSONPublisher encodes the messages as JSON before publishing.
+type JSONPublisher[T any] struct {
+	pub  Producer
+	opts *JSONOptions
+}
+
+func NewJSONPublisher[T any](pub Producer, opts *JSONOptions) *JSONPublisher[T] {
+	return &JSONPublisher[T]{
+		pub:  pub,
+		opts: opts.valid(),
+	}
+}
+
+// Publish encodes and publishes the messages. Nothing is published if any
+// message cannot be encoded.
+func (p *JSONPublisher[T]) Publish(ctx context.Context, msgs ...*JSONMessage[T]) error {
+	m := make([]Message, len(msgs))
+	for i, msg := range msgs {
+		b, err := json.Marshal(msg.Data)
+		if err != nil {
+			return err
+		}
+		msg.value = b
+		if p.opts.Schema != "" {
+			msg.SetHeader(HeaderSchema, p.opts.Schema)
+		}
+
+		m[i] = msg
+	}
+
+	return p.pub.Publish(ctx, m...)
+}
+
+// JSONSubscriber decodes the received messages from JSON.
+type JSONSubscriber[T any] struct {
+	sub  Consumer
+	opts *JSONOptions
+}
+
+func NewJSONSubscriber[T any](sub Consumer, opts *JSONOptions) *JSONSubscriber[T] {
+	return &JSONSubscriber[T]{
+		sub:  sub,
+		opts: opts.valid(),
+	}
+}
+
+// Receive handles the decoded messages. Messages that cannot be decoded are
+// passed to Options.OnDecodeError instead.
+func (s *JSONSubscriber[T]) Receive(ctx context.Context, h func(ctx context.Context, msg *JSONMessage[T]) error) (func(), <-chan error) {
+	return s.sub.Receive(ctx, func(ctx context.Context, msg Message) error {
+		m, err := s.decode(msg)
+		if err != nil {
+			return s.opts.OnDecodeError(ctx, msg, err)
+		}
+
+		return h(ctx, m)
+	})
+}
+
+func (s *JSONSubscriber[T]) decode(msg Message) (*JSONMessage[T], error) {
+	if schema := Header(msg, HeaderSchema); s.opts.Schema != "" && schema != "" && schema != s.opts.Schema {
+		return nil, fmt.Errorf("%w: want %q, got %q", ErrSchemaMismatch, s.opts.Schema, schema)
+	}
+
+	var data T
+	if err := json.Unmarshal(msg.Value(), &data); err != nil {
+		return nil, err
+	}
+
+	m := NewJSONMessage(msg.Key(), data)
+	m.value = msg.Value()
+	CopyHeaders(m, msg)
+
+	return m, nil
+}
@@ -0,0 +1,175 @@
+package pubsub
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Key returns the key of the Kafka message, which is the usual KeyFn.
+func Key(msg Message) string {
+	return string(msg.Key())
+}
+
+// receiveKeyed dispatches the messages to the workers by the hash of their
+// key, so that the messages with the same key are handled in order by the
+// same worker.
+// The offsets are committed only when all the earlier messages of the
+// partition are handled, so that no message is skipped on restart.
+// When a message fails, no more messages are fetched, and the worker skips
+// the remaining messages to preserve the order of their keys. The other
+// workers finish their queued messages before the error is returned.
+// When stopped, the workers only finish the messages in flight.
+func (s *Subscriber) receiveKeyed(ctx, hctx context.Context, h Handler) error {
+	fetchCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg      sync.WaitGroup
+		offsets = newOffsetTracker()
+		queues  = make([]chan kafka.Message, s.opts.Workers)
+	)
+
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, s.opts.QueueSize)
+
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+
+			var failed bool
+			for msg := range queue {
+				if failed || ctx.Err() != nil {
+					continue
+				}
+
+				if err := s.process(ctx, hctx, h, msg); err != nil {
+					failed = true
+					cancel(err)
+
+					continue
+				}
+
+				if err := offsets.done(msg, func(msg kafka.Message) error {
+					return s.reader.CommitMessages(hctx, msg)
+				}); err != nil {
+					failed = true
+					cancel(err)
+				}
+			}
+		}(queues[i])
+	}
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+
+		wg.Wait()
+	}()
+
+	for {
+		msg, err := s.reader.FetchMessage(fetchCtx)
+		if err != nil {
+			return cause(fetchCtx, err)
+		}
+		offsets.add(msg)
+
+		queue := queues[hash(s.opts.KeyFn(NewMessage(msg)))%uint32(len(queues))]
+		select {
+		case <-fetchCtx.Done():
+			return context.Cause(fetchCtx)
+		case queue <- msg:
+		}
+	}
+}
+
+// cause returns the cause of the cancellation, which is the error of the
+// failed message, instead of context.Canceled.
+func cause(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+
+	return err
+}
+
+func hash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return h.Sum32()
+}
+
+type partition struct {
+	topic string
+	id    int
+}
+
+// offsetTracker tracks the messages handled out of order, and commits the
+// offsets in order per partition.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partition]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	// pending are the offsets in the order they are fetched.
+	pending []int64
+	done    map[int64]kafka.Message
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partition]*partitionOffsets),
+	}
+}
+
+func (t *offsetTracker) add(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := partition{msg.Topic, msg.Partition}
+	p, ok := t.partitions[k]
+	if !ok {
+		p = &partitionOffsets{
+			done: make(map[int64]kafka.Message),
+		}
+		t.partitions[k] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+}
+
+// done marks the message as handled, and commits the last message of the
+// partition where all the earlier messages are handled.
+// The commit is called under the lock, so that the offsets are committed in
+// order.
+func (t *offsetTracker) done(msg kafka.Message, commit func(kafka.Message) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partitions[partition{msg.Topic, msg.Partition}]
+	p.done[msg.Offset] = msg
+
+	var (
+		last kafka.Message
+		ok   bool
+	)
+	for len(p.pending) > 0 {
+		m, found := p.done[p.pending[0]]
+		if !found {
+			break
+		}
+
+		delete(p.done, p.pending[0])
+		p.pending = p.pending[1:]
+		last, ok = m, true
+	}
+	if !ok {
+		return nil
+	}
+
+	return commit(last)
+}
@@ -0,0 +1,149 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriberKeyedOrder(t *testing.T) {
+	r := newReader()
+	sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+		KeyFn:   pubsub.Key,
+		Workers: 4,
+	})
+
+	var (
+		mu  sync.Mutex
+		got = make(map[string][]string)
+	)
+	stop, errCh := sub.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
+		// The earlier messages take longer.
+		i, _ := strconv.Atoi(string(msg.Value()))
+		time.Sleep(time.Duration(20-i) * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := string(msg.Key())
+		got[key] = append(got[key], string(msg.Value()))
+
+		return nil
+	})
+	defer stop()
+	go func() {
+		for range errCh {
+		}
+	}()
+
+	keys := []string{"a", "b", "c", "d"}
+	want := make(map[string][]string)
+	for i := range 20 {
+		key := keys[i%len(keys)]
+		value := fmt.Sprint(i)
+		want[key] = append(want[key], value)
+
+		r.msgs <- kafka.Message{Key: []byte(key), Value: []byte(value), Offset: int64(i)}
+	}
+
+	// The messages of the same key are handled in order.
+	is := assert.New(t)
+	is.Eventually(func() bool {
+		return len(r.committed()) > 0 && r.committed()[len(r.committed())-1].Offset == 19
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	is.Equal(want, got)
+}
+
+func TestSubscriberKeyedOffsets(t *testing.T) {
+	r := newReader()
+	sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+		KeyFn: pubsub.Key,
+		// The keys "a" and "b" are dispatched to different workers.
+		Workers: 2,
+	})
+
+	release := make(chan struct{})
+	handled := make(chan string, 3)
+	stop, errCh := sub.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
+		if string(msg.Value()) == "slow" {
+			<-release
+		}
+		handled <- string(msg.Value())
+
+		return nil
+	})
+	defer stop()
+	go func() {
+		for range errCh {
+		}
+	}()
+
+	r.msgs <- kafka.Message{Partition: 0, Offset: 10, Key: []byte("a"), Value: []byte("slow")}
+	r.msgs <- kafka.Message{Partition: 0, Offset: 11, Key: []byte("b"), Value: []byte("fast")}
+	r.msgs <- kafka.Message{Partition: 1, Offset: 20, Key: []byte("b"), Value: []byte("other")}
+
+	is := assert.New(t)
+	is.Equal("fast", <-handled)
+	is.Equal("other", <-handled)
+
+	// The other partitions are committed independently.
+	is.Eventually(func() bool {
+		return len(r.committed()) == 1
+	}, time.Second, 10*time.Millisecond)
+	is.Equal(1, r.committed()[0].Partition)
+	is.Equal(int64(20), r.committed()[0].Offset)
+
+	// Offset 11 is not committed until the earlier offset is handled.
+	time.Sleep(50 * time.Millisecond)
+	is.Len(r.committed(), 1)
+
+	close(release)
+	is.Equal("slow", <-handled)
+
+	// Only the last handled offset is committed.
+	is.Eventually(func() bool {
+		return len(r.committed()) == 2
+	}, time.Second, 10*time.Millisecond)
+	is.Equal(0, r.committed()[1].Partition)
+	is.Equal(int64(11), r.committed()[1].Offset)
+}
+
+func TestSubscriberKeyedFailed(t *testing.T) {
+	r := newReader()
+	sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+		KeyFn:   pubsub.Key,
+		Workers: 2,
+	})
+
+	release := make(chan struct{})
+	stop, errCh := sub.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
+		if string(msg.Value()) == "fail" {
+			<-release
+			return errHandler
+		}
+
+		return nil
+	})
+	defer stop()
+
+	r.msgs <- kafka.Message{Offset: 0, Key: []byte("a"), Value: []byte("fail")}
+	r.msgs <- kafka.Message{Offset: 1, Key: []byte("b"), Value: []byte("ok")}
+	close(release)
+
+	is := assert.New(t)
+	is.ErrorIs(<-errCh, errHandler)
+
+	// The offset after the failed message is not committed, so that the
+	// failed message is fetched again.
+	is.Empty(r.committed())
+}
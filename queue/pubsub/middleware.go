@@ -0,0 +1,71 @@
+package pubsub
+
+import (
+	"context"
+	"slices"
+)
+
+// Middleware wraps a Handler with cross-cutting concerns, e.g. logging,
+// metrics, tracing or validation. The same middleware can be applied to both
+// the Publisher and the Subscriber.
+type Middleware func(Handler) Handler
+
+// Chain composes the middlewares, with the first middleware being the
+// outermost.
+func Chain(mws ...Middleware) Middleware {
+	return func(h Handler) Handler {
+		for _, mw := range slices.Backward(mws) {
+			h = mw(h)
+		}
+
+		return h
+	}
+}
+
+// Use appends the middlewares applied to every published message.
+func (p *Publisher) Use(mws ...Middleware) {
+	p.mws = append(p.mws, mws...)
+}
+
+// Use appends the middlewares applied to every received message.
+func (s *Subscriber) Use(mws ...Middleware) {
+	s.mws = append(s.mws, mws...)
+}
+
+// SetHeader sets the header of the message, replacing the existing value.
+// It is a no-op for messages that do not carry headers.
+func SetHeader(msg Message, key, value string) {
+	if m, ok := msg.(HeaderCarrier); ok {
+		m.SetHeader(key, value)
+	}
+}
+
+// Header returns the header of the message.
+func Header(msg Message, key string) string {
+	if m, ok := msg.(HeaderCarrier); ok {
+		return m.GetHeader(key)
+	}
+
+	return ""
+}
+
+// CopyHeaders copies the headers of src to dst, e.g. when converting the
+// message for another broker.
+func CopyHeaders(dst, src Message) {
+	s, ok := src.(HeaderCarrier)
+	if !ok {
+		return
+	}
+
+	for _, k := range s.HeaderKeys() {
+		SetHeader(dst, k, s.GetHeader(k))
+	}
+}
+
+func handle(ctx context.Context, mws []Middleware, h Handler, msg Message) error {
+	if len(mws) == 0 {
+		return h(ctx, msg)
+	}
+
+	return Chain(mws...)(h)(ctx, msg)
+}
@@ -0,0 +1,155 @@
+// Package natsjs implements the pubsub Producer and Consumer on top of
+// NATS JetStream.
+package natsjs
+
+import (
+	"context"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HeaderKey is the header carrying the message key, since NATS messages do
+// not have keys.
+const HeaderKey = "pubsub-key"
+
+var (
+	_ pubsub.Message       = (*Message)(nil)
+	_ pubsub.HeaderCarrier = (*Message)(nil)
+	_ pubsub.Producer      = (*Publisher)(nil)
+)
+
+type Message struct {
+	Header nats.Header
+	key    []byte
+	value  []byte
+	msg    jetstream.Msg
+}
+
+func NewMessage(key, value []byte) *Message {
+	return &Message{
+		Header: make(nats.Header),
+		key:    key,
+		value:  value,
+	}
+}
+
+func fromMsg(msg jetstream.Msg) *Message {
+	h := msg.Headers()
+	if h == nil {
+		h = make(nats.Header)
+	}
+
+	return &Message{
+		Header: h,
+		key:    []byte(h.Get(HeaderKey)),
+		value:  msg.Data(),
+		msg:    msg,
+	}
+}
+
+// Msg returns the underlying JetStream message, which is nil for messages
+// that are not received.
+func (m *Message) Msg() jetstream.Msg {
+	return m.msg
+}
+
+func (m *Message) Key() []byte {
+	return m.key
+}
+
+func (m *Message) Value() []byte {
+	return m.value
+}
+
+func (m *Message) GetHeader(key string) string {
+	return m.Header.Get(key)
+}
+
+func (m *Message) HeaderKeys() []string {
+	keys := make([]string, 0, len(m.Header))
+	for k := range m.Header {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (m *Message) SetHeader(key, value string) {
+	if m.Header == nil {
+		m.Header = make(nats.Header)
+	}
+	m.Header.Set(key, value)
+}
+
+// toMessage converts the messages of other types, e.g. *pubsub.KafkaMessage.
+func toMessage(msg pubsub.Message) *Message {
+	if m, ok := msg.(*Message); ok {
+		return m
+	}
+
+	m := NewMessage(msg.Key(), msg.Value())
+	pubsub.CopyHeaders(m, msg)
+
+	return m
+}
+
+// Publisher publishes the messages to a subject captured by a stream.
+type Publisher struct {
+	js      jetstream.JetStream
+	subject string
+	mws     []pubsub.Middleware
+}
+
+func NewPublisher(js jetstream.JetStream, subject string) *Publisher {
+	return &Publisher{
+		js:      js,
+		subject: subject,
+	}
+}
+
+// Use appends the middlewares applied to every published message.
+func (p *Publisher) Use(mws ...pubsub.Middleware) {
+	p.mws = append(p.mws, mws...)
+}
+
+// Publish publishes the messages in order, waiting for each message to be
+// acknowledged by the stream. Nothing is published if any middleware returns
+// an error.
+func (p *Publisher) Publish(ctx context.Context, msgs ...pubsub.Message) error {
+	m := make([]*nats.Msg, 0, len(msgs))
+	collect := func(ctx context.Context, msg pubsub.Message) error {
+		nm := toMessage(msg)
+		h := make(nats.Header, len(nm.Header)+1)
+		for k, v := range nm.Header {
+			h[k] = v
+		}
+		if len(nm.key) > 0 {
+			h.Set(HeaderKey, string(nm.key))
+		}
+
+		m = append(m, &nats.Msg{
+			Subject: p.subject,
+			Header:  h,
+			Data:    nm.value,
+		})
+
+		return nil
+	}
+
+	chain := pubsub.Chain(p.mws...)(collect)
+	for _, msg := range msgs {
+		if err := chain(ctx, toMessage(msg)); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range m {
+		if _, err := p.js.PublishMsg(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
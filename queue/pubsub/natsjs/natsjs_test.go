@@ -0,0 +1,201 @@
+package natsjs_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/alextanhongpin/core/queue/pubsub/natsjs"
+)
+
+var ctx = context.Background()
+
+func TestHeaders(t *testing.T) {
+	js := new(jetStream)
+	pub := natsjs.NewPublisher(js, "orders")
+
+	msg := natsjs.NewMessage([]byte("key"), []byte("natsjs"))
+	msg.SetHeader("foo", "bar")
+
+	is := assert.New(t)
+	is.Nil(pub.Publish(ctx,
+		msg,
+		// The headers of the other message types are copied.
+		pubsub.NewMessage(kafka.Message{
+			Key:     []byte("key"),
+			Value:   []byte("kafka"),
+			Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+		}),
+	))
+
+	published := js.published()
+	is.Len(published, 2)
+	is.Equal("orders", published[0].Subject)
+
+	var got []pubsub.Message
+	errCh := receive(t, newConsumer(published...), func(ctx context.Context, msg pubsub.Message) error {
+		got = append(got, msg)
+		return nil
+	})
+	is.Nil(<-errCh)
+
+	is.Len(got, 2)
+	for i, want := range []string{"natsjs", "kafka"} {
+		is.Equal("key", string(got[i].Key()))
+		is.Equal(want, string(got[i].Value()))
+		is.Equal("bar", pubsub.Header(got[i], "foo"))
+	}
+
+	// The key is not stored in the published message.
+	is.Empty(msg.GetHeader(natsjs.HeaderKey))
+}
+
+func TestAck(t *testing.T) {
+	c := newConsumer(
+		&nats.Msg{Data: []byte("ok")},
+		&nats.Msg{Data: []byte("fail")},
+	)
+
+	wantErr := errors.New("want error")
+	errCh := receive(t, c, func(ctx context.Context, msg pubsub.Message) error {
+		if string(msg.Value()) == "fail" {
+			return wantErr
+		}
+
+		return nil
+	})
+
+	is := assert.New(t)
+	is.ErrorIs(<-errCh, wantErr)
+	is.Nil(<-errCh)
+
+	// Acknowledged when handled, and negatively acknowledged for redelivery
+	// otherwise.
+	is.True(c.msgs[0].acked.Load())
+	is.False(c.msgs[0].naked.Load())
+	is.False(c.msgs[1].acked.Load())
+	is.True(c.msgs[1].naked.Load())
+}
+
+// receive handles the messages of the consumer, and returns the errors, with
+// a nil error once the messages are consumed.
+func receive(t *testing.T, c *consumer, h pubsub.Handler) <-chan error {
+	t.Helper()
+
+	stop, errCh := natsjs.NewSubscriber(c).Receive(ctx, h)
+
+	go func() {
+		<-c.done
+		stop()
+	}()
+
+	res := make(chan error, len(c.msgs)+1)
+	go func() {
+		for err := range errCh {
+			res <- err
+		}
+		res <- nil
+	}()
+
+	return res
+}
+
+type jetStream struct {
+	jetstream.JetStream
+
+	mu   sync.Mutex
+	msgs []*nats.Msg
+}
+
+func (js *jetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	js.msgs = append(js.msgs, msg)
+
+	return &jetstream.PubAck{Sequence: uint64(len(js.msgs))}, nil
+}
+
+func (js *jetStream) published() []*nats.Msg {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	return append([]*nats.Msg(nil), js.msgs...)
+}
+
+// consumer delivers the messages once, and closes done after.
+type consumer struct {
+	jetstream.Consumer
+
+	msgs []*msg
+	done chan struct{}
+}
+
+func newConsumer(msgs ...*nats.Msg) *consumer {
+	c := &consumer{
+		done: make(chan struct{}),
+	}
+	for _, m := range msgs {
+		c.msgs = append(c.msgs, &msg{msg: m})
+	}
+
+	return c
+}
+
+func (c *consumer) Consume(h jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	go func() {
+		defer close(c.done)
+
+		for _, m := range c.msgs {
+			h(m)
+		}
+	}()
+
+	return &consumeContext{done: c.done}, nil
+}
+
+type consumeContext struct {
+	jetstream.ConsumeContext
+
+	done chan struct{}
+}
+
+func (cc *consumeContext) Drain() {}
+
+func (cc *consumeContext) Closed() <-chan struct{} {
+	return cc.done
+}
+
+type msg struct {
+	jetstream.Msg
+
+	msg   *nats.Msg
+	acked atomic.Bool
+	naked atomic.Bool
+}
+
+func (m *msg) Data() []byte {
+	return m.msg.Data
+}
+
+func (m *msg) Headers() nats.Header {
+	return m.msg.Header
+}
+
+func (m *msg) Ack() error {
+	m.acked.Store(true)
+	return nil
+}
+
+func (m *msg) Nak() error {
+	m.naked.Store(true)
+	return nil
+}
@@ -0,0 +1,101 @@
+package natsjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var _ pubsub.Consumer = (*Subscriber)(nil)
+
+// Subscriber consumes the messages from a JetStream consumer. Durable
+// consumers are shared by the subscribers, with each message delivered to one
+// of them.
+type Subscriber struct {
+	consumer jetstream.Consumer
+	mws      []pubsub.Middleware
+}
+
+func NewSubscriber(c jetstream.Consumer) *Subscriber {
+	return &Subscriber{
+		consumer: c,
+	}
+}
+
+// Use appends the middlewares applied to every received message.
+func (s *Subscriber) Use(mws ...pubsub.Middleware) {
+	s.mws = append(s.mws, mws...)
+}
+
+// Receive handles the messages consumed. The message is acknowledged once
+// handled successfully, and negatively acknowledged for redelivery otherwise.
+// The number of redeliveries is bounded by the consumer's MaxDeliver.
+func (s *Subscriber) Receive(ctx context.Context, h pubsub.Handler) (func(), <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		closed bool
+	)
+	errCh := make(chan error)
+	send := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if closed {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+		case errCh <- err:
+		}
+	}
+	stop := func() {
+		cancel()
+
+		wg.Wait()
+	}
+
+	chain := pubsub.Chain(s.mws...)(h)
+	cc, err := s.consumer.Consume(func(msg jetstream.Msg) {
+		if err := chain(ctx, fromMsg(msg)); err != nil {
+			send(errors.Join(err, msg.Nak()))
+			return
+		}
+
+		if err := msg.Ack(); err != nil {
+			send(err)
+		}
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		send(err)
+	}))
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer func() {
+			mu.Lock()
+			closed = true
+			close(errCh)
+			mu.Unlock()
+		}()
+		defer cancel()
+
+		if err != nil {
+			send(err)
+			return
+		}
+
+		<-ctx.Done()
+		cc.Drain()
+		<-cc.Closed()
+	}()
+
+	return stop, errCh
+}
@@ -6,9 +6,11 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
+// Publisher publishes messages to Kafka.
 type Publisher struct {
 	writer *kafka.Writer
 	topic  string
+	mws    []Middleware
 }
 
 func NewPublisher(w *kafka.Writer) *Publisher {
@@ -17,12 +19,32 @@ func NewPublisher(w *kafka.Writer) *Publisher {
 	}
 }
 
+// Publish publishes the messages in a single batch, after passing each
+// message through the middlewares. The batch is not published if any
+// middleware returns an error.
 func (p *Publisher) Publish(ctx context.Context, msgs ...Message) error {
-	m := make([]kafka.Message, len(msgs))
-	for i, msg := range msgs {
-		m[i] = kafka.Message{
-			Key:   msg.Key(),
-			Value: msg.Value(),
+	m := make([]kafka.Message, 0, len(msgs))
+	collect := func(ctx context.Context, msg Message) error {
+		km, ok := msg.(*KafkaMessage)
+		if !ok {
+			km = NewMessage(kafka.Message{
+				Key:   msg.Key(),
+				Value: msg.Value(),
+			})
+			CopyHeaders(km, msg)
+		}
+		m = append(m, kafka.Message{
+			Key:     km.Key(),
+			Value:   km.Value(),
+			Headers: km.Headers,
+		})
+
+		return nil
+	}
+
+	for _, msg := range msgs {
+		if err := handle(ctx, p.mws, collect, msg); err != nil {
+			return err
 		}
 	}
 
@@ -6,19 +6,34 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-type publisher interface {
-	Publish(ctx context.Context, msg Message) error
+// Producer publishes messages to a broker, e.g. *Publisher.
+type Producer interface {
+	Publish(ctx context.Context, msgs ...Message) error
 }
 
-type subscriber interface {
-	Subscribe(Handler)
+// Consumer receives messages from a broker until stopped, e.g. *Subscriber.
+// The errors are sent to the channel, which must be drained.
+type Consumer interface {
+	Receive(ctx context.Context, h Handler) (stop func(), errCh <-chan error)
 }
 
+var (
+	_ Producer = (*Publisher)(nil)
+	_ Consumer = (*Subscriber)(nil)
+)
+
 type Message interface {
 	Key() []byte
 	Value() []byte
 }
 
+// HeaderCarrier is implemented by the messages that carry headers.
+type HeaderCarrier interface {
+	GetHeader(key string) string
+	SetHeader(key, value string)
+	HeaderKeys() []string
+}
+
 type KafkaMessage struct {
 	kafka.Message
 }
@@ -45,3 +60,26 @@ func (k KafkaMessage) Key() []byte {
 func (k KafkaMessage) Value() []byte {
 	return k.Message.Value
 }
+
+func (k *KafkaMessage) GetHeader(key string) string {
+	return header(k.Message, key)
+}
+
+func (k *KafkaMessage) HeaderKeys() []string {
+	keys := make([]string, len(k.Headers))
+	for i, h := range k.Headers {
+		keys[i] = h.Key
+	}
+
+	return keys
+}
+
+func (k *KafkaMessage) SetHeader(key, value string) {
+	for i, h := range k.Headers {
+		if h.Key == key {
+			k.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	k.Headers = append(k.Headers, kafkaHeader(key, value))
+}
@@ -0,0 +1,67 @@
+package redisstream
+
+import (
+	"context"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	redis "github.com/redis/go-redis/v9"
+)
+
+var _ pubsub.Producer = (*Publisher)(nil)
+
+// Publisher appends the messages to a stream.
+type Publisher struct {
+	// MaxLen approximately caps the length of the stream. Zero means
+	// unbounded.
+	MaxLen int64
+
+	client *redis.Client
+	stream string
+	mws    []pubsub.Middleware
+}
+
+func NewPublisher(client *redis.Client, stream string) *Publisher {
+	return &Publisher{
+		client: client,
+		stream: stream,
+	}
+}
+
+// Use appends the middlewares applied to every published message.
+func (p *Publisher) Use(mws ...pubsub.Middleware) {
+	p.mws = append(p.mws, mws...)
+}
+
+// Publish appends the messages in a single round trip, after passing each
+// message through the middlewares. Nothing is published if any middleware
+// returns an error.
+func (p *Publisher) Publish(ctx context.Context, msgs ...pubsub.Message) error {
+	args := make([]*redis.XAddArgs, 0, len(msgs))
+	collect := func(ctx context.Context, msg pubsub.Message) error {
+		args = append(args, &redis.XAddArgs{
+			Stream: p.stream,
+			MaxLen: p.MaxLen,
+			Approx: p.MaxLen > 0,
+			Values: toMessage(msg).values(),
+		})
+
+		return nil
+	}
+
+	chain := pubsub.Chain(p.mws...)(collect)
+	for _, msg := range msgs {
+		if err := chain(ctx, toMessage(msg)); err != nil {
+			return err
+		}
+	}
+
+	_, err := p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, a := range args {
+			pipe.XAdd(ctx, a)
+		}
+
+		return nil
+	})
+
+	return err
+}
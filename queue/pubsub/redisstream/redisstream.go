@@ -0,0 +1,110 @@
+// Package redisstream implements the pubsub Producer and Consumer on top
+// of Redis Streams, using consumer groups to distribute the messages.
+package redisstream
+
+import (
+	"strings"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+	fieldKey     = "key"
+	fieldValue   = "value"
+	headerPrefix = "h:"
+)
+
+var (
+	_ pubsub.Message       = (*Message)(nil)
+	_ pubsub.HeaderCarrier = (*Message)(nil)
+)
+
+// Message is a stream entry. The key, value and headers are stored as fields
+// of the entry.
+type Message struct {
+	// ID is the entry id, set for received messages.
+	ID      string
+	Headers map[string]string
+	key     []byte
+	value   []byte
+}
+
+func NewMessage(key, value []byte) *Message {
+	return &Message{
+		Headers: make(map[string]string),
+		key:     key,
+		value:   value,
+	}
+}
+
+func (m *Message) Key() []byte {
+	return m.key
+}
+
+func (m *Message) Value() []byte {
+	return m.value
+}
+
+func (m *Message) GetHeader(key string) string {
+	return m.Headers[key]
+}
+
+func (m *Message) HeaderKeys() []string {
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (m *Message) SetHeader(key, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[key] = value
+}
+
+func (m *Message) values() map[string]any {
+	v := make(map[string]any, len(m.Headers)+2)
+	v[fieldKey] = m.key
+	v[fieldValue] = m.value
+	for k, h := range m.Headers {
+		v[headerPrefix+k] = h
+	}
+
+	return v
+}
+
+func fromXMessage(msg redis.XMessage) *Message {
+	m := &Message{
+		ID:      msg.ID,
+		Headers: make(map[string]string),
+	}
+	for k, v := range msg.Values {
+		s, _ := v.(string)
+		switch {
+		case k == fieldKey:
+			m.key = []byte(s)
+		case k == fieldValue:
+			m.value = []byte(s)
+		case strings.HasPrefix(k, headerPrefix):
+			m.Headers[strings.TrimPrefix(k, headerPrefix)] = s
+		}
+	}
+
+	return m
+}
+
+// toMessage converts the messages of other types, e.g. *pubsub.KafkaMessage.
+func toMessage(msg pubsub.Message) *Message {
+	if m, ok := msg.(*Message); ok {
+		return m
+	}
+
+	m := NewMessage(msg.Key(), msg.Value())
+	pubsub.CopyHeaders(m, msg)
+
+	return m
+}
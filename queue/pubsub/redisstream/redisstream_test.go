@@ -0,0 +1,124 @@
+package redisstream_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/alextanhongpin/core/queue/pubsub/redisstream"
+	"github.com/alextanhongpin/core/storage/redis/redistest"
+)
+
+var ctx = context.Background()
+
+func TestMain(m *testing.M) {
+	stop := redistest.Init()
+	defer stop()
+
+	m.Run()
+}
+
+func TestHeaders(t *testing.T) {
+	client := redistest.Client(t)
+	pub := redisstream.NewPublisher(client, t.Name())
+
+	msg := redisstream.NewMessage([]byte("key"), []byte("redisstream"))
+	msg.SetHeader("foo", "bar")
+
+	is := assert.New(t)
+	is.Nil(pub.Publish(ctx,
+		msg,
+		// The headers of the other message types are copied.
+		pubsub.NewMessage(kafka.Message{
+			Key:     []byte("key"),
+			Value:   []byte("kafka"),
+			Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+		}),
+	))
+
+	sub := redisstream.NewSubscriber(client, t.Name(), &redisstream.Options{
+		Group: "group",
+		Block: 10 * time.Millisecond,
+	})
+
+	msgs := make(chan *redisstream.Message, 2)
+	stop, errCh := sub.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
+		msgs <- msg.(*redisstream.Message)
+		return nil
+	})
+	defer stop()
+	go drain(errCh)
+
+	for _, want := range []string{"redisstream", "kafka"} {
+		got := <-msgs
+		is.NotEmpty(got.ID)
+		is.Equal("key", string(got.Key()))
+		is.Equal(want, string(got.Value()))
+		is.Equal(map[string]string{"foo": "bar"}, got.Headers)
+	}
+}
+
+func TestAck(t *testing.T) {
+	client := redistest.Client(t)
+	pub := redisstream.NewPublisher(client, t.Name())
+
+	is := assert.New(t)
+	is.Nil(pub.Publish(ctx,
+		redisstream.NewMessage(nil, []byte("ok")),
+		redisstream.NewMessage(nil, []byte("fail")),
+	))
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+	wantErr := errors.New("want error")
+	sub := redisstream.NewSubscriber(client, t.Name(), &redisstream.Options{
+		Group:   "group",
+		Block:   10 * time.Millisecond,
+		MinIdle: 100 * time.Millisecond,
+	})
+	stop, errCh := sub.Receive(ctx, func(ctx context.Context, msg pubsub.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		v := string(msg.Value())
+		attempts[v]++
+		if v == "fail" && attempts[v] == 1 {
+			return wantErr
+		}
+
+		return nil
+	})
+	defer stop()
+
+	// The failed message is left pending, while the other is acknowledged.
+	is.ErrorIs(<-errCh, wantErr)
+	go drain(errCh)
+
+	pending, err := client.XPending(ctx, t.Name(), "group").Result()
+	is.Nil(err)
+	is.Equal(int64(1), pending.Count)
+
+	// The failed message is redelivered after MinIdle.
+	is.Eventually(func() bool {
+		pending, err := client.XPending(ctx, t.Name(), "group").Result()
+		return err == nil && pending.Count == 0
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	is.Equal(map[string]int{"ok": 1, "fail": 2}, attempts)
+}
+
+func drain(errCh <-chan error) {
+	for range errCh {
+	}
+}
@@ -0,0 +1,209 @@
+package redisstream
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	redis "github.com/redis/go-redis/v9"
+)
+
+var _ pubsub.Consumer = (*Subscriber)(nil)
+
+type Options struct {
+	// Group is the consumer group. The group is created at the start of the
+	// stream if it does not exist.
+	Group string
+
+	// Consumer is the consumer name within the group, which must be unique.
+	// Defaults to the hostname and pid.
+	Consumer string
+
+	// Count is the maximum number of messages read at a time.
+	// Defaults to 10.
+	Count int64
+
+	// Block is the duration to wait for new messages. Defaults to 1s.
+	Block time.Duration
+
+	// MinIdle is the duration after which the messages that are not
+	// acknowledged, because the handler failed or the consumer crashed, are
+	// claimed and redelivered. Defaults to 30s.
+	MinIdle time.Duration
+}
+
+func (o *Options) valid() *Options {
+	o = cmp.Or(o, &Options{})
+	if o.Group == "" {
+		panic("redisstream: group is required")
+	}
+	if o.Consumer == "" {
+		host, _ := os.Hostname()
+		o.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	o.Count = cmp.Or(o.Count, 10)
+	o.Block = cmp.Or(o.Block, time.Second)
+	o.MinIdle = cmp.Or(o.MinIdle, 30*time.Second)
+
+	return o
+}
+
+// Subscriber reads the messages from a stream as part of a consumer group.
+// Each message is delivered to one consumer in the group, and acknowledged
+// once handled successfully.
+type Subscriber struct {
+	client *redis.Client
+	stream string
+	opts   *Options
+	mws    []pubsub.Middleware
+}
+
+func NewSubscriber(client *redis.Client, stream string, opts *Options) *Subscriber {
+	return &Subscriber{
+		client: client,
+		stream: stream,
+		opts:   opts.valid(),
+	}
+}
+
+// Use appends the middlewares applied to every received message.
+func (s *Subscriber) Use(mws ...pubsub.Middleware) {
+	s.mws = append(s.mws, mws...)
+}
+
+// Receive handles the messages read from the stream.
+// Returning an error leaves the message pending, and the message is
+// redelivered after Options.MinIdle.
+func (s *Subscriber) Receive(ctx context.Context, h pubsub.Handler) (func(), <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error)
+	stop := func() {
+		cancel()
+
+		wg.Wait()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(errCh)
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case errCh <- s.receive(ctx, h):
+			}
+		}
+	}()
+
+	return stop, errCh
+}
+
+func (s *Subscriber) receive(ctx context.Context, h pubsub.Handler) error {
+	if err := s.createGroup(ctx); err != nil {
+		return err
+	}
+
+	var claimAt time.Time
+	for {
+		if time.Now().After(claimAt) {
+			if err := s.claim(ctx, h); err != nil {
+				return err
+			}
+			claimAt = time.Now().Add(s.opts.MinIdle / 2)
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.opts.Group,
+			Consumer: s.opts.Consumer,
+			Streams:  []string{s.stream, ">"},
+			Count:    s.opts.Count,
+			Block:    s.opts.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, stream := range streams {
+			if err := s.handle(ctx, h, stream.Messages); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Subscriber) createGroup(ctx context.Context) error {
+	err := s.client.XGroupCreateMkStream(ctx, s.stream, s.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+// claim redelivers the pending messages that have been idle for longer than
+// MinIdle to this consumer.
+func (s *Subscriber) claim(ctx context.Context, h pubsub.Handler) error {
+	start := "0-0"
+	for {
+		msgs, next, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   s.stream,
+			Group:    s.opts.Group,
+			Consumer: s.opts.Consumer,
+			MinIdle:  s.opts.MinIdle,
+			Start:    start,
+			Count:    s.opts.Count,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		if err := s.handle(ctx, h, msgs); err != nil {
+			return err
+		}
+
+		if next == "0-0" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// handle acknowledges the messages that are handled successfully. The other
+// messages in the batch are still handled when a handler fails.
+func (s *Subscriber) handle(ctx context.Context, h pubsub.Handler, msgs []redis.XMessage) error {
+	chain := pubsub.Chain(s.mws...)(h)
+
+	var errs []error
+	ids := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		if err := chain(ctx, fromXMessage(msg)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ids = append(ids, msg.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := s.client.XAck(ctx, s.stream, s.opts.Group, ids...).Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+package pubsub
+
+import (
+	"cmp"
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// The headers set on the messages republished to the retry and dead-letter
+// topics.
+const (
+	HeaderAttempt       = "pubsub-attempt"
+	HeaderRetryAt       = "pubsub-retry-at"
+	HeaderOriginalTopic = "pubsub-original-topic"
+	HeaderError         = "pubsub-error"
+)
+
+// Writer writes the messages, e.g. *kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type Options struct {
+	// Writer publishes the failed messages to the retry and dead-letter
+	// topics. The Writer must not have the Topic set, since the topic is set
+	// per message. Retry is disabled when nil, and the failed message is not
+	// committed instead.
+	Writer Writer
+
+	// MaxRetries is the number of retries before the message is routed to
+	// the dead-letter topic. Defaults to 3.
+	MaxRetries int
+
+	// Backoff returns the delay before the given attempt, starting from 1.
+	// Defaults to exponential backoff starting from 1s, capped at 1m.
+	Backoff func(attempt int) time.Duration
+
+	// RetryTopicSuffix is appended to the original topic to form the retry
+	// topic, which must be consumed by a Subscriber with the same handler.
+	// All the attempts share the retry topic, so a message waiting for a
+	// long backoff delays the messages behind it in the partition, even if
+	// they are due earlier. Defaults to "-retry".
+	RetryTopicSuffix string
+
+	// DLQTopic is the dead-letter topic. Defaults to the original topic
+	// suffixed with "-dlq".
+	DLQTopic string
+
+	// KeyFn enables the keyed dispatch, where the messages with the same key
+	// are handled in order, and the messages with different keys are handled
+	// in parallel by the workers. The messages are handled one at a time when
+	// nil.
+	KeyFn func(msg Message) string
+
+	// Workers is the number of workers of the keyed dispatch. Defaults to
+	// GOMAXPROCS.
+	Workers int
+
+	// QueueSize is the number of messages buffered per worker of the keyed
+	// dispatch. Defaults to 100.
+	QueueSize int
+
+	// ShutdownTimeout is how long to wait for the handlers in flight when
+	// stopped, before they are canceled. Defaults to 30s.
+	ShutdownTimeout time.Duration
+}
+
+func (o *Options) valid() *Options {
+	o = cmp.Or(o, &Options{})
+	o.MaxRetries = cmp.Or(o.MaxRetries, 3)
+	o.RetryTopicSuffix = cmp.Or(o.RetryTopicSuffix, "-retry")
+	o.Workers = cmp.Or(o.Workers, runtime.GOMAXPROCS(0))
+	o.QueueSize = cmp.Or(o.QueueSize, 100)
+	o.ShutdownTimeout = cmp.Or(o.ShutdownTimeout, 30*time.Second)
+	if o.Backoff == nil {
+		o.Backoff = exponentialBackoff
+	}
+
+	return o
+}
+
+// retry republishes the failed message to the retry topic with the attempt
+// incremented, or to the dead-letter topic once the retries are exhausted.
+func (s *Subscriber) retry(ctx context.Context, msg kafka.Message, cause error) error {
+	topic := header(msg, HeaderOriginalTopic)
+	if topic == "" {
+		topic = strings.TrimSuffix(msg.Topic, s.opts.RetryTopicSuffix)
+	}
+	attempt, _ := strconv.Atoi(header(msg, HeaderAttempt))
+	attempt++
+
+	headers := []kafka.Header{
+		kafkaHeader(HeaderAttempt, strconv.Itoa(attempt)),
+		kafkaHeader(HeaderOriginalTopic, topic),
+		kafkaHeader(HeaderError, cause.Error()),
+	}
+
+	out := kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+	}
+	if attempt > s.opts.MaxRetries {
+		out.Topic = cmp.Or(s.opts.DLQTopic, topic+"-dlq")
+	} else {
+		out.Topic = topic + s.opts.RetryTopicSuffix
+		retryAt := time.Now().Add(s.opts.Backoff(attempt))
+		headers = append(headers, kafkaHeader(HeaderRetryAt, strconv.FormatInt(retryAt.UnixMilli(), 10)))
+	}
+	out.Headers = append(withoutHeaders(msg.Headers), headers...)
+
+	return s.opts.Writer.WriteMessages(ctx, out)
+}
+
+// wait blocks until the message is due for retry. This blocks the partition,
+// so the messages behind it are delayed until it is due, e.g. the first
+// attempt of another message waits behind the third attempt of this one.
+func wait(ctx context.Context, msg kafka.Message) error {
+	ms, err := strconv.ParseInt(header(msg, HeaderRetryAt), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	d := time.Until(time.UnixMilli(ms))
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-t.C:
+		return nil
+	}
+}
+
+func kafkaHeader(key, value string) kafka.Header {
+	return kafka.Header{Key: key, Value: []byte(value)}
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+// withoutHeaders returns the headers, excluding the ones set by retry.
+func withoutHeaders(headers []kafka.Header) []kafka.Header {
+	res := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		switch h.Key {
+		case HeaderAttempt, HeaderRetryAt, HeaderOriginalTopic, HeaderError:
+		default:
+			res = append(res, h)
+		}
+	}
+
+	return res
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	return min(time.Second<<(attempt-1), time.Minute)
+}
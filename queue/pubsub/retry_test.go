@@ -0,0 +1,200 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var errHandler = errors.New("handler failed")
+
+func TestSubscriberRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      kafka.Message
+		dlqTopic string
+		topic    string
+		attempt  string
+	}{
+		{
+			name:    "first attempt",
+			msg:     kafka.Message{Topic: "orders"},
+			topic:   "orders-retry",
+			attempt: "1",
+		},
+		{
+			name: "retry attempt",
+			msg: kafka.Message{
+				Topic: "orders-retry",
+				Headers: []kafka.Header{
+					{Key: pubsub.HeaderAttempt, Value: []byte("1")},
+					{Key: pubsub.HeaderOriginalTopic, Value: []byte("orders")},
+				},
+			},
+			topic:   "orders-retry",
+			attempt: "2",
+		},
+		{
+			name: "retries exhausted",
+			msg: kafka.Message{
+				Topic: "orders-retry",
+				Headers: []kafka.Header{
+					{Key: pubsub.HeaderAttempt, Value: []byte("3")},
+					{Key: pubsub.HeaderOriginalTopic, Value: []byte("orders")},
+				},
+			},
+			topic:   "orders-dlq",
+			attempt: "4",
+		},
+		{
+			name: "custom dlq topic",
+			msg: kafka.Message{
+				Topic: "orders-retry",
+				Headers: []kafka.Header{
+					{Key: pubsub.HeaderAttempt, Value: []byte("3")},
+				},
+			},
+			dlqTopic: "dlq",
+			topic:    "dlq",
+			attempt:  "4",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			r := newReader()
+			w := new(writer)
+			sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+				Writer:     w,
+				MaxRetries: 3,
+				DLQTopic:   tc.dlqTopic,
+				Backoff: func(attempt int) time.Duration {
+					return time.Minute
+				},
+			})
+			stop, errCh := sub.Receive(context.Background(), func(ctx context.Context, msg pubsub.Message) error {
+				return errHandler
+			})
+			defer stop()
+			go func() {
+				for range errCh {
+				}
+			}()
+
+			tc.msg.Key = []byte("key")
+			tc.msg.Value = []byte("value")
+			tc.msg.Headers = append(tc.msg.Headers, kafka.Header{Key: "trace-id", Value: []byte("abc")})
+			r.msgs <- tc.msg
+
+			is.Eventually(func() bool {
+				return len(r.committed()) == 1
+			}, time.Second, 10*time.Millisecond)
+
+			msgs := w.written()
+			is.Len(msgs, 1)
+
+			out := msgs[0]
+			is.Equal(tc.topic, out.Topic)
+			is.Equal([]byte("key"), out.Key)
+			is.Equal([]byte("value"), out.Value)
+
+			headers := make(map[string]string)
+			for _, h := range out.Headers {
+				_, ok := headers[h.Key]
+				is.False(ok, "duplicate header %s", h.Key)
+				headers[h.Key] = string(h.Value)
+			}
+			is.Equal(tc.attempt, headers[pubsub.HeaderAttempt])
+			is.Equal("orders", headers[pubsub.HeaderOriginalTopic])
+			is.Equal(errHandler.Error(), headers[pubsub.HeaderError])
+			is.Equal("abc", headers["trace-id"])
+
+			retryAt, ok := headers[pubsub.HeaderRetryAt]
+			if tc.topic == "orders-retry" {
+				ms, err := strconv.ParseInt(retryAt, 10, 64)
+				is.Nil(err)
+				is.WithinDuration(time.Now().Add(time.Minute), time.UnixMilli(ms), time.Second)
+			} else {
+				is.False(ok)
+			}
+		})
+	}
+}
+
+func TestSubscriberRetryDisabled(t *testing.T) {
+	is := assert.New(t)
+
+	r := newReader()
+	sub := pubsub.NewSubscriber(r)
+	stop, errCh := sub.Receive(context.Background(), func(ctx context.Context, msg pubsub.Message) error {
+		return errHandler
+	})
+	defer stop()
+
+	r.msgs <- kafka.Message{Topic: "orders"}
+	is.ErrorIs(<-errCh, errHandler)
+	is.Empty(r.committed())
+}
+
+func TestSubscriberRetryDue(t *testing.T) {
+	is := assert.New(t)
+
+	r := newReader()
+	sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+		Writer: new(writer),
+	})
+
+	handled := make(chan time.Time, 1)
+	stop, errCh := sub.Receive(context.Background(), func(ctx context.Context, msg pubsub.Message) error {
+		handled <- time.Now()
+		return nil
+	})
+	defer stop()
+	go func() {
+		for range errCh {
+		}
+	}()
+
+	retryAt := time.Now().Add(100 * time.Millisecond)
+	r.msgs <- kafka.Message{
+		Topic: "orders-retry",
+		Headers: []kafka.Header{
+			{Key: pubsub.HeaderRetryAt, Value: []byte(strconv.FormatInt(retryAt.UnixMilli(), 10))},
+		},
+	}
+
+	// The retry time is truncated to milliseconds.
+	is.False((<-handled).Before(retryAt.Truncate(time.Millisecond)))
+	is.Eventually(func() bool {
+		return len(r.committed()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+type writer struct {
+	mu   sync.Mutex
+	msgs []kafka.Message
+}
+
+func (w *writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	w.msgs = append(w.msgs, msgs...)
+	w.mu.Unlock()
+
+	return nil
+}
+
+func (w *writer) written() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]kafka.Message(nil), w.msgs...)
+}
@@ -0,0 +1,204 @@
+package pubsub
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// HeaderSchemaID is the ID of the schema of the message value in the schema
+// registry, when the wire format is not used.
+const HeaderSchemaID = "pubsub-schema-id"
+
+var ErrInvalidSchema = errors.New("pubsub: invalid schema")
+
+// Validator validates the message values against the schemas of a schema
+// registry, e.g. an adapter for the Confluent Schema Registry client.
+type Validator interface {
+	// Validate validates the value against the latest schema of the subject,
+	// and returns the ID of the schema.
+	Validate(ctx context.Context, subject string, value []byte) (id int, err error)
+
+	// ValidateID validates the value against the schema of the ID.
+	ValidateID(ctx context.Context, id int, value []byte) error
+}
+
+type SchemaOptions struct {
+	// Subject returns the subject of the schema of the message. Defaults to
+	// the HeaderSchema header, e.g. set by JSONOptions.Schema.
+	Subject func(msg Message) string
+
+	// WireFormat prefixes the value with the magic byte and the schema ID, as
+	// in the Confluent wire format, instead of setting the HeaderSchemaID
+	// header.
+	// The prefix is stripped by wrapping the received message, so the
+	// handlers cannot type assert the message, e.g. to *KafkaMessage, and
+	// AsKafkaMessage panics. Use Key, Value and Header instead.
+	WireFormat bool
+}
+
+func (o *SchemaOptions) valid() *SchemaOptions {
+	o = cmp.Or(o, &SchemaOptions{})
+	if o.Subject == nil {
+		o.Subject = func(msg Message) string {
+			return Header(msg, HeaderSchema)
+		}
+	}
+
+	return o
+}
+
+// PublishSchema returns the publisher middleware that rejects the messages
+// that do not validate against the schema of the subject, so that malformed
+// messages are never published. The ID of the schema is set on the message.
+func PublishSchema(v Validator, opts *SchemaOptions) Middleware {
+	opts = opts.valid()
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg Message) error {
+			subject := opts.Subject(msg)
+			if subject == "" {
+				return fmt.Errorf("%w: missing subject", ErrInvalidSchema)
+			}
+
+			id, err := v.Validate(ctx, subject, msg.Value())
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrInvalidSchema, subject, err)
+			}
+
+			if opts.WireFormat {
+				return next(ctx, &schemaMessage{
+					Message: msg,
+					value:   EncodeWireFormat(id, msg.Value()),
+				})
+			}
+
+			SetHeader(msg, HeaderSchemaID, strconv.Itoa(id))
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// ReceiveSchema returns the subscriber middleware that rejects the messages
+// that do not validate against the schema of the message. The handlers get
+// the ID of the schema with SchemaID, and the value without the wire format
+// prefix.
+// Messages without the schema ID are validated against the latest schema of
+// the subject instead.
+func ReceiveSchema(v Validator, opts *SchemaOptions) Middleware {
+	opts = opts.valid()
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg Message) error {
+			id, msg, err := schemaID(msg, opts.WireFormat)
+			if err != nil {
+				return err
+			}
+
+			if id >= 0 {
+				err = v.ValidateID(ctx, id, msg.Value())
+			} else if subject := opts.Subject(msg); subject != "" {
+				id, err = v.Validate(ctx, subject, msg.Value())
+			} else {
+				err = errors.New("missing schema")
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+			}
+
+			return next(context.WithValue(ctx, schemaIDKey{}, id), msg)
+		}
+	}
+}
+
+type schemaIDKey struct{}
+
+// SchemaID returns the ID of the schema of the received message, see
+// ReceiveSchema.
+func SchemaID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(schemaIDKey{}).(int)
+	return id, ok
+}
+
+// schemaID returns the schema ID of the message, or -1 if there is none, and
+// the message without the wire format prefix.
+func schemaID(msg Message, wireFormat bool) (int, Message, error) {
+	if wireFormat {
+		id, value, err := DecodeWireFormat(msg.Value())
+		if err != nil {
+			return 0, nil, err
+		}
+
+		return id, &schemaMessage{Message: msg, value: value}, nil
+	}
+
+	s := Header(msg, HeaderSchemaID)
+	if s == "" {
+		return -1, msg, nil
+	}
+
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: invalid schema id %q", ErrInvalidSchema, s)
+	}
+
+	return id, msg, nil
+}
+
+// magicByte is the first byte of the Confluent wire format.
+const magicByte = 0
+
+// EncodeWireFormat prefixes the value with the magic byte and the 4-byte
+// big-endian schema ID, as in the Confluent wire format.
+func EncodeWireFormat(id int, value []byte) []byte {
+	b := make([]byte, 5, 5+len(value))
+	b[0] = magicByte
+	binary.BigEndian.PutUint32(b[1:], uint32(id))
+
+	return append(b, value...)
+}
+
+// DecodeWireFormat returns the schema ID and the value of the Confluent wire
+// format.
+func DecodeWireFormat(b []byte) (id int, value []byte, err error) {
+	if len(b) < 5 || b[0] != magicByte {
+		return 0, nil, fmt.Errorf("%w: unknown wire format", ErrInvalidSchema)
+	}
+
+	return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+}
+
+var (
+	_ Message       = (*schemaMessage)(nil)
+	_ HeaderCarrier = (*schemaMessage)(nil)
+)
+
+// schemaMessage replaces the value of the message, and keeps the headers.
+type schemaMessage struct {
+	Message
+	value []byte
+}
+
+func (m *schemaMessage) Value() []byte {
+	return m.value
+}
+
+func (m *schemaMessage) GetHeader(key string) string {
+	return Header(m.Message, key)
+}
+
+func (m *schemaMessage) SetHeader(key, value string) {
+	SetHeader(m.Message, key, value)
+}
+
+func (m *schemaMessage) HeaderKeys() []string {
+	if h, ok := m.Message.(HeaderCarrier); ok {
+		return h.HeaderKeys()
+	}
+
+	return nil
+}
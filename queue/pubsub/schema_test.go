@@ -0,0 +1,168 @@
+package pubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+)
+
+func TestPublishSchema(t *testing.T) {
+	var got pubsub.Message
+	h := pubsub.PublishSchema(new(validator), nil)(func(ctx context.Context, msg pubsub.Message) error {
+		got = msg
+		return nil
+	})
+
+	is := assert.New(t)
+	is.Nil(h(ctx, newSchemaMessage("order/v1", `{"id": 1}`)))
+	is.Equal("1", pubsub.Header(got, pubsub.HeaderSchemaID))
+	is.Equal(`{"id": 1}`, string(got.Value()))
+
+	is.ErrorIs(h(ctx, newSchemaMessage("order/v1", `{`)), pubsub.ErrInvalidSchema)
+	is.ErrorIs(h(ctx, newSchemaMessage("unknown", `{}`)), pubsub.ErrInvalidSchema)
+	is.ErrorIs(h(ctx, newSchemaMessage("", `{}`)), pubsub.ErrInvalidSchema)
+}
+
+func TestPublishSchemaWireFormat(t *testing.T) {
+	var got pubsub.Message
+	h := pubsub.PublishSchema(new(validator), &pubsub.SchemaOptions{
+		WireFormat: true,
+	})(func(ctx context.Context, msg pubsub.Message) error {
+		got = msg
+		return nil
+	})
+
+	is := assert.New(t)
+	is.Nil(h(ctx, newSchemaMessage("order/v1", `{"id": 1}`)))
+	is.Equal(pubsub.EncodeWireFormat(1, []byte(`{"id": 1}`)), got.Value())
+	is.Equal("key", string(got.Key()))
+
+	// The headers are kept, without the schema ID.
+	is.Equal("order/v1", pubsub.Header(got, pubsub.HeaderSchema))
+	is.Empty(pubsub.Header(got, pubsub.HeaderSchemaID))
+}
+
+func TestReceiveSchema(t *testing.T) {
+	var (
+		got pubsub.Message
+		id  int
+	)
+	h := pubsub.ReceiveSchema(new(validator), nil)(func(ctx context.Context, msg pubsub.Message) error {
+		got = msg
+		id, _ = pubsub.SchemaID(ctx)
+		return nil
+	})
+
+	is := assert.New(t)
+	msg := newSchemaMessage("", `{"id": 1}`)
+	msg.SetHeader(pubsub.HeaderSchemaID, "2")
+	is.Nil(h(ctx, msg))
+	is.Equal(2, id)
+	is.Same(msg, got)
+
+	// Validated against the latest schema of the subject without the ID.
+	is.Nil(h(ctx, newSchemaMessage("order/v1", `{"id": 1}`)))
+	is.Equal(1, id)
+
+	msg.SetHeader(pubsub.HeaderSchemaID, "3")
+	is.ErrorIs(h(ctx, msg), pubsub.ErrInvalidSchema)
+
+	msg.SetHeader(pubsub.HeaderSchemaID, "abc")
+	is.ErrorIs(h(ctx, msg), pubsub.ErrInvalidSchema)
+
+	is.ErrorIs(h(ctx, newSchemaMessage("", `{}`)), pubsub.ErrInvalidSchema)
+}
+
+func TestReceiveSchemaWireFormat(t *testing.T) {
+	var (
+		got pubsub.Message
+		id  int
+	)
+	h := pubsub.ReceiveSchema(new(validator), &pubsub.SchemaOptions{
+		WireFormat: true,
+	})(func(ctx context.Context, msg pubsub.Message) error {
+		got = msg
+		id, _ = pubsub.SchemaID(ctx)
+		return nil
+	})
+
+	is := assert.New(t)
+	msg := newSchemaMessage("order/v1", "")
+	msg.Message.Value = pubsub.EncodeWireFormat(2, []byte(`{"id": 1}`))
+	is.Nil(h(ctx, msg))
+	is.Equal(2, id)
+	is.Equal(`{"id": 1}`, string(got.Value()))
+	is.Equal("order/v1", pubsub.Header(got, pubsub.HeaderSchema))
+
+	// The message is wrapped.
+	_, ok := got.(*pubsub.KafkaMessage)
+	is.False(ok)
+
+	msg.Message.Value = []byte(`{"id": 1}`)
+	is.ErrorIs(h(ctx, msg), pubsub.ErrInvalidSchema)
+}
+
+func TestWireFormat(t *testing.T) {
+	b := pubsub.EncodeWireFormat(258, []byte("hello"))
+
+	is := assert.New(t)
+	is.Equal([]byte{0, 0, 0, 1, 2, 'h', 'e', 'l', 'l', 'o'}, b)
+
+	id, value, err := pubsub.DecodeWireFormat(b)
+	is.Nil(err)
+	is.Equal(258, id)
+	is.Equal([]byte("hello"), value)
+
+	id, value, err = pubsub.DecodeWireFormat(pubsub.EncodeWireFormat(1, nil))
+	is.Nil(err)
+	is.Equal(1, id)
+	is.Empty(value)
+
+	for _, b := range [][]byte{nil, {0, 0, 0, 1}, {1, 0, 0, 0, 1}} {
+		_, _, err := pubsub.DecodeWireFormat(b)
+		is.ErrorIs(err, pubsub.ErrInvalidSchema)
+	}
+}
+
+func newSchemaMessage(subject, value string) *pubsub.KafkaMessage {
+	msg := pubsub.NewMessage(kafka.Message{
+		Key:   []byte("key"),
+		Value: []byte(value),
+	})
+	if subject != "" {
+		msg.SetHeader(pubsub.HeaderSchema, subject)
+	}
+
+	return msg
+}
+
+var errUnknownSchema = errors.New("unknown schema")
+
+// validator accepts the JSON values of the schemas, where the subject
+// "order/v1" is at ID 1, and ID 2 is an older version.
+type validator struct{}
+
+func (v *validator) Validate(ctx context.Context, subject string, value []byte) (int, error) {
+	if subject != "order/v1" {
+		return 0, errUnknownSchema
+	}
+
+	return 1, v.ValidateID(ctx, 1, value)
+}
+
+func (v *validator) ValidateID(ctx context.Context, id int, value []byte) error {
+	if id != 1 && id != 2 {
+		return errUnknownSchema
+	}
+	if !json.Valid(value) {
+		return errors.New("invalid json")
+	}
+
+	return nil
+}
@@ -3,47 +3,99 @@ package pubsub
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
 type Handler func(ctx context.Context, msg Message) error
 
+// Reader fetches and commits the messages, e.g. *kafka.Reader.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Subscriber receives messages from Kafka.
 type Subscriber struct {
-	reader *kafka.Reader
+	reader Reader
+	opts   *Options
+	mws    []Middleware
+}
+
+func NewSubscriber(r Reader) *Subscriber {
+	return NewSubscriberWithOptions(r, nil)
 }
 
-func NewSubscriber(r *kafka.Reader) *Subscriber {
+// NewSubscriberWithOptions returns a subscriber with the retry and the keyed
+// dispatch configured by the options.
+func NewSubscriberWithOptions(r Reader, opts *Options) *Subscriber {
 	return &Subscriber{
 		reader: r,
+		opts:   opts.valid(),
 	}
 }
 
 // Receive handles the message received from the message queue.
-// Returning an error will not commit the offset.
+// Returning an error will not commit the offset, unless retry is enabled with
+// Options.Writer, in which case the message is republished to the retry or
+// dead-letter topic and committed.
+// The messages are handled one at a time, unless Options.KeyFn is set, e.g.
+// to Key, where the messages are handled in order per key, and in parallel
+// across keys.
+//
+// The returned function stops fetching new messages, waits for the handlers
+// in flight up to Options.ShutdownTimeout, commits the offsets of the
+// handled messages, and closes the reader. This avoids handling the messages
+// again after a deploy.
 func (s *Subscriber) Receive(ctx context.Context, h Handler) (func(), <-chan error) {
+	// The handlers are not canceled when stopped, until the shutdown times
+	// out.
+	hctx, hcancel := context.WithCancel(context.WithoutCancel(ctx))
 	ctx, cancel := context.WithCancel(ctx)
 
 	var wg sync.WaitGroup
 	errCh := make(chan error)
-	stop := func() {
+	stop := sync.OnceFunc(func() {
 		cancel()
 
 		wg.Wait()
-	}
+		s.reader.Close()
+	})
 
-	wg.Add(1)
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		defer hcancel()
+
+		select {
+		case <-hctx.Done():
+			return
+		case <-ctx.Done():
+		}
+
+		t := time.NewTimer(s.opts.ShutdownTimeout)
+		defer t.Stop()
+
+		select {
+		case <-hctx.Done():
+		case <-t.C:
+		}
+	}()
 
 	go func() {
 		defer wg.Done()
 		defer close(errCh)
+		defer hcancel()
 		defer cancel()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case errCh <- s.receive(ctx, h):
+			case errCh <- s.receive(ctx, hctx, h):
 			}
 		}
 	}()
@@ -51,19 +103,44 @@ func (s *Subscriber) Receive(ctx context.Context, h Handler) (func(), <-chan err
 	return stop, errCh
 }
 
-func (s *Subscriber) receive(ctx context.Context, h Handler) error {
+// receive fetches the messages until ctx is done, and handles them with hctx.
+func (s *Subscriber) receive(ctx, hctx context.Context, h Handler) error {
+	if s.opts.KeyFn != nil {
+		return s.receiveKeyed(ctx, hctx, h)
+	}
+
 	for {
 		msg, err := s.reader.FetchMessage(ctx)
 		if err != nil {
 			return err
 		}
 
-		if err := h(ctx, NewMessage(msg)); err != nil {
+		if err := s.process(ctx, hctx, h, msg); err != nil {
+			return err
+		}
+
+		if err := s.reader.CommitMessages(hctx, msg); err != nil {
 			return err
 		}
+	}
+}
+
+// process handles the message with hctx once it is due, and retries it on
+// failure. The wait for the message to be due is canceled with ctx when
+// stopped, so that stopping is not delayed until the message is due. The
+// message is not committed, and is fetched again on restart.
+func (s *Subscriber) process(ctx, hctx context.Context, h Handler, msg kafka.Message) error {
+	if err := wait(ctx, msg); err != nil {
+		return err
+	}
 
-		if err := s.reader.CommitMessages(ctx, msg); err != nil {
+	if err := handle(hctx, s.mws, h, NewMessage(msg)); err != nil {
+		if s.opts.Writer == nil {
 			return err
 		}
+
+		return s.retry(hctx, msg, err)
 	}
+
+	return nil
 }
@@ -0,0 +1,103 @@
+package pubsub_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/queue/pubsub"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriberStopWhileRetryPending(t *testing.T) {
+	tests := []struct {
+		name  string
+		keyFn func(pubsub.Message) string
+	}{
+		{"sequential", nil},
+		{"keyed", pubsub.Key},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			r := newReader()
+			sub := pubsub.NewSubscriberWithOptions(r, &pubsub.Options{
+				KeyFn:           tc.keyFn,
+				ShutdownTimeout: time.Minute,
+			})
+
+			var handled atomic.Bool
+			stop, errCh := sub.Receive(context.Background(), func(ctx context.Context, msg pubsub.Message) error {
+				handled.Store(true)
+				return nil
+			})
+			go func() {
+				for range errCh {
+				}
+			}()
+
+			retryAt := time.Now().Add(time.Hour).UnixMilli()
+			// The send returns once the message is fetched.
+			r.msgs <- kafka.Message{
+				Key:   []byte("key"),
+				Value: []byte("value"),
+				Headers: []kafka.Header{
+					{Key: pubsub.HeaderRetryAt, Value: []byte(strconv.FormatInt(retryAt, 10))},
+				},
+			}
+
+			start := time.Now()
+			stop()
+			is.Less(time.Since(start), time.Second)
+			is.False(handled.Load())
+			is.Empty(r.committed())
+		})
+	}
+}
+
+type reader struct {
+	msgs chan kafka.Message
+
+	mu     sync.Mutex
+	commit []kafka.Message
+}
+
+func newReader() *reader {
+	return &reader{
+		msgs: make(chan kafka.Message),
+	}
+}
+
+func (r *reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, context.Cause(ctx)
+	case msg := <-r.msgs:
+		return msg, nil
+	}
+}
+
+func (r *reader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	r.commit = append(r.commit, msgs...)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *reader) Close() error {
+	return nil
+}
+
+func (r *reader) committed() []kafka.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]kafka.Message(nil), r.commit...)
+}
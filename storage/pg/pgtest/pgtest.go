@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -71,6 +74,14 @@ func DSN() string {
 	return c.dsn
 }
 
+// Schema returns a *sql.DB with an isolated schema for the test, which is
+// dropped when the test ends. The migrations are run on the schema.
+// Unlike Tx, the schema supports concurrent connections and statements
+// that cannot run in a transaction.
+func Schema(t *testing.T) *sql.DB {
+	return c.Schema(t)
+}
+
 type Option func(*config) error
 
 func Hook(fn func(*sql.DB) error) func(*config) error {
@@ -103,11 +114,34 @@ func Image(image string) func(*config) error {
 	}
 }
 
+// Migrations sets the migrations run on every schema created by Schema.
+// The .sql files at the root of the fsys are run in the order of their
+// names.
+func Migrations(fsys fs.FS) func(*config) error {
+	return func(cfg *config) error {
+		cfg.Migrations = fsys
+
+		return nil
+	}
+}
+
+// Connect connects to an existing instance at the dsn, instead of starting a
+// container, e.g. in CI. Defaults to the PGTEST_DSN environment variable.
+func Connect(dsn string) func(*config) error {
+	return func(cfg *config) error {
+		cfg.DSN = dsn
+
+		return nil
+	}
+}
+
 type config struct {
 	Repository string
 	Tag        string
 	Expire     time.Duration
 	Hook       func(*sql.DB) error
+	Migrations fs.FS
+	DSN        string
 }
 
 func newConfig() *config {
@@ -115,6 +149,7 @@ func newConfig() *config {
 		Repository: "postgres",
 		Tag:        "latest",
 		Expire:     10 * time.Minute,
+		DSN:        os.Getenv("PGTEST_DSN"),
 		Hook: func(*sql.DB) error {
 			return nil
 		},
@@ -146,6 +181,13 @@ func newClient(opts ...Option) (*client, error) {
 	}
 
 	c := &client{cfg: cfg}
+	if cfg.DSN != "" {
+		c.dsn = cfg.DSN
+		c.close = func() {}
+
+		return c, nil
+	}
+
 	if err := c.init(); err != nil {
 		return nil, err
 	}
@@ -280,6 +322,41 @@ func (c *client) Tx(t *testing.T) *sql.DB {
 	return db
 }
 
+func (c *client) Schema(t *testing.T) *sql.DB {
+	t.Helper()
+
+	// The name is random instead of sequential, since the instance may be
+	// shared by the test binaries of the other packages.
+	name := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	admin := pg.New(c.dsn)
+	if _, err := admin.Exec(fmt.Sprintf("create schema %q", name)); err != nil {
+		_ = admin.Close()
+		t.Fatal(newError("could not create schema: %s", err))
+	}
+
+	dsn, err := withSearchPath(c.dsn, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pg.New(dsn)
+	t.Cleanup(func() {
+		_ = db.Close()
+
+		if _, err := admin.Exec(fmt.Sprintf("drop schema %q cascade", name)); err != nil {
+			t.Error(newError("could not drop schema: %s", err))
+		}
+		_ = admin.Close()
+	})
+
+	if err := migrate(db, c.cfg.Migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
 type testClient struct {
 	t *testing.T
 	c *client
@@ -312,10 +389,54 @@ func (tc *testClient) Tx() *sql.DB {
 	return tc.c.Tx(tc.t)
 }
 
+func (tc *testClient) Schema() *sql.DB {
+	return tc.c.Schema(tc.t)
+}
+
 func (tc *testClient) DSN() string {
 	return tc.c.dsn
 }
 
+func migrate(db *sql.DB, fsys fs.FS) error {
+	if fsys == nil {
+		return nil
+	}
+
+	// The names are sorted.
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return newError("could not list migrations: %s", err)
+	}
+
+	for _, f := range files {
+		b, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return newError("could not read migration %s: %s", f, err)
+		}
+
+		if _, err := db.Exec(string(b)); err != nil {
+			return newError("could not run migration %s: %s", f, err)
+		}
+	}
+
+	return nil
+}
+
+// withSearchPath sets the default schema of the connections, which lib/pq
+// sends as a run-time parameter.
+func withSearchPath(dsn, schema string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", newError("invalid dsn: %s", err)
+	}
+
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
 func newError(msg string, args ...any) error {
 	return fmt.Errorf("%w: %s", Error, fmt.Sprintf(msg, args...))
 }
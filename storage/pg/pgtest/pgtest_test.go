@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/alextanhongpin/core/storage/pg/pgtest"
 	_ "github.com/lib/pq"
@@ -14,6 +15,10 @@ import (
 var opts = []pgtest.Option{
 	pgtest.Image("postgres:15.1-alpine"),
 	pgtest.Hook(migrate),
+	pgtest.Migrations(fstest.MapFS{
+		"001_create_numbers.sql": {Data: []byte(`create table numbers(n int);`)},
+		"002_seed_numbers.sql":   {Data: []byte(`insert into numbers(n) values (0);`)},
+	}),
 }
 
 func TestMain(m *testing.M) {
@@ -103,6 +108,46 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestSchema(t *testing.T) {
+	for i := range 3 {
+		t.Run(fmt.Sprintf("goroutine:%d", i+1), func(t *testing.T) {
+			t.Parallel()
+
+			// Each schema is migrated and seeded separately.
+			db := pgtest.Schema(t)
+
+			var got int
+			if err := db.QueryRow(`select count(*) from numbers`).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if want := 1; want != got {
+				t.Fatalf("want %d, got %d", want, got)
+			}
+
+			// Unlike Tx, the statements run in separate connections.
+			var wg sync.WaitGroup
+			for j := range 3 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					if _, err := db.Exec(`insert into numbers(n) values ($1)`, j); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if err := db.QueryRow(`select count(*) from numbers`).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if want := 4; want != got {
+				t.Fatalf("want %d, got %d", want, got)
+			}
+		})
+	}
+}
+
 func testDB(db *sql.DB, i int) error {
 	_, err := db.Exec(`insert into numbers(n) values ($1)`, i)
 	if err != nil {
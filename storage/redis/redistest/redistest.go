@@ -47,7 +47,19 @@ func Client(t *testing.T) *redis.Client {
 	return c.Client(t)
 }
 
+// New returns an isolated database for the test.
+// If Init was called, a free logical database of the shared instance is
+// allocated, and flushed when the test ends, so the tests can run with
+// t.Parallel. New blocks until a database is free. The options are ignored,
+// since they are already applied by Init.
+// Otherwise, a new instance is started for the test.
 func New(t *testing.T, opts ...Option) *testClient {
+	t.Helper()
+
+	if c != nil {
+		return c.acquire(t)
+	}
+
 	return newTestClient(t, opts...)
 }
 
@@ -56,12 +68,14 @@ type Option func(c *config) error
 type config struct {
 	Repository string
 	Tag        string
+	Databases  int
 }
 
 func newConfig() *config {
 	return &config{
 		Repository: "redis",
 		Tag:        "latest",
+		Databases:  64,
 	}
 }
 
@@ -88,10 +102,25 @@ func Image(image string) Option {
 	}
 }
 
+// Databases sets the number of logical databases of the instance, which
+// limits the number of tests using New at the same time. Database 0 is
+// reserved for Client and Addr. Defaults to 64.
+func Databases(n int) Option {
+	return func(c *config) error {
+		if n < 2 {
+			return newError("databases must be at least 2, got %d", n)
+		}
+
+		c.Databases = n
+		return nil
+	}
+}
+
 type client struct {
 	cfg   *config
 	addr  string
 	close func()
+	dbs   chan int
 }
 
 func newClient(opts ...Option) (*client, error) {
@@ -102,6 +131,10 @@ func newClient(opts ...Option) (*client, error) {
 
 	c := &client{
 		cfg: cfg,
+		dbs: make(chan int, cfg.Databases-1),
+	}
+	for db := 1; db < cfg.Databases; db++ {
+		c.dbs <- db
 	}
 
 	if err := c.init(); err != nil {
@@ -112,8 +145,13 @@ func newClient(opts ...Option) (*client, error) {
 }
 
 func (c *client) Client(t *testing.T) *redis.Client {
+	return c.client(t, 0)
+}
+
+func (c *client) client(t *testing.T, db int) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr: c.addr,
+		DB:   db,
 	})
 
 	t.Cleanup(func() {
@@ -134,7 +172,11 @@ func (c *client) init() error {
 		return newError("could not connect to Docker: %s", err)
 	}
 
-	resource, err := pool.Run(c.cfg.Repository, c.cfg.Tag, nil)
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: c.cfg.Repository,
+		Tag:        c.cfg.Tag,
+		Cmd:        []string{"redis-server", "--databases", fmt.Sprint(c.cfg.Databases)},
+	})
 	if err != nil {
 		return newError("could not start resource: %s", err)
 	}
@@ -160,9 +202,51 @@ func (c *client) init() error {
 	return nil
 }
 
+// acquire allocates a free logical database for the test, which is flushed
+// and released when the test ends.
+func (c *client) acquire(t *testing.T) *testClient {
+	t.Helper()
+
+	db := <-c.dbs
+	tc := &testClient{
+		t:  t,
+		c:  c,
+		db: db,
+	}
+
+	flush := func() error {
+		client := redis.NewClient(&redis.Options{
+			Addr: c.addr,
+			DB:   db,
+		})
+		defer client.Close()
+
+		return client.FlushDB(context.Background()).Err()
+	}
+
+	// Flush before use too, in case the previous test did not clean up.
+	if err := flush(); err != nil {
+		c.dbs <- db
+		t.Fatal(newError("could not flush db %d: %s", db, err))
+	}
+
+	t.Cleanup(func() {
+		defer func() {
+			c.dbs <- db
+		}()
+
+		if err := flush(); err != nil {
+			t.Error(newError("could not flush db %d: %s", db, err))
+		}
+	})
+
+	return tc
+}
+
 type testClient struct {
-	t *testing.T
-	c *client
+	t  *testing.T
+	c  *client
+	db int
 }
 
 func newTestClient(t *testing.T, opts ...Option) *testClient {
@@ -184,8 +268,15 @@ func (tc *testClient) Addr() string {
 	return tc.c.addr
 }
 
+// DB returns the logical database of the test.
+func (tc *testClient) DB() int {
+	return tc.db
+}
+
+// Client returns a client of the database, which is closed when the test
+// ends.
 func (tc *testClient) Client() *redis.Client {
-	return tc.c.Client(tc.t)
+	return tc.c.client(tc.t, tc.db)
 }
 
 func newError(msg string, args ...any) error {
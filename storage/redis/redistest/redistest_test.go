@@ -44,7 +44,7 @@ func TestRedisPing(t *testing.T) {
 }
 
 func TestRedisNew(t *testing.T) {
-	// Setup two separate redis instance.
+	// Allocate two separate databases.
 	// They do not share the same data.
 	db1 := redistest.New(t).Client()
 	db2 := redistest.New(t).Client()
@@ -66,3 +66,25 @@ func TestRedisNew(t *testing.T) {
 		t.Fatalf("want redis.Nil, got %v", err)
 	}
 }
+
+func TestRedisNewParallel(t *testing.T) {
+	for range 10 {
+		t.Run("parallel", func(t *testing.T) {
+			t.Parallel()
+
+			tc := redistest.New(t)
+			if tc.DB() == 0 {
+				t.Fatal("want non-zero db")
+			}
+
+			// The tests use the same key without conflicts.
+			n, err := tc.Client().Incr(ctx, "counter").Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Fatalf("want 1, got %d", n)
+			}
+		})
+	}
+}
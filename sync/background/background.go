@@ -5,8 +5,12 @@ package background
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"runtime/debug"
 	"sync"
+
+	"github.com/alextanhongpin/core/sync/promise"
 )
 
 var ErrTerminated = errors.New("worker: terminated")
@@ -73,3 +77,59 @@ func (w *Worker[T]) init(ctx context.Context) func() {
 		wg.Wait()
 	}
 }
+
+// ResultWorker is like Worker, but the handler returns a result, which
+// resolves the promise returned by Submit.
+type ResultWorker[T, R any] struct {
+	w *Worker[task[T, R]]
+}
+
+type task[T, R any] struct {
+	v T
+	p *promise.Promise[R]
+}
+
+// PanicError is the error the promise is rejected with when the task
+// panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("background: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// NewResult returns a new background manager for tasks with results. A panic
+// in fn rejects the promise with a PanicError, instead of crashing the
+// worker.
+func NewResult[T, R any](ctx context.Context, n int, fn func(context.Context, T) (R, error)) (*ResultWorker[T, R], func()) {
+	w, stop := New(ctx, n, func(ctx context.Context, t task[T, R]) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.p.Reject(&PanicError{Value: r, Stack: debug.Stack()})
+			}
+		}()
+
+		v, err := fn(ctx, t.v)
+		if err != nil {
+			t.p.Reject(err)
+		} else {
+			t.p.Resolve(v)
+		}
+	})
+
+	return &ResultWorker[T, R]{w: w}, stop
+}
+
+// Submit sends the task to the worker, and returns the promise of the
+// result. The promise is rejected if the worker is stopped before the task
+// is received.
+func (w *ResultWorker[T, R]) Submit(v T) *promise.Promise[R] {
+	p := promise.Deferred[R]()
+	if err := w.w.Send(task[T, R]{v: v, p: p}); err != nil {
+		p.Reject(err)
+	}
+
+	return p
+}
@@ -2,6 +2,7 @@ package background_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/alextanhongpin/core/sync/background"
@@ -21,6 +22,28 @@ func TestBackground(t *testing.T) {
 		is.Nil(bg.Send(42))
 	})
 
+	t.Run("panic", func(t *testing.T) {
+		bg, stop := background.NewResult(ctx, 1, func(ctx context.Context, n int) (int, error) {
+			if n < 0 {
+				panic("negative")
+			}
+
+			return n, nil
+		})
+		defer stop()
+
+		is := assert.New(t)
+		_, err := bg.Submit(-1).Await()
+		var pe *background.PanicError
+		is.ErrorAs(err, &pe)
+		is.Equal("negative", pe.Value)
+
+		// The worker keeps running.
+		n, err := bg.Submit(1).Await()
+		is.Nil(err)
+		is.Equal(1, n)
+	})
+
 	t.Run("early stop", func(t *testing.T) {
 		is := assert.New(t)
 		bg, stop := background.New(ctx, -1, func(ctx context.Context, n int) {
@@ -31,3 +54,61 @@ func TestBackground(t *testing.T) {
 		is.ErrorIs(bg.Send(1), background.ErrTerminated)
 	})
 }
+
+func TestResultWorker(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		bg, stop := background.NewResult(ctx, 2, func(ctx context.Context, n int) (int, error) {
+			if n < 0 {
+				return 0, errors.New("negative")
+			}
+
+			return n * n, nil
+		})
+		defer stop()
+
+		is := assert.New(t)
+		p1 := bg.Submit(3)
+		p2 := bg.Submit(-1)
+
+		n, err := p1.Await()
+		is.Nil(err)
+		is.Equal(9, n)
+
+		_, err = p2.Await()
+		is.EqualError(err, "negative")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		bg, stop := background.NewResult(ctx, 1, func(ctx context.Context, n int) (int, error) {
+			if n < 0 {
+				panic("negative")
+			}
+
+			return n, nil
+		})
+		defer stop()
+
+		is := assert.New(t)
+		_, err := bg.Submit(-1).Await()
+		var pe *background.PanicError
+		is.ErrorAs(err, &pe)
+		is.Equal("negative", pe.Value)
+
+		// The worker keeps running.
+		n, err := bg.Submit(1).Await()
+		is.Nil(err)
+		is.Equal(1, n)
+	})
+
+	t.Run("early stop", func(t *testing.T) {
+		bg, stop := background.NewResult(ctx, -1, func(ctx context.Context, n int) (int, error) {
+			return n, nil
+		})
+		stop()
+
+		_, err := bg.Submit(1).Await()
+
+		is := assert.New(t)
+		is.ErrorIs(err, background.ErrTerminated)
+	})
+}
@@ -1,7 +1,9 @@
 package batch
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"sync"
 	"time"
 )
@@ -13,22 +15,67 @@ type cache[K comparable, V any] interface {
 
 var _ cache[int, any] = (*Cache[int, any])(nil)
 
+type CacheOptions[V any] struct {
+	// MaxEntries is the maximum number of entries before the least recently
+	// used entry is evicted. Zero means no limit.
+	MaxEntries int
+
+	// MaxCost is the maximum total cost of the entries before the least
+	// recently used entry is evicted. Zero means no limit.
+	MaxCost int64
+
+	// CostFn returns the approximate cost, e.g. size in bytes, of the value.
+	// Required when MaxCost is set.
+	CostFn func(V) int64
+}
+
+func (o *CacheOptions[V]) Valid() error {
+	if o.MaxEntries < 0 {
+		return errors.New("batch: MaxEntries must not be negative")
+	}
+	if o.MaxCost < 0 {
+		return errors.New("batch: MaxCost must not be negative")
+	}
+	if o.MaxCost > 0 && o.CostFn == nil {
+		return errors.New("batch: CostFn is required when MaxCost is set")
+	}
+
+	return nil
+}
+
 type Cache[K comparable, V any] struct {
+	opts *CacheOptions[V]
+
 	mu   sync.Mutex
-	data map[K]*value[V]
+	data map[K]*list.Element
+	ll   *list.List
+	cost int64
 }
 
 func NewCache[K comparable, V any]() *Cache[K, V] {
+	return NewLRUCache[K](new(CacheOptions[V]))
+}
+
+// NewLRUCache returns a cache that evicts the least recently used entries
+// when either the number of entries or the total cost exceeds the limit.
+func NewLRUCache[K comparable, V any](opts *CacheOptions[V]) *Cache[K, V] {
+	if err := opts.Valid(); err != nil {
+		panic(err)
+	}
+
 	return &Cache[K, V]{
-		data: make(map[K]*value[V]),
+		opts: opts,
+		data: make(map[K]*list.Element),
+		ll:   list.New(),
 	}
 }
 
 func (c *Cache[K, V]) StoreMany(ctx context.Context, kv map[K]V, ttl time.Duration) error {
 	c.mu.Lock()
 	for k, v := range kv {
-		c.data[k] = newValue(v, ttl)
+		c.store(k, v, ttl)
 	}
+	c.evict()
 	c.mu.Unlock()
 
 	return nil
@@ -39,15 +86,17 @@ func (c *Cache[K, V]) LoadMany(ctx context.Context, ks ...K) (map[K]V, error) {
 
 	c.mu.Lock()
 	for _, k := range ks {
-		v, ok := c.data[k]
+		e, ok := c.data[k]
 		if !ok {
 			continue
 		}
+		v := e.Value.(*value[K, V])
 		if v.expired() {
-			delete(c.data, k)
+			c.remove(e)
 			continue
 		}
 
+		c.ll.MoveToFront(e)
 		m[k] = v.data
 	}
 	c.mu.Unlock()
@@ -55,19 +104,77 @@ func (c *Cache[K, V]) LoadMany(ctx context.Context, ks ...K) (map[K]V, error) {
 	return m, nil
 }
 
-type value[T any] struct {
+// Len returns the number of entries, including expired entries that are not
+// yet evicted.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	n := c.ll.Len()
+	c.mu.Unlock()
+
+	return n
+}
+
+// Cost returns the total cost of the entries.
+func (c *Cache[K, V]) Cost() int64 {
+	c.mu.Lock()
+	n := c.cost
+	c.mu.Unlock()
+
+	return n
+}
+
+func (c *Cache[K, V]) store(k K, v V, ttl time.Duration) {
+	if e, ok := c.data[k]; ok {
+		c.remove(e)
+	}
+
+	val := newValue(k, v, ttl)
+	if c.opts.CostFn != nil {
+		val.cost = c.opts.CostFn(v)
+	}
+	c.data[k] = c.ll.PushFront(val)
+	c.cost += val.cost
+}
+
+func (c *Cache[K, V]) evict() {
+	for c.overflow() {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *Cache[K, V]) overflow() bool {
+	if c.ll.Len() == 0 {
+		return false
+	}
+	if c.opts.MaxEntries > 0 && c.ll.Len() > c.opts.MaxEntries {
+		return true
+	}
+
+	return c.opts.MaxCost > 0 && c.cost > c.opts.MaxCost
+}
+
+func (c *Cache[K, V]) remove(e *list.Element) {
+	v := c.ll.Remove(e).(*value[K, V])
+	delete(c.data, v.key)
+	c.cost -= v.cost
+}
+
+type value[K comparable, T any] struct {
+	key      K
 	data     T
+	cost     int64
 	deadline time.Time
 }
 
-func newValue[T any](v T, ttl time.Duration) *value[T] {
-	return &value[T]{
+func newValue[K comparable, T any](k K, v T, ttl time.Duration) *value[K, T] {
+	return &value[K, T]{
+		key:      k,
 		data:     v,
 		deadline: time.Now().Add(ttl),
 	}
 }
 
-func (v *value[T]) expired() bool {
+func (v *value[K, T]) expired() bool {
 	return gte(time.Now(), v.deadline)
 }
 
@@ -43,3 +43,46 @@ func TestCache(t *testing.T) {
 	is.Nil(err)
 	is.Len(res, 0)
 }
+
+func TestLRUCache(t *testing.T) {
+	t.Run("max entries", func(t *testing.T) {
+		cache := batch.NewLRUCache[int](&batch.CacheOptions[int]{
+			MaxEntries: 2,
+		})
+
+		is := assert.New(t)
+		is.Nil(cache.StoreMany(ctx, map[int]int{1: 100, 2: 200}, time.Hour))
+
+		// Access 1, so that 2 becomes the least recently used.
+		_, err := cache.LoadMany(ctx, 1)
+		is.Nil(err)
+
+		is.Nil(cache.StoreMany(ctx, map[int]int{3: 300}, time.Hour))
+		is.Equal(2, cache.Len())
+
+		res, err := cache.LoadMany(ctx, 1, 2, 3)
+		is.Nil(err)
+		is.Equal(map[int]int{1: 100, 3: 300}, res)
+	})
+
+	t.Run("max cost", func(t *testing.T) {
+		cache := batch.NewLRUCache[int](&batch.CacheOptions[string]{
+			MaxCost: 10,
+			CostFn: func(s string) int64 {
+				return int64(len(s))
+			},
+		})
+
+		is := assert.New(t)
+		is.Nil(cache.StoreMany(ctx, map[int]string{1: "hello"}, time.Hour))
+		is.Nil(cache.StoreMany(ctx, map[int]string{2: "world"}, time.Hour))
+		is.Equal(int64(10), cache.Cost())
+
+		is.Nil(cache.StoreMany(ctx, map[int]string{3: "!"}, time.Hour))
+		is.Equal(int64(6), cache.Cost())
+
+		res, err := cache.LoadMany(ctx, 1, 2, 3)
+		is.Nil(err)
+		is.Equal(map[int]string{2: "world", 3: "!"}, res)
+	})
+}
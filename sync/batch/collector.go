@@ -0,0 +1,162 @@
+package batch
+
+import (
+	"cmp"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCollectorClosed = errors.New("batch: collector closed")
+
+type CollectorOptions[T any] struct {
+	BatchSize int
+	Timeout   time.Duration
+	FlushFn   func([]T)
+}
+
+func (o *CollectorOptions[T]) Valid() error {
+	o.BatchSize = cmp.Or(o.BatchSize, 100)
+	o.Timeout = cmp.Or(o.Timeout, time.Second)
+	if o.BatchSize <= 0 {
+		return errors.New("batch: BatchSize must be greater than 0")
+	}
+	if o.Timeout <= 0 {
+		return errors.New("batch: Timeout must be greater than 0")
+	}
+	if o.FlushFn == nil {
+		return errors.New("batch: FlushFn is required")
+	}
+
+	return nil
+}
+
+// Collector accumulates items and flushes them when either BatchSize items
+// are collected, or Timeout elapsed since the first item of the batch was
+// added.
+type Collector[T any] struct {
+	opts *CollectorOptions[T]
+
+	mu     sync.Mutex
+	items  []T
+	gen    int
+	t      *time.Timer
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewCollector returns a new collector, and a stop function that flushes the
+// remaining items before returning.
+func NewCollector[T any](opts *CollectorOptions[T]) (*Collector[T], func()) {
+	if err := opts.Valid(); err != nil {
+		panic(err)
+	}
+
+	c := &Collector[T]{
+		opts:  opts,
+		items: make([]T, 0, opts.BatchSize),
+	}
+
+	return c, sync.OnceFunc(c.close)
+}
+
+// Add adds the items to the current batch. The batch is flushed in the
+// caller's goroutine once it reaches the batch size.
+func (c *Collector[T]) Add(vs ...T) error {
+	for _, v := range vs {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return ErrCollectorClosed
+		}
+
+		c.items = append(c.items, v)
+		if len(c.items) == 1 {
+			c.schedule()
+		}
+
+		var batch []T
+		if len(c.items) >= c.opts.BatchSize {
+			batch = c.swap()
+		}
+		c.mu.Unlock()
+
+		if len(batch) > 0 {
+			c.opts.FlushFn(batch)
+		}
+	}
+
+	return nil
+}
+
+// Flush flushes the current batch immediately, regardless of its size.
+func (c *Collector[T]) Flush() {
+	c.mu.Lock()
+	batch := c.swap()
+	c.mu.Unlock()
+
+	if len(batch) > 0 {
+		c.opts.FlushFn(batch)
+	}
+}
+
+// Len returns the number of items pending flush.
+func (c *Collector[T]) Len() int {
+	c.mu.Lock()
+	n := len(c.items)
+	c.mu.Unlock()
+
+	return n
+}
+
+func (c *Collector[T]) close() {
+	c.mu.Lock()
+	c.closed = true
+	batch := c.swap()
+	c.mu.Unlock()
+
+	if len(batch) > 0 {
+		c.opts.FlushFn(batch)
+	}
+
+	// Wait for in-flight timer flushes.
+	c.wg.Wait()
+}
+
+// schedule starts the timer for the current batch. The generation ensures
+// the timer does not flush a batch that was already flushed by size.
+func (c *Collector[T]) schedule() {
+	gen := c.gen
+	c.wg.Add(1)
+	c.t = time.AfterFunc(c.opts.Timeout, func() {
+		defer c.wg.Done()
+
+		c.mu.Lock()
+		if c.gen != gen {
+			c.mu.Unlock()
+			return
+		}
+		batch := c.swap()
+		c.mu.Unlock()
+
+		if len(batch) > 0 {
+			c.opts.FlushFn(batch)
+		}
+	})
+}
+
+// swap must be called with the lock held.
+func (c *Collector[T]) swap() []T {
+	if c.t != nil {
+		if c.t.Stop() {
+			c.wg.Done()
+		}
+		c.t = nil
+	}
+	c.gen++
+
+	batch := c.items
+	c.items = make([]T, 0, c.opts.BatchSize)
+
+	return batch
+}
@@ -0,0 +1,71 @@
+package batch_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/batch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	t.Run("flush by size", func(t *testing.T) {
+		var batches [][]int
+		c, stop := batch.NewCollector(&batch.CollectorOptions[int]{
+			BatchSize: 2,
+			Timeout:   time.Hour,
+			FlushFn: func(vs []int) {
+				batches = append(batches, vs)
+			},
+		})
+		defer stop()
+
+		is := assert.New(t)
+		is.Nil(c.Add(1, 2, 3))
+		is.Equal([][]int{{1, 2}}, batches)
+		is.Equal(1, c.Len())
+	})
+
+	t.Run("flush by timeout", func(t *testing.T) {
+		var mu sync.Mutex
+		var batches [][]int
+		c, stop := batch.NewCollector(&batch.CollectorOptions[int]{
+			BatchSize: 10,
+			Timeout:   10 * time.Millisecond,
+			FlushFn: func(vs []int) {
+				mu.Lock()
+				batches = append(batches, vs)
+				mu.Unlock()
+			},
+		})
+		defer stop()
+
+		is := assert.New(t)
+		is.Nil(c.Add(1, 2, 3))
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		is.Equal([][]int{{1, 2, 3}}, batches)
+		mu.Unlock()
+		is.Equal(0, c.Len())
+	})
+
+	t.Run("flush on stop", func(t *testing.T) {
+		var batches [][]int
+		c, stop := batch.NewCollector(&batch.CollectorOptions[int]{
+			BatchSize: 10,
+			Timeout:   time.Hour,
+			FlushFn: func(vs []int) {
+				batches = append(batches, vs)
+			},
+		})
+
+		is := assert.New(t)
+		is.Nil(c.Add(1, 2, 3))
+		stop()
+
+		is.Equal([][]int{{1, 2, 3}}, batches)
+		is.ErrorIs(c.Add(4), batch.ErrCollectorClosed)
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,8 +34,33 @@ func (o *LoaderOptions[K, V]) Valid() error {
 	return nil
 }
 
+// Loader fetches the keys in batches, and caches the results.
+// Concurrent loads of the same keys are coalesced, so that each key is
+// fetched at most once at a time.
 type Loader[K comparable, V any] struct {
 	opts *LoaderOptions[K, V]
+
+	mu       sync.Mutex
+	inflight map[K]*call[V]
+
+	fetched   atomic.Int64
+	coalesced atomic.Int64
+}
+
+// call is the in-flight fetch of a key.
+type call[V any] struct {
+	done chan struct{}
+	res  *Result[V]
+	err  error
+}
+
+// LoaderMetrics is a snapshot of the loader metrics.
+type LoaderMetrics struct {
+	// Fetched is the number of keys fetched by BatchFn.
+	Fetched int64
+	// Coalesced is the number of keys that waited for the in-flight fetch of
+	// another load, instead of being fetched again.
+	Coalesced int64
 }
 
 func NewLoader[K comparable, V any](opts *LoaderOptions[K, V]) *Loader[K, V] {
@@ -42,7 +69,15 @@ func NewLoader[K comparable, V any](opts *LoaderOptions[K, V]) *Loader[K, V] {
 	}
 
 	return &Loader[K, V]{
-		opts: opts,
+		opts:     opts,
+		inflight: make(map[K]*call[V]),
+	}
+}
+
+func (l *Loader[K, V]) Metrics() LoaderMetrics {
+	return LoaderMetrics{
+		Fetched:   l.fetched.Load(),
+		Coalesced: l.coalesced.Load(),
 	}
 }
 
@@ -96,29 +131,86 @@ func (l *Loader[K, V]) LoadManyResult(ctx context.Context, ks []K) (map[K]*Resul
 		return res, nil
 	}
 
-	// Fetch the pending keys.
-	b, err := l.opts.BatchFn(pks)
+	// Only fetch the pending keys that are not already in-flight, in the
+	// order of the keys.
+	fks := make([]K, 0, len(pks))
+	own := make(map[K]*call[V])
+	wait := make(map[K]*call[V])
+	l.mu.Lock()
+	for _, k := range pks {
+		if _, ok := own[k]; ok {
+			continue
+		}
+		if c, ok := l.inflight[k]; ok {
+			wait[k] = c
+			continue
+		}
+
+		c := &call[V]{done: make(chan struct{})}
+		l.inflight[k] = c
+		own[k] = c
+		fks = append(fks, k)
+	}
+	l.mu.Unlock()
+	l.coalesced.Add(int64(len(wait)))
+
+	if len(own) > 0 {
+		if err := l.fetch(ctx, fks, own); err != nil {
+			return nil, err
+		}
+		for k, c := range own {
+			res[k] = c.res
+		}
+	}
+
+	for k, c := range wait {
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-c.done:
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		res[k] = c.res
+	}
+
+	return res, nil
+}
+
+// fetch fetches the keys, and stores them in the cache before releasing the
+// waiting loads. The calls are indexed by the keys.
+func (l *Loader[K, V]) fetch(ctx context.Context, ks []K, calls map[K]*call[V]) (err error) {
+	defer func() {
+		l.mu.Lock()
+		for k, c := range calls {
+			c.err = err
+			delete(l.inflight, k)
+			close(c.done)
+		}
+		l.mu.Unlock()
+	}()
+
+	l.fetched.Add(int64(len(ks)))
+
+	b, err := l.opts.BatchFn(ks)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Stores the new keys in the cache.
 	n := make(map[K]*Result[V])
-	for _, k := range pks {
+	for k, c := range calls {
 		v, ok := b[k]
 		if ok {
 			n[k] = newResult(v, nil)
 		} else {
 			n[k] = newResult(v, newKeyError(fmt.Sprint(k), ErrKeyNotExist))
 		}
-		res[k] = n[k]
+		c.res = n[k]
 	}
 
-	if err := l.opts.Cache.StoreMany(ctx, n, l.opts.TTL); err != nil {
-		return nil, err
-	}
-
-	return res, nil
+	return l.opts.Cache.StoreMany(ctx, n, l.opts.TTL)
 }
 
 type Result[T any] struct {
@@ -1,8 +1,11 @@
 package batch_test
 
 import (
+	"slices"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/alextanhongpin/core/sync/batch"
 	"github.com/stretchr/testify/assert"
@@ -76,6 +79,87 @@ func TestLoader_LoadManyResult(t *testing.T) {
 	})
 }
 
+func TestLoader_Coalesce(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetched [][]int
+		started = make(chan bool)
+		release = make(chan bool)
+	)
+	loader := batch.NewLoader(&batch.LoaderOptions[int, string]{
+		BatchFn: func(ks []int) (map[int]string, error) {
+			mu.Lock()
+			fetched = append(fetched, ks)
+			first := len(fetched) == 1
+			mu.Unlock()
+
+			// Blocks the first fetch until the second load is waiting.
+			if first {
+				close(started)
+				<-release
+			}
+
+			res := make(map[int]string)
+			for _, k := range ks {
+				res[k] = strconv.Itoa(k)
+			}
+
+			return res, nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		vs, err := loader.LoadMany(ctx, []int{1, 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "2"}, vs)
+	}()
+
+	<-started
+	go func() {
+		defer wg.Done()
+
+		vs, err := loader.LoadMany(ctx, []int{2, 3})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"2", "3"}, vs)
+	}()
+
+	for loader.Metrics().Coalesced == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	is := assert.New(t)
+	is.ElementsMatch([][]int{{1, 2}, {3}}, fetched)
+	is.Equal(batch.LoaderMetrics{Fetched: 3, Coalesced: 1}, loader.Metrics())
+}
+
+func TestLoader_Order(t *testing.T) {
+	var fetched []int
+	loader := batch.NewLoader(&batch.LoaderOptions[int, string]{
+		BatchFn: func(ks []int) (map[int]string, error) {
+			fetched = slices.Clone(ks)
+
+			res := make(map[int]string)
+			for _, k := range ks {
+				res[k] = strconv.Itoa(k)
+			}
+
+			return res, nil
+		},
+	})
+
+	// The keys are fetched in the order passed, without the duplicates.
+	is := assert.New(t)
+	_, err := loader.LoadMany(ctx, []int{5, 3, 9, 1, 3, 7})
+	is.Nil(err)
+	is.Equal([]int{5, 3, 9, 1, 7}, fetched)
+}
+
 func newBatchLoader() *batch.Loader[int, string] {
 	return batch.NewLoader(&batch.LoaderOptions[int, string]{
 		BatchFn: func(ks []int) (map[int]string, error) {
@@ -49,10 +49,24 @@ type Breaker struct {
 	SlowCallCount    func(time.Duration) int
 	SuccessThreshold int
 
+	// HalfOpenMaxCalls is the maximum number of concurrent probe calls in
+	// the half-open state. The other calls fail with ErrBrokenCircuit, so that
+	// the recovering dependency is not overwhelmed. Zero means no limit.
+	HalfOpenMaxCalls int
+
+	// OnTransition is called after the status changes.
+	OnTransition func(Transition)
+
+	// Store persists the state after the status changes, so that it can be
+	// restored with Restore after a restart.
+	Store Store
+
 	// State.
 	mu     sync.RWMutex
 	status Status
+	until  time.Time
 	timer  *time.Timer
+	probes int
 }
 
 func New() *Breaker {
@@ -91,6 +105,22 @@ func (b *Breaker) Status() Status {
 	return status
 }
 
+// Metrics returns the status and the error rate within the sampling
+// duration.
+func (b *Breaker) Metrics() Metrics {
+	b.mu.RLock()
+	status := b.status
+	b.mu.RUnlock()
+
+	r := b.Counter.Rate()
+	return Metrics{
+		Status:      status,
+		Success:     r.Success(),
+		Failure:     r.Failure(),
+		FailureRate: failureRate(r.Success(), r.Failure()),
+	}
+}
+
 func (b *Breaker) Do(fn func() error) error {
 	switch b.Status() {
 	case Open:
@@ -115,16 +145,24 @@ func (b *Breaker) canOpen(n int) bool {
 }
 
 func (b *Breaker) open() {
+	b.openUntil(time.Now().Add(b.BreakDuration))
+}
+
+func (b *Breaker) openUntil(until time.Time) {
 	b.mu.Lock()
+	from := b.status
 	b.status = Open
+	b.until = until
 	b.Counter.Reset()
 	if b.timer != nil {
 		b.timer.Stop()
 	}
-	b.timer = time.AfterFunc(b.BreakDuration, func() {
+	b.timer = time.AfterFunc(time.Until(until), func() {
 		b.halfOpen()
 	})
 	b.mu.Unlock()
+
+	b.transition(from, Open)
 }
 
 func (b *Breaker) opened() error {
@@ -139,9 +177,12 @@ func (b *Breaker) canClose() bool {
 
 func (b *Breaker) close() {
 	b.mu.Lock()
+	from := b.status
 	b.status = Closed
 	b.Counter.Reset()
 	b.mu.Unlock()
+
+	b.transition(from, Closed)
 }
 
 func (b *Breaker) closed(fn func() error) error {
@@ -170,12 +211,38 @@ func (b *Breaker) closed(fn func() error) error {
 
 func (b *Breaker) halfOpen() {
 	b.mu.Lock()
+	from := b.status
 	b.status = HalfOpen
 	b.Counter.Reset()
 	b.mu.Unlock()
+
+	b.transition(from, HalfOpen)
+}
+
+func (b *Breaker) transition(from, to Status) {
+	if from == to {
+		return
+	}
+
+	b.save()
+
+	if b.OnTransition == nil {
+		return
+	}
+
+	b.OnTransition(Transition{
+		From: from,
+		To:   to,
+		At:   time.Now(),
+	})
 }
 
 func (b *Breaker) halfOpened(fn func() error) error {
+	if !b.probe() {
+		return ErrBrokenCircuit
+	}
+	defer b.probeDone()
+
 	start := time.Now()
 	if err := fn(); err != nil {
 		b.open()
@@ -197,6 +264,25 @@ func (b *Breaker) halfOpened(fn func() error) error {
 	return nil
 }
 
+// probe reserves a probe call in the half-open state.
+func (b *Breaker) probe() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.HalfOpenMaxCalls > 0 && b.probes >= b.HalfOpenMaxCalls {
+		return false
+	}
+	b.probes++
+
+	return true
+}
+
+func (b *Breaker) probeDone() {
+	b.mu.Lock()
+	b.probes--
+	b.mu.Unlock()
+}
+
 func (b *Breaker) isHealthy(success, _ float64) bool {
 	return math.Ceil(success) >= float64(b.SuccessThreshold)
 }
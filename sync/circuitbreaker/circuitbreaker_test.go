@@ -3,6 +3,7 @@ package circuitbreaker_test
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -87,6 +88,47 @@ func TestHalfOpenFail(t *testing.T) {
 	is.Equal(circuitbreaker.Open, cb.Status())
 }
 
+func TestHalfOpenMaxCalls(t *testing.T) {
+	cb := circuitbreaker.New()
+	cb.BreakDuration = 50 * time.Millisecond
+	cb.HalfOpenMaxCalls = 1
+
+	for range cb.FailureThreshold {
+		_ = cb.Do(func() error {
+			return wantErr
+		})
+	}
+
+	time.Sleep(cb.BreakDuration + 5*time.Millisecond)
+	is := assert.New(t)
+	is.Equal(circuitbreaker.HalfOpen, cb.Status())
+
+	probing := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- cb.Do(func() error {
+			close(probing)
+			time.Sleep(10 * time.Millisecond)
+
+			return nil
+		})
+	}()
+	<-probing
+
+	// Rejected while the probe is in flight.
+	err := cb.Do(func() error {
+		return nil
+	})
+	is.ErrorIs(err, circuitbreaker.ErrBrokenCircuit)
+	is.Nil(<-done)
+
+	// Allowed after the probe completes.
+	err = cb.Do(func() error {
+		return nil
+	})
+	is.Nil(err)
+}
+
 func TestSlowCount(t *testing.T) {
 	cb := circuitbreaker.New()
 	cb.SlowCallCount = func(time.Duration) int {
@@ -102,3 +144,49 @@ func TestSlowCount(t *testing.T) {
 	is.Nil(err)
 	is.Equal(circuitbreaker.Open, cb.Status())
 }
+
+func TestRestore(t *testing.T) {
+	store := circuitbreaker.NewFileStore(filepath.Join(t.TempDir(), "breaker.json"))
+
+	cb := circuitbreaker.New()
+	cb.BreakDuration = 50 * time.Millisecond
+	cb.Store = store
+
+	is := assert.New(t)
+	is.Nil(cb.Restore())
+	is.Equal(circuitbreaker.Closed, cb.Status())
+
+	for range cb.FailureThreshold {
+		_ = cb.Do(func() error {
+			return wantErr
+		})
+	}
+	is.Equal(circuitbreaker.Open, cb.Status())
+
+	t.Run("open", func(t *testing.T) {
+		// The restarted breaker stays open until the break ends.
+		restarted := circuitbreaker.New()
+		restarted.Store = store
+
+		is := assert.New(t)
+		is.Nil(restarted.Restore())
+		is.Equal(circuitbreaker.Open, restarted.Status())
+		is.True(cb.State().Until.Equal(restarted.State().Until))
+
+		time.Sleep(time.Until(cb.State().Until) + 5*time.Millisecond)
+		is.Equal(circuitbreaker.HalfOpen, restarted.Status())
+	})
+
+	t.Run("break ended", func(t *testing.T) {
+		state, err := store.Load()
+		is := assert.New(t)
+		is.Nil(err)
+		is.Equal(circuitbreaker.HalfOpen, state.Status)
+
+		restarted := circuitbreaker.New()
+		restarted.Store = circuitbreaker.EnvStore("CIRCUIT_STATE")
+		t.Setenv("CIRCUIT_STATE", `{"status":"open","until":"2000-01-01T00:00:00Z"}`)
+		is.Nil(restarted.Restore())
+		is.Equal(circuitbreaker.HalfOpen, restarted.Status())
+	})
+}
@@ -0,0 +1,120 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// historySize is the number of transitions kept per breaker.
+const historySize = 20
+
+// StatusHandler renders the status, counters and recent transitions of the
+// registered breakers as JSON, or as HTML with "?format=html".
+//
+//	h := circuitbreaker.NewStatusHandler()
+//	h.Register("payment", cb)
+//	mux.Handle("GET /debug/circuitbreakers", h)
+type StatusHandler struct {
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+	history  map[string]*History
+}
+
+func NewStatusHandler() *StatusHandler {
+	return &StatusHandler{
+		breakers: make(map[string]*Breaker),
+		history:  make(map[string]*History),
+	}
+}
+
+// Register adds the breaker under the name, and starts recording its
+// transitions. The existing OnTransition callback, if any, is still called.
+// Register must be called before the breaker is used.
+func (h *StatusHandler) Register(name string, b *Breaker) {
+	hist := NewHistory(historySize)
+	next := b.OnTransition
+	b.OnTransition = func(t Transition) {
+		hist.Record(t)
+		if next != nil {
+			next(t)
+		}
+	}
+
+	h.mu.Lock()
+	h.breakers[name] = b
+	h.history[name] = hist
+	h.mu.Unlock()
+}
+
+type BreakerStatus struct {
+	Name        string       `json:"name"`
+	Status      Status       `json:"status"`
+	Success     float64      `json:"success"`
+	Failure     float64      `json:"failure"`
+	FailureRate float64      `json:"failure_rate"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Statuses returns the status of the registered breakers, sorted by name.
+func (h *StatusHandler) Statuses() []BreakerStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := slices.Sorted(maps.Keys(h.breakers))
+	res := make([]BreakerStatus, len(names))
+	for i, name := range names {
+		m := h.breakers[name].Metrics()
+		res[i] = BreakerStatus{
+			Name:        name,
+			Status:      m.Status,
+			Success:     m.Success,
+			Failure:     m.Failure,
+			FailureRate: m.FailureRate,
+			Transitions: h.history[name].List(),
+		}
+	}
+
+	return res
+}
+
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	statuses := h.Statuses()
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusTemplate.Execute(w, statuses); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Circuit breakers</title></head>
+<body>
+<table border="1">
+<tr><th>Name</th><th>Status</th><th>Success</th><th>Failure</th><th>Failure rate</th><th>Transitions</th></tr>
+{{- range .}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{.Status}}</td>
+<td>{{printf "%.0f" .Success}}</td>
+<td>{{printf "%.0f" .Failure}}</td>
+<td>{{printf "%.2f" .FailureRate}}</td>
+<td>{{range .Transitions}}{{.At.Format "15:04:05"}} {{.From}} &rarr; {{.To}}<br>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
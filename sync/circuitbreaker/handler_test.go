@@ -0,0 +1,65 @@
+package circuitbreaker_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alextanhongpin/core/sync/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	h := circuitbreaker.NewHistory(2)
+	h.Record(circuitbreaker.Transition{From: circuitbreaker.Closed, To: circuitbreaker.Open})
+	h.Record(circuitbreaker.Transition{From: circuitbreaker.Open, To: circuitbreaker.HalfOpen})
+	h.Record(circuitbreaker.Transition{From: circuitbreaker.HalfOpen, To: circuitbreaker.Closed})
+
+	is := assert.New(t)
+	is.Equal([]circuitbreaker.Transition{
+		{From: circuitbreaker.Open, To: circuitbreaker.HalfOpen},
+		{From: circuitbreaker.HalfOpen, To: circuitbreaker.Closed},
+	}, h.List())
+}
+
+func TestStatusHandler(t *testing.T) {
+	cb := circuitbreaker.New()
+
+	var called int
+	cb.OnTransition = func(circuitbreaker.Transition) {
+		called++
+	}
+
+	h := circuitbreaker.NewStatusHandler()
+	h.Register("payment", cb)
+
+	for range cb.FailureThreshold {
+		_ = cb.Do(func() error {
+			return wantErr
+		})
+	}
+
+	is := assert.New(t)
+	is.Equal(1, called)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	is.Equal(http.StatusOK, rec.Code)
+
+	var got []map[string]any
+	is.Nil(json.Unmarshal(rec.Body.Bytes(), &got))
+	is.Len(got, 1)
+	is.Equal("payment", got[0]["name"])
+	is.Equal("open", got[0]["status"])
+
+	transitions := got[0]["transitions"].([]any)
+	is.Len(transitions, 1)
+	is.Equal("closed", transitions[0].(map[string]any)["from"])
+	is.Equal("open", transitions[0].(map[string]any)["to"])
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?format=html", nil))
+	is.Equal(http.StatusOK, rec.Code)
+	is.Contains(rec.Body.String(), "<td>payment</td>")
+}
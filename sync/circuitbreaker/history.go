@@ -0,0 +1,86 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Metrics struct {
+	Status      Status
+	Success     float64
+	Failure     float64
+	FailureRate float64
+}
+
+type Transition struct {
+	From Status    `json:"from"`
+	To   Status    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Status) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err != nil {
+		return err
+	}
+
+	for status, t := range statusText {
+		if t == text {
+			*s = status
+			return nil
+		}
+	}
+
+	return fmt.Errorf("circuit-breaker: unknown status %q", text)
+}
+
+// History keeps the most recent transitions in a ring buffer.
+//
+//	h := circuitbreaker.NewHistory(10)
+//	cb.OnTransition = h.Record
+type History struct {
+	mu    sync.RWMutex
+	items []Transition
+	next  int
+	full  bool
+}
+
+func NewHistory(size int) *History {
+	if size <= 0 {
+		panic("circuit-breaker: history size must be positive")
+	}
+
+	return &History{
+		items: make([]Transition, size),
+	}
+}
+
+// Record adds the transition, overwriting the oldest one when the history is
+// full.
+func (h *History) Record(t Transition) {
+	h.mu.Lock()
+	h.items[h.next] = t
+	h.next = (h.next + 1) % len(h.items)
+	h.full = h.full || h.next == 0
+	h.mu.Unlock()
+}
+
+// List returns the transitions from oldest to newest.
+func (h *History) List() []Transition {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.full {
+		return append([]Transition(nil), h.items[:h.next]...)
+	}
+
+	res := make([]Transition, 0, len(h.items))
+	res = append(res, h.items[h.next:]...)
+	return append(res, h.items[:h.next]...)
+}
@@ -0,0 +1,148 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the persisted state of the breaker.
+type State struct {
+	Status Status `json:"status"`
+	// Until is the end of the break, when the status is open.
+	Until time.Time `json:"until,omitempty"`
+}
+
+// Store persists the state of the breaker across restarts, so that a restart
+// does not close an open circuit, and hammer the struggling dependency.
+type Store interface {
+	// Load returns nil if there is no state.
+	Load() (*State, error)
+	Save(State) error
+}
+
+// Restore restores the state from the store. The circuit is half-open if the
+// break ended while the service was down.
+func (b *Breaker) Restore() error {
+	if b.Store == nil {
+		return nil
+	}
+
+	s, err := b.Store.Load()
+	if err != nil || s == nil {
+		return err
+	}
+
+	switch {
+	case s.Status == Open && time.Now().Before(s.Until):
+		b.openUntil(s.Until)
+	case s.Status == Open, s.Status == HalfOpen:
+		b.halfOpen()
+	default:
+		b.close()
+	}
+
+	return nil
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	s := State{Status: b.status}
+	if s.Status == Open {
+		s.Until = b.until
+	}
+
+	return s
+}
+
+// save saves the state to the store. The errors are ignored, since the
+// breaker should work without the store. Wrap the store to log the errors.
+func (b *Breaker) save() {
+	if b.Store == nil {
+		return
+	}
+
+	_ = b.Store.Save(b.State())
+}
+
+// FileStore persists the state as JSON in a file, for single-node
+// services.
+type FileStore struct {
+	Path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+func (f *FileStore) Load() (*State, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Save writes the state to a temporary file first, so that the file is never
+// partially written.
+func (f *FileStore) Save(s State) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// EnvStore loads the state as JSON from the environment variable, e.g.
+// CIRCUIT_STATE='{"status":"open","until":"2024-01-01T00:00:00Z"}', so that
+// the operators can start the service with the circuit open. The state is
+// not saved, since the environment does not outlive the process.
+type EnvStore string
+
+func (key EnvStore) Load() (*State, error) {
+	v := os.Getenv(string(key))
+	if v == "" {
+		return nil, nil
+	}
+
+	var s State
+	if err := json.Unmarshal([]byte(v), &s); err != nil {
+		return nil, fmt.Errorf("circuit-breaker: invalid %s: %w", string(key), err)
+	}
+
+	return &s, nil
+}
+
+func (EnvStore) Save(State) error {
+	return nil
+}
@@ -0,0 +1,146 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrNotRegistered is returned when no loader is registered for the type.
+	ErrNotRegistered = errors.New("dataloader: loader not registered")
+
+	// ErrNoScope is returned when the context does not carry a scope created
+	// by Registry.Scope.
+	ErrNoScope = errors.New("dataloader: no scope in context")
+)
+
+type scopeContextKey struct{}
+
+// Registry holds the factories of the loaders, keyed by the loader type.
+// Loaders are created lazily once per scope, which is usually a request.
+//
+//	type UserLoader = *dataloader.DataLoader[int, User]
+//
+//	reg := dataloader.NewRegistry()
+//	dataloader.Register(reg, func(ctx context.Context) UserLoader {
+//		return dataloader.New(ctx, &dataloader.Options[int, User]{...})
+//	})
+//
+//	ctx, stop := reg.Scope(ctx)
+//	defer stop()
+//
+//	users, err := dataloader.Get[UserLoader](ctx)
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[reflect.Type]func(ctx context.Context) any
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[reflect.Type]func(ctx context.Context) any),
+	}
+}
+
+// Register registers the factory for the loader type T. Registering the same
+// type twice replaces the previous factory.
+// The factory receives the scope context, so it can resolve other loaders
+// that it depends on with Get. Cyclic dependencies are not supported.
+func Register[T any](r *Registry, fn func(ctx context.Context) T) {
+	r.mu.Lock()
+	r.factories[reflect.TypeFor[T]()] = func(ctx context.Context) any {
+		return fn(ctx)
+	}
+	r.mu.Unlock()
+}
+
+// Scope returns a context carrying a new scope. Loaders resolved from the
+// context are created once and shared within the scope.
+// The returned function stops all loaders created in the scope.
+func (r *Registry) Scope(ctx context.Context) (context.Context, func()) {
+	s := &scope{
+		registry: r,
+		loaders:  make(map[reflect.Type]*lazy),
+	}
+	ctx = context.WithValue(ctx, scopeContextKey{}, s)
+	s.ctx = ctx
+
+	return ctx, sync.OnceFunc(s.stop)
+}
+
+// Get returns the loader of type T from the scope in the context, creating it
+// if it does not exist.
+func Get[T any](ctx context.Context) (t T, err error) {
+	s, ok := ctx.Value(scopeContextKey{}).(*scope)
+	if !ok {
+		return t, ErrNoScope
+	}
+
+	v, err := s.load(reflect.TypeFor[T]())
+	if err != nil {
+		return t, err
+	}
+
+	return v.(T), nil
+}
+
+// MustGet is like Get, but panics on error.
+func MustGet[T any](ctx context.Context) T {
+	t, err := Get[T](ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+type scope struct {
+	ctx      context.Context
+	registry *Registry
+
+	mu      sync.Mutex
+	loaders map[reflect.Type]*lazy
+}
+
+type lazy struct {
+	once sync.Once
+	v    any
+}
+
+func (s *scope) load(typ reflect.Type) (any, error) {
+	s.registry.mu.RLock()
+	fn, ok := s.registry.factories[typ]
+	s.registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, typ)
+	}
+
+	s.mu.Lock()
+	l, ok := s.loaders[typ]
+	if !ok {
+		l = new(lazy)
+		s.loaders[typ] = l
+	}
+	s.mu.Unlock()
+
+	// The factory is called outside the lock, so that it can resolve its
+	// dependencies.
+	l.once.Do(func() {
+		l.v = fn(s.ctx)
+	})
+
+	return l.v, nil
+}
+
+func (s *scope) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.loaders {
+		if v, ok := l.v.(interface{ Stop() }); ok {
+			v.Stop()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package dataloader_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alextanhongpin/core/sync/dataloader"
+	"github.com/stretchr/testify/assert"
+)
+
+type NumberLoader = *dataloader.DataLoader[string, int]
+
+// StringLoader depends on NumberLoader.
+type StringLoader struct {
+	numbers NumberLoader
+}
+
+func (l *StringLoader) Load(k string) (string, error) {
+	n, err := l.numbers.Load(k)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(n * 2), nil
+}
+
+func TestRegistry(t *testing.T) {
+	var created int
+
+	reg := dataloader.NewRegistry()
+	dataloader.Register(reg, func(ctx context.Context) NumberLoader {
+		created++
+
+		return dataloader.New(ctx, &dataloader.Options[string, int]{
+			BatchFn: newBatchFn,
+		})
+	})
+	dataloader.Register(reg, func(ctx context.Context) *StringLoader {
+		return &StringLoader{
+			numbers: dataloader.MustGet[NumberLoader](ctx),
+		}
+	})
+
+	is := assert.New(t)
+
+	_, err := dataloader.Get[NumberLoader](ctx)
+	is.ErrorIs(err, dataloader.ErrNoScope)
+
+	ctx, stop := reg.Scope(ctx)
+	defer stop()
+
+	_, err = dataloader.Get[*dataloader.DataLoader[int, int]](ctx)
+	is.ErrorIs(err, dataloader.ErrNotRegistered)
+
+	sl, err := dataloader.Get[*StringLoader](ctx)
+	is.Nil(err)
+
+	s, err := sl.Load("21")
+	is.Nil(err)
+	is.Equal("42", s)
+
+	nl, err := dataloader.Get[NumberLoader](ctx)
+	is.Nil(err)
+
+	n, err := nl.Load("21")
+	is.Nil(err)
+	is.Equal(21, n)
+
+	// The loader is only created once per scope.
+	is.Equal(1, created)
+
+	stop()
+	_, err = nl.Load("42")
+	is.ErrorIs(err, dataloader.ErrTerminated)
+}
@@ -0,0 +1,218 @@
+// Package expire implements a map where each key expires after its own TTL.
+package expire
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Map is a map where each key expires after its own TTL. Expired keys are
+// removed by a single background goroutine, which calls OnExpire.
+type Map[K comparable, V any] struct {
+	// OnExpire is called with the expired key and value. It is not called
+	// for keys that are deleted or overwritten.
+	OnExpire func(key K, value V)
+
+	mu    sync.Mutex
+	items map[K]*item[K, V]
+	queue queue[K, V]
+	wake  chan struct{}
+}
+
+type item[K comparable, V any] struct {
+	key   K
+	value V
+	at    time.Time
+	// index is the index of the item in the queue.
+	index int
+}
+
+// New returns a new Map. The returned function stops the background
+// goroutine.
+func New[K comparable, V any](onExpire func(key K, value V)) (*Map[K, V], func()) {
+	m := &Map[K, V]{
+		OnExpire: onExpire,
+		items:    make(map[K]*item[K, V]),
+		wake:     make(chan struct{}, 1),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	done := make(chan struct{})
+	go func() {
+		defer wg.Done()
+
+		m.loop(done)
+	}()
+
+	return m, sync.OnceFunc(func() {
+		close(done)
+		wg.Wait()
+	})
+}
+
+// Set sets the value of the key, which expires after the ttl.
+func (m *Map[K, V]) Set(key K, value V, ttl time.Duration) {
+	m.mu.Lock()
+	at := time.Now().Add(ttl)
+	it, ok := m.items[key]
+	if ok {
+		it.value = value
+		it.at = at
+		heap.Fix(&m.queue, it.index)
+	} else {
+		it = &item[K, V]{key: key, value: value, at: at}
+		m.items[key] = it
+		heap.Push(&m.queue, it)
+	}
+	earliest := it.index == 0
+	m.mu.Unlock()
+
+	if earliest {
+		m.notify()
+	}
+}
+
+// Get returns the value of the key, if it has not expired.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.items[key]
+	if !ok || !time.Now().Before(it.at) {
+		return v, false
+	}
+
+	return it.value, true
+}
+
+// Touch extends the expiry of the key by the ttl from now. It returns false
+// if the key does not exist.
+func (m *Map[K, V]) Touch(key K, ttl time.Duration) bool {
+	m.mu.Lock()
+	it, ok := m.items[key]
+	if !ok {
+		m.mu.Unlock()
+		return false
+	}
+	it.at = time.Now().Add(ttl)
+	heap.Fix(&m.queue, it.index)
+	earliest := it.index == 0
+	m.mu.Unlock()
+
+	if earliest {
+		m.notify()
+	}
+
+	return true
+}
+
+// Delete deletes the key without calling OnExpire.
+func (m *Map[K, V]) Delete(key K) bool {
+	m.mu.Lock()
+	it, ok := m.items[key]
+	if ok {
+		delete(m.items, key)
+		heap.Remove(&m.queue, it.index)
+	}
+	m.mu.Unlock()
+
+	return ok
+}
+
+func (m *Map[K, V]) Len() int {
+	m.mu.Lock()
+	n := len(m.items)
+	m.mu.Unlock()
+
+	return n
+}
+
+func (m *Map[K, V]) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *Map[K, V]) loop(done <-chan struct{}) {
+	t := time.NewTimer(time.Hour)
+	defer t.Stop()
+
+	for {
+		t.Reset(m.expire())
+
+		select {
+		case <-done:
+			return
+		case <-m.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// expire removes the expired keys, and returns the duration until the next
+// deadline.
+func (m *Map[K, V]) expire() time.Duration {
+	type expired struct {
+		key   K
+		value V
+	}
+
+	var keys []expired
+
+	m.mu.Lock()
+	now := time.Now()
+	next := time.Hour
+	for len(m.queue) > 0 {
+		it := m.queue[0]
+		if it.at.After(now) {
+			next = it.at.Sub(now)
+			break
+		}
+		heap.Pop(&m.queue)
+		delete(m.items, it.key)
+		keys = append(keys, expired{it.key, it.value})
+	}
+	m.mu.Unlock()
+
+	if m.OnExpire != nil {
+		for _, e := range keys {
+			m.OnExpire(e.key, e.value)
+		}
+	}
+
+	return next
+}
+
+// queue implements heap.Interface, ordered by the earliest deadline. Each
+// key has a single item, which is fixed in place when the key is overwritten
+// or touched, and removed when the key is deleted.
+type queue[K comparable, V any] []*item[K, V]
+
+func (q queue[K, V]) Len() int           { return len(q) }
+func (q queue[K, V]) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q queue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *queue[K, V]) Push(x any) {
+	it := x.(*item[K, V])
+	it.index = len(*q)
+	*q = append(*q, it)
+}
+
+func (q *queue[K, V]) Pop() any {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return it
+}
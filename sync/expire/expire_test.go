@@ -0,0 +1,80 @@
+package expire_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/expire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	type kv struct {
+		key   string
+		value int
+	}
+
+	ch := make(chan kv, 3)
+	m, stop := expire.New(func(key string, value int) {
+		ch <- kv{key, value}
+	})
+	defer stop()
+
+	m.Set("a", 1, 30*time.Millisecond)
+	m.Set("b", 2, 10*time.Millisecond)
+	m.Set("c", 3, 20*time.Millisecond)
+	m.Delete("c")
+
+	is := assert.New(t)
+	v, ok := m.Get("a")
+	is.True(ok)
+	is.Equal(1, v)
+	is.Equal(2, m.Len())
+
+	is.Equal(kv{"b", 2}, <-ch)
+	is.Equal(kv{"a", 1}, <-ch)
+	is.Equal(0, m.Len())
+
+	_, ok = m.Get("a")
+	is.False(ok)
+}
+
+func TestMapTouch(t *testing.T) {
+	ch := make(chan string, 1)
+	m, stop := expire.New(func(key string, _ struct{}) {
+		ch <- key
+	})
+	defer stop()
+
+	start := time.Now()
+	m.Set("a", struct{}{}, 10*time.Millisecond)
+
+	is := assert.New(t)
+	is.True(m.Touch("a", 50*time.Millisecond))
+	is.False(m.Touch("b", 50*time.Millisecond))
+
+	is.Equal("a", <-ch)
+	is.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+}
+
+func TestMapOverwrite(t *testing.T) {
+	ch := make(chan int, 10)
+	m, stop := expire.New(func(key string, value int) {
+		ch <- value
+	})
+	defer stop()
+
+	start := time.Now()
+	for i := range 10 {
+		m.Set("a", i, time.Duration(i+1)*10*time.Millisecond)
+	}
+
+	is := assert.New(t)
+	is.Equal(9, <-ch)
+	is.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+	is.Equal(0, m.Len())
+
+	// The overwritten values do not expire.
+	time.Sleep(20 * time.Millisecond)
+	is.Empty(ch)
+}
@@ -0,0 +1,217 @@
+package lock
+
+import (
+	"bytes"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// ReasonCycle indicates that the goroutines are waiting on each other.
+	ReasonCycle = "cycle"
+
+	// ReasonLockOrder indicates that the keys are acquired in different order
+	// by different goroutines, which may deadlock under contention.
+	ReasonLockOrder = "lock order"
+
+	// ReasonLongHold indicates that the key is held for longer than the
+	// LongHold duration while other goroutines are waiting for it.
+	ReasonLongHold = "long hold"
+)
+
+// Report describes a potential deadlock.
+type Report struct {
+	Reason string
+	// Key is the key being acquired.
+	Key string
+	// Holders are the goroutines involved, with the stack trace at the time
+	// the key was acquired, or waited on.
+	Holders []Holder
+}
+
+type Holder struct {
+	Goroutine int64
+	Key       string
+	Since     time.Time
+	Stack     string
+}
+
+// detector tracks which goroutines hold or wait for which keys.
+// All methods must be called with the lock held.
+type detector struct {
+	holds map[string][]*Holder
+	waits map[int64]*Holder
+	order map[[2]string]*Holder
+}
+
+func newDetector() *detector {
+	return &detector{
+		holds: make(map[string][]*Holder),
+		waits: make(map[int64]*Holder),
+		order: make(map[[2]string]*Holder),
+	}
+}
+
+// acquiring records the order of acquisition of the keys held by the
+// goroutine, and reports if the keys were acquired in the opposite order
+// before.
+func (d *detector) acquiring(key string, g int64) []Report {
+	var reports []Report
+	for k, hs := range d.holds {
+		if k == key {
+			continue
+		}
+
+		i := slices.IndexFunc(hs, func(h *Holder) bool {
+			return h.Goroutine == g
+		})
+		if i == -1 {
+			continue
+		}
+
+		if prev, ok := d.order[[2]string{key, k}]; ok {
+			reports = append(reports, Report{
+				Reason:  ReasonLockOrder,
+				Key:     key,
+				Holders: []Holder{*prev, *hs[i]},
+			})
+		}
+
+		edge := [2]string{k, key}
+		if _, ok := d.order[edge]; !ok {
+			d.order[edge] = newHolder(key, g)
+		}
+	}
+
+	return reports
+}
+
+// waiting records the goroutine as waiting for the key, and reports if the
+// holders of the key are, directly or indirectly, waiting for the goroutine.
+func (d *detector) waiting(key string, g int64) []Report {
+	d.waits[g] = newHolder(key, g)
+
+	var path []Holder
+	visited := make(map[int64]bool)
+
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		for _, h := range d.holds[key] {
+			if visited[h.Goroutine] {
+				continue
+			}
+			visited[h.Goroutine] = true
+
+			path = append(path, *h)
+			if h.Goroutine == g {
+				return true
+			}
+
+			if w, ok := d.waits[h.Goroutine]; ok && visit(w.Key) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+
+		return false
+	}
+
+	if !visit(key) {
+		return nil
+	}
+
+	return []Report{{
+		Reason:  ReasonCycle,
+		Key:     key,
+		Holders: append(path, *d.waits[g]),
+	}}
+}
+
+func (d *detector) held(key string, g int64) {
+	delete(d.waits, g)
+	d.holds[key] = append(d.holds[key], newHolder(key, g))
+}
+
+func (d *detector) cancelled(g int64) {
+	delete(d.waits, g)
+}
+
+// released removes the goroutine from the holders of the key. The lock may
+// be released by a different goroutine, in which case the earliest holder is
+// removed.
+func (d *detector) released(key string, g int64) {
+	hs := d.holds[key]
+	if len(hs) == 0 {
+		return
+	}
+
+	i := slices.IndexFunc(hs, func(h *Holder) bool {
+		return h.Goroutine == g
+	})
+	if i == -1 {
+		i = 0
+	}
+
+	hs = slices.Delete(hs, i, i+1)
+	if len(hs) == 0 {
+		delete(d.holds, key)
+	} else {
+		d.holds[key] = hs
+	}
+}
+
+// longHolds reports the holders of the key that held it for longer than the
+// duration.
+func (d *detector) longHolds(key string, g int64, threshold time.Duration) []Report {
+	var hs []Holder
+	for _, h := range d.holds[key] {
+		if time.Since(h.Since) >= threshold {
+			hs = append(hs, *h)
+		}
+	}
+	if len(hs) == 0 {
+		return nil
+	}
+
+	if w, ok := d.waits[g]; ok {
+		hs = append(hs, *w)
+	}
+
+	return []Report{{
+		Reason:  ReasonLongHold,
+		Key:     key,
+		Holders: hs,
+	}}
+}
+
+func newHolder(key string, g int64) *Holder {
+	return &Holder{
+		Goroutine: g,
+		Key:       key,
+		Since:     time.Now(),
+		Stack:     stack(),
+	}
+}
+
+// goid returns the current goroutine id, parsed from the stack trace header,
+// e.g. "goroutine 42 [running]:".
+func goid() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := bytes.Fields(buf[:n])
+	if len(fields) < 2 {
+		return 0
+	}
+
+	id, _ := strconv.ParseInt(string(fields[1]), 10, 64)
+	return id
+}
+
+func stack() string {
+	buf := make([]byte, 4096)
+	n := runtime.Stack(buf, false)
+
+	return string(buf[:n])
+}
@@ -0,0 +1,108 @@
+package lock_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetector(t *testing.T) {
+	t.Run("lock order", func(t *testing.T) {
+		var reports []lock.Report
+		l := lock.New(&lock.Options{
+			OnPotentialDeadlock: func(r lock.Report) {
+				reports = append(reports, r)
+			},
+		})
+
+		l.Lock("a")
+		l.Lock("b")
+		l.Unlock("b")
+		l.Unlock("a")
+
+		l.Lock("b")
+		l.Lock("a")
+		l.Unlock("a")
+		l.Unlock("b")
+
+		is := assert.New(t)
+		is.Len(reports, 1)
+		is.Equal(lock.ReasonLockOrder, reports[0].Reason)
+		is.Equal("a", reports[0].Key)
+		is.Len(reports[0].Holders, 2)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		var mu sync.Mutex
+		var reports []lock.Report
+		l := lock.New(&lock.Options{
+			OnPotentialDeadlock: func(r lock.Report) {
+				mu.Lock()
+				reports = append(reports, r)
+				mu.Unlock()
+			},
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		transfer := func(from, to string) {
+			defer wg.Done()
+
+			l.Lock(from)
+			defer l.Unlock(from)
+
+			// Wait for the other goroutine to acquire the lock.
+			time.Sleep(10 * time.Millisecond)
+			if err := l.LockWithTimeout(to, 50*time.Millisecond); err == nil {
+				l.Unlock(to)
+			}
+		}
+		go transfer("a", "b")
+		go transfer("b", "a")
+		wg.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var cycles []lock.Report
+		for _, r := range reports {
+			if r.Reason == lock.ReasonCycle {
+				cycles = append(cycles, r)
+			}
+		}
+
+		is := assert.New(t)
+		is.Len(cycles, 1)
+		is.Len(cycles[0].Holders, 3)
+		is.NotEmpty(cycles[0].Holders[0].Stack)
+	})
+
+	t.Run("long hold", func(t *testing.T) {
+		ch := make(chan lock.Report, 1)
+		l := lock.New(&lock.Options{
+			LongHold: 10 * time.Millisecond,
+			OnPotentialDeadlock: func(r lock.Report) {
+				ch <- r
+			},
+		})
+
+		l.Lock("a")
+		defer l.Unlock("a")
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- l.LockWithTimeout("a", 50*time.Millisecond)
+		}()
+
+		is := assert.New(t)
+		is.ErrorIs(<-errCh, lock.ErrTimeout)
+
+		r := <-ch
+		is.Equal(lock.ReasonLongHold, r.Reason)
+		is.Len(r.Holders, 2)
+	})
+}
@@ -0,0 +1,399 @@
+// Package lock implements keyed mutexes and read-write mutexes.
+package lock
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrTimeout = errors.New("lock: timeout")
+
+type Options struct {
+	// Fair grants the lock in FIFO order per key. When disabled, waiting
+	// writers are preferred over new readers, but waiters are otherwise woken
+	// up in arbitrary order.
+	Fair bool
+
+	// OnPotentialDeadlock enables the deadlock detector, which tracks the
+	// goroutines holding and waiting for each key. This is expensive, since
+	// the stack trace is captured on every acquisition, and should only be
+	// enabled for debugging.
+	OnPotentialDeadlock func(Report)
+
+	// LongHold is the duration after which a holder is reported when other
+	// goroutines are waiting for the key. Defaults to 10s.
+	LongHold time.Duration
+
+	// Pending controls what RunExclusive does when the key is already
+	// running. Defaults to Skip.
+	Pending Pending
+}
+
+type Metrics struct {
+	// Keys is the number of keys currently held or waited on.
+	Keys int
+	// Waiting is the number of callers currently waiting for a lock.
+	Waiting int
+	// MaxWaiting is the highest number of callers waiting for the same key.
+	MaxWaiting int
+	// Acquired is the number of successful lock acquisitions.
+	Acquired int64
+	// Contended is the number of acquisitions that had to wait.
+	Contended int64
+	// Timeouts is the number of acquisitions that timed out or were
+	// cancelled.
+	Timeouts int64
+}
+
+// Lock manages a read-write mutex per key. The mutex is removed once there
+// are no more holders or waiters for the key.
+type Lock struct {
+	opts *Options
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	metrics Metrics
+	det     *detector
+	runs    map[string]*run
+}
+
+func New(opts *Options) *Lock {
+	opts = cmp.Or(opts, &Options{})
+	opts.LongHold = cmp.Or(opts.LongHold, 10*time.Second)
+
+	l := &Lock{
+		opts:    opts,
+		entries: make(map[string]*entry),
+		runs:    make(map[string]*run),
+	}
+	if opts.OnPotentialDeadlock != nil {
+		l.det = newDetector()
+	}
+
+	return l
+}
+
+// Lock locks the key for writing.
+func (l *Lock) Lock(key string) {
+	_ = l.LockWithContext(context.Background(), key)
+}
+
+// Unlock unlocks the key for writing.
+func (l *Lock) Unlock(key string) {
+	l.mu.Lock()
+	e := l.entries[key]
+	if e == nil || !e.writer {
+		l.mu.Unlock()
+		panic("lock: unlock of unlocked key " + key)
+	}
+	e.writer = false
+	l.released(key)
+	l.release(key, e)
+	l.mu.Unlock()
+}
+
+// RLock locks the key for reading.
+func (l *Lock) RLock(key string) {
+	_ = l.RLockWithContext(context.Background(), key)
+}
+
+// RUnlock unlocks the key for reading.
+func (l *Lock) RUnlock(key string) {
+	l.mu.Lock()
+	e := l.entries[key]
+	if e == nil || e.readers == 0 {
+		l.mu.Unlock()
+		panic("lock: runlock of unlocked key " + key)
+	}
+	e.readers--
+	l.released(key)
+	l.release(key, e)
+	l.mu.Unlock()
+}
+
+// TryLock tries to lock the key for writing without waiting.
+func (l *Lock) TryLock(key string) bool {
+	return l.try(key, true)
+}
+
+// TryRLock tries to lock the key for reading without waiting.
+func (l *Lock) TryRLock(key string) bool {
+	return l.try(key, false)
+}
+
+// LockWithTimeout locks the key for writing, or returns ErrTimeout if the
+// lock cannot be acquired within the timeout.
+func (l *Lock) LockWithTimeout(key string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), timeout, ErrTimeout)
+	defer cancel()
+
+	return l.LockWithContext(ctx, key)
+}
+
+// RLockWithTimeout locks the key for reading, or returns ErrTimeout if the
+// lock cannot be acquired within the timeout.
+func (l *Lock) RLockWithTimeout(key string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), timeout, ErrTimeout)
+	defer cancel()
+
+	return l.RLockWithContext(ctx, key)
+}
+
+// LockWithContext locks the key for writing, waiting until the lock is
+// acquired or the context is done.
+func (l *Lock) LockWithContext(ctx context.Context, key string) error {
+	return l.wait(ctx, key, true)
+}
+
+// RLockWithContext locks the key for reading, waiting until the lock is
+// acquired or the context is done.
+func (l *Lock) RLockWithContext(ctx context.Context, key string) error {
+	return l.wait(ctx, key, false)
+}
+
+// Metrics returns a snapshot of the lock metrics.
+func (l *Lock) Metrics() Metrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := l.metrics
+	m.Keys = len(l.entries)
+	for _, e := range l.entries {
+		m.Waiting += len(e.queue)
+	}
+
+	return m
+}
+
+func (l *Lock) try(key string, write bool) bool {
+	g := l.goid()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.acquire(key)
+	if len(e.queue) > 0 || !e.available(write) {
+		l.release(key, e)
+		return false
+	}
+	e.grant(write)
+	l.metrics.Acquired++
+	l.held(key, g)
+
+	return true
+}
+
+func (l *Lock) wait(ctx context.Context, key string, write bool) error {
+	g := l.goid()
+
+	l.mu.Lock()
+	reports := l.acquiring(key, g)
+	e := l.acquire(key)
+	if len(e.queue) == 0 && e.available(write) {
+		e.grant(write)
+		l.metrics.Acquired++
+		l.held(key, g)
+		l.mu.Unlock()
+		l.report(reports)
+
+		return nil
+	}
+
+	w := &waiter{write: write}
+	e.queue = append(e.queue, w)
+	l.metrics.Contended++
+	l.metrics.MaxWaiting = max(l.metrics.MaxWaiting, len(e.queue))
+	reports = append(reports, l.waiting(key, g)...)
+
+	if l.det != nil {
+		t := time.AfterFunc(l.opts.LongHold, func() {
+			l.mu.Lock()
+			reports := l.det.longHolds(key, g, l.opts.LongHold)
+			l.mu.Unlock()
+			l.report(reports)
+		})
+		defer t.Stop()
+	}
+
+	for {
+		ch := e.changed
+		l.mu.Unlock()
+		l.report(reports)
+		reports = nil
+
+		select {
+		case <-ctx.Done():
+			l.mu.Lock()
+			e.dequeue(w)
+			l.metrics.Timeouts++
+			l.cancelled(g)
+			l.release(key, e)
+			l.mu.Unlock()
+
+			return context.Cause(ctx)
+		case <-ch:
+		}
+
+		l.mu.Lock()
+		if l.grantable(e, w) {
+			e.dequeue(w)
+			e.grant(write)
+			l.metrics.Acquired++
+			l.held(key, g)
+
+			// Wake up the other waiters, since consecutive readers may proceed
+			// together.
+			e.broadcast()
+			l.mu.Unlock()
+
+			return nil
+		}
+	}
+}
+
+// The methods below are no-op when the deadlock detector is disabled.
+
+func (l *Lock) goid() int64 {
+	if l.det == nil {
+		return 0
+	}
+
+	return goid()
+}
+
+func (l *Lock) acquiring(key string, g int64) []Report {
+	if l.det == nil {
+		return nil
+	}
+
+	return l.det.acquiring(key, g)
+}
+
+func (l *Lock) waiting(key string, g int64) []Report {
+	if l.det == nil {
+		return nil
+	}
+
+	return l.det.waiting(key, g)
+}
+
+func (l *Lock) held(key string, g int64) {
+	if l.det != nil {
+		l.det.held(key, g)
+	}
+}
+
+func (l *Lock) cancelled(g int64) {
+	if l.det != nil {
+		l.det.cancelled(g)
+	}
+}
+
+func (l *Lock) released(key string) {
+	if l.det != nil {
+		l.det.released(key, goid())
+	}
+}
+
+// report must be called without the lock held.
+func (l *Lock) report(reports []Report) {
+	for _, r := range reports {
+		l.opts.OnPotentialDeadlock(r)
+	}
+}
+
+// grantable returns true if the waiter can acquire the lock.
+// Must be called with the lock held.
+func (l *Lock) grantable(e *entry, w *waiter) bool {
+	if !e.available(w.write) {
+		return false
+	}
+
+	i := slices.Index(e.queue, w)
+	if l.opts.Fair {
+		// Writers must be at the head of the queue, while readers can proceed
+		// together as long as there are no writers ahead of them.
+		if w.write {
+			return i == 0
+		}
+
+		return !slices.ContainsFunc(e.queue[:i], isWriter)
+	}
+
+	// Waiting writers are preferred over readers to avoid starving writers.
+	return w.write || !slices.ContainsFunc(e.queue, isWriter)
+}
+
+// acquire returns the entry for the key, incrementing the reference count.
+// Must be called with the lock held.
+func (l *Lock) acquire(key string) *entry {
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry{
+			changed: make(chan struct{}),
+		}
+		l.entries[key] = e
+	}
+	e.refs++
+
+	return e
+}
+
+// release decrements the reference count, and removes the entry once there
+// are no more holders or waiters.
+// Must be called with the lock held.
+func (l *Lock) release(key string, e *entry) {
+	e.refs--
+	if e.refs == 0 {
+		delete(l.entries, key)
+	}
+	e.broadcast()
+}
+
+type waiter struct {
+	write bool
+}
+
+func isWriter(w *waiter) bool {
+	return w.write
+}
+
+type entry struct {
+	refs    int
+	readers int
+	writer  bool
+	queue   []*waiter
+	changed chan struct{}
+}
+
+func (e *entry) available(write bool) bool {
+	if write {
+		return !e.writer && e.readers == 0
+	}
+
+	return !e.writer
+}
+
+func (e *entry) grant(write bool) {
+	if write {
+		e.writer = true
+	} else {
+		e.readers++
+	}
+}
+
+func (e *entry) dequeue(w *waiter) {
+	e.queue = slices.DeleteFunc(e.queue, func(v *waiter) bool {
+		return v == w
+	})
+}
+
+// broadcast wakes up all waiters.
+func (e *entry) broadcast() {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
@@ -0,0 +1,147 @@
+package lock_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	l := lock.New(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+
+	var n int
+	for range 10 {
+		go func() {
+			defer wg.Done()
+
+			l.Lock("key")
+			n++
+			l.Unlock("key")
+		}()
+	}
+	wg.Wait()
+
+	is := assert.New(t)
+	is.Equal(10, n)
+	is.Equal(0, l.Metrics().Keys)
+	is.Equal(int64(10), l.Metrics().Acquired)
+}
+
+func TestTryLock(t *testing.T) {
+	l := lock.New(nil)
+
+	is := assert.New(t)
+	is.True(l.TryLock("key"))
+	is.False(l.TryLock("key"))
+	is.False(l.TryRLock("key"))
+	is.True(l.TryLock("other-key"))
+	l.Unlock("key")
+	l.Unlock("other-key")
+
+	is.True(l.TryRLock("key"))
+	is.True(l.TryRLock("key"))
+	is.False(l.TryLock("key"))
+	l.RUnlock("key")
+	l.RUnlock("key")
+	is.Equal(0, l.Metrics().Keys)
+}
+
+func TestLockWithContext(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		l := lock.New(nil)
+		l.RLock("key")
+		defer l.RUnlock("key")
+
+		is := assert.New(t)
+		is.ErrorIs(l.LockWithTimeout("key", 10*time.Millisecond), lock.ErrTimeout)
+		is.Equal(int64(1), l.Metrics().Timeouts)
+		is.Equal(int64(1), l.Metrics().Contended)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		l := lock.New(nil)
+		l.Lock("key")
+		defer l.Unlock("key")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		is := assert.New(t)
+		is.ErrorIs(l.RLockWithContext(ctx, "key"), context.Canceled)
+	})
+
+	t.Run("acquired", func(t *testing.T) {
+		l := lock.New(nil)
+		l.Lock("key")
+		time.AfterFunc(10*time.Millisecond, func() {
+			l.Unlock("key")
+		})
+
+		is := assert.New(t)
+		is.Nil(l.RLockWithTimeout("key", time.Second))
+		l.RUnlock("key")
+	})
+
+	t.Run("writer preferred", func(t *testing.T) {
+		l := lock.New(nil)
+		l.RLock("key")
+
+		done := make(chan bool)
+		go func() {
+			done <- l.LockWithTimeout("key", time.Second) == nil
+			l.Unlock("key")
+		}()
+
+		// Wait for the writer to queue.
+		time.Sleep(10 * time.Millisecond)
+
+		is := assert.New(t)
+		is.False(l.TryRLock("key"))
+		l.RUnlock("key")
+		is.True(<-done)
+	})
+}
+
+func TestLockFair(t *testing.T) {
+	l := lock.New(&lock.Options{Fair: true})
+	l.Lock("key")
+
+	var mu sync.Mutex
+	var order []int
+
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			l.Lock("key")
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			l.Unlock("key")
+		}()
+
+		// Wait for the goroutine to queue.
+		for l.Metrics().Waiting != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	is := assert.New(t)
+	is.Equal(5, l.Metrics().Waiting)
+	is.Equal(5, l.Metrics().MaxWaiting)
+
+	l.Unlock("key")
+	wg.Wait()
+
+	is.Equal([]int{0, 1, 2, 3, 4}, order)
+	is.Equal(0, l.Metrics().Waiting)
+}
@@ -0,0 +1,70 @@
+package lock
+
+// Pending controls what happens to a run when the key is already running.
+type Pending int
+
+const (
+	// Skip discards the run.
+	Skip Pending = iota
+
+	// Queue runs the first pending run after the current run completes.
+	// Subsequent runs are discarded while there is a pending run.
+	Queue
+
+	// Replace runs the latest pending run after the current run completes.
+	Replace
+)
+
+type run struct {
+	pending func()
+}
+
+// RunExclusive starts fn in a new goroutine, unless another fn is already
+// running for the key. It returns true if fn is started.
+// When the key is already running, fn is skipped, queued or replaces the
+// pending fn, depending on Options.Pending.
+func (l *Lock) RunExclusive(key string, fn func()) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.runs[key]; ok {
+		switch l.opts.Pending {
+		case Queue:
+			if r.pending == nil {
+				r.pending = fn
+			}
+		case Replace:
+			r.pending = fn
+		}
+
+		return false
+	}
+
+	l.runs[key] = new(run)
+	go l.run(key, fn)
+
+	return true
+}
+
+func (l *Lock) run(key string, fn func()) {
+	// Release the key if fn panics, discarding the pending run.
+	defer func() {
+		if fn != nil {
+			l.mu.Lock()
+			delete(l.runs, key)
+			l.mu.Unlock()
+		}
+	}()
+
+	for fn != nil {
+		fn()
+
+		l.mu.Lock()
+		r := l.runs[key]
+		fn, r.pending = r.pending, nil
+		if fn == nil {
+			delete(l.runs, key)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,57 @@
+package lock_test
+
+import (
+	"testing"
+
+	"github.com/alextanhongpin/core/sync/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExclusive(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending lock.Pending
+		want    []int
+	}{
+		{"skip", lock.Skip, []int{1}},
+		{"queue", lock.Queue, []int{1, 2}},
+		{"replace", lock.Replace, []int{1, 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := lock.New(&lock.Options{Pending: tc.pending})
+
+			unblock := make(chan struct{})
+			ch := make(chan int, 3)
+			runFn := func(i int) func() {
+				return func() {
+					if i == 1 {
+						<-unblock
+					}
+					ch <- i
+				}
+			}
+
+			is := assert.New(t)
+			is.True(l.RunExclusive("user", runFn(1)))
+			is.False(l.RunExclusive("user", runFn(2)))
+			is.False(l.RunExclusive("user", runFn(3)))
+
+			close(unblock)
+
+			var got []int
+			for range tc.want {
+				got = append(got, <-ch)
+			}
+			is.Equal(tc.want, got)
+
+			// Other keys are not affected.
+			done := make(chan struct{})
+			is.True(l.RunExclusive("other", func() {
+				close(done)
+			}))
+			<-done
+		})
+	}
+}
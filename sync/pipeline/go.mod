@@ -2,9 +2,18 @@ module github.com/alextanhongpin/core/sync/pipeline
 
 go 1.23.0
 
-require github.com/alextanhongpin/core/sync/rate v0.0.0-20241129045434-84469bdbd179
+require (
+	github.com/alextanhongpin/core/sync/rate v0.0.0-20241129045434-84469bdbd179
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	golang.org/x/exp v0.0.0-20241108190413-2d47ceb2692f
 	golang.org/x/sync v0.9.0
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
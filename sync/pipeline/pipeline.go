@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
+var ErrTimeout = errors.New("pipeline: timeout")
+
 type Result[T any] struct {
 	Data T
 	Err  error
@@ -337,6 +340,70 @@ func RateLimit[T any](request int, period time.Duration, in <-chan T) <-chan T {
 	return Every(period/time.Duration(request), in)
 }
 
+// Expired is the error of an item that is not processed within the timeout.
+type Expired[T any] struct {
+	Data    T
+	Elapsed time.Duration
+}
+
+func (e *Expired[T]) Error() string {
+	return fmt.Sprintf("%s: not processed after %s", ErrTimeout, e.Elapsed)
+}
+
+func (e *Expired[T]) Unwrap() error {
+	return ErrTimeout
+}
+
+// Timeout transforms each item with fn, with a context that is canceled after
+// the duration, so that a slow item does not stall the pipeline.
+// Items that time out are emitted as Result errors of type *Expired[T], which
+// wrap ErrTimeout. If fn does not return when the context is canceled, it
+// keeps running in the background while the next items are processed.
+func Timeout[T, V any](ctx context.Context, d time.Duration, in <-chan T, fn func(context.Context, T) (V, error)) <-chan Result[V] {
+	out := make(chan Result[V])
+
+	go func() {
+		defer close(out)
+
+		for v := range in {
+			out <- timeout(ctx, d, v, fn)
+		}
+	}()
+
+	return out
+}
+
+func timeout[T, V any](ctx context.Context, d time.Duration, v T, fn func(context.Context, T) (V, error)) Result[V] {
+	ctx, cancel := context.WithTimeoutCause(ctx, d, ErrTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Create a channel with a buffer of 1 to prevent goroutine leak.
+	ch := make(chan Result[V], 1)
+	go func() {
+		ch <- MakeResult(fn(ctx, v))
+	}()
+
+	select {
+	case res := <-ch:
+		// fn may return the context error before the deadline is observed
+		// here.
+		if res.Err == nil || !errors.Is(context.Cause(ctx), ErrTimeout) {
+			return res
+		}
+	case <-ctx.Done():
+		if err := context.Cause(ctx); !errors.Is(err, ErrTimeout) {
+			return Result[V]{Err: err}
+		}
+	}
+
+	return Result[V]{Err: &Expired[T]{
+		Data:    v,
+		Elapsed: time.Since(start),
+	}}
+}
+
 func Tee[T any](in chan T) (out1, out2 chan T) {
 	out1, out2 = make(chan T), make(chan T)
 
@@ -0,0 +1,97 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/pipeline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	in := make(chan time.Duration)
+	go func() {
+		defer close(in)
+
+		in <- 0
+		in <- time.Second
+		in <- 0
+	}()
+
+	out := pipeline.Timeout(context.Background(), 50*time.Millisecond, in, func(ctx context.Context, d time.Duration) (time.Duration, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(d):
+			return d, nil
+		}
+	})
+
+	is := assert.New(t)
+	res := <-out
+	is.Nil(res.Err)
+
+	res = <-out
+	is.ErrorIs(res.Err, pipeline.ErrTimeout)
+
+	var expired *pipeline.Expired[time.Duration]
+	is.True(errors.As(res.Err, &expired))
+	is.Equal(time.Second, expired.Data)
+	is.GreaterOrEqual(expired.Elapsed, 50*time.Millisecond)
+
+	res = <-out
+	is.Nil(res.Err)
+
+	_, ok := <-out
+	is.False(ok)
+}
+
+func TestTimeoutIgnoresContext(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+
+		in <- 1
+		in <- 2
+	}()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	// The first item never returns, but does not stall the next item.
+	out := pipeline.Timeout(context.Background(), 10*time.Millisecond, in, func(ctx context.Context, n int) (int, error) {
+		if n == 1 {
+			<-block
+		}
+
+		return n, nil
+	})
+
+	is := assert.New(t)
+	is.ErrorIs((<-out).Err, pipeline.ErrTimeout)
+
+	res := <-out
+	is.Nil(res.Err)
+	is.Equal(2, res.Data)
+}
+
+func TestTimeoutCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := make(chan int, 1)
+	in <- 1
+	close(in)
+
+	out := pipeline.Timeout(ctx, time.Second, in, func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	is := assert.New(t)
+	res := <-out
+	is.ErrorIs(res.Err, context.Canceled)
+	is.NotErrorIs(res.Err, pipeline.ErrTimeout)
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import "context"
+
+// retrier is implemented by sync/retry.Retry.
+type retrier interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// breaker is implemented by sync/circuitbreaker.Breaker.
+type breaker interface {
+	Do(fn func() error) error
+}
+
+// Retry transforms each item with fn, retrying the failed attempts with the
+// retrier, e.g. retry.New(retry.NewExponentialBackOff(time.Second, time.Minute)).
+// Items that still fail after the retries are emitted as Result errors.
+func Retry[T, V any](ctx context.Context, r retrier, in <-chan T, fn func(context.Context, T) (V, error)) <-chan Result[V] {
+	out := make(chan Result[V])
+
+	go func() {
+		defer close(out)
+
+		for v := range in {
+			var res V
+			err := r.Do(ctx, func(ctx context.Context) error {
+				var err error
+				res, err = fn(ctx, v)
+				return err
+			})
+
+			out <- MakeResult(res, err)
+		}
+	}()
+
+	return out
+}
+
+// CircuitBreak transforms each item with fn through the breaker, e.g.
+// circuitbreaker.New(). When the downstream keeps failing, the circuit opens
+// and the items fail fast without calling fn, until the breaker recovers.
+// Failed items are emitted as Result errors, e.g.
+// circuitbreaker.ErrBrokenCircuit.
+func CircuitBreak[T, V any](cb breaker, in <-chan T, fn func(T) (V, error)) <-chan Result[V] {
+	out := make(chan Result[V])
+
+	go func() {
+		defer close(out)
+
+		for v := range in {
+			var res V
+			err := cb.Do(func() error {
+				var err error
+				res, err = fn(v)
+				return err
+			})
+
+			out <- MakeResult(res, err)
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,102 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/alextanhongpin/core/sync/pipeline"
+	"github.com/stretchr/testify/assert"
+)
+
+var wantErr = errors.New("want error")
+
+func TestRetry(t *testing.T) {
+	attempts := make(map[int]int)
+	out := pipeline.Retry(ctx, retrier(3), pipeline.Generator(ctx, 3), func(ctx context.Context, n int) (string, error) {
+		attempts[n]++
+
+		// Succeeds on the second attempt, except for 2, which always fails.
+		if n == 2 || attempts[n] < 2 {
+			return "", wantErr
+		}
+
+		return strconv.Itoa(n), nil
+	})
+
+	is := assert.New(t)
+	res := collect(out)
+	is.Len(res, 3)
+
+	for i, want := range []string{"0", "1"} {
+		data, err := res[i].Unwrap()
+		is.Nil(err)
+		is.Equal(want, data)
+	}
+
+	// Emitted as error after the retries are exhausted.
+	is.ErrorIs(res[2].Err, wantErr)
+	is.Equal(map[int]int{0: 2, 1: 2, 2: 3}, attempts)
+}
+
+func TestCircuitBreak(t *testing.T) {
+	cb := &breaker{threshold: 2}
+
+	var calls int
+	out := pipeline.CircuitBreak(cb, pipeline.Generator(ctx, 4), func(n int) (int, error) {
+		calls++
+		if n > 0 {
+			return 0, wantErr
+		}
+
+		return n, nil
+	})
+
+	is := assert.New(t)
+	res := collect(out)
+	is.Len(res, 4)
+	is.Nil(res[0].Err)
+	is.ErrorIs(res[1].Err, wantErr)
+	is.ErrorIs(res[2].Err, wantErr)
+
+	// Fails fast without calling fn once the circuit opens.
+	is.ErrorIs(res[3].Err, errBrokenCircuit)
+	is.Equal(3, calls)
+}
+
+// retrier makes up to the given number of attempts.
+type retrier int
+
+func (r retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for range int(r) {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+var errBrokenCircuit = errors.New("broken circuit")
+
+// breaker opens after the threshold of consecutive failures.
+type breaker struct {
+	threshold int
+	failures  int
+}
+
+func (b *breaker) Do(fn func() error) error {
+	if b.failures >= b.threshold {
+		return errBrokenCircuit
+	}
+
+	if err := fn(); err != nil {
+		b.failures++
+		return err
+	}
+	b.failures = 0
+
+	return nil
+}
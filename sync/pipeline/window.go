@@ -0,0 +1,172 @@
+package pipeline
+
+import "time"
+
+// Window aggregates the items in tumbling windows of the period, and emits
+// the aggregate at the end of each window, e.g. for per-minute rollups.
+// Each window starts from the zero value of V. Empty windows are skipped, and
+// the last window is emitted when the input is closed.
+func Window[T, V any](period time.Duration, in <-chan T, fn func(T, V) V) <-chan V {
+	out := make(chan V)
+
+	go func() {
+		defer close(out)
+
+		var (
+			agg V
+			n   int
+		)
+		flush := func() {
+			if n == 0 {
+				return
+			}
+
+			out <- agg
+
+			var zero V
+			agg, n = zero, 0
+		}
+		defer flush()
+
+		t := time.NewTicker(period)
+		defer t.Stop()
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+
+				agg = fn(v, agg)
+				n++
+			case <-t.C:
+				flush()
+			}
+		}
+	}()
+
+	return out
+}
+
+// CountWindow aggregates the items in tumbling windows of n items. The last
+// window may have fewer items.
+func CountWindow[T, V any](n int, in <-chan T, fn func(T, V) V) <-chan V {
+	out := make(chan V)
+
+	go func() {
+		defer close(out)
+
+		var (
+			agg V
+			i   int
+		)
+		for v := range in {
+			agg = fn(v, agg)
+			i++
+
+			if i == n {
+				out <- agg
+
+				var zero V
+				agg, i = zero, 0
+			}
+		}
+
+		if i > 0 {
+			out <- agg
+		}
+	}()
+
+	return out
+}
+
+// SlidingWindow aggregates the items received within the last size, and
+// emits the aggregate every period, e.g. the moving average over the last
+// five minutes, every minute.
+// The window is aggregated from the items on each emit, so the size should
+// bound the number of items held.
+func SlidingWindow[T, V any](size, every time.Duration, in <-chan T, fn func(T, V) V) <-chan V {
+	type item struct {
+		data T
+		at   time.Time
+	}
+
+	out := make(chan V)
+
+	go func() {
+		defer close(out)
+
+		var items []item
+		emit := func(now time.Time) {
+			// Evict the items that are outside of the window.
+			i := 0
+			for i < len(items) && now.Sub(items[i].at) > size {
+				i++
+			}
+			items = items[i:]
+			if len(items) == 0 {
+				return
+			}
+
+			var agg V
+			for _, it := range items {
+				agg = fn(it.data, agg)
+			}
+
+			out <- agg
+		}
+
+		t := time.NewTicker(every)
+		defer t.Stop()
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					emit(time.Now())
+					return
+				}
+
+				items = append(items, item{data: v, at: time.Now()})
+			case now := <-t.C:
+				emit(now)
+			}
+		}
+	}()
+
+	return out
+}
+
+// SlidingCountWindow aggregates the last n items, and emits the aggregate
+// every step items.
+func SlidingCountWindow[T, V any](n, step int, in <-chan T, fn func(T, V) V) <-chan V {
+	out := make(chan V)
+
+	go func() {
+		defer close(out)
+
+		items := make([]T, 0, n)
+		var i int
+		for v := range in {
+			if len(items) == n {
+				items = append(items[:0], items[1:]...)
+			}
+			items = append(items, v)
+			i++
+
+			if i%step != 0 {
+				continue
+			}
+
+			var agg V
+			for _, it := range items {
+				agg = fn(it, agg)
+			}
+
+			out <- agg
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,103 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/pipeline"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func TestWindow(t *testing.T) {
+	in := make(chan int)
+	out := pipeline.Window(50*time.Millisecond, in, sum)
+
+	is := assert.New(t)
+	in <- 1
+	in <- 2
+	is.Equal(3, <-out)
+
+	// The empty windows are skipped.
+	select {
+	case v := <-out:
+		t.Fatalf("emitted empty window: %d", v)
+	case <-time.After(120 * time.Millisecond):
+	}
+
+	// The last window is emitted on close.
+	in <- 4
+	close(in)
+	is.Equal([]int{4}, collect(out))
+}
+
+func TestCountWindow(t *testing.T) {
+	out := pipeline.CountWindow(3, pipeline.Generator(ctx, 7), sum)
+
+	// The last window has fewer items.
+	is := assert.New(t)
+	is.Equal([]int{0 + 1 + 2, 3 + 4 + 5, 6}, collect(out))
+}
+
+func TestCountWindowEmpty(t *testing.T) {
+	out := pipeline.CountWindow(3, pipeline.Generator(ctx, 0), sum)
+
+	is := assert.New(t)
+	is.Empty(collect(out))
+}
+
+func TestSlidingWindow(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+
+		in <- 1
+		time.Sleep(150 * time.Millisecond)
+		in <- 2
+	}()
+
+	out := pipeline.SlidingWindow(100*time.Millisecond, 30*time.Millisecond, in, sum)
+
+	// The items outside of the window are evicted, so 1 and 2 are never
+	// aggregated together.
+	is := assert.New(t)
+	got := collect(out)
+	is.Contains(got, 1)
+	is.Contains(got, 2)
+	is.NotContains(got, 3)
+}
+
+func TestSlidingWindowClose(t *testing.T) {
+	in := make(chan int)
+	out := pipeline.SlidingWindow(time.Minute, time.Hour, in, sum)
+
+	in <- 1
+	in <- 2
+	close(in)
+
+	// The window is emitted on close.
+	is := assert.New(t)
+	is.Equal([]int{3}, collect(out))
+}
+
+func TestSlidingCountWindow(t *testing.T) {
+	out := pipeline.SlidingCountWindow(3, 2, pipeline.Generator(ctx, 6), sum)
+
+	is := assert.New(t)
+	is.Equal([]int{0 + 1, 1 + 2 + 3, 3 + 4 + 5}, collect(out))
+}
+
+func sum(v, agg int) int {
+	return agg + v
+}
+
+func collect[T any](in <-chan T) []T {
+	var res []T
+	for v := range in {
+		res = append(res, v)
+	}
+
+	return res
+}
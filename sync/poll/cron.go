@@ -0,0 +1,215 @@
+package poll
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression in the standard five fields format:
+//
+//	┌───────────── minute (0 - 59)
+//	│ ┌───────────── hour (0 - 23)
+//	│ │ ┌───────────── day of the month (1 - 31)
+//	│ │ │ ┌───────────── month (1 - 12)
+//	│ │ │ │ ┌───────────── day of the week (0 - 6, Sunday to Saturday)
+//	│ │ │ │ │
+//	* * * * *
+//
+// Each field supports "*", single values "5", ranges "1-5", lists "1,3,5"
+// and steps "*/5" or "1-30/5".
+type Cron struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// When either day of the month or day of the week is restricted, the day
+	// matches if either field matches.
+	anyDay bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // Minute.
+	{0, 23}, // Hour.
+	{1, 31}, // Day of the month.
+	{1, 12}, // Month.
+	{0, 6},  // Day of the week.
+}
+
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("poll: invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("poll: invalid cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+
+	c := &Cron{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDay: !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*"),
+	}
+	if !c.matchable() {
+		return nil, fmt.Errorf("poll: invalid cron expression %q: never matches", expr)
+	}
+
+	return c, nil
+}
+
+// daysInMonth is the maximum number of days of the month, including the leap
+// years.
+var daysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// matchable reports whether any of the days of the month exists in any of the
+// months, e.g. "0 0 30 2 *" never matches. Every day of the week exists in
+// every month, so the expression always matches when the day of the week
+// matches on its own.
+func (c *Cron) matchable() bool {
+	if c.anyDay {
+		return true
+	}
+
+	for m := 1; m <= 12; m++ {
+		if !has(c.month, m) {
+			continue
+		}
+		for d := 1; d <= daysInMonth[m]; d++ {
+			if has(c.dom, d) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func MustParseCron(expr string) *Cron {
+	c, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// Next returns the next time after t that matches the expression.
+// The zero time is returned if there is no match within the next five
+// years. The expressions that never match, e.g. "0 0 30 2 *", are rejected by
+// ParseCron.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+
+	for t.Before(end) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// BackOff returns the duration until the next scheduled time, ignoring the
+// idle count. It can be used as Poll.BackOff.
+// It never fails, and checks again after a day if there is no match within
+// the next five years, see Next.
+func (c *Cron) BackOff(idle int) time.Duration {
+	now := time.Now()
+	next := c.Next(now)
+	if next.IsZero() {
+		return 24 * time.Hour
+	}
+
+	return next.Sub(now)
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	dom := has(c.dom, t.Day())
+	dow := has(c.dow, int(t.Weekday()))
+	if c.anyDay {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+func parseCronField(s string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+
+			var err error
+			lo, err = strconv.Atoi(a)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				hi, err = strconv.Atoi(b)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("value %q out of range [%d, %d]", part, f.min, f.max)
+		}
+
+		n := 1
+		if hasStep {
+			var err error
+			n, err = strconv.Atoi(step)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		for i := lo; i <= hi; i += n {
+			bits |= 1 << i
+		}
+	}
+
+	return bits, nil
+}
+
+func has(bits uint64, i int) bool {
+	return bits&(1<<i) != 0
+}
@@ -0,0 +1,84 @@
+package poll_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/poll"
+)
+
+func TestCron(t *testing.T) {
+	// Monday.
+	now := time.Date(2024, 1, 1, 10, 2, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 3, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 8-10/2 * * 1-5", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either the day of the month, or the day of the week matches.
+		{"0 0 15 * 3", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			c, err := poll.ParseCron(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.Next(now); !got.Equal(tc.want) {
+				t.Fatalf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"0 0 30 2 *",
+		"0 0 31 4,6,9,11 *",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := poll.ParseCron(expr); err == nil {
+				t.Fatal("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestCronLeapDay(t *testing.T) {
+	// Matches on the leap years only.
+	c := poll.MustParseCron("0 0 29 2 *")
+	want := time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)
+	if got := c.Next(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); !got.Equal(want) {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+	if d := c.BackOff(0); d <= 0 {
+		t.Fatalf("want positive backoff, got %s", d)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	backoff := poll.Jitter(poll.Interval(time.Second), 0.1)
+	for i := range 100 {
+		d := backoff(i)
+		if d < 900*time.Millisecond || d > 1100*time.Millisecond {
+			t.Fatalf("want duration between 900ms and 1.1s, got %s", d)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package poll
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a snapshot of the poller metrics.
+type Metrics struct {
+	// Iterations is the number of batches run.
+	Iterations int64
+	// Successes is the number of successful calls.
+	Successes int64
+	// Empties is the number of batches that ended because the queue is empty.
+	Empties int64
+	// Failures is the number of failed calls.
+	Failures int64
+	// BackOff is the current duration to sleep before the next batch.
+	BackOff time.Duration
+	// InFlight is the number of calls currently running, up to
+	// MaxConcurrency.
+	InFlight int64
+}
+
+type metrics struct {
+	iterations atomic.Int64
+	successes  atomic.Int64
+	empties    atomic.Int64
+	failures   atomic.Int64
+	backoff    atomic.Int64
+	inFlight   atomic.Int64
+}
+
+// Metrics returns a snapshot of the metrics, accumulated across all Poll
+// calls.
+func (p *Poll) Metrics() Metrics {
+	return Metrics{
+		Iterations: p.metrics.iterations.Load(),
+		Successes:  p.metrics.successes.Load(),
+		Empties:    p.metrics.empties.Load(),
+		Failures:   p.metrics.failures.Load(),
+		BackOff:    time.Duration(p.metrics.backoff.Load()),
+		InFlight:   p.metrics.inFlight.Load(),
+	}
+}
+
+// Collector returns a prometheus.Collector that exports the metrics with the
+// name as the "poller" label.
+//
+//	prometheus.MustRegister(p.Collector("orders"))
+func (p *Poll) Collector(name string) prometheus.Collector {
+	return &collector{
+		poll: p,
+		name: name,
+	}
+}
+
+var (
+	iterationsDesc = newDesc("poll_iterations_total", "The number of batches run.")
+	successesDesc  = newDesc("poll_successes_total", "The number of successful calls.")
+	emptiesDesc    = newDesc("poll_empties_total", "The number of batches that ended because the queue is empty.")
+	failuresDesc   = newDesc("poll_failures_total", "The number of failed calls.")
+	backoffDesc    = newDesc("poll_backoff_seconds", "The current duration to sleep before the next batch.")
+	inFlightDesc   = newDesc("poll_in_flight", "The number of calls currently running.")
+)
+
+func newDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(name, help, []string{"poller"}, nil)
+}
+
+type collector struct {
+	poll *Poll
+	name string
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- iterationsDesc
+	ch <- successesDesc
+	ch <- emptiesDesc
+	ch <- failuresDesc
+	ch <- backoffDesc
+	ch <- inFlightDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.poll.Metrics()
+	ch <- prometheus.MustNewConstMetric(iterationsDesc, prometheus.CounterValue, float64(m.Iterations), c.name)
+	ch <- prometheus.MustNewConstMetric(successesDesc, prometheus.CounterValue, float64(m.Successes), c.name)
+	ch <- prometheus.MustNewConstMetric(emptiesDesc, prometheus.CounterValue, float64(m.Empties), c.name)
+	ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.CounterValue, float64(m.Failures), c.name)
+	ch <- prometheus.MustNewConstMetric(backoffDesc, prometheus.GaugeValue, m.BackOff.Seconds(), c.name)
+	ch <- prometheus.MustNewConstMetric(inFlightDesc, prometheus.GaugeValue, float64(m.InFlight), c.name)
+}
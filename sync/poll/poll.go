@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -22,6 +24,12 @@ type Poll struct {
 	FailureThreshold int
 	BackOff          func(idle int) time.Duration
 	MaxConcurrency   int
+
+	metrics metrics
+
+	mu     sync.Mutex
+	cfg    atomic.Pointer[config]
+	paused chan struct{}
 }
 
 func New() *Poll {
@@ -35,20 +43,26 @@ func New() *Poll {
 
 func (p *Poll) Poll(fn func(context.Context) error) (<-chan Event, func()) {
 	var (
-		batchSize        = p.BatchSize
 		ch               = make(chan Event)
 		done             = make(chan struct{})
 		failureThreshold = p.FailureThreshold
-		backoff          = p.BackOff
-		maxConcurrency   = p.MaxConcurrency
 	)
 
-	batch := func(ctx context.Context) (err error) {
+	batch := func(ctx context.Context, cfg *config) (err error) {
 		limiter := NewLimiter(failureThreshold)
 		work := func() error {
+			p.metrics.inFlight.Add(1)
 			err := limiter.Do(func() error {
 				return fn(ctx)
 			})
+			p.metrics.inFlight.Add(-1)
+
+			switch {
+			case err == nil:
+				p.metrics.successes.Add(1)
+			case !errors.Is(err, EOQ) && !errors.Is(err, ErrLimitExceeded):
+				p.metrics.failures.Add(1)
+			}
 
 			if errors.Is(err, EOQ) || errors.Is(err, ErrLimitExceeded) {
 				return err
@@ -93,11 +107,16 @@ func (p *Poll) Poll(fn func(context.Context) error) (<-chan Event, func()) {
 		}
 
 		g, ctx := errgroup.WithContext(ctx)
-		g.SetLimit(maxConcurrency)
+		g.SetLimit(cfg.maxConcurrency)
 
 	loop:
 		// Minus one work done earlier.
-		for range batchSize - 1 {
+		for range cfg.batchSize - 1 {
+			// Let the running work finish when paused.
+			if p.Paused() {
+				break loop
+			}
+
 			select {
 			case <-done:
 				break loop
@@ -120,8 +139,20 @@ func (p *Poll) Poll(fn func(context.Context) error) (<-chan Event, func()) {
 
 		var idle int
 		for {
+			if resumed := p.pausedChan(); resumed != nil {
+				select {
+				case <-done:
+					return
+				case <-resumed:
+				}
+			}
+
+			// The config may change between batches.
+			cfg := p.config()
+
 			// When the process is idle, we can sleep for a longer duration.
-			sleep := backoff(idle)
+			sleep := cfg.backoff(idle)
+			p.metrics.backoff.Store(int64(sleep))
 
 			select {
 			case <-done:
@@ -140,9 +171,11 @@ func (p *Poll) Poll(fn func(context.Context) error) (<-chan Event, func()) {
 			case <-done:
 				return
 			case <-time.After(sleep):
-				if err := batch(context.Background()); err != nil {
+				p.metrics.iterations.Add(1)
+				if err := batch(context.Background(), cfg); err != nil {
 					// Queue is empty, increment idle.
 					if errors.Is(err, Empty) {
+						p.metrics.empties.Add(1)
 						idle++
 
 						continue
@@ -171,6 +204,93 @@ func (p *Poll) Poll(fn func(context.Context) error) (<-chan Event, func()) {
 	})
 }
 
+type config struct {
+	batchSize      int
+	maxConcurrency int
+	backoff        func(idle int) time.Duration
+}
+
+// config returns the config set at runtime, or the fields.
+func (p *Poll) config() *config {
+	if cfg := p.cfg.Load(); cfg != nil {
+		return cfg
+	}
+
+	return &config{
+		batchSize:      p.BatchSize,
+		maxConcurrency: p.MaxConcurrency,
+		backoff:        p.BackOff,
+	}
+}
+
+func (p *Poll) update(fn func(*config)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cfg := *p.config()
+	fn(&cfg)
+	p.cfg.Store(&cfg)
+}
+
+// SetBatchSize changes the batch size of the running pollers, starting from
+// the next batch.
+func (p *Poll) SetBatchSize(n int) {
+	p.update(func(cfg *config) {
+		cfg.batchSize = n
+	})
+}
+
+// SetMaxConcurrency changes the max concurrency of the running pollers,
+// starting from the next batch.
+func (p *Poll) SetMaxConcurrency(n int) {
+	p.update(func(cfg *config) {
+		cfg.maxConcurrency = n
+	})
+}
+
+// SetBackOff changes the backoff of the running pollers, starting from the
+// next poll.
+func (p *Poll) SetBackOff(backoff func(idle int) time.Duration) {
+	p.update(func(cfg *config) {
+		cfg.backoff = backoff
+	})
+}
+
+// Pause stops the running pollers from starting new work, e.g. to throttle a
+// backfill during peak hours. The work in flight is completed.
+func (p *Poll) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused == nil {
+		p.paused = make(chan struct{})
+	}
+}
+
+// Resume resumes the paused pollers.
+func (p *Poll) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused != nil {
+		close(p.paused)
+		p.paused = nil
+	}
+}
+
+func (p *Poll) Paused() bool {
+	return p.pausedChan() != nil
+}
+
+// pausedChan returns the channel that is closed on resume, or nil if not
+// paused.
+func (p *Poll) pausedChan() chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused
+}
+
 // ExponentialBackOff returns the duration to sleep before the next batch.
 // Idle will be zero if there are items in the queue. Otherwise, it will
 // increment.
@@ -180,6 +300,26 @@ func ExponentialBackOff(idle int) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// Interval returns a backoff that polls at fixed interval, regardless of
+// whether the queue is idle.
+func Interval(d time.Duration) func(idle int) time.Duration {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// Jitter randomizes the duration returned by the backoff by up to the given
+// ratio, e.g. a ratio of 0.1 returns between 90% and 110% of the duration.
+// This avoids multiple pollers from running at the same time.
+func Jitter(backoff func(idle int) time.Duration, ratio float64) func(idle int) time.Duration {
+	return func(idle int) time.Duration {
+		d := float64(backoff(idle))
+		d += d * ratio * (2*rand.Float64() - 1)
+
+		return time.Duration(max(d, 0))
+	}
+}
+
 func MaxConcurrency() int {
 	return min(runtime.GOMAXPROCS(0), runtime.NumCPU())
 }
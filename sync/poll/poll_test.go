@@ -5,8 +5,10 @@ import (
 	"errors"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alextanhongpin/core/sync/poll"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestPoll(t *testing.T) {
@@ -106,3 +108,80 @@ func TestIdle(t *testing.T) {
 		}
 	}
 }
+
+func TestMetrics(t *testing.T) {
+	p := poll.New()
+	p.BatchSize = 3
+	p.MaxConcurrency = 1
+	p.BackOff = poll.Interval(10 * time.Millisecond)
+
+	i := new(atomic.Int64)
+	ch, stop := p.Poll(func(ctx context.Context) error {
+		if i.Add(1)%3 == 0 {
+			return poll.EOQ
+		}
+
+		return nil
+	})
+
+	for msg := range ch {
+		if errors.Is(msg.Err, poll.EOQ) {
+			stop()
+		}
+	}
+
+	m := p.Metrics()
+	if want := int64(1); m.Iterations != want {
+		t.Fatalf("want %d iterations, got %d", want, m.Iterations)
+	}
+	if want := int64(2); m.Successes != want {
+		t.Fatalf("want %d successes, got %d", want, m.Successes)
+	}
+	if m.Failures != 0 || m.Empties != 0 || m.InFlight != 0 {
+		t.Fatalf("want no failures, empties and in flight, got %+v", m)
+	}
+	if want := 10 * time.Millisecond; m.BackOff != want {
+		t.Fatalf("want %s backoff, got %s", want, m.BackOff)
+	}
+
+	if n := testutil.CollectAndCount(p.Collector("test")); n != 6 {
+		t.Fatalf("want 6 metrics, got %d", n)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	p := poll.New()
+	p.BatchSize = 3
+	p.MaxConcurrency = 1
+	p.BackOff = poll.Interval(time.Millisecond)
+	p.Pause()
+
+	var count atomic.Int64
+	ch, stop := p.Poll(func(ctx context.Context) error {
+		count.Add(1)
+		return nil
+	})
+	defer stop()
+
+	time.Sleep(20 * time.Millisecond)
+	if n := count.Load(); n != 0 {
+		t.Fatalf("want no calls when paused, got %d", n)
+	}
+
+	p.SetBatchSize(5)
+	p.Resume()
+	if p.Paused() {
+		t.Fatal("want resumed")
+	}
+
+	for msg := range ch {
+		if msg.Name != "batch" {
+			continue
+		}
+		if want, got := 5, msg.Data["total"]; got != want {
+			t.Fatalf("want batch of %d, got %v", want, got)
+		}
+
+		break
+	}
+}
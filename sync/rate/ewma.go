@@ -0,0 +1,71 @@
+package rate
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// EWMA is the exponentially weighted moving average of the rate of events
+// per second. Unlike Rate, where the count decays linearly within the
+// period, the weight of an event halves every half-life, so that a spike
+// fades out smoothly instead of dominating the window, e.g. for health
+// checks.
+type EWMA struct {
+	Now      func() time.Time
+	count    float64
+	last     int64
+	mu       sync.Mutex
+	halfLife float64
+}
+
+// NewEWMA returns an EWMA where the weight of an event halves after the
+// half-life.
+func NewEWMA(halfLife time.Duration) *EWMA {
+	return &EWMA{
+		Now:      time.Now,
+		halfLife: halfLife.Seconds(),
+	}
+}
+
+// Update records n events.
+func (e *EWMA) Update(n float64) {
+	e.mu.Lock()
+	e.update(n)
+	e.mu.Unlock()
+}
+
+// Rate returns the smoothed number of events per second.
+func (e *EWMA) Rate() float64 {
+	e.mu.Lock()
+	count := e.update(0)
+	e.mu.Unlock()
+
+	// At a constant rate r, the decayed count converges to
+	// r*halfLife/ln(2).
+	return count * math.Ln2 / e.halfLife
+}
+
+// Per returns the smoothed number of events per duration.
+func (e *EWMA) Per(t time.Duration) float64 {
+	return e.Rate() * t.Seconds()
+}
+
+func (e *EWMA) Reset() {
+	e.mu.Lock()
+	e.count = 0
+	e.last = 0
+	e.mu.Unlock()
+}
+
+func (e *EWMA) update(n float64) float64 {
+	now := e.Now().UnixNano()
+	if e.last != 0 {
+		elapsed := time.Duration(max(now-e.last, 0)).Seconds()
+		e.count *= math.Exp2(-elapsed / e.halfLife)
+	}
+	e.count += n
+	e.last = now
+
+	return e.count
+}
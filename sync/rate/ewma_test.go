@@ -0,0 +1,76 @@
+package rate_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/rate"
+	"github.com/stretchr/testify/assert"
+)
+
+// ExampleEWMA compares the Rate and the EWMA on a spiky traffic of 10
+// requests per second, with a burst of 100 requests at the 5th second.
+func ExampleEWMA() {
+	now := time.Now()
+	r := rate.NewRate(time.Second)
+	e := rate.NewEWMA(time.Second)
+	r.Now = func() time.Time { return now }
+	e.Now = func() time.Time { return now }
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
+	fmt.Fprintf(w, "%s\t%s\t%s\t\n", "s", "rate", "ewma")
+	for i := range 100 {
+		now = now.Add(100 * time.Millisecond)
+
+		n := 1.0
+		if i == 50 {
+			n = 100
+		}
+		r.Add(n)
+		e.Update(n)
+
+		if i%10 == 9 {
+			fmt.Fprintf(w, "%d\t%.2f\t%.2f\t\n", (i+1)/10, r.Count(), e.Rate())
+		}
+	}
+	w.Flush()
+	// Output:
+	//   s|  rate|  ewma|
+	//   1|  6.51|  5.18|
+	//   2|  8.78|  7.76|
+	//   3|  9.58|  9.06|
+	//   4|  9.85|  9.70|
+	//   5|  9.95| 10.03|
+	//   6| 48.34| 46.96|
+	//   7| 23.37| 28.66|
+	//   8| 14.66| 19.50|
+	//   9| 11.63| 14.93|
+	//  10| 10.57| 12.64|
+}
+
+func TestEWMA(t *testing.T) {
+	now := time.Now()
+	e := rate.NewEWMA(time.Second)
+	e.Now = func() time.Time { return now }
+
+	is := assert.New(t)
+
+	// Converges to the constant rate of 10 events per second.
+	for range 1000 {
+		now = now.Add(100 * time.Millisecond)
+		e.Update(1)
+	}
+	is.InDelta(10, e.Rate(), 0.5)
+	is.InDelta(600, e.Per(time.Minute), 30)
+
+	// Halves every half-life without events.
+	rate := e.Rate()
+	now = now.Add(time.Second)
+	is.InDelta(rate/2, e.Rate(), 1e-9)
+
+	e.Reset()
+	is.Equal(0.0, e.Rate())
+}
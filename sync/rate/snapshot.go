@@ -0,0 +1,134 @@
+package rate
+
+import (
+	"encoding/json"
+	"expvar"
+	"time"
+)
+
+var (
+	_ expvar.Var     = (*Rate)(nil)
+	_ expvar.Var     = (*Errors)(nil)
+	_ expvar.Var     = (*Limiter)(nil)
+	_ json.Marshaler = (*Rate)(nil)
+	_ json.Marshaler = (*Errors)(nil)
+	_ json.Marshaler = (*Limiter)(nil)
+)
+
+type RateSnapshot struct {
+	Count  float64       `json:"count"`
+	Period time.Duration `json:"period"`
+	// Start and End are the boundaries of the window, which slides with the
+	// time of the snapshot.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Snapshot returns the current count within the period.
+func (r *Rate) Snapshot() RateSnapshot {
+	r.mu.Lock()
+	count := r.add(0)
+	end := time.Unix(0, r.last)
+	r.mu.Unlock()
+
+	period := time.Duration(r.period)
+	return RateSnapshot{
+		Count:  count,
+		Period: period,
+		Start:  end.Add(-period),
+		End:    end,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Snapshot())
+}
+
+// String implements expvar.Var, so that the rate can be published with
+// expvar.Publish.
+func (r *Rate) String() string {
+	return jsonString(r)
+}
+
+type ErrorsSnapshot struct {
+	Success float64       `json:"success"`
+	Failure float64       `json:"failure"`
+	Total   float64       `json:"total"`
+	Ratio   float64       `json:"ratio"`
+	Period  time.Duration `json:"period"`
+	Start   time.Time     `json:"start"`
+	End     time.Time     `json:"end"`
+}
+
+func (e *Errors) Snapshot() ErrorsSnapshot {
+	e.mu.Lock()
+	success := e.success.Snapshot()
+	failure := e.failure.Snapshot()
+	e.mu.Unlock()
+
+	r := &ErrorRate{
+		success: success.Count,
+		failure: failure.Count,
+	}
+
+	return ErrorsSnapshot{
+		Success: r.Success(),
+		Failure: r.Failure(),
+		Total:   r.Total(),
+		Ratio:   r.Ratio(),
+		Period:  success.Period,
+		Start:   success.Start,
+		End:     success.End,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *Errors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Snapshot())
+}
+
+// String implements expvar.Var.
+func (e *Errors) String() string {
+	return jsonString(e)
+}
+
+type LimiterSnapshot struct {
+	Limit   float64 `json:"limit"`
+	Total   float64 `json:"total"`
+	Success int     `json:"success"`
+	Failure int     `json:"failure"`
+	Allow   bool    `json:"allow"`
+}
+
+func (l *Limiter) Snapshot() LimiterSnapshot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return LimiterSnapshot{
+		Limit:   l.limit,
+		Total:   l.total,
+		Success: l.success,
+		Failure: l.failure,
+		Allow:   l.total < l.limit,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l *Limiter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Snapshot())
+}
+
+// String implements expvar.Var.
+func (l *Limiter) String() string {
+	return jsonString(l)
+}
+
+func jsonString(v json.Marshaler) string {
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return "null"
+	}
+
+	return string(b)
+}
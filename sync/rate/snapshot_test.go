@@ -0,0 +1,44 @@
+package rate_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/rate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := rate.NewRate(time.Second)
+	r.Now = func() time.Time {
+		return now
+	}
+	r.Add(10)
+
+	is := assert.New(t)
+	is.Equal(rate.RateSnapshot{
+		Count:  10,
+		Period: time.Second,
+		Start:  now.Add(-time.Second).Local(),
+		End:    now.Local(),
+	}, r.Snapshot())
+
+	e := rate.NewErrors(time.Second)
+	e.SetNow(r.Now)
+	e.Success().Add(3)
+	e.Failure().Add(1)
+
+	var got rate.ErrorsSnapshot
+	is.Nil(json.Unmarshal([]byte(e.String()), &got))
+	is.Equal(4.0, got.Total)
+	is.Equal(0.25, got.Ratio)
+
+	l := rate.NewLimiter(1)
+	l.Err()
+	expvar.Publish(t.Name(), l)
+	is.JSONEq(`{"limit":1,"total":1,"success":0,"failure":1,"allow":false}`, expvar.Get(t.Name()).String())
+}
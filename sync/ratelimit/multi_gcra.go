@@ -14,6 +14,7 @@ type MultiGCRA struct {
 	interval int64
 	offset   int64
 	period   int64
+	limit    int
 	Now      func() time.Time
 }
 
@@ -26,6 +27,7 @@ func NewMultiGCRA(limit int, period time.Duration, burst int) *MultiGCRA {
 		interval: interval,
 		offset:   interval * int64(burst),
 		period:   period.Nanoseconds(),
+		limit:    limit,
 		Now:      time.Now,
 	}
 }
@@ -35,18 +37,43 @@ func (r *MultiGCRA) Allow(key string) bool {
 }
 
 func (r *MultiGCRA) AllowN(key string, n int) bool {
+	return r.AllowNDetail(key, n).Allow
+}
+
+// AllowDetail is like Allow, but returns the detail of the decision for the
+// key.
+func (r *MultiGCRA) AllowDetail(key string) Result {
+	return r.AllowNDetail(key, 1)
+}
+
+func (r *MultiGCRA) AllowNDetail(key string, n int) Result {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	now := r.Now().UnixNano()
 	r.state[key] = max(r.state[key], now)
-	if r.state[key]-r.offset <= now {
+
+	allow := r.state[key]-r.offset <= now
+	if allow {
 		r.state[key] += int64(n) * r.interval
+	}
+
+	res := Result{
+		Allow: allow,
+		Limit: r.limit,
+	}
 
-		return true
+	// The next request is allowed once the theoretical arrival time is
+	// within the burst.
+	if wait := r.state[key] - r.offset - now; wait > 0 {
+		if !allow {
+			res.RetryIn = time.Duration(wait)
+		}
+	} else {
+		res.Remaining = int(-wait/r.interval) + 1
 	}
 
-	return false
+	return res
 }
 
 func (r *MultiGCRA) RetryAt(key string) time.Time {
@@ -36,3 +36,22 @@ func TestMultiGCRA_Clear(t *testing.T) {
 	r.Clear()
 	is.Equal(0, r.Size())
 }
+
+func TestMultiGCRA_AllowDetail(t *testing.T) {
+	now := time.Now()
+	r := ratelimit.NewMultiGCRA(5, time.Second, 1)
+	r.Now = func() time.Time {
+		return now
+	}
+
+	is := assert.New(t)
+	is.Equal(ratelimit.Result{Allow: true, Limit: 5, Remaining: 1}, r.AllowDetail("foo"))
+	is.Equal(ratelimit.Result{Allow: true, Limit: 5, Remaining: 0}, r.AllowDetail("foo"))
+	is.Equal(ratelimit.Result{Allow: false, Limit: 5, RetryIn: 200 * time.Millisecond}, r.AllowDetail("foo"))
+
+	// The other keys are not limited.
+	is.Equal(ratelimit.Result{Allow: true, Limit: 5, Remaining: 1}, r.AllowDetail("bar"))
+
+	now = now.Add(200 * time.Millisecond)
+	is.Equal(ratelimit.Result{Allow: true, Limit: 5, Remaining: 0}, r.AllowDetail("foo"))
+}
@@ -1,5 +1,19 @@
 package ratelimit
 
+import "time"
+
+// Result is the detail of the rate limit decision, e.g. for the
+// X-RateLimit-* and Retry-After headers.
+type Result struct {
+	Allow bool
+	Limit int
+	// Remaining is the number of requests that can still be made now.
+	Remaining int
+	// RetryIn is the duration until the next request is allowed, when the
+	// request is denied.
+	RetryIn time.Duration
+}
+
 type ratelimiter interface {
 	Allow() bool
 	AllowN(int) bool
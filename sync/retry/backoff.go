@@ -13,6 +13,7 @@ var (
 	_ backOffPolicy = (*ConstantBackOff)(nil)
 	_ backOffPolicy = (*ExponentialBackOff)(nil)
 	_ backOffPolicy = (*LinearBackOff)(nil)
+	_ backOffPolicy = (*JitterBackOff)(nil)
 )
 
 type ConstantBackOff struct {
@@ -60,3 +61,25 @@ func NewLinearBackOff(period time.Duration) *LinearBackOff {
 func (b *LinearBackOff) BackOff(attempts int) time.Duration {
 	return b.Period * time.Duration(attempts)
 }
+
+// JitterBackOff randomizes the backoff of the underlying policy by up to the
+// given ratio, e.g. a ratio of 0.2 returns between 80% and 120% of the
+// backoff.
+type JitterBackOff struct {
+	Policy backOffPolicy
+	Ratio  float64
+}
+
+func NewJitterBackOff(policy backOffPolicy, ratio float64) *JitterBackOff {
+	return &JitterBackOff{
+		Policy: policy,
+		Ratio:  ratio,
+	}
+}
+
+func (b *JitterBackOff) BackOff(attempts int) time.Duration {
+	d := float64(b.Policy.BackOff(attempts))
+	d += d * b.Ratio * (2*rand.Float64() - 1)
+
+	return time.Duration(max(d, 0))
+}
@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/rate"
+)
+
+type budget interface {
+	Request()
+	Allow() bool
+}
+
+var _ budget = (*Budget)(nil)
+
+// Budget limits the retries to a ratio of the recent requests. Unlike the
+// Throttler, the Budget can be shared by multiple retries in the process,
+// so that the retries cannot amplify an outage:
+//
+//	b := retry.NewBudget(0.1, time.Minute)
+//
+//	r1 := retry.New(retry.NewExponentialBackOff(time.Second, time.Minute))
+//	r1.Budget = b
+//
+//	r2 := retry.New(retry.NewConstantBackOff(time.Second))
+//	r2.Budget = b
+type Budget struct {
+	// Ratio is the maximum ratio of retries to requests.
+	Ratio float64
+
+	// MinRetries is the number of retries allowed within the period
+	// regardless of the ratio, so that the retries are not starved when the
+	// traffic is low.
+	MinRetries float64
+
+	mu       sync.Mutex
+	requests *rate.Rate
+	retries  *rate.Rate
+}
+
+// NewBudget returns a Budget that allows retries at most the ratio of the
+// requests within the period, e.g. 0.1 allows 1 retry for every 10
+// requests.
+func NewBudget(ratio float64, period time.Duration) *Budget {
+	return &Budget{
+		Ratio:      ratio,
+		MinRetries: 10,
+		requests:   rate.NewRate(period),
+		retries:    rate.NewRate(period),
+	}
+}
+
+// Request records a request, which is the first attempt.
+func (b *Budget) Request() {
+	if b == nil {
+		return
+	}
+
+	b.requests.Inc()
+}
+
+// Allow reports whether a retry is allowed, and records the retry if so.
+func (b *Budget) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	limit := max(b.MinRetries, b.Ratio*b.requests.Count())
+	if b.retries.Count()+1 > limit {
+		return false
+	}
+	b.retries.Inc()
+
+	return true
+}
@@ -0,0 +1,42 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/retry"
+)
+
+func ExampleBudget() {
+	// Allow 1 retry for every 10 requests, without the minimum.
+	b := retry.NewBudget(0.1, time.Minute)
+	b.MinRetries = 0
+
+	newRetry := func() *retry.Retry {
+		r := retry.New(retry.NewConstantBackOff(time.Millisecond))
+		r.Budget = b
+		r.Limit = 3
+		return r
+	}
+
+	ctx := context.Background()
+	r1, r2 := newRetry(), newRetry()
+	for range 10 {
+		_ = r1.Do(ctx, func(ctx context.Context) error {
+			return nil
+		})
+	}
+
+	// The budget is shared by both retries.
+	var n int
+	err := r2.Do(ctx, func(ctx context.Context) error {
+		n++
+		return errors.New("down")
+	})
+	fmt.Println(n, errors.Is(err, retry.ErrBudgetExceeded))
+
+	// Output:
+	// 2 true
+}
@@ -0,0 +1,37 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/retry"
+)
+
+func ExampleDoValue() {
+	r := retry.New(retry.NewJitterBackOff(retry.NewConstantBackOff(time.Millisecond), 0.5))
+	r.Limit = 3
+
+	ctx := context.Background()
+	n, err := retry.DoValue(ctx, r, func(ctx context.Context) (int, error) {
+		if retry.Attempt(ctx) < 2 {
+			return 0, errors.New("failed")
+		}
+
+		return 42, nil
+	})
+	fmt.Println(n, err)
+
+	_, err = retry.DoValue(ctx, r, func(ctx context.Context) (int, error) {
+		return 0, fmt.Errorf("attempt %d failed", retry.Attempt(ctx))
+	})
+	fmt.Println(errors.Is(err, retry.ErrLimitExceeded))
+	fmt.Println(err)
+
+	// Output:
+	// 42 <nil>
+	// true
+	// attempt 2 failed
+	// retry: limit exceeded
+}
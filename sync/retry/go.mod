@@ -1,3 +1,5 @@
 module github.com/alextanhongpin/core/sync/retry
 
 go 1.23.1
+
+require github.com/alextanhongpin/core/sync/rate v0.0.0-20241129045434-84469bdbd179
@@ -9,8 +9,9 @@ import (
 )
 
 var (
-	ErrLimitExceeded = errors.New("retry: limit exceeded")
-	ErrThrottled     = errors.New("retry: throttled")
+	ErrLimitExceeded  = errors.New("retry: limit exceeded")
+	ErrThrottled      = errors.New("retry: throttled")
+	ErrBudgetExceeded = errors.New("retry: budget exceeded")
 )
 
 type retry interface {
@@ -22,17 +23,41 @@ var _ retry = (*Retry)(nil)
 type Retry struct {
 	BackOffPolicy backOffPolicy
 	Throttler     throttler
+
+	// Budget limits the retries across multiple retries. See Budget.
+	Budget budget
+
+	// Limit is the maximum number of attempts used by Do and DoValue.
+	Limit int
+
+	// AttemptTimeout sets the timeout of each attempt in Do and DoValue.
+	// Zero means no timeout.
+	AttemptTimeout time.Duration
 }
 
 func New(bop backOffPolicy) *Retry {
-	var t *Throttler
+	var (
+		t *Throttler
+		b *Budget
+	)
 
 	return &Retry{
 		BackOffPolicy: bop,
 		Throttler:     t,
+		Budget:        b,
+		Limit:         10,
 	}
 }
 
+// Do calls fn until it succeeds, or the retry is exhausted.
+func (r *Retry) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, r, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+
+	return err
+}
+
 func (r *Retry) Try(ctx context.Context, limit int) iter.Seq2[int, error] {
 	return func(yield func(int, error) bool) {
 		for i := range limit + 1 {
@@ -49,6 +74,14 @@ func (r *Retry) Try(ctx context.Context, limit int) iter.Seq2[int, error] {
 				break
 			}
 
+			if i == 0 {
+				r.Budget.Request()
+			} else if !r.Budget.Allow() {
+				yield(i, ErrBudgetExceeded)
+
+				break
+			}
+
 			if err := ctx.Err(); err != nil {
 				yield(i, err)
 
@@ -73,3 +106,43 @@ func (r *Retry) Try(ctx context.Context, limit int) iter.Seq2[int, error] {
 		}
 	}
 }
+
+// DoValue calls fn until it succeeds, and returns the value.
+// When the retry is exhausted or throttled, the last error is returned
+// together with ErrLimitExceeded or ErrThrottled.
+// The attempt number, starting from 0, can be obtained from the context
+// passed to fn using Attempt.
+func DoValue[T any](ctx context.Context, r *Retry, fn func(ctx context.Context) (T, error)) (v T, err error) {
+	for i, tryErr := range r.Try(ctx, r.Limit) {
+		if tryErr != nil {
+			return v, errors.Join(err, tryErr)
+		}
+
+		v, err = attempt(ctx, r, i, fn)
+		if err == nil {
+			return v, nil
+		}
+	}
+
+	return v, err
+}
+
+type attemptContextKey struct{}
+
+// Attempt returns the attempt number from the context passed to Do and
+// DoValue.
+func Attempt(ctx context.Context) int {
+	n, _ := ctx.Value(attemptContextKey{}).(int)
+	return n
+}
+
+func attempt[T any](ctx context.Context, r *Retry, i int, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx = context.WithValue(ctx, attemptContextKey{}, i)
+	if r.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.AttemptTimeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
+}
@@ -2,10 +2,29 @@ package singleflight
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
 )
 
+// PanicError is the error returned to all the callers when the function
+// panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("singleflight: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
 type Group[T any] struct {
+	// IsolateErrors shares only the successes. The callers waiting on a
+	// failed call retry once instead, so that a transient failure of one
+	// caller does not fail the others. Panics are always shared.
+	IsolateErrors bool
+
 	mu    sync.Mutex
 	tasks map[string]*task[T]
 }
@@ -17,12 +36,25 @@ func New[T any]() *Group[T] {
 }
 
 func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	data, waited, err := g.do(ctx, key, fn)
+	if waited && err != nil && g.IsolateErrors {
+		var pe *PanicError
+		if !errors.As(err, &pe) {
+			data, waited, err = g.do(ctx, key, fn)
+		}
+	}
+
+	return data, waited && err == nil, err
+}
+
+// do returns true if the call waited for the result of another call.
+func (g *Group[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, bool, error) {
 	g.mu.Lock()
 	t, ok := g.tasks[key]
 	if ok {
 		g.mu.Unlock()
 		data, err := t.Unwrap()
-		return data, err == nil, err
+		return data, true, err
 	}
 
 	t = newTask[T]()
@@ -31,14 +63,18 @@ func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Conte
 
 	go func() {
 		defer t.wg.Done()
+		defer func() {
+			g.mu.Lock()
+			delete(g.tasks, key)
+			g.mu.Unlock()
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				t.Err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
 
-		data, err := fn(ctx)
-		t.Data = data
-		t.Err = err
-
-		g.mu.Lock()
-		delete(g.tasks, key)
-		g.mu.Unlock()
+		t.Data, t.Err = fn(ctx)
 	}()
 
 	data, err := t.Unwrap()
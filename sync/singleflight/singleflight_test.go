@@ -2,6 +2,8 @@ package singleflight_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -50,3 +52,84 @@ func TestSingleflight(t *testing.T) {
 	is.Equal(int64(1), exec.Load())
 	is.Equal(int64(9), share.Load())
 }
+
+func TestPanic(t *testing.T) {
+	g := singleflight.New[int]()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	start := make(chan struct{})
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, _, errs[i] = g.Do(context.Background(), "foo", func(ctx context.Context) (int, error) {
+				<-start
+				panic("boom")
+			})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(start)
+	wg.Wait()
+
+	is := assert.New(t)
+	for _, err := range errs {
+		var pe *singleflight.PanicError
+		is.ErrorAs(err, &pe)
+		is.Equal("boom", pe.Value)
+	}
+}
+
+func TestIsolateErrors(t *testing.T) {
+	wantErr := errors.New("transient")
+
+	for _, isolate := range []bool{false, true} {
+		t.Run(fmt.Sprintf("isolate=%t", isolate), func(t *testing.T) {
+			g := singleflight.New[int]()
+			g.IsolateErrors = isolate
+
+			var calls atomic.Int64
+			fn := func(ctx context.Context) (int, error) {
+				// Only the first call fails.
+				if calls.Add(1) == 1 {
+					time.Sleep(10 * time.Millisecond)
+					return 0, wantErr
+				}
+
+				return 42, nil
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, 5)
+			for i := range errs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					// Make sure the first call is the leader.
+					if i > 0 {
+						time.Sleep(time.Millisecond)
+					}
+					_, _, errs[i] = g.Do(context.Background(), "foo", fn)
+				}()
+			}
+			wg.Wait()
+
+			var failed int
+			for _, err := range errs {
+				if err != nil {
+					failed++
+				}
+			}
+
+			is := assert.New(t)
+			if isolate {
+				is.Equal(1, failed)
+			} else {
+				is.Equal(len(errs), failed)
+			}
+		})
+	}
+}
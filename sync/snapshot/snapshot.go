@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,13 +15,53 @@ var ErrTerminated = errors.New("snapshot: terminated")
 type Event struct {
 	Count  int
 	Policy Policy
+	// Skipped is the number of triggers skipped since the previous event,
+	// because the handler was still running. The count of the skipped
+	// triggers is included in this event.
+	Skipped int
 }
 
+// Mode controls how the handler is executed.
+type Mode int
+
+const (
+	// Sync runs the handler in the scheduler. Inc blocks while the handler
+	// is running.
+	Sync Mode = iota
+
+	// SkipIfRunning runs the handler in a worker, and skips the trigger if
+	// the previous handler is still running.
+	SkipIfRunning
+
+	// QueueOne runs the handler in a worker, and queues at most one trigger
+	// while the previous handler is still running. Further triggers are
+	// coalesced into the next one.
+	QueueOne
+)
+
 type Policy struct {
+	// Name identifies the policy in the events and metrics.
+	Name     string
 	Every    int
 	Interval time.Duration
 }
 
+type PolicyMetrics struct {
+	Policy Policy
+	// Runs is the number of times the policy triggered the callback.
+	Runs int
+	// Skipped is the number of intervals where the count did not reach the
+	// threshold.
+	Skipped int
+	// LastRunAt is when the policy last triggered the callback.
+	LastRunAt time.Time
+	// LastCount is the count when the policy last triggered the callback.
+	LastCount int
+	// Overlapped is the number of triggers skipped because the handler was
+	// still running.
+	Overlapped int
+}
+
 func NewOptions() []Policy {
 	return []Policy{
 		{Every: 1_000, Interval: time.Second},
@@ -35,14 +76,31 @@ type Background struct {
 	ch   chan int
 	ctx  context.Context
 	fn   func(ctx context.Context, evt Event)
+	mode Mode
+
+	paused  atomic.Bool
+	mu      sync.RWMutex
+	metrics []PolicyMetrics
 }
 
 func New(ctx context.Context, fn func(context.Context, Event), opts ...Policy) (*Background, func()) {
+	return NewAsync(ctx, fn, Sync, opts...)
+}
+
+// NewAsync is like New, but runs the handler according to the mode, so that
+// slow handlers do not pile up or overlap.
+func NewAsync(ctx context.Context, fn func(context.Context, Event), mode Mode, opts ...Policy) (*Background, func()) {
 	bg := &Background{
 		opts: opts,
 		ch:   make(chan int),
 		fn:   fn,
+		mode: mode,
+	}
+	bg.metrics = make([]PolicyMetrics, len(opts))
+	for i, p := range opts {
+		bg.metrics[i].Policy = p
 	}
+
 	ctx, cancel := context.WithCancelCause(ctx)
 	stop := bg.init(ctx)
 	bg.ctx = ctx
@@ -62,15 +120,44 @@ func (b *Background) Inc(n int) error {
 	}
 }
 
+// Touch increments the count by one.
+func (b *Background) Touch() error {
+	return b.Inc(1)
+}
+
+// Pause stops the policies from triggering the callback. The count is still
+// incremented while paused.
+func (b *Background) Pause() {
+	b.paused.Store(true)
+}
+
+// Resume resumes the policies.
+func (b *Background) Resume() {
+	b.paused.Store(false)
+}
+
+func (b *Background) Paused() bool {
+	return b.paused.Load()
+}
+
+// Metrics returns the metrics of each policy, in the order they are passed
+// to New.
+func (b *Background) Metrics() []PolicyMetrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]PolicyMetrics(nil), b.metrics...)
+}
+
 func (b *Background) init(ctx context.Context) func() {
 	var count int
 
 	var wg sync.WaitGroup
 	wg.Add(len(b.opts))
 
-	ch := make(chan Policy)
+	ch := make(chan int)
 
-	for _, p := range b.opts {
+	for i, p := range b.opts {
 		go func() {
 			defer wg.Done()
 
@@ -83,7 +170,7 @@ func (b *Background) init(ctx context.Context) func() {
 					return
 				case <-t.C:
 					select {
-					case ch <- p:
+					case ch <- i:
 					case <-ctx.Done():
 						return
 					}
@@ -92,25 +179,87 @@ func (b *Background) init(ctx context.Context) func() {
 		}()
 	}
 
+	// The worker receives the events from the scheduler. An unbuffered
+	// channel only accepts an event when the worker is idle, while a buffer
+	// of one queues an event while the worker is busy.
+	var work chan Event
+	switch b.mode {
+	case SkipIfRunning:
+		work = make(chan Event)
+	case QueueOne:
+		work = make(chan Event, 1)
+	}
+
+	if work != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case evt := <-work:
+					b.fn(ctx, evt)
+				}
+			}
+		}()
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+
+		var skipped int
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case n := <-b.ch:
 				count += n
-			case p := <-ch:
+			case i := <-ch:
+				if b.paused.Load() {
+					continue
+				}
+
+				p := b.opts[i]
 				if count < p.Every {
+					b.mu.Lock()
+					b.metrics[i].Skipped++
+					b.mu.Unlock()
+
 					continue
 				}
 				evt := Event{
-					Count:  count,
-					Policy: p,
+					Count:   count,
+					Policy:  p,
+					Skipped: skipped,
+				}
+				if work != nil {
+					select {
+					case work <- evt:
+					default:
+						// Keep the count for the next trigger.
+						skipped++
+						b.mu.Lock()
+						b.metrics[i].Overlapped++
+						b.mu.Unlock()
+
+						continue
+					}
 				}
+
+				b.mu.Lock()
+				b.metrics[i].Runs++
+				b.metrics[i].LastRunAt = time.Now()
+				b.metrics[i].LastCount = count
+				b.mu.Unlock()
+
 				count = 0
-				b.fn(ctx, evt)
+				skipped = 0
+				if work == nil {
+					b.fn(ctx, evt)
+				}
 			}
 		}
 	}()
@@ -37,3 +37,63 @@ func TestSnapshot(t *testing.T) {
 	time.Sleep(30 * time.Millisecond)
 	is.Equal(snapshot.Event{Count: 100, Policy: policies[2]}, events[2])
 }
+
+func TestPauseResume(t *testing.T) {
+	policies := []snapshot.Policy{
+		{Name: "fast", Every: 1, Interval: 10 * time.Millisecond},
+	}
+	events := make(chan snapshot.Event, 1)
+	bg, stop := snapshot.New(ctx, func(ctx context.Context, evt snapshot.Event) {
+		events <- evt
+	}, policies...)
+	defer stop()
+
+	bg.Pause()
+	is := assert.New(t)
+	is.Nil(bg.Touch())
+	is.Nil(bg.Touch())
+
+	select {
+	case <-events:
+		t.Fatal("want no events while paused")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	bg.Resume()
+	evt := <-events
+	is.Equal(snapshot.Event{Count: 2, Policy: policies[0]}, evt)
+
+	m := bg.Metrics()
+	is.Len(m, 1)
+	is.Equal("fast", m[0].Policy.Name)
+	is.Equal(1, m[0].Runs)
+	is.Equal(2, m[0].LastCount)
+}
+
+func TestSkipIfRunning(t *testing.T) {
+	policies := []snapshot.Policy{
+		{Every: 1, Interval: 5 * time.Millisecond},
+	}
+
+	release := make(chan struct{})
+	events := make(chan snapshot.Event, 2)
+	bg, stop := snapshot.NewAsync(ctx, func(ctx context.Context, evt snapshot.Event) {
+		events <- evt
+		<-release
+	}, snapshot.SkipIfRunning, policies...)
+	defer stop()
+
+	is := assert.New(t)
+	is.Nil(bg.Inc(1))
+	is.Equal(1, (<-events).Count)
+
+	// Triggers are skipped while the handler is blocked.
+	is.Nil(bg.Inc(2))
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+
+	evt := <-events
+	is.Equal(2, evt.Count)
+	is.Positive(evt.Skipped)
+	is.Positive(bg.Metrics()[0].Overlapped)
+}
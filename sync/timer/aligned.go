@@ -0,0 +1,100 @@
+package timer
+
+import (
+	"cmp"
+	"sync"
+	"time"
+)
+
+// Missed is the policy for the ticks that are missed, because the previous
+// call took longer than the interval, or the process was paused, e.g. by a
+// long GC pause or a laptop sleep.
+type Missed int
+
+const (
+	// Skip drops the missed ticks, and waits for the next boundary.
+	Skip Missed = iota
+
+	// CatchUp calls the function once for every missed tick, one after
+	// another.
+	CatchUp
+)
+
+// SetAlignedInterval calls fn on the wall clock boundaries of the interval,
+// starting from the boundary of the align, e.g. an interval of 15s with the
+// align of a minute calls fn at :00, :15, :30 and :45 of every minute, so
+// that periodic jobs emit aligned buckets. The align defaults to the
+// interval.
+//
+// The ticks are computed from the wall clock, so they do not drift after a
+// sleep, and never go backwards when the clock is adjusted.
+func SetAlignedInterval(fn func(), interval, align time.Duration, missed Missed) *Interval {
+	if interval <= 0 {
+		panic("timer: non-positive interval for SetAlignedInterval")
+	}
+	align = cmp.Or(align, interval)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	i := new(Interval)
+	done := make(chan struct{})
+	go func() {
+		defer wg.Done()
+
+		// Round(0) strips the monotonic clock reading, so that the durations
+		// are measured with the wall clock.
+		now := time.Now().Round(0)
+		start := now.Truncate(align)
+		next := start.Add((now.Sub(start)/interval + 1) * interval)
+
+		t := time.NewTimer(time.Until(next))
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+
+			// The timer may fire early when the wall clock is adjusted.
+			now := time.Now().Round(0)
+			if now.Before(next) {
+				t.Reset(next.Sub(now))
+				continue
+			}
+
+			due := int(now.Sub(next)/interval) + 1
+			calls := 1
+			if missed == CatchUp {
+				calls = due
+			}
+			for range calls {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if !i.Paused() {
+					fn()
+				}
+			}
+			next = next.Add(time.Duration(due) * interval)
+
+			// The ticks missed during the calls are skipped. Otherwise, the
+			// timer fires immediately to catch up.
+			if now := time.Now().Round(0); missed == Skip && !next.After(now) {
+				next = next.Add((now.Sub(next)/interval + 1) * interval)
+			}
+			t.Reset(time.Until(next))
+		}
+	}()
+
+	i.stop = sync.OnceFunc(func() {
+		close(done)
+		wg.Wait()
+	})
+
+	return i
+}
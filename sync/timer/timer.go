@@ -2,13 +2,46 @@ package timer
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Interval calls a function repeatedly until it is stopped.
+type Interval struct {
+	paused atomic.Bool
+	stop   func()
+}
+
+// Stop stops the interval, and waits for the running call, if any, to
+// complete.
+func (i *Interval) Stop() {
+	i.stop()
+}
+
+// Pause skips the calls until the interval is resumed.
+func (i *Interval) Pause() {
+	i.paused.Store(true)
+}
+
+func (i *Interval) Resume() {
+	i.paused.Store(false)
+}
+
+func (i *Interval) Paused() bool {
+	return i.paused.Load()
+}
+
 func SetInterval(fn func(), duration time.Duration) func() {
+	return NewInterval(fn, duration).Stop
+}
+
+// NewInterval is like SetInterval, but returns the Interval, which can be
+// paused and resumed.
+func NewInterval(fn func(), duration time.Duration) *Interval {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
+	i := new(Interval)
 	done := make(chan struct{})
 	go func() {
 
@@ -21,15 +54,20 @@ func SetInterval(fn func(), duration time.Duration) func() {
 			case <-done:
 				return
 			case <-t.C:
+				if i.Paused() {
+					continue
+				}
 				fn()
 			}
 		}
 	}()
 
-	return sync.OnceFunc(func() {
+	i.stop = sync.OnceFunc(func() {
 		close(done)
 		wg.Wait()
 	})
+
+	return i
 }
 
 func SetTimeout(fn func(), duration time.Duration) func() {
@@ -38,3 +76,9 @@ func SetTimeout(fn func(), duration time.Duration) func() {
 		_ = stop()
 	})
 }
+
+// At calls fn once at the given time. If the time is in the past, fn is
+// called immediately.
+func At(t time.Time, fn func()) func() {
+	return SetTimeout(fn, time.Until(t))
+}
@@ -0,0 +1,148 @@
+package timer_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/timer"
+)
+
+func TestSetInterval(t *testing.T) {
+	var n atomic.Int64
+	stop := timer.SetInterval(func() {
+		n.Add(1)
+	}, time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	stop()
+
+	stopped := n.Load()
+	if stopped == 0 {
+		t.Fatal("want interval called before stop")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if got := n.Load(); got != stopped {
+		t.Fatalf("want no calls after stop, got %d", got-stopped)
+	}
+}
+
+func TestIntervalPauseResume(t *testing.T) {
+	var n atomic.Int64
+	i := timer.NewInterval(func() {
+		n.Add(1)
+	}, time.Millisecond)
+	defer i.Stop()
+
+	time.Sleep(10 * time.Millisecond)
+	i.Pause()
+	time.Sleep(2 * time.Millisecond)
+
+	paused := n.Load()
+	if paused == 0 {
+		t.Fatal("want interval called before pause")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if got := n.Load(); got != paused {
+		t.Fatalf("want no calls while paused, got %d", got-paused)
+	}
+
+	i.Resume()
+	time.Sleep(10 * time.Millisecond)
+	if n.Load() == paused {
+		t.Fatal("want interval called after resume")
+	}
+}
+
+func TestAt(t *testing.T) {
+	done := make(chan time.Time)
+	at := time.Now().Add(10 * time.Millisecond)
+	timer.At(at, func() {
+		done <- time.Now()
+	})
+
+	if got := <-done; got.Before(at) {
+		t.Fatalf("want called at %s, got %s", at, got)
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			if got := timer.MustParseCron(tc.expr).Next(now); !got.Equal(tc.want) {
+				t.Fatalf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+
+	for _, expr := range []string{"60 * * * *", "0 0 30 2 *", "0 0 31 4,6 *"} {
+		if _, err := timer.Cron(expr, func() {}); err == nil {
+			t.Fatalf("want error for invalid expression %q", expr)
+		}
+	}
+
+	// February 29 only exists in the leap years.
+	if got, want := timer.MustParseCron("0 0 29 2 *").Next(now), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+}
+
+func TestSetAlignedInterval(t *testing.T) {
+	interval := 20 * time.Millisecond
+
+	calls := make(chan time.Time, 10)
+	i := timer.SetAlignedInterval(func() {
+		calls <- time.Now()
+	}, interval, 0, timer.Skip)
+
+	at := <-calls
+	i.Stop()
+
+	if offset := at.Sub(at.Truncate(interval)); offset > 10*time.Millisecond {
+		t.Fatalf("want called on the boundary, got %s after", offset)
+	}
+}
+
+func TestSetAlignedIntervalMissed(t *testing.T) {
+	interval := 20 * time.Millisecond
+
+	// The first call takes longer than the interval, and misses a tick.
+	gap := func(missed timer.Missed) time.Duration {
+		var n atomic.Int64
+		var end time.Time
+		calls := make(chan time.Duration, 10)
+		i := timer.SetAlignedInterval(func() {
+			switch n.Add(1) {
+			case 1:
+				time.Sleep(interval + interval/4)
+				end = time.Now()
+			case 2:
+				calls <- time.Since(end)
+			}
+		}, interval, 0, missed)
+		defer i.Stop()
+
+		return <-calls
+	}
+
+	if got := gap(timer.Skip); got < interval/4 {
+		t.Fatalf("want missed tick skipped, got next call after %s", got)
+	}
+	if got := gap(timer.CatchUp); got > interval/4 {
+		t.Fatalf("want missed tick caught up, got next call after %s", got)
+	}
+}
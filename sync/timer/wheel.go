@@ -0,0 +1,230 @@
+package timer
+
+import (
+	"cmp"
+	"container/list"
+	"sync"
+	"time"
+)
+
+type WheelOptions struct {
+	// Tick is the resolution of the wheel. Timers fire on the tick after
+	// their deadline. Defaults to 10ms.
+	Tick time.Duration
+
+	// Size is the number of slots per wheel. Durations beyond Tick*Size
+	// overflow into the next wheel, which has a Size times coarser tick.
+	// Defaults to 512.
+	Size int
+}
+
+// Wheel is a hierarchical timing wheel, which schedules all the callbacks on
+// a single goroutine. It is suitable for a large number of timers that do
+// not need a precise deadline, e.g. timeouts.
+// The callbacks are executed serially, so slow callbacks delay the other
+// timers.
+type Wheel struct {
+	tick time.Duration
+	size uint64
+
+	mu     sync.Mutex
+	now    uint64 // The current tick.
+	levels [][]*list.List
+	count  int
+}
+
+type wheelTimer struct {
+	fn       func()
+	expire   uint64
+	interval uint64
+	slot     *list.List
+	elem     *list.Element
+}
+
+func NewWheel(opts *WheelOptions) (*Wheel, func()) {
+	opts = cmp.Or(opts, &WheelOptions{})
+	w := &Wheel{
+		tick: cmp.Or(opts.Tick, 10*time.Millisecond),
+		size: uint64(cmp.Or(opts.Size, 512)),
+	}
+	w.levels = [][]*list.List{w.newLevel()}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	done := make(chan struct{})
+	go func() {
+		defer wg.Done()
+
+		w.run(done)
+	}()
+
+	return w, sync.OnceFunc(func() {
+		close(done)
+		wg.Wait()
+	})
+}
+
+// SetTimeout calls the fn once after the duration. The returned function
+// cancels the timer.
+func (w *Wheel) SetTimeout(fn func(), duration time.Duration) func() {
+	return w.schedule(fn, duration, false)
+}
+
+// SetInterval calls the fn every duration. The returned function cancels the
+// timer.
+func (w *Wheel) SetInterval(fn func(), duration time.Duration) func() {
+	return w.schedule(fn, duration, true)
+}
+
+// Len returns the number of pending timers.
+func (w *Wheel) Len() int {
+	w.mu.Lock()
+	n := w.count
+	w.mu.Unlock()
+
+	return n
+}
+
+func (w *Wheel) schedule(fn func(), duration time.Duration, repeat bool) func() {
+	ticks := w.ticks(duration)
+
+	t := &wheelTimer{fn: fn}
+	if repeat {
+		t.interval = ticks
+	}
+
+	w.mu.Lock()
+	t.expire = w.now + ticks
+	w.add(t)
+	w.count++
+	w.mu.Unlock()
+
+	return sync.OnceFunc(func() {
+		w.mu.Lock()
+		if t.slot != nil {
+			t.slot.Remove(t.elem)
+			t.slot = nil
+			w.count--
+		}
+		// Prevents an interval that is firing from being rescheduled.
+		t.interval = 0
+		w.mu.Unlock()
+	})
+}
+
+// ticks rounds the duration up to the number of ticks, with a minimum of one.
+func (w *Wheel) ticks(d time.Duration) uint64 {
+	return max(uint64((d+w.tick-1)/w.tick), 1)
+}
+
+func (w *Wheel) newLevel() []*list.List {
+	slots := make([]*list.List, w.size)
+	for i := range slots {
+		slots[i] = list.New()
+	}
+
+	return slots
+}
+
+// add places the timer into the slot of the lowest level that can hold its
+// deadline.
+// Must be called with the lock held.
+func (w *Wheel) add(t *wheelTimer) {
+	delta := t.expire - w.now
+
+	// span is the duration covered by one slot at the level.
+	span := uint64(1)
+	for level := 0; ; level++ {
+		if level == len(w.levels) {
+			w.levels = append(w.levels, w.newLevel())
+		}
+
+		if delta < span*w.size {
+			t.slot = w.levels[level][(t.expire/span)%w.size]
+			t.elem = t.slot.PushBack(t)
+			return
+		}
+		span *= w.size
+	}
+}
+
+func (w *Wheel) run(done <-chan struct{}) {
+	start := time.Now()
+
+	t := time.NewTicker(w.tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			// Catch up on the missed ticks, if any.
+			target := uint64(time.Since(start) / w.tick)
+			for w.advance(target) {
+			}
+		}
+	}
+}
+
+// advance moves the wheel forward by one tick and executes the expired
+// timers. It returns false once the wheel reaches the target.
+func (w *Wheel) advance(target uint64) bool {
+	w.mu.Lock()
+	if w.now >= target {
+		w.mu.Unlock()
+		return false
+	}
+	w.now++
+
+	// Cascade the timers from the higher levels into the lower levels, once
+	// the slot is due.
+	for level := len(w.levels) - 1; level > 0; level-- {
+		span := pow(w.size, level)
+		if w.now%span != 0 {
+			continue
+		}
+
+		slot := w.levels[level][(w.now/span)%w.size]
+		for e := slot.Front(); e != nil; {
+			next := e.Next()
+			t := slot.Remove(e).(*wheelTimer)
+			w.add(t)
+			e = next
+		}
+	}
+
+	var fns []func()
+	slot := w.levels[0][w.now%w.size]
+	for e := slot.Front(); e != nil; {
+		next := e.Next()
+		t := slot.Remove(e).(*wheelTimer)
+		t.slot = nil
+		fns = append(fns, t.fn)
+
+		if t.interval > 0 {
+			t.expire = w.now + t.interval
+			w.add(t)
+		} else {
+			w.count--
+		}
+		e = next
+	}
+	w.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+
+	return true
+}
+
+func pow(n uint64, exp int) uint64 {
+	res := uint64(1)
+	for range exp {
+		res *= n
+	}
+
+	return res
+}
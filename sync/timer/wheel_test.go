@@ -0,0 +1,68 @@
+package timer_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/core/sync/timer"
+)
+
+func TestWheelSetTimeout(t *testing.T) {
+	w, stop := timer.NewWheel(&timer.WheelOptions{
+		Tick: time.Millisecond,
+		Size: 4,
+	})
+	defer stop()
+
+	// The durations span multiple levels of the wheel.
+	durations := []time.Duration{
+		2 * time.Millisecond,
+		7 * time.Millisecond,
+		23 * time.Millisecond,
+		70 * time.Millisecond,
+	}
+
+	done := make(chan time.Duration, len(durations))
+	start := time.Now()
+	for _, d := range durations {
+		w.SetTimeout(func() {
+			done <- time.Since(start)
+		}, d)
+	}
+	cancel := w.SetTimeout(func() {
+		t.Error("want cancelled timer not called")
+	}, 10*time.Millisecond)
+	cancel()
+
+	for _, d := range durations {
+		if took := <-done; took < d {
+			t.Fatalf("want timer called after %s, got %s", d, took)
+		}
+	}
+	if n := w.Len(); n != 0 {
+		t.Fatalf("want no pending timers, got %d", n)
+	}
+}
+
+func TestWheelSetInterval(t *testing.T) {
+	w, stop := timer.NewWheel(&timer.WheelOptions{
+		Tick: time.Millisecond,
+	})
+	defer stop()
+
+	var n atomic.Int64
+	cancel := w.SetInterval(func() {
+		n.Add(1)
+	}, 5*time.Millisecond)
+
+	time.Sleep(32 * time.Millisecond)
+	cancel()
+
+	if got := n.Load(); got < 4 || got > 6 {
+		t.Fatalf("want about 6 calls, got %d", got)
+	}
+	if n := w.Len(); n != 0 {
+		t.Fatalf("want no pending timers, got %d", n)
+	}
+}
@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"cmp"
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/exp/event"
+)
+
+type AsyncOptions struct {
+	// QueueSize is the maximum number of events waiting to be exported.
+	// Events are dropped when the queue is full. Defaults to 1000.
+	QueueSize int
+
+	// Workers is the number of goroutines exporting the events. Events may be
+	// exported out of order when there is more than one worker.
+	// Defaults to 1.
+	Workers int
+}
+
+type AsyncMetrics struct {
+	Queued   int64
+	Exported int64
+	Dropped  int64
+}
+
+// AsyncHandler exports the events in the background, so that slow sinks do
+// not add latency to the caller.
+// Since the events are handled after Event returns, the context returned by
+// the wrapped handler is discarded. It should not wrap handlers that modify
+// the context, such as trace handlers.
+type AsyncHandler struct {
+	h handler
+
+	mu     sync.RWMutex
+	queue  chan asyncEvent
+	closed bool
+
+	pending struct {
+		sync.Mutex
+		n    int
+		idle chan struct{}
+	}
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+}
+
+type asyncEvent struct {
+	ctx context.Context
+	ev  *event.Event
+}
+
+var _ event.Handler = (*AsyncHandler)(nil)
+
+// NewAsyncHandler wraps the handler. The returned function stops accepting
+// new events, and waits for the queued events to be exported.
+func NewAsyncHandler(h handler, opts *AsyncOptions) (*AsyncHandler, func()) {
+	opts = cmp.Or(opts, &AsyncOptions{})
+	opts.QueueSize = cmp.Or(opts.QueueSize, 1_000)
+	opts.Workers = cmp.Or(opts.Workers, 1)
+
+	a := &AsyncHandler{
+		h:     h,
+		queue: make(chan asyncEvent, opts.QueueSize),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(opts.Workers)
+	for range opts.Workers {
+		go func() {
+			defer wg.Done()
+
+			for e := range a.queue {
+				a.h.Event(e.ctx, e.ev)
+				a.exported.Add(1)
+				a.done()
+			}
+		}()
+	}
+
+	return a, sync.OnceFunc(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.queue)
+		a.mu.Unlock()
+
+		wg.Wait()
+	})
+}
+
+func (a *AsyncHandler) Event(ctx context.Context, ev *event.Event) context.Context {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return ctx
+	}
+
+	a.add()
+
+	// The event is cloned, since the exporter reuses the event once this
+	// method returns.
+	select {
+	case a.queue <- asyncEvent{ctx: context.WithoutCancel(ctx), ev: ev.Clone()}:
+	default:
+		a.dropped.Add(1)
+		a.done()
+	}
+
+	return ctx
+}
+
+// Flush waits until all the queued events are exported, or the context is
+// done.
+func (a *AsyncHandler) Flush(ctx context.Context) error {
+	a.pending.Lock()
+	if a.pending.n == 0 {
+		a.pending.Unlock()
+		return nil
+	}
+	idle := a.pending.idle
+	a.pending.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-idle:
+		return nil
+	}
+}
+
+func (a *AsyncHandler) Metrics() AsyncMetrics {
+	return AsyncMetrics{
+		Queued:   int64(len(a.queue)),
+		Exported: a.exported.Load(),
+		Dropped:  a.dropped.Load(),
+	}
+}
+
+func (a *AsyncHandler) add() {
+	a.pending.Lock()
+	if a.pending.n == 0 {
+		a.pending.idle = make(chan struct{})
+	}
+	a.pending.n++
+	a.pending.Unlock()
+}
+
+func (a *AsyncHandler) done() {
+	a.pending.Lock()
+	a.pending.n--
+	if a.pending.n == 0 {
+		close(a.pending.idle)
+	}
+	a.pending.Unlock()
+}
@@ -0,0 +1,53 @@
+package telemetry_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/alextanhongpin/core/telemetry"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/event"
+	"golang.org/x/exp/event/eventtest"
+)
+
+type blockingHandler struct {
+	mu      sync.Mutex
+	unblock chan struct{}
+	msgs    []string
+}
+
+func (h *blockingHandler) Event(ctx context.Context, ev *event.Event) context.Context {
+	<-h.unblock
+
+	h.mu.Lock()
+	h.msgs = append(h.msgs, ev.Find("msg").String())
+	h.mu.Unlock()
+
+	return ctx
+}
+
+func TestAsyncHandler(t *testing.T) {
+	h := &blockingHandler{unblock: make(chan struct{})}
+	async, stop := telemetry.NewAsyncHandler(h, &telemetry.AsyncOptions{
+		QueueSize: 2,
+	})
+	defer stop()
+
+	ctx := event.WithExporter(ctx, event.NewExporter(async, eventtest.ExporterOptions()))
+	for range 5 {
+		event.Log(ctx, "hello")
+	}
+
+	is := assert.New(t)
+	is.Positive(async.Metrics().Dropped)
+
+	close(h.unblock)
+	is.Nil(async.Flush(ctx))
+
+	m := async.Metrics()
+	is.Equal(int64(5), m.Exported+m.Dropped)
+	is.Equal(int64(0), m.Queued)
+	is.Len(h.msgs, int(m.Exported))
+	is.Equal("hello", h.msgs[0])
+}
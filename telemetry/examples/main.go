@@ -14,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"golang.org/x/exp/event"
 	"golang.org/x/exp/event/eventtest"
 	"golang.org/x/exp/slog"
@@ -43,10 +44,22 @@ func main() {
 		AddSource: true,
 	}))
 	ctx := context.Background()
-	ctx = event.WithExporter(ctx, event.NewExporter(&telemetry.MultiHandler{
+
+	// Exports the spans to the collector at localhost:4317.
+	tp, err := telemetry.NewOTLPTracerProvider(ctx, otlptracegrpc.WithInsecure())
+	if err != nil {
+		panic(err)
+	}
+	defer tp.Shutdown(context.Background())
+
+	exp := event.NewExporter(&telemetry.MultiHandler{
 		Metric: ph,
 		Log:    telemetry.NewSlogHandler(logger),
-	}, opt))
+		Trace:  telemetry.NewTraceHandler(tp.Tracer("example")),
+	}, opt)
+	// The default exporter is used for the request contexts.
+	event.SetDefaultExporter(exp)
+	ctx = event.WithExporter(ctx, exp)
 	event.Log(ctx, "my event", event.Int64("myInt", 6))
 	event.Log(ctx, "error event", event.String("myString", "some string value"))
 	event.Error(ctx, "hello", errors.New("unexpected error has occured"))
@@ -68,11 +81,16 @@ func main() {
 	}()
 
 	log.Println("listening to port *:8000")
-	http.Handle("/", promhttp.InstrumentMetricHandler(reg, http.HandlerFunc(handler)))
+	http.Handle("/", promhttp.InstrumentMetricHandler(reg, telemetry.TraceContextHandler(http.HandlerFunc(handler))))
 	http.Handle("/metrics", promhttp.Handler())
 	panic(http.ListenAndServe(":8000", nil))
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
+	// Continues the trace from the traceparent header, if any.
+	ctx := event.Start(r.Context(), "handler")
+	defer event.End(ctx)
+
+	event.Log(ctx, "hello")
 	fmt.Fprint(w, "hello")
 }
@@ -0,0 +1,205 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/event"
+)
+
+// ExpvarHandler is an event.Handler that mirrors the counter and gauge
+// metrics into expvar, so that /debug/vars shows the same numbers as
+// Prometheus. Its Event method handles Metric events and ignores all others.
+//
+// The variable is named after the metric, prefixed with the namespace, e.g.
+// my_ns_hits. Metrics with labels are published as an expvar.Map keyed by the
+// label values, e.g. {"version=canary": 456}.
+type ExpvarHandler struct {
+	// OnError is called when the metric cannot be mirrored, e.g. when the
+	// variable of the name is published with another type. The metric is
+	// skipped after. Defaults to logging the error.
+	OnError func(err error)
+
+	names map[string]bool
+
+	mu          sync.Mutex
+	recordFuncs map[expvarKey]recordFunc
+}
+
+// ErrExpvarType is returned when the variable of the metric is published
+// with another type, e.g. when the metric is recorded with and without
+// labels.
+var ErrExpvarType = errors.New("telemetry: expvar type mismatch")
+
+// expvarKey identifies the variable of the metric, since the metric recorded
+// with and without labels is published as different types.
+type expvarKey struct {
+	metric event.Metric
+	labels string
+}
+
+var _ event.Handler = (*ExpvarHandler)(nil)
+
+// NewExpvarHandler creates a new ExpvarHandler that mirrors only the metrics
+// with the given names, or all the metrics if none are given.
+func NewExpvarHandler(names ...string) *ExpvarHandler {
+	m := make(map[string]bool)
+	for _, name := range names {
+		m[name] = true
+	}
+
+	return &ExpvarHandler{
+		OnError: func(err error) {
+			log.Println(err)
+		},
+		names:       m,
+		recordFuncs: map[expvarKey]recordFunc{},
+	}
+}
+
+func (m *ExpvarHandler) Event(ctx context.Context, e *event.Event) context.Context {
+	if e.Kind != event.MetricKind {
+		return ctx
+	}
+
+	mi, ok := event.MetricKey.Find(e)
+	if !ok {
+		panic(errors.New("no metric key for metric event"))
+	}
+
+	em := mi.(event.Metric)
+	lval := e.Find(event.MetricVal)
+	if !lval.HasValue() {
+		panic(errors.New("no metric value for metric event"))
+	}
+
+	// Metrics that are not selected, or not supported, are skipped.
+	rf, err := m.getRecordFunc(em, e.Labels)
+	if err != nil && m.OnError != nil {
+		m.OnError(err)
+	}
+	if rf != nil {
+		rf(ctx, lval, e.Labels)
+	}
+
+	return ctx
+}
+
+// getRecordFunc returns the record function of the metric and the label
+// names. The error is only returned the first time, and the metric is skipped
+// after.
+func (m *ExpvarHandler) getRecordFunc(em event.Metric, labels []event.Label) (recordFunc, error) {
+	keys, _ := labelsToKeyVals(labels)
+	k := expvarKey{metric: em, labels: strings.Join(keys, ",")}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.recordFuncs[k]; ok {
+		return f, nil
+	}
+	f, err := m.newRecordFunc(em, keys)
+	m.recordFuncs[k] = f
+	return f, err
+}
+
+func (m *ExpvarHandler) newRecordFunc(em event.Metric, keys []string) (recordFunc, error) {
+	opts := em.Options()
+	name := em.Name()
+	if opts.Namespace != "" {
+		name = opts.Namespace + "_" + name
+	}
+	if len(m.names) > 0 && !m.names[name] {
+		return nil, nil
+	}
+
+	switch em.(type) {
+	case *event.Counter:
+		if len(keys) == 0 {
+			v, err := publish(name, new(expvar.Int))
+			if err != nil {
+				return nil, err
+			}
+
+			return func(ctx context.Context, l event.Label, labels []event.Label) {
+				v.Add(l.Int64())
+			}, nil
+		}
+
+		v, err := publish(name, new(expvar.Map))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, l event.Label, labels []event.Label) {
+			v.Add(labelsKey(labels), l.Int64())
+		}, nil
+
+	case *event.FloatGauge:
+		if len(keys) == 0 {
+			v, err := publish(name, new(expvar.Float))
+			if err != nil {
+				return nil, err
+			}
+
+			return func(ctx context.Context, l event.Label, labels []event.Label) {
+				v.Set(l.Float64())
+			}, nil
+		}
+
+		v, err := publish(name, new(expvar.Map))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, l event.Label, labels []event.Label) {
+			key := labelsKey(labels)
+
+			// Map.AddFloat creates the float, but there is no SetFloat.
+			f, ok := v.Get(key).(*expvar.Float)
+			if !ok {
+				f = new(expvar.Float)
+				v.Set(key, f)
+			}
+			f.Set(l.Float64())
+		}, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// publish returns the published variable of the name, or publishes v. This
+// allows mirroring into the variables published manually, e.g. with
+// expvar.NewInt.
+func publish[T expvar.Var](name string, v T) (T, error) {
+	existing := expvar.Get(name)
+	if existing == nil {
+		expvar.Publish(name, v)
+
+		return v, nil
+	}
+
+	t, ok := existing.(T)
+	if !ok {
+		return t, fmt.Errorf("%w: %q is %T, not %T", ErrExpvarType, name, existing, v)
+	}
+
+	return t, nil
+}
+
+// labelsKey returns the labels as key=value pairs, e.g. version=canary.
+func labelsKey(labels []event.Label) string {
+	keys, vals := labelsToKeyVals(labels)
+
+	pairs := make([]string, len(keys))
+	for i := range keys {
+		pairs[i] = keys[i] + "=" + vals[i]
+	}
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,82 @@
+package telemetry_test
+
+import (
+	"expvar"
+	"testing"
+
+	"github.com/alextanhongpin/core/telemetry"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/event"
+	"golang.org/x/exp/event/eventtest"
+)
+
+func TestExpvar(t *testing.T) {
+	// Mirrors into the variables published manually.
+	hits := expvar.NewInt("expvar_ns_hits")
+
+	metric := telemetry.NewExpvarHandler("expvar_ns_hits", "expvar_ns_cpu", "expvar_ns_requests")
+	ctx := event.WithExporter(ctx, event.NewExporter(metric, eventtest.ExporterOptions()))
+
+	opts := &event.MetricOptions{Namespace: "expvar_ns"}
+	event.NewCounter("hits", opts).Record(ctx, 123)
+	event.NewCounter("hits", opts).Record(ctx, 456)
+
+	requests := event.NewCounter("requests", opts)
+	requests.Record(ctx, 1, event.String("version", "stable"))
+	requests.Record(ctx, 2, event.String("version", "canary"))
+
+	cpu := event.NewFloatGauge("cpu", opts)
+	cpu.Record(ctx, 0.5)
+	cpu.Record(ctx, 0.25)
+
+	// Not selected.
+	event.NewCounter("ignored", opts).Record(ctx, 1)
+
+	is := assert.New(t)
+	is.Equal(int64(579), hits.Value())
+	is.Equal(`{"version=canary": 2, "version=stable": 1}`, expvar.Get("expvar_ns_requests").String())
+	is.Equal(0.25, expvar.Get("expvar_ns_cpu").(*expvar.Float).Value())
+	is.Nil(expvar.Get("expvar_ns_ignored"))
+}
+
+func TestExpvarLabels(t *testing.T) {
+	var errs []error
+	metric := telemetry.NewExpvarHandler()
+	metric.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	ctx := event.WithExporter(ctx, event.NewExporter(metric, eventtest.ExporterOptions()))
+
+	opts := &event.MetricOptions{Namespace: "expvar_labels"}
+	requests := event.NewCounter("requests", opts)
+	requests.Record(ctx, 1, event.String("version", "stable"))
+	requests.Record(ctx, 2, event.String("version", "canary"), event.String("region", "eu"))
+
+	// The metric recorded without labels clashes with the map, and is
+	// skipped.
+	requests.Record(ctx, 3)
+	requests.Record(ctx, 4)
+
+	is := assert.New(t)
+	is.Equal(`{"version=canary,region=eu": 2, "version=stable": 1}`, expvar.Get("expvar_labels_requests").String())
+	is.Len(errs, 1)
+	is.ErrorIs(errs[0], telemetry.ErrExpvarType)
+}
+
+func TestExpvarTypeMismatch(t *testing.T) {
+	expvar.NewString("expvar_mismatch_hits")
+
+	var errs []error
+	metric := telemetry.NewExpvarHandler()
+	metric.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	ctx := event.WithExporter(ctx, event.NewExporter(metric, eventtest.ExporterOptions()))
+
+	// Does not panic.
+	event.NewCounter("hits", &event.MetricOptions{Namespace: "expvar_mismatch"}).Record(ctx, 1)
+
+	is := assert.New(t)
+	is.Len(errs, 1)
+	is.ErrorIs(errs[0], telemetry.ErrExpvarType)
+}
@@ -0,0 +1,177 @@
+// This is a modified version of https://pkg.go.dev/golang.org/x/exp/event@v0.0.0-20241108190413-2d47ceb2692f/otel, which records the labels and
+// errors on the spans.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/event"
+)
+
+// TraceHandler is an event.Handler for OpenTelemetry traces.
+// event.Start starts a span as a child of the span in the context, which may
+// be a remote span extracted from the W3C traceparent header, and event.End
+// ends it. The labels are recorded as span attributes, and the log events
+// within the span are recorded as span events.
+type TraceHandler struct {
+	tracer trace.Tracer
+}
+
+var _ event.Handler = (*TraceHandler)(nil)
+
+func NewTraceHandler(t trace.Tracer) *TraceHandler {
+	return &TraceHandler{
+		tracer: t,
+	}
+}
+
+type spanKey struct{}
+
+func (t *TraceHandler) Event(ctx context.Context, ev *event.Event) context.Context {
+	switch ev.Kind {
+	case event.StartKind:
+		name, opts := labelsToSpanStartOptions(ev.Labels)
+		ctx, span := t.tracer.Start(ctx, name, opts...)
+		return context.WithValue(ctx, spanKey{}, span)
+	case event.EndKind:
+		// Only end the spans started by the handler.
+		span, ok := ctx.Value(spanKey{}).(trace.Span)
+		if !ok {
+			return ctx
+		}
+		recordLabels(span, ev.Labels)
+		span.End(trace.WithTimestamp(ev.At))
+		return ctx
+	case event.LogKind:
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return ctx
+		}
+
+		msg := ev.Find("msg").String()
+		span.AddEvent(msg, trace.WithTimestamp(ev.At), trace.WithAttributes(spanAttributes(ev.Labels)...))
+		if l := ev.Find("error"); l.HasValue() {
+			if err, ok := l.Interface().(error); ok {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, msg)
+		}
+		return ctx
+	default:
+		return ctx
+	}
+}
+
+// NewOTLPTracerProvider returns a TracerProvider exporting the spans to the
+// OTLP/gRPC endpoint, configured by the options or the OTEL_EXPORTER_OTLP_*
+// environment variables. It is also installed as the global TracerProvider,
+// with the W3C trace context propagator.
+// The provider must be shut down to flush the spans.
+func NewOTLPTracerProvider(ctx context.Context, opts ...otlptracegrpc.Option) (*sdktrace.TracerProvider, error) {
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp, nil
+}
+
+// TraceContextHandler extracts the W3C traceparent header into the request
+// context, so that the spans started by the handlers continue the caller's
+// trace.
+func TraceContextHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func labelsToSpanStartOptions(ls []event.Label) (string, []trace.SpanStartOption) {
+	var opts []trace.SpanStartOption
+	var name string
+	for _, l := range ls {
+		switch l.Name {
+		case "link":
+			opts = append(opts, trace.WithLinks(l.Interface().(trace.Link)))
+		case "newRoot":
+			opts = append(opts, trace.WithNewRoot())
+		case "spanKind":
+			opts = append(opts, trace.WithSpanKind(l.Interface().(trace.SpanKind)))
+		case "name":
+			name = l.String()
+		}
+	}
+	opts = append(opts, trace.WithAttributes(spanAttributes(ls)...))
+
+	return name, opts
+}
+
+func recordLabels(span trace.Span, ls []event.Label) {
+	span.SetAttributes(spanAttributes(ls)...)
+	for _, l := range ls {
+		if l.Name != "error" || !l.HasValue() {
+			continue
+		}
+
+		if err, ok := l.Interface().(error); ok {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
+
+// spanAttributes converts the labels to attributes, skipping the labels used
+// for the span options and the message.
+func spanAttributes(ls []event.Label) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, l := range ls {
+		switch l.Name {
+		case "", "link", "newRoot", "spanKind", "name", "msg", "error":
+			continue
+		}
+		if !l.HasValue() {
+			continue
+		}
+
+		attrs = append(attrs, spanAttribute(l))
+	}
+
+	return attrs
+}
+
+// spanAttribute is like labelToAttribute, but does not panic on unsupported
+// values, since labels on traces are free-form.
+func spanAttribute(l event.Label) attribute.KeyValue {
+	switch {
+	case l.IsString():
+		return attribute.String(l.Name, l.String())
+	case l.IsBytes():
+		return attribute.String(l.Name, string(l.Bytes()))
+	case l.IsInt64():
+		return attribute.Int64(l.Name, l.Int64())
+	case l.IsUint64():
+		return attribute.Int64(l.Name, int64(l.Uint64()))
+	case l.IsFloat64():
+		return attribute.Float64(l.Name, l.Float64())
+	case l.IsBool():
+		return attribute.Bool(l.Name, l.Bool())
+	default:
+		return attribute.String(l.Name, fmt.Sprint(l.Interface()))
+	}
+}
@@ -0,0 +1,65 @@
+package telemetry_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alextanhongpin/core/telemetry"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/exp/event"
+	"golang.org/x/exp/event/eventtest"
+)
+
+func TestTraceHandler(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	th := telemetry.NewTraceHandler(tp.Tracer(t.Name()))
+	h := telemetry.TraceContextHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := event.WithExporter(r.Context(), event.NewExporter(&telemetry.MultiHandler{
+			Trace: th,
+		}, eventtest.ExporterOptions()))
+
+		ctx = event.Start(ctx, "checkout", event.String("user", "john"))
+		{
+			ctx := event.Start(ctx, "payment")
+			event.Error(ctx, "declined", errors.New("insufficient funds"))
+			event.End(ctx)
+		}
+		event.End(ctx, event.Int64("items", 3))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/checkout", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	is := assert.New(t)
+	spans := rec.Ended()
+	is.Len(spans, 2)
+
+	payment, checkout := spans[0], spans[1]
+	is.Equal("payment", payment.Name())
+	is.Equal(codes.Error, payment.Status().Code)
+	is.Equal(checkout.SpanContext().SpanID(), payment.Parent().SpanID())
+
+	// The root span continues the remote trace.
+	is.Equal("checkout", checkout.Name())
+	is.Equal("4bf92f3577b34da6a3ce929d0e0e4736", checkout.SpanContext().TraceID().String())
+	is.Equal("00f067aa0ba902b7", checkout.Parent().SpanID().String())
+	is.True(checkout.Parent().IsRemote())
+
+	attrs := make(map[string]any)
+	for _, kv := range checkout.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	is.Equal("john", attrs["user"])
+	is.Equal(int64(3), attrs["items"])
+}